@@ -0,0 +1,55 @@
+// Command seed-pois loads the points-of-interest catalog from the bundled
+// CSV, or from -file (CSV or GeoJSON). Existing places with the same
+// district and name are updated. Run it from the backend directory.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"trip-planner-backend/config"
+	"trip-planner-backend/handlers"
+	"trip-planner-backend/models"
+	"trip-planner-backend/utils"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	file := flag.String("file", "", "CSV or GeoJSON file to import instead of the bundled catalog")
+	flag.Parse()
+
+	config.InitDB()
+	defer config.CloseDB()
+	utils.SetDistrictLoader(handlers.LoadDistricts, 0)
+
+	var pois []models.POI
+	var rowErrors []utils.POIImportError
+	var err error
+	if *file == "" {
+		pois, rowErrors, err = utils.BundledPOIs()
+	} else {
+		f, ferr := os.Open(*file)
+		if ferr != nil {
+			log.Fatal("Error opening file:", ferr)
+		}
+		defer f.Close()
+		pois, rowErrors, err = utils.ParsePOIFile(*file, f)
+	}
+	if err != nil {
+		log.Fatal("Error reading places:", err)
+	}
+	for _, e := range rowErrors {
+		log.Printf("Skipped row %d: %s", e.Row, e.Error)
+	}
+
+	inserted, updated, err := handlers.UpsertPOIs(pois)
+	if err != nil {
+		log.Fatal("Error importing places:", err)
+	}
+	log.Printf("Imported places: %d inserted, %d updated, %d skipped", inserted, updated, len(rowErrors))
+}
@@ -0,0 +1,45 @@
+// Command upload-gc finds stored uploads that no package or district photo
+// refers to and, with -dry-run=false, deletes those older than the grace
+// period. Run it from the backend directory so .env and uploads/ resolve.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+	"trip-planner-backend/config"
+	"trip-planner-backend/jobs"
+	"trip-planner-backend/storage"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	cfg := jobs.UploadGCConfigFromEnv()
+	dryRun := flag.Bool("dry-run", true, "only report orphaned files")
+	graceHours := flag.Int("grace-hours", int(cfg.GracePeriod.Hours()), "keep orphans younger than this many hours")
+	flag.Parse()
+
+	cfg.DryRun = *dryRun
+	cfg.GracePeriod = time.Duration(*graceHours) * time.Hour
+
+	config.InitDB()
+	defer config.CloseDB()
+	storage.Init()
+
+	report, err := jobs.RunUploadGC(context.Background(), cfg)
+	if err != nil {
+		log.Fatal("Upload GC failed:", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(report)
+}
@@ -0,0 +1,43 @@
+-- Migration: Agency verification, KYC documents and admin review
+-- This script assumes PostgreSQL
+
+-- Agencies that registered before verification existed keep trading, so
+-- the column is added as approved and new rows then default to pending
+ALTER TABLE travel_agencies ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'approved'
+    CHECK (status IN ('pending', 'approved', 'suspended', 'rejected'));
+ALTER TABLE travel_agencies ALTER COLUMN status SET DEFAULT 'pending';
+ALTER TABLE travel_agencies ADD COLUMN IF NOT EXISTS reviewer_notes TEXT;
+ALTER TABLE travel_agencies ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP;
+-- Set when an agency asks for (another) review
+ALTER TABLE travel_agencies ADD COLUMN IF NOT EXISTS submitted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_travel_agencies_status ON travel_agencies(status, submitted_at);
+
+-- Files live in upload storage under kyc/, which is never served publicly
+-- or garbage collected; they are only read through the API
+CREATE TABLE IF NOT EXISTS agency_documents (
+    document_id SERIAL PRIMARY KEY,
+    agency_id INTEGER NOT NULL REFERENCES travel_agencies(agency_id) ON DELETE CASCADE,
+    document_type VARCHAR(30) NOT NULL
+        CHECK (document_type IN ('registration_certificate', 'gst_certificate', 'other')),
+    storage_key VARCHAR(255) NOT NULL,
+    original_filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    uploaded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_agency_documents_agency ON agency_documents(agency_id, uploaded_at);
+
+-- Every status change, with the reviewer's notes
+CREATE TABLE IF NOT EXISTS agency_reviews (
+    review_id SERIAL PRIMARY KEY,
+    agency_id INTEGER NOT NULL REFERENCES travel_agencies(agency_id) ON DELETE CASCADE,
+    from_status VARCHAR(20) NOT NULL,
+    to_status VARCHAR(20) NOT NULL,
+    notes TEXT,
+    reviewed_by VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_agency_reviews_agency ON agency_reviews(agency_id, created_at);
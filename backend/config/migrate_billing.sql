@@ -0,0 +1,53 @@
+-- Migration: GST billing profile, quotations and invoices
+-- This script assumes PostgreSQL
+
+-- Printed on every document. The state code is the two-digit GST state
+-- code and decides between CGST/SGST and IGST.
+ALTER TABLE travel_agencies ADD COLUMN IF NOT EXISTS gstin VARCHAR(15);
+ALTER TABLE travel_agencies ADD COLUMN IF NOT EXISTS billing_address TEXT;
+ALTER TABLE travel_agencies ADD COLUMN IF NOT EXISTS billing_state_code CHAR(2);
+
+-- Documents are numbered per agency, type and financial year. The row is
+-- locked while a document is issued, so numbers have no gaps.
+CREATE TABLE IF NOT EXISTS billing_document_sequences (
+    agency_id INTEGER NOT NULL REFERENCES travel_agencies(agency_id) ON DELETE CASCADE,
+    document_type VARCHAR(10) NOT NULL,
+    financial_year VARCHAR(7) NOT NULL,
+    last_number INTEGER NOT NULL,
+    PRIMARY KEY (agency_id, document_type, financial_year)
+);
+
+CREATE TABLE IF NOT EXISTS billing_documents (
+    document_id SERIAL PRIMARY KEY,
+    agency_id INTEGER NOT NULL REFERENCES travel_agencies(agency_id) ON DELETE CASCADE,
+    package_id INTEGER REFERENCES travel_packages(package_id) ON DELETE SET NULL,
+    package_title VARCHAR(255) NOT NULL,
+    document_type VARCHAR(10) NOT NULL CHECK (document_type IN ('quotation', 'invoice')),
+    document_number VARCHAR(16) NOT NULL,
+    financial_year VARCHAR(7) NOT NULL,
+    issue_date DATE NOT NULL DEFAULT CURRENT_DATE,
+    valid_until DATE,
+    -- Supplier and customer as printed, so later profile edits don't
+    -- change issued documents
+    supplier JSONB NOT NULL,
+    customer JSONB NOT NULL,
+    customer_name VARCHAR(255) NOT NULL,
+    place_of_supply CHAR(2) NOT NULL,
+    sac_code VARCHAR(8) NOT NULL,
+    prices_include_tax BOOLEAN NOT NULL,
+    -- The itemized price quote the document was issued from
+    quote JSONB NOT NULL,
+    tax_rate NUMERIC(5,2) NOT NULL,
+    intra_state BOOLEAN NOT NULL,
+    taxable_value NUMERIC(12,2) NOT NULL,
+    cgst NUMERIC(12,2) NOT NULL DEFAULT 0,
+    sgst NUMERIC(12,2) NOT NULL DEFAULT 0,
+    igst NUMERIC(12,2) NOT NULL DEFAULT 0,
+    total NUMERIC(12,2) NOT NULL,
+    notes TEXT,
+    emailed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (agency_id, document_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_billing_documents_agency ON billing_documents(agency_id, issue_date DESC, document_id DESC);
@@ -0,0 +1,44 @@
+-- Migration: Package bookings
+-- This script assumes PostgreSQL
+
+CREATE TABLE IF NOT EXISTS bookings (
+    booking_id SERIAL PRIMARY KEY,
+    userid INTEGER NOT NULL REFERENCES users(userid) ON DELETE CASCADE,
+    agency_id INTEGER NOT NULL REFERENCES travel_agencies(agency_id) ON DELETE CASCADE,
+    -- Kept when the package is deleted; the title snapshot still describes it
+    package_id INTEGER REFERENCES travel_packages(package_id) ON DELETE SET NULL,
+    package_title VARCHAR(150) NOT NULL,
+    departure_date DATE NOT NULL,
+    num_travelers INTEGER NOT NULL CHECK (num_travelers > 0),
+    unit_price NUMERIC(10,2) NOT NULL CHECK (unit_price >= 0),
+    total_price NUMERIC(12,2) NOT NULL CHECK (total_price >= 0),
+    currency VARCHAR(3) NOT NULL DEFAULT 'INR',
+    contact_name VARCHAR(100) NOT NULL,
+    contact_email VARCHAR(100) NOT NULL,
+    contact_phone VARCHAR(20) NOT NULL,
+    special_requests TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'requested'
+        CHECK (status IN ('requested', 'confirmed', 'completed', 'cancelled')),
+    cancelled_by VARCHAR(10) CHECK (cancelled_by IN ('user', 'agency')),
+    cancellation_reason TEXT,
+    confirmed_at TIMESTAMP,
+    completed_at TIMESTAMP,
+    cancelled_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_bookings_user ON bookings(userid, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_bookings_agency_status ON bookings(agency_id, status, departure_date);
+CREATE INDEX IF NOT EXISTS idx_bookings_package ON bookings(package_id);
+
+CREATE TABLE IF NOT EXISTS booking_travelers (
+    traveler_id SERIAL PRIMARY KEY,
+    booking_id INTEGER NOT NULL REFERENCES bookings(booking_id) ON DELETE CASCADE,
+    full_name VARCHAR(100) NOT NULL,
+    age INTEGER NOT NULL CHECK (age >= 0 AND age <= 120),
+    gender VARCHAR(20),
+    sort_order INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_booking_travelers_booking ON booking_travelers(booking_id);
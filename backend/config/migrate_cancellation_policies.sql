@@ -0,0 +1,6 @@
+-- Migration: Package cancellation policies
+-- This script assumes PostgreSQL
+
+-- Tiered refunds as [{"min_days_before": 30, "refund_percent": 100}, ...],
+-- sorted from the earliest cancellation. NULL means no published policy.
+ALTER TABLE travel_packages ADD COLUMN IF NOT EXISTS cancellation_policy JSONB;
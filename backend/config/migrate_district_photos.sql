@@ -0,0 +1,19 @@
+-- Migration: Managed district photo galleries
+CREATE TABLE IF NOT EXISTS district_photos (
+    photo_id SERIAL PRIMARY KEY,
+    district_name VARCHAR(100) NOT NULL,
+    folder VARCHAR(100) NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    caption TEXT,
+    alt_text TEXT,
+    credit VARCHAR(255),
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    is_cover BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(folder, filename)
+);
+
+CREATE INDEX IF NOT EXISTS idx_district_photos_district ON district_photos(district_name, sort_order);
+
+-- At most one cover photo per district
+CREATE UNIQUE INDEX IF NOT EXISTS idx_district_photos_cover ON district_photos(district_name) WHERE is_cover;
@@ -0,0 +1,50 @@
+-- Migration: District knowledge base
+-- Canonical names match the values already stored in feedbacks and trips
+CREATE TABLE IF NOT EXISTS districts (
+    district_id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    slug VARCHAR(100) NOT NULL UNIQUE,
+    aliases TEXT[] NOT NULL DEFAULT '{}',
+    latitude DOUBLE PRECISION NOT NULL,
+    longitude DOUBLE PRECISION NOT NULL,
+    description TEXT,
+    best_season VARCHAR(255),
+    highlights TEXT[] NOT NULL DEFAULT '{}',
+    photo_folder VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO districts (name, slug, aliases, latitude, longitude, description, best_season, highlights, photo_folder) VALUES
+    ('Bagalkot', 'bagalkot', ARRAY['Bagalkote']::TEXT[], 16.1691, 75.6615, 'North Karnataka district at the heart of early Chalukyan temple architecture.', 'October to February', ARRAY['Badami cave temples', 'Aihole temple complex', 'Pattadakal (UNESCO World Heritage Site)', 'Banashankari temple']::TEXT[], 'Bagalkot'),
+    ('Ballari (Bellary)', 'ballari', ARRAY['Ballari', 'Bellary']::TEXT[], 15.1394, 76.9214, 'Rocky Deccan district known for its hill forts and the forested Sandur hills.', 'October to February', ARRAY['Ballari Fort', 'Sandur hills', 'Kumaraswamy temple, Sandur']::TEXT[], 'Ballari'),
+    ('Belagavi (Belgaum)', 'belagavi', ARRAY['Belagavi', 'Belgaum']::TEXT[], 15.8497, 74.4977, 'Border district with historic forts and waterfalls that swell in the monsoon.', 'October to February; July to September for the waterfalls', ARRAY['Belagavi Fort', 'Gokak Falls', 'Kittur Fort', 'Saundatti Yellamma temple']::TEXT[], 'Belagavi'),
+    ('Bengaluru Rural', 'bengaluru-rural', ARRAY[]::TEXT[], 13.2257, 77.391, 'Countryside around the capital with hill forts, temples and weekend treks.', 'September to February', ARRAY['Devanahalli Fort', 'Ghati Subramanya temple', 'Makalidurga']::TEXT[], 'Bengaluru Rural'),
+    ('Bengaluru Urban', 'bengaluru-urban', ARRAY['Bengaluru', 'Bangalore']::TEXT[], 12.9716, 77.5946, 'Karnataka''s capital, known for its parks, palaces, food and nightlife.', 'Year-round; October to February is most pleasant', ARRAY['Lalbagh Botanical Garden', 'Cubbon Park', 'Bangalore Palace', 'Tipu Sultan''s Summer Palace']::TEXT[], 'Bengaluru Urban'),
+    ('Bidar', 'bidar', ARRAY[]::TEXT[], 17.9104, 77.5199, 'Northernmost district with well-preserved Bahmani-era monuments.', 'October to February', ARRAY['Bidar Fort', 'Mahmud Gawan Madrasa', 'Bahmani tombs, Ashtur', 'Gurudwara Nanak Jhira Sahib']::TEXT[], 'Bidar'),
+    ('Chamarajanagar', 'chamarajanagar', ARRAY[]::TEXT[], 11.9261, 76.9398, 'Southern forest district bordering Tamil Nadu and Kerala, rich in wildlife.', 'October to March', ARRAY['Bandipur National Park', 'Biligiriranga (BR) Hills', 'Male Mahadeshwara Hills', 'Gopalaswamy Betta']::TEXT[], 'Chamarajanagar'),
+    ('Chikkaballapur', 'chikkaballapur', ARRAY['Chikballapur']::TEXT[], 13.4355, 77.7278, 'Hill district north of Bengaluru, popular for sunrise viewpoints.', 'September to February', ARRAY['Nandi Hills', 'Bhoga Nandeeshwara temple', 'Skandagiri']::TEXT[], 'Chikkaballapur'),
+    ('Chikkamagaluru', 'chikkamagaluru', ARRAY['Chikmagalur']::TEXT[], 13.3161, 75.7747, 'Coffee country in the Western Ghats with the state''s highest peaks.', 'September to March', ARRAY['Mullayanagiri', 'Baba Budangiri', 'Kudremukh National Park', 'Hebbe Falls', 'Coffee estates']::TEXT[], 'Chikkamagaluru'),
+    ('Chitradurga', 'chitradurga', ARRAY[]::TEXT[], 14.2251, 76.398, 'Central Karnataka district famous for its sprawling stone fort.', 'October to February', ARRAY['Chitradurga Fort', 'Chandravalli caves', 'Jogimatti forest']::TEXT[], 'Chitradurga'),
+    ('Dakshina Kannada', 'dakshina-kannada', ARRAY['Mangaluru', 'Mangalore']::TEXT[], 12.9141, 74.856, 'Coastal district with beaches, temple towns and Tulu Nadu cuisine.', 'October to March', ARRAY['Panambur Beach', 'Tannirbhavi Beach', 'Dharmasthala', 'Kukke Subramanya temple', 'Kateel Durgaparameshwari temple']::TEXT[], 'Dakshina Kannada'),
+    ('Davanagere', 'davanagere', ARRAY['Davangere']::TEXT[], 14.4644, 75.9218, 'Central district known for its lakes and benne dosa.', 'October to February', ARRAY['Kunduvada Kere', 'Harihareshwara temple, Harihar', 'Santhebennur Pushkarani']::TEXT[], 'Davanagere'),
+    ('Dharwad', 'dharwad', ARRAY['Hubballi', 'Hubli']::TEXT[], 15.4589, 75.0078, 'Twin-city district of Hubballi-Dharwad, a centre of Hindustani music.', 'October to February', ARRAY['Unkal Lake', 'Nrupatunga Betta', 'Sadhankeri Lake']::TEXT[], 'Dharwad'),
+    ('Gadag', 'gadag', ARRAY[]::TEXT[], 15.4166, 75.629, 'Dry north Karnataka district with Kalyani Chalukya temples.', 'October to February; November to March for birds', ARRAY['Lakkundi temples', 'Trikuteshwara temple', 'Veeranarayana temple', 'Magadi bird sanctuary']::TEXT[], 'Gadag'),
+    ('Hassan', 'hassan', ARRAY[]::TEXT[], 13.0068, 76.0996, 'Home to the finest Hoysala temples and the hill station of Sakleshpur.', 'October to March', ARRAY['Chennakeshava temple, Belur', 'Hoysaleswara temple, Halebidu', 'Shravanabelagola', 'Sakleshpur']::TEXT[], 'Hassan'),
+    ('Haveri', 'haveri', ARRAY[]::TEXT[], 14.7951, 75.399, 'Agricultural district with Chalukyan temples and a peacock sanctuary.', 'October to February', ARRAY['Bankapura peacock sanctuary', 'Siddheshwara temple', 'Galageshwara temple']::TEXT[], 'Haveri'),
+    ('Kalaburagi (Gulbarga)', 'kalaburagi', ARRAY['Kalaburagi', 'Gulbarga']::TEXT[], 17.3297, 76.8343, 'Former Bahmani capital with forts, domes and Sufi shrines.', 'October to February', ARRAY['Gulbarga Fort', 'Khwaja Bande Nawaz Dargah', 'Sharana Basaveshwara temple', 'Sannati']::TEXT[], 'Kalaburagi'),
+    ('Kodagu (Coorg)', 'kodagu', ARRAY['Kodagu', 'Coorg', 'Madikeri']::TEXT[], 12.4244, 75.7382, 'Misty hill district of coffee estates, waterfalls and Kodava culture.', 'October to March', ARRAY['Abbey Falls', 'Raja''s Seat', 'Dubare Elephant Camp', 'Namdroling Monastery, Bylakuppe', 'Talakaveri']::TEXT[], 'Kodagu'),
+    ('Kolar', 'kolar', ARRAY[]::TEXT[], 13.136, 78.129, 'Eastern district known for its temples and the old gold fields.', 'October to February', ARRAY['Kolaramma temple', 'Antara Gange', 'Kotilingeshwara temple']::TEXT[], 'Kolar'),
+    ('Koppal', 'koppal', ARRAY[]::TEXT[], 15.355, 76.1548, 'Boulder-strewn district across the Tungabhadra from Hampi.', 'October to February', ARRAY['Anegundi', 'Anjanadri hill', 'Itagi Mahadeva temple', 'Pampa Sarovar']::TEXT[], 'Koppal'),
+    ('Mandya', 'mandya', ARRAY[]::TEXT[], 12.5218, 76.8958, 'Sugarcane belt on the Kaveri with Srirangapatna and bird sanctuaries.', 'October to February', ARRAY['Srirangapatna', 'Ranganathittu Bird Sanctuary', 'KRS Dam and Brindavan Gardens', 'Melukote', 'Shivanasamudra Falls']::TEXT[], 'Mandya'),
+    ('Mysuru (Mysore)', 'mysuru', ARRAY['Mysuru', 'Mysore']::TEXT[], 12.2958, 76.6394, 'The royal city, known for its palace, Dasara festival, silk and sandalwood.', 'October to February; Dasara in September or October', ARRAY['Mysore Palace', 'Chamundi Hills', 'St. Philomena''s Church', 'Mysuru Zoo']::TEXT[], 'Mysuru'),
+    ('Raichur', 'raichur', ARRAY[]::TEXT[], 16.212, 77.3566, 'Doab district between the Krishna and Tungabhadra with hill forts and Ashokan edicts.', 'October to February', ARRAY['Raichur Fort', 'Mudgal Fort', 'Maski Ashokan edict']::TEXT[], 'Raichur'),
+    ('Ramanagara', 'ramanagara', ARRAY[]::TEXT[], 12.7159, 77.2826, 'Granite hill district known for rock climbing and Channapatna toys.', 'October to February', ARRAY['Ramadevara Betta', 'Savandurga', 'Channapatna toy workshops', 'Kanva Reservoir']::TEXT[], 'Ramanagara'),
+    ('Shivamogga (Shimoga)', 'shivamogga', ARRAY['Shivamogga', 'Shimoga']::TEXT[], 13.9299, 75.5681, 'Malnad district of rainforests, waterfalls and the Sharavathi valley.', 'August to January; Jog Falls is fullest after the monsoon', ARRAY['Jog Falls', 'Agumbe', 'Sakrebailu Elephant Camp', 'Kodachadri']::TEXT[], 'Shivamogga'),
+    ('Tumakuru (Tumkur)', 'tumakuru', ARRAY['Tumakuru', 'Tumkur']::TEXT[], 13.3379, 77.101, 'District north-west of Bengaluru with hill forts and monastery towns.', 'October to February', ARRAY['Devarayanadurga', 'Madhugiri Fort', 'Siddaganga Matha']::TEXT[], 'Tumakuru'),
+    ('Udupi', 'udupi', ARRAY[]::TEXT[], 13.3409, 74.7421, 'Temple town and coast famous for the Krishna Matha and Udupi cuisine.', 'October to March', ARRAY['Sri Krishna Matha', 'Malpe Beach', 'St. Mary''s Island', 'Kaup lighthouse', 'Kollur Mookambika temple']::TEXT[], 'Udupi'),
+    ('Uttara Kannada (Karwar)', 'uttara-kannada', ARRAY['Uttara Kannada', 'Karwar']::TEXT[], 14.8182, 74.124, 'Long coastline and dense forests, from Gokarna''s beaches to Dandeli.', 'October to March', ARRAY['Gokarna', 'Murudeshwar', 'Dandeli', 'Yana rocks', 'Karwar beaches']::TEXT[], 'Uttara Kannada'),
+    ('Vijayanagara', 'vijayanagara', ARRAY['Hampi', 'Hosapete', 'Hospet']::TEXT[], 15.335, 76.47, 'Carved out of Ballari in 2021; home to the ruins of Hampi.', 'October to February', ARRAY['Hampi (UNESCO World Heritage Site)', 'Virupaksha temple', 'Vittala temple', 'Tungabhadra Dam']::TEXT[], 'Vijayanagara'),
+    ('Vijayapura (Bijapur)', 'vijayapura', ARRAY['Vijayapura', 'Bijapur']::TEXT[], 16.8302, 75.71, 'Adil Shahi capital with some of the Deccan''s grandest Islamic architecture.', 'October to February', ARRAY['Gol Gumbaz', 'Ibrahim Rauza', 'Bara Kaman', 'Almatti Dam']::TEXT[], 'Vijayapura'),
+    ('Yadgir', 'yadgir', ARRAY[]::TEXT[], 16.77, 77.1383, 'Northern district with a hill fort and wetlands.', 'October to February', ARRAY['Yadgir Fort', 'Bonal bird sanctuary', 'Shorapur']::TEXT[], 'Yadgir')
+ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,6 @@
+-- Migration: Ground generated itineraries in the POI catalog
+-- Tags (cuisine, dishes, themes) feed keyword retrieval
+ALTER TABLE pois ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+-- Which itinerary items were found in the catalog when the trip was generated
+ALTER TABLE trips ADD COLUMN IF NOT EXISTS grounding JSONB;
@@ -0,0 +1,25 @@
+-- Migration: Package departures and seat inventory
+-- This script assumes PostgreSQL
+
+CREATE TABLE IF NOT EXISTS package_departures (
+    departure_id SERIAL PRIMARY KEY,
+    package_id INTEGER NOT NULL REFERENCES travel_packages(package_id) ON DELETE CASCADE,
+    start_date DATE NOT NULL,
+    total_seats INTEGER NOT NULL CHECK (total_seats > 0),
+    -- Decremented under a row lock when a booking is made, restored on cancellation
+    seats_available INTEGER NOT NULL CHECK (seats_available >= 0 AND seats_available <= total_seats),
+    -- Replaces the package's per-person price for this departure when set
+    price_override NUMERIC(10,2) CHECK (price_override >= 0),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (package_id, start_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_package_departures_open ON package_departures(package_id, start_date)
+    WHERE is_active = TRUE;
+
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS departure_id INTEGER
+    REFERENCES package_departures(departure_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_bookings_departure ON bookings(departure_id);
@@ -0,0 +1,11 @@
+-- Migration: Indexes for filtering, sorting and paginating public packages
+-- This script assumes PostgreSQL
+
+-- Each sort order pages on (sort key, package_id) over active packages
+CREATE INDEX IF NOT EXISTS idx_packages_active_created ON travel_packages(created_at DESC, package_id DESC) WHERE is_active = TRUE;
+CREATE INDEX IF NOT EXISTS idx_packages_active_price ON travel_packages(price, package_id) WHERE is_active = TRUE;
+CREATE INDEX IF NOT EXISTS idx_packages_active_duration ON travel_packages(duration_days, package_id) WHERE is_active = TRUE;
+
+CREATE INDEX IF NOT EXISTS idx_packages_location_lower ON travel_packages(LOWER(location));
+CREATE INDEX IF NOT EXISTS idx_packages_initial_destination_lower ON travel_packages(LOWER(initial_destination));
+CREATE INDEX IF NOT EXISTS idx_packages_locations ON travel_packages USING GIN (locations);
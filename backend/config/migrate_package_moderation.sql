@@ -0,0 +1,37 @@
+-- Migration: Package moderation queue
+-- This script assumes PostgreSQL
+
+-- Packages that were already published stay visible, so the column is
+-- added as approved and new rows then default to pending_review
+ALTER TABLE travel_packages ADD COLUMN IF NOT EXISTS moderation_status VARCHAR(20) NOT NULL DEFAULT 'approved'
+    CHECK (moderation_status IN ('pending_review', 'approved', 'changes_requested', 'rejected'));
+ALTER TABLE travel_packages ALTER COLUMN moderation_status SET DEFAULT 'pending_review';
+ALTER TABLE travel_packages ADD COLUMN IF NOT EXISTS moderation_notes TEXT;
+-- Automated check results; NULL until the checks have run
+ALTER TABLE travel_packages ADD COLUMN IF NOT EXISTS moderation_flags JSONB;
+ALTER TABLE travel_packages ADD COLUMN IF NOT EXISTS submitted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE travel_packages ADD COLUMN IF NOT EXISTS moderated_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_travel_packages_moderation ON travel_packages(moderation_status, submitted_at);
+
+-- Every moderation status change, with the reviewer's notes and the flags
+-- the package had at the time
+CREATE TABLE IF NOT EXISTS package_reviews (
+    review_id SERIAL PRIMARY KEY,
+    package_id INTEGER NOT NULL REFERENCES travel_packages(package_id) ON DELETE CASCADE,
+    from_status VARCHAR(20) NOT NULL,
+    to_status VARCHAR(20) NOT NULL,
+    notes TEXT,
+    flags JSONB,
+    reviewed_by VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_package_reviews_package ON package_reviews(package_id, created_at);
+
+-- Words and phrases the automated checks flag in titles, descriptions and
+-- stops. Stored lower case.
+CREATE TABLE IF NOT EXISTS moderation_banned_words (
+    word VARCHAR(100) PRIMARY KEY,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,23 @@
+-- Migration: Package photos with ids, ordering and a cover photo
+-- travel_packages.photos is kept as the ordered list (cover first) for reads
+CREATE TABLE IF NOT EXISTS package_photos (
+    photo_id SERIAL PRIMARY KEY,
+    package_id INTEGER NOT NULL REFERENCES travel_packages(package_id) ON DELETE CASCADE,
+    filename VARCHAR(255) NOT NULL,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    is_cover BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(package_id, filename)
+);
+
+CREATE INDEX IF NOT EXISTS idx_package_photos_package ON package_photos(package_id, sort_order);
+
+-- At most one cover photo per package
+CREATE UNIQUE INDEX IF NOT EXISTS idx_package_photos_cover ON package_photos(package_id) WHERE is_cover;
+
+-- Backfill from the photos array; the first photo becomes the cover
+INSERT INTO package_photos (package_id, filename, sort_order, is_cover)
+SELECT p.package_id, ph.filename, ph.ord - 1, ph.ord = 1
+FROM travel_packages p, unnest(p.photos) WITH ORDINALITY AS ph(filename, ord)
+WHERE ph.filename IS NOT NULL AND ph.filename <> ''
+ON CONFLICT (package_id, filename) DO NOTHING;
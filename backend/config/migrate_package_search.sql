@@ -0,0 +1,44 @@
+-- Migration: Full-text search over travel packages
+-- This script assumes PostgreSQL
+
+-- pg_trgm provides similarity() for "did you mean" suggestions
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE travel_packages ADD COLUMN IF NOT EXISTS search_vector tsvector;
+
+-- Title weighs most, then the destination and stops, then the description
+CREATE OR REPLACE FUNCTION travel_packages_search_vector(title TEXT, location TEXT, locations TEXT[], description TEXT)
+RETURNS tsvector AS $$
+    SELECT setweight(to_tsvector('english', COALESCE(title, '')), 'A') ||
+           setweight(to_tsvector('english', COALESCE(location, '') || ' ' || COALESCE(array_to_string(locations, ' '), '')), 'B') ||
+           setweight(to_tsvector('english', COALESCE(description, '')), 'C');
+$$ LANGUAGE SQL IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION travel_packages_search_vector_update() RETURNS trigger AS $$
+BEGIN
+    NEW.search_vector := travel_packages_search_vector(NEW.title, NEW.location, NEW.locations, NEW.description);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS travel_packages_search_vector_trigger ON travel_packages;
+CREATE TRIGGER travel_packages_search_vector_trigger
+    BEFORE INSERT OR UPDATE OF title, location, locations, description ON travel_packages
+    FOR EACH ROW EXECUTE FUNCTION travel_packages_search_vector_update();
+
+UPDATE travel_packages
+SET search_vector = travel_packages_search_vector(title, location, locations, description);
+
+CREATE INDEX IF NOT EXISTS idx_packages_search_vector ON travel_packages USING GIN (search_vector);
+
+-- Trigram indexes for the suggestion query's % and %> filters. Stops are
+-- matched through one lowercased string per package, since an array can't
+-- be trigram indexed; array_to_string isn't immutable, hence the wrapper.
+CREATE OR REPLACE FUNCTION travel_packages_stops_text(locations TEXT[])
+RETURNS TEXT AS $$
+    SELECT LOWER(COALESCE(array_to_string(locations, ' '), ''));
+$$ LANGUAGE SQL IMMUTABLE;
+
+CREATE INDEX IF NOT EXISTS idx_packages_title_trgm ON travel_packages USING GIN (LOWER(title) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_packages_location_trgm ON travel_packages USING GIN (LOWER(location) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_packages_stops_trgm ON travel_packages USING GIN (travel_packages_stops_text(locations) gin_trgm_ops);
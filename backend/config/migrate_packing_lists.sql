@@ -0,0 +1,25 @@
+-- Migration: Store trip parameters and per-trip packing checklists
+ALTER TABLE trips
+  ADD COLUMN IF NOT EXISTS initial_destination VARCHAR(150),
+  ADD COLUMN IF NOT EXISTS final_destination VARCHAR(150),
+  ADD COLUMN IF NOT EXISTS start_date DATE,
+  ADD COLUMN IF NOT EXISTS end_date DATE,
+  ADD COLUMN IF NOT EXISTS num_travelers INTEGER,
+  ADD COLUMN IF NOT EXISTS mood VARCHAR(50);
+
+CREATE INDEX IF NOT EXISTS idx_trips_start_date ON trips(start_date);
+
+CREATE TABLE IF NOT EXISTS packing_list_items (
+    item_id SERIAL PRIMARY KEY,
+    tripid INTEGER NOT NULL REFERENCES trips(tripid) ON DELETE CASCADE,
+    userid INTEGER NOT NULL REFERENCES users(userid) ON DELETE CASCADE,
+    category VARCHAR(50) NOT NULL,
+    name VARCHAR(200) NOT NULL,
+    quantity INTEGER NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    reason TEXT,
+    checked BOOLEAN DEFAULT FALSE,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_packing_items_trip_user ON packing_list_items(tripid, userid);
@@ -0,0 +1,60 @@
+-- Migration: Payments, payment attempts and refunds
+-- This script assumes PostgreSQL
+
+CREATE TABLE IF NOT EXISTS payments (
+    payment_id SERIAL PRIMARY KEY,
+    userid INTEGER NOT NULL REFERENCES users(userid) ON DELETE CASCADE,
+    agency_id INTEGER NOT NULL REFERENCES travel_agencies(agency_id) ON DELETE CASCADE,
+    package_id INTEGER REFERENCES travel_packages(package_id) ON DELETE SET NULL,
+    booking_id INTEGER REFERENCES bookings(booking_id) ON DELETE SET NULL,
+    num_travelers INTEGER NOT NULL CHECK (num_travelers > 0),
+    amount NUMERIC(12,2) NOT NULL CHECK (amount > 0),
+    amount_refunded NUMERIC(12,2) NOT NULL DEFAULT 0 CHECK (amount_refunded >= 0 AND amount_refunded <= amount),
+    currency VARCHAR(3) NOT NULL DEFAULT 'INR',
+    gateway VARCHAR(20) NOT NULL,
+    gateway_order_id VARCHAR(100) NOT NULL,
+    gateway_payment_id VARCHAR(100),
+    -- duplicate is a capture for a booking another payment had already paid;
+    -- it is owed back in full
+    status VARCHAR(20) NOT NULL DEFAULT 'created'
+        CHECK (status IN ('created', 'paid', 'failed', 'partially_refunded', 'refunded', 'duplicate')),
+    paid_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (gateway, gateway_order_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payments_user ON payments(userid, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_payments_agency ON payments(agency_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_payments_booking ON payments(booking_id);
+
+-- Every checkout callback and webhook we receive for an order, including
+-- ones that failed signature verification or didn't match the order amount
+CREATE TABLE IF NOT EXISTS payment_attempts (
+    attempt_id SERIAL PRIMARY KEY,
+    payment_id INTEGER NOT NULL REFERENCES payments(payment_id) ON DELETE CASCADE,
+    source VARCHAR(20) NOT NULL CHECK (source IN ('callback', 'webhook')),
+    event VARCHAR(50),
+    gateway_payment_id VARCHAR(100),
+    outcome VARCHAR(20) NOT NULL CHECK (outcome IN ('success', 'failed', 'invalid_signature', 'amount_mismatch', 'duplicate_capture')),
+    error_message TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_attempts_payment ON payment_attempts(payment_id, created_at);
+
+-- A refund is recorded as pending before the gateway is called, so
+-- gateway_refund_id is NULL until the gateway answers
+CREATE TABLE IF NOT EXISTS payment_refunds (
+    refund_id SERIAL PRIMARY KEY,
+    payment_id INTEGER NOT NULL REFERENCES payments(payment_id) ON DELETE CASCADE,
+    gateway_refund_id VARCHAR(100) UNIQUE,
+    amount NUMERIC(12,2) NOT NULL CHECK (amount > 0),
+    reason TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'processed', 'failed')),
+    requested_by VARCHAR(10) NOT NULL CHECK (requested_by IN ('agency', 'admin')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_refunds_payment ON payment_refunds(payment_id);
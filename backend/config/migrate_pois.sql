@@ -0,0 +1,22 @@
+-- Migration: Points-of-interest catalog
+-- Seed it with `go run ./cmd/seed-pois` from the backend directory
+CREATE TABLE IF NOT EXISTS pois (
+    poi_id SERIAL PRIMARY KEY,
+    district_name VARCHAR(100) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    category VARCHAR(50) NOT NULL,
+    description TEXT,
+    latitude DOUBLE PRECISION NOT NULL,
+    longitude DOUBLE PRECISION NOT NULL,
+    opening_hours JSONB NOT NULL DEFAULT '[]',
+    visit_duration_minutes INTEGER NOT NULL DEFAULT 0,
+    entry_fee_min DECIMAL(10, 2),
+    entry_fee_max DECIMAL(10, 2),
+    accessibility TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(district_name, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pois_district ON pois(district_name, category);
+CREATE INDEX IF NOT EXISTS idx_pois_location ON pois(latitude, longitude);
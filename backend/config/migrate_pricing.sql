@@ -0,0 +1,36 @@
+-- Migration: Package pricing rules and promo codes
+-- This script assumes PostgreSQL
+
+-- Per-person vs per-group pricing, child/senior prices, seasonal
+-- multipliers and group discount tiers. NULL prices per person with no
+-- adjustments.
+ALTER TABLE travel_packages ADD COLUMN IF NOT EXISTS pricing_rules JSONB;
+
+CREATE TABLE IF NOT EXISTS promo_codes (
+    promo_id SERIAL PRIMARY KEY,
+    -- Stored upper-case; codes are matched case-insensitively
+    code VARCHAR(30) NOT NULL UNIQUE,
+    -- NULL for admin-issued codes valid on every agency's packages
+    agency_id INTEGER REFERENCES travel_agencies(agency_id) ON DELETE CASCADE,
+    -- Limits the code to one package when set
+    package_id INTEGER REFERENCES travel_packages(package_id) ON DELETE CASCADE,
+    discount_type VARCHAR(10) NOT NULL CHECK (discount_type IN ('percent', 'flat')),
+    discount_value NUMERIC(10,2) NOT NULL CHECK (discount_value > 0),
+    max_discount NUMERIC(10,2) CHECK (max_discount > 0),
+    usage_limit INTEGER CHECK (usage_limit > 0),
+    used_count INTEGER NOT NULL DEFAULT 0 CHECK (used_count >= 0),
+    starts_at TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by VARCHAR(10) NOT NULL CHECK (created_by IN ('agency', 'admin')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (discount_type <> 'percent' OR discount_value <= 100)
+);
+
+CREATE INDEX IF NOT EXISTS idx_promo_codes_agency ON promo_codes(agency_id, created_at DESC);
+
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS promo_code_id INTEGER REFERENCES promo_codes(promo_id) ON DELETE SET NULL;
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS discount_total NUMERIC(12,2) NOT NULL DEFAULT 0;
+-- The itemized quote the total was computed from
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS price_breakdown JSONB;
@@ -0,0 +1,12 @@
+-- Migration: Track severe-weather alerts sent for saved trips
+-- One row per trip and forecast day keeps alerts from being repeated
+CREATE TABLE IF NOT EXISTS weather_alerts (
+    alert_id SERIAL PRIMARY KEY,
+    tripid INTEGER NOT NULL REFERENCES trips(tripid) ON DELETE CASCADE,
+    alert_date DATE NOT NULL,
+    alert_kinds VARCHAR(100) NOT NULL,
+    sent_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(tripid, alert_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_weather_alerts_tripid ON weather_alerts(tripid);
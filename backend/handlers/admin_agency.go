@@ -11,15 +11,23 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-// GetAllAgenciesAdmin retrieves all travel agencies for admin
+// GetAllAgenciesAdmin retrieves all travel agencies for admin, optionally
+// only those with ?status=
 func GetAllAgenciesAdmin(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	status := r.URL.Query().Get("status")
+	if _, ok := agencyStatusTransitions[status]; status != "" && !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid status"})
+		return
+	}
 	rows, err := config.DB.Query(`
-		SELECT agency_id, name, email, phone, website, created_at 
+		SELECT agency_id, name, email, phone, website, status, created_at 
 		FROM travel_agencies 
+		WHERE $1 = '' OR status = $1
 		ORDER BY created_at DESC
-	`)
+	`, status)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to fetch agencies"})
@@ -32,7 +40,7 @@ func GetAllAgenciesAdmin(w http.ResponseWriter, r *http.Request) {
 		var agency models.TravelAgency
 		err := rows.Scan(
 			&agency.AgencyID, &agency.Name, &agency.Email,
-			&agency.Phone, &agency.Website, &agency.CreatedAt,
+			&agency.Phone, &agency.Website, &agency.Status, &agency.CreatedAt,
 		)
 		if err != nil {
 			continue
@@ -90,15 +98,15 @@ func AdminCreateAgency(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Insert new agency
+	// Insert new agency; agencies an admin creates are approved directly
 	var agency models.TravelAgency
 	err = config.DB.QueryRow(`
-		INSERT INTO travel_agencies (name, email, phone, website, password)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING agency_id, name, email, phone, website, created_at
+		INSERT INTO travel_agencies (name, email, phone, website, password, status, reviewed_at)
+		VALUES ($1, $2, $3, $4, $5, 'approved', NOW())
+		RETURNING agency_id, name, email, phone, website, status, created_at
 	`, request.Name, request.Email, request.Phone, request.Website, string(hashedPassword)).Scan(
 		&agency.AgencyID, &agency.Name, &agency.Email,
-		&agency.Phone, &agency.Website, &agency.CreatedAt,
+		&agency.Phone, &agency.Website, &agency.Status, &agency.CreatedAt,
 	)
 
 	if err != nil {
@@ -213,7 +221,7 @@ func GetAgencyPackagesAdmin(w http.ResponseWriter, r *http.Request) {
 	rows, err := config.DB.Query(`
 		SELECT p.package_id, p.agency_id, a.name as agency_name, p.title, p.description,
 			   p.location, p.initial_destination, p.duration_days, p.num_travelers,
-			   p.transport_mode, p.price, p.is_active, p.created_at
+			   p.transport_mode, p.price, p.is_active, p.moderation_status, p.created_at
 		FROM travel_packages p
 		JOIN travel_agencies a ON p.agency_id = a.agency_id
 		WHERE p.agency_id = $1
@@ -232,7 +240,7 @@ func GetAgencyPackagesAdmin(w http.ResponseWriter, r *http.Request) {
 		err := rows.Scan(
 			&pkg.PackageID, &pkg.AgencyID, &pkg.AgencyName, &pkg.Title, &pkg.Description,
 			&pkg.Location, &pkg.InitialDestination, &pkg.DurationDays, &pkg.NumTravelers,
-			&pkg.TransportMode, &pkg.Price, &pkg.IsActive, &pkg.CreatedAt,
+			&pkg.TransportMode, &pkg.Price, &pkg.IsActive, &pkg.ModerationStatus, &pkg.CreatedAt,
 		)
 		if err != nil {
 			continue
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"trip-planner-backend/jobs"
+)
+
+// AdminUploadGC reports stored files that no package or district photo
+// refers to. GET is always a dry run; POST deletes orphans older than the
+// grace period. grace_hours overrides the configured grace period.
+func AdminUploadGC(w http.ResponseWriter, r *http.Request) {
+	cfg := jobs.UploadGCConfigFromEnv()
+	cfg.DryRun = r.Method == http.MethodGet || r.URL.Query().Get("dry_run") == "true"
+
+	if v := r.URL.Query().Get("grace_hours"); v != "" {
+		hours, err := strconv.Atoi(v)
+		if err != nil || hours < 0 {
+			sendJSONError(w, "grace_hours must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		cfg.GracePeriod = time.Duration(hours) * time.Hour
+	}
+
+	report, err := jobs.RunUploadGC(r.Context(), cfg)
+	if err != nil {
+		log.Printf("Upload GC failed: %v", err)
+		sendJSONError(w, "Error scanning uploads", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
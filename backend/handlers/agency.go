@@ -4,11 +4,9 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"math/rand"
 	"net/http"
-	"os"
 	"strconv"
 	"time"
 	"trip-planner-backend/config"
@@ -178,10 +176,10 @@ func AgencyRegister(w http.ResponseWriter, r *http.Request) {
 	var agency models.TravelAgency
 	query := `INSERT INTO travel_agencies (name, email, password, phone, website)
 			  VALUES ($1, $2, $3, $4, $5)
-			  RETURNING agency_id, name, email, phone, website, created_at`
+			  RETURNING agency_id, name, email, phone, website, status, created_at`
 
 	err = config.DB.QueryRow(query, req.Name, req.Email, hashedPassword, req.Phone, req.Website).
-		Scan(&agency.AgencyID, &agency.Name, &agency.Email, &agency.Phone, &agency.Website, &agency.CreatedAt)
+		Scan(&agency.AgencyID, &agency.Name, &agency.Email, &agency.Phone, &agency.Website, &agency.Status, &agency.CreatedAt)
 	if err != nil {
 		http.Error(w, "Agency with that email already exists", http.StatusConflict)
 		return
@@ -197,7 +195,7 @@ func AgencyRegister(w http.ResponseWriter, r *http.Request) {
 		"token":     token,
 		"agency":    agency,
 		"role":      "agency",
-		"message":   "Agency registered successfully",
+		"message":   "Agency registered. Upload your registration documents and submit them for review to publish packages.",
 		"agency_id": agency.AgencyID,
 	}
 
@@ -217,7 +215,7 @@ func AgencyLogin(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var agency models.TravelAgency
-	query := `SELECT agency_id, name, email, phone, website, password, created_at
+	query := `SELECT agency_id, name, email, phone, website, password, status, created_at
 			  FROM travel_agencies WHERE email = $1`
 
 	err := config.DB.QueryRow(query, req.Email).Scan(
@@ -227,6 +225,7 @@ func AgencyLogin(w http.ResponseWriter, r *http.Request) {
 		&agency.Phone,
 		&agency.Website,
 		&agency.Password,
+		&agency.Status,
 		&agency.CreatedAt,
 	)
 
@@ -267,9 +266,7 @@ func CreateTravelPackage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse the multipart form (limit: 20MB)
-	if err := r.ParseMultipartForm(20 << 20); err != nil {
-		http.Error(w, "Error parsing form data: "+err.Error(), http.StatusBadRequest)
+	if !parseUploadForm(w, r) {
 		return
 	}
 
@@ -312,35 +309,39 @@ func CreateTravelPackage(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	policy, storedPolicy, msg := parseCancellationPolicy(getStr("cancellation_policy"))
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	pricing, storedPricing, msg := parsePricingRules(getStr("pricing_rules"))
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
 
-	photos := []string{}
 	files := r.MultipartForm.File["photos"]
-	for _, fileHeader := range files {
-		file, err := fileHeader.Open()
-		if err != nil {
-			continue
-		}
-		defer file.Close()
-		filename := fmt.Sprintf("%d_%d_%s", agencyID, time.Now().UnixNano(), fileHeader.Filename)
-		filepath := fmt.Sprintf("uploads/packages/%s", filename)
-		out, err := os.Create(filepath)
-		if err != nil {
-			continue
-		}
-		defer out.Close()
-		_, err = io.Copy(out, file)
-		if err == nil {
-			photos = append(photos, filename)
-		}
+	if len(files) > uploadLimits().MaxPhotosPerPackage {
+		http.Error(w, fmt.Sprintf("A package can have at most %d photos", uploadLimits().MaxPhotosPerPackage), http.StatusBadRequest)
+		return
+	}
+	photos, photoErrors := savePackagePhotos(r.Context(), agencyID, files)
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		removeStoredPackagePhotos(r.Context(), photos)
+		http.Error(w, "Failed to create travel package", http.StatusInternalServerError)
+		return
 	}
+	defer tx.Rollback()
 
 	var pkg models.TravelPackage
 	query := `INSERT INTO travel_packages
-		(agency_id, title, description, location, initial_destination, duration_days, num_travelers, transport_mode, price, is_active, locations, photos)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
-		RETURNING package_id, title, description, location, initial_destination, duration_days, num_travelers, transport_mode, price, is_active, locations, photos, created_at, updated_at`
-	err := config.DB.QueryRow(query,
-		agencyID, title, description, location, initialDestination, durationDays, numTravelers, transportMode, price, isActive, pq.Array(locations), pq.Array(photos),
+		(agency_id, title, description, location, initial_destination, duration_days, num_travelers, transport_mode, price, is_active, locations, photos, cancellation_policy, pricing_rules)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING package_id, title, description, location, initial_destination, duration_days, num_travelers, transport_mode, price, is_active, moderation_status, locations, photos, created_at, updated_at`
+	err = tx.QueryRow(query,
+		agencyID, title, description, location, initialDestination, durationDays, numTravelers, transportMode, price, isActive, pq.Array(locations), pq.Array(photos), storedPolicy, storedPricing,
 	).Scan(
 		&pkg.PackageID,
 		&pkg.Title,
@@ -352,18 +353,34 @@ func CreateTravelPackage(w http.ResponseWriter, r *http.Request) {
 		&pkg.TransportMode,
 		&pkg.Price,
 		&pkg.IsActive,
+		&pkg.ModerationStatus,
 		pq.Array(&pkg.Locations),
 		pq.Array(&pkg.Photos),
 		&pkg.CreatedAt,
 		&pkg.UpdatedAt,
 	)
+	if err == nil {
+		err = addPackagePhotos(tx, pkg.PackageID, photos)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
 	if err != nil {
+		removeStoredPackagePhotos(r.Context(), photos)
 		http.Error(w, "Failed to create travel package", http.StatusInternalServerError)
 		return
 	}
+	// New packages wait in the moderation queue
+	go runPackageChecks(pkg.PackageID)
 	pkg.AgencyID = agencyID
+	pkg.CancellationPolicy = policy
+	pkg.PricingRules = pricing
+	setPackagePhotoVariants(r.Context(), &pkg)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(pkg)
+	json.NewEncoder(w).Encode(struct {
+		models.TravelPackage
+		PhotoErrors []uploadError `json:"photo_errors"`
+	}{pkg, photoErrors})
 }
 
 func GetAgencyPackages(w http.ResponseWriter, r *http.Request) {
@@ -373,7 +390,7 @@ func GetAgencyPackages(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	query := `SELECT package_id, title, description, location, initial_destination, duration_days, num_travelers, transport_mode, price, is_active, locations, photos, created_at, updated_at
+	query := `SELECT package_id, title, description, location, initial_destination, duration_days, num_travelers, transport_mode, price, is_active, moderation_status, COALESCE(moderation_notes, ''), locations, photos, cancellation_policy, pricing_rules, created_at, updated_at
 			  FROM travel_packages
 			  WHERE agency_id = $1
 			  ORDER BY created_at DESC`
@@ -388,6 +405,7 @@ func GetAgencyPackages(w http.ResponseWriter, r *http.Request) {
 	var packages []models.TravelPackage
 	for rows.Next() {
 		var pkg models.TravelPackage
+		var policy, pricing []byte
 		if err := rows.Scan(
 			&pkg.PackageID,
 			&pkg.Title,
@@ -399,14 +417,21 @@ func GetAgencyPackages(w http.ResponseWriter, r *http.Request) {
 			&pkg.TransportMode,
 			&pkg.Price,
 			&pkg.IsActive,
+			&pkg.ModerationStatus,
+			&pkg.ModerationNotes,
 			pq.Array(&pkg.Locations),
 			pq.Array(&pkg.Photos),
+			&policy,
+			&pricing,
 			&pkg.CreatedAt,
 			&pkg.UpdatedAt,
 		); err != nil {
 			continue
 		}
 		pkg.AgencyID = agencyID
+		pkg.CancellationPolicy = decodeCancellationPolicy(policy)
+		pkg.PricingRules = decodePricingRules(pricing)
+		setPackagePhotoVariants(r.Context(), &pkg)
 		packages = append(packages, pkg)
 	}
 
@@ -430,8 +455,7 @@ func UpdateTravelPackage(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid package ID", http.StatusBadRequest)
 		return
 	}
-	if err := r.ParseMultipartForm(20 << 20); err != nil {
-		http.Error(w, "Error parsing form data: "+err.Error(), http.StatusBadRequest)
+	if !parseUploadForm(w, r) {
 		return
 	}
 	getStr := func(key string) string { return r.FormValue(key) }
@@ -470,41 +494,140 @@ func UpdateTravelPackage(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
-	photos := []string{}
+	_, storedPolicy, msg := parseCancellationPolicy(getStr("cancellation_policy"))
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	_, storedPricing, msg := parsePricingRules(getStr("pricing_rules"))
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	// New photos are added to the gallery; existing ones are kept unless
+	// replace_photos is set
+	replacePhotos := getBool("replace_photos")
 	files := r.MultipartForm.File["photos"]
-	for _, fileHeader := range files {
-		file, err := fileHeader.Open()
-		if err != nil {
-			continue
-		}
-		defer file.Close()
-		filename := fmt.Sprintf("%d_%d_%s", agencyID, time.Now().UnixNano(), fileHeader.Filename)
-		filepath := fmt.Sprintf("uploads/packages/%s", filename)
-		out, err := os.Create(filepath)
-		if err != nil {
-			continue
-		}
-		defer out.Close()
-		_, err = io.Copy(out, file)
-		if err == nil {
-			photos = append(photos, filename)
+	existing := 0
+	if !replacePhotos {
+		var err error
+		if existing, err = countPackagePhotos(config.DB, packageID); err != nil {
+			http.Error(w, "Failed to update package", http.StatusInternalServerError)
+			return
 		}
 	}
-	query := `UPDATE travel_packages
+	if existing+len(files) > uploadLimits().MaxPhotosPerPackage {
+		http.Error(w, fmt.Sprintf("A package can have at most %d photos (it has %d)", uploadLimits().MaxPhotosPerPackage, existing), http.StatusBadRequest)
+		return
+	}
+
+	photos, photoErrors := savePackagePhotos(r.Context(), agencyID, files)
+	if replacePhotos && len(files) > 0 && len(photos) == 0 {
+		// Don't wipe the gallery when none of the replacements were usable
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":        "None of the replacement photos could be saved",
+			"photo_errors": photoErrors,
+		})
+		return
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		removeStoredPackagePhotos(r.Context(), photos)
+		http.Error(w, "Failed to update package", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// changed is whether anything travelers see was edited; toggling
+	// is_active alone doesn't need another review
+	query := `UPDATE travel_packages p
 			  SET title = $1, description = $2, location = $3, initial_destination = $4, duration_days = $5,
 		    num_travelers = $6, transport_mode = $7, price = $8, is_active = $9, updated_at = $10,
-		    locations = $11, photos = $12
-		WHERE package_id = $13 AND agency_id = $14
-			  RETURNING package_id`
-	result := config.DB.QueryRow(query,
-		title, description, location, initialDestination, durationDays, numTravelers, transportMode, price, isActive, time.Now(), pq.Array(locations), pq.Array(photos), packageID, agencyID,
+		    locations = $11, cancellation_policy = CASE WHEN $14 THEN $15::jsonb ELSE p.cancellation_policy END,
+		    pricing_rules = CASE WHEN $16 THEN $17::jsonb ELSE p.pricing_rules END
+		FROM (SELECT * FROM travel_packages WHERE package_id = $12 AND agency_id = $13 FOR UPDATE) old
+		WHERE p.package_id = old.package_id
+			  RETURNING p.package_id,
+		    (p.title, p.description, p.location, p.initial_destination, p.duration_days, p.num_travelers,
+		     p.transport_mode, p.price, p.locations, p.cancellation_policy, p.pricing_rules) IS DISTINCT FROM
+		    (old.title, old.description, old.location, old.initial_destination, old.duration_days, old.num_travelers,
+		     old.transport_mode, old.price, old.locations, old.cancellation_policy, old.pricing_rules)`
+	// Clients that don't send cancellation_policy or pricing_rules leave
+	// them as they are
+	_, policySent := r.MultipartForm.Value["cancellation_policy"]
+	_, pricingSent := r.MultipartForm.Value["pricing_rules"]
+	result := tx.QueryRow(query,
+		title, description, location, initialDestination, durationDays, numTravelers, transportMode, price, isActive, time.Now(), pq.Array(locations), packageID, agencyID,
+		policySent, storedPolicy, pricingSent, storedPricing,
 	)
-	if err := result.Scan(&packageID); err != nil {
-		http.Error(w, "Package not found or not owned by agency", http.StatusNotFound)
+	var changed bool
+	if err := result.Scan(&packageID, &changed); err != nil {
+		removeStoredPackagePhotos(r.Context(), photos)
+		if err == sql.ErrNoRows {
+			http.Error(w, "Package not found or not owned by agency", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error updating package %d: %v", packageID, err)
+		http.Error(w, "Failed to update package", http.StatusInternalServerError)
 		return
 	}
+
+	// The update locked the package row, so photos added since the check
+	// above are counted now
+	if !replacePhotos {
+		if existing, err = countPackagePhotos(tx, packageID); err != nil {
+			removeStoredPackagePhotos(r.Context(), photos)
+			http.Error(w, "Failed to update package", http.StatusInternalServerError)
+			return
+		}
+		if existing+len(photos) > uploadLimits().MaxPhotosPerPackage {
+			removeStoredPackagePhotos(r.Context(), photos)
+			http.Error(w, fmt.Sprintf("A package can have at most %d photos (it has %d)", uploadLimits().MaxPhotosPerPackage, existing), http.StatusBadRequest)
+			return
+		}
+	}
+
+	removed := []string{}
+	if replacePhotos {
+		removed, err = removeAllPackagePhotos(tx, packageID)
+	}
+	if err == nil {
+		err = addPackagePhotos(tx, packageID, photos)
+	}
+	if err == nil {
+		// addPackagePhotos skips the sync when nothing was uploaded
+		err = syncPackagePhotoArray(tx, packageID)
+	}
+	inReview := false
+	if err == nil && (changed || len(photos) > 0 || len(removed) > 0) {
+		inReview, err = resubmitPackage(tx, packageID)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		removeStoredPackagePhotos(r.Context(), photos)
+		http.Error(w, "Failed to update package", http.StatusInternalServerError)
+		return
+	}
+	removeStoredPackagePhotos(r.Context(), removed)
+	if inReview {
+		go runPackageChecks(packageID)
+	}
+
+	message := "Package updated successfully"
+	if inReview {
+		message = "Package updated and sent for review"
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"message": "Package updated successfully"})
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":      message,
+		"in_review":    inReview,
+		"photo_errors": photoErrors,
+	})
 }
 
 func DeleteTravelPackage(w http.ResponseWriter, r *http.Request) {
@@ -539,70 +662,3 @@ func DeleteTravelPackage(w http.ResponseWriter, r *http.Request) {
 		"message": "Package deleted successfully",
 	})
 }
-
-func GetPublicTravelPackages(w http.ResponseWriter, r *http.Request) {
-	query := `SELECT p.package_id,
-					 p.title,
-					 p.description,
-					 p.location,
-					 p.initial_destination,
-					 p.duration_days,
-					 p.num_travelers,
-					 p.transport_mode,
-					 p.price,
-					 p.is_active,
-					 p.locations,
-					 p.photos,
-					 p.created_at,
-					 p.updated_at,
-					 a.agency_id,
-					 a.name,
-					 COALESCE(a.email, '') as agency_email,
-					 COALESCE(a.phone, '') as agency_phone
-			  FROM travel_packages p
-			  INNER JOIN travel_agencies a ON p.agency_id = a.agency_id
-			  WHERE p.is_active = TRUE
-			  ORDER BY p.created_at DESC`
-
-	rows, err := config.DB.Query(query)
-	if err != nil {
-		http.Error(w, "Failed to load travel packages", http.StatusInternalServerError)
-		return
-	}
-	defer rows.Close()
-
-	var packages []models.TravelPackage
-	for rows.Next() {
-		var pkg models.TravelPackage
-		if err := rows.Scan(
-			&pkg.PackageID,
-			&pkg.Title,
-			&pkg.Description,
-			&pkg.Location,
-			&pkg.InitialDestination,
-			&pkg.DurationDays,
-			&pkg.NumTravelers,
-			&pkg.TransportMode,
-			&pkg.Price,
-			&pkg.IsActive,
-			pq.Array(&pkg.Locations),
-			pq.Array(&pkg.Photos),
-			&pkg.CreatedAt,
-			&pkg.UpdatedAt,
-			&pkg.AgencyID,
-			&pkg.AgencyName,
-			&pkg.AgencyEmail,
-			&pkg.AgencyPhone,
-		); err != nil {
-			continue
-		}
-		packages = append(packages, pkg)
-	}
-
-	if packages == nil {
-		packages = []models.TravelPackage{}
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(packages)
-}
@@ -0,0 +1,511 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"trip-planner-backend/config"
+	"trip-planner-backend/models"
+	"trip-planner-backend/storage"
+	"trip-planner-backend/utils"
+
+	"github.com/gorilla/mux"
+)
+
+const maxAgencyDocuments = 10
+
+// KYC uploads may be scans or PDFs; the key is the sniffed content type
+var agencyDocumentTypes = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+}
+
+var agencyDocumentKinds = map[string]bool{
+	models.AgencyDocRegistration: true,
+	models.AgencyDocGST:          true,
+	models.AgencyDocOther:        true,
+}
+
+// agencyStatusTransitions lists the statuses an admin may move an agency
+// to from each status
+var agencyStatusTransitions = map[string][]string{
+	models.AgencyPending:   {models.AgencyApproved, models.AgencyRejected},
+	models.AgencyApproved:  {models.AgencySuspended},
+	models.AgencySuspended: {models.AgencyApproved, models.AgencyRejected},
+	models.AgencyRejected:  {models.AgencyApproved},
+}
+
+var (
+	errAgencyNotFound   = errors.New("agency not found")
+	errAgencyTransition = errors.New("status change not allowed")
+	errNoRegistration   = errors.New("no registration certificate on file")
+)
+
+// agencyDocumentKey is where an agency's KYC files are stored, out of reach
+// of the public uploads handler
+func agencyDocumentKey(agencyID int, name string) string {
+	return storage.Key(storage.PrivatePrefix, "kyc", strconv.Itoa(agencyID), name)
+}
+
+// fetchAgencyVerification loads an agency's status with its documents and
+// review history
+func fetchAgencyVerification(agencyID int) (models.AgencyVerification, error) {
+	v := models.AgencyVerification{Documents: []models.AgencyDocument{}, Reviews: []models.AgencyReview{}}
+	var submittedAt, reviewedAt sql.NullTime
+	err := config.DB.QueryRow(`
+		SELECT agency_id, name, email, COALESCE(phone, ''), COALESCE(website, ''), COALESCE(gstin, ''), status,
+			COALESCE(reviewer_notes, ''), submitted_at, reviewed_at, created_at
+		FROM travel_agencies WHERE agency_id = $1
+	`, agencyID).Scan(&v.AgencyID, &v.Name, &v.Email, &v.Phone, &v.Website, &v.GSTIN, &v.Status,
+		&v.ReviewerNotes, &submittedAt, &reviewedAt, &v.CreatedAt)
+	if err == sql.ErrNoRows {
+		return v, errAgencyNotFound
+	}
+	if err != nil {
+		return v, err
+	}
+	if submittedAt.Valid {
+		v.SubmittedAt = &submittedAt.Time
+	}
+	if reviewedAt.Valid {
+		v.ReviewedAt = &reviewedAt.Time
+	}
+
+	rows, err := config.DB.Query(`
+		SELECT document_id, agency_id, document_type, original_filename, content_type, size_bytes, uploaded_at
+		FROM agency_documents WHERE agency_id = $1
+		ORDER BY uploaded_at, document_id
+	`, agencyID)
+	if err != nil {
+		return v, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var d models.AgencyDocument
+		if err := rows.Scan(&d.DocumentID, &d.AgencyID, &d.DocumentType, &d.OriginalFilename, &d.ContentType,
+			&d.SizeBytes, &d.UploadedAt); err != nil {
+			return v, err
+		}
+		v.Documents = append(v.Documents, d)
+	}
+	if err := rows.Err(); err != nil {
+		return v, err
+	}
+
+	reviews, err := config.DB.Query(`
+		SELECT review_id, from_status, to_status, COALESCE(notes, ''), reviewed_by, created_at
+		FROM agency_reviews WHERE agency_id = $1
+		ORDER BY created_at DESC, review_id DESC
+	`, agencyID)
+	if err != nil {
+		return v, err
+	}
+	defer reviews.Close()
+	for reviews.Next() {
+		var rv models.AgencyReview
+		if err := reviews.Scan(&rv.ReviewID, &rv.FromStatus, &rv.ToStatus, &rv.Notes, &rv.ReviewedBy, &rv.CreatedAt); err != nil {
+			return v, err
+		}
+		v.Reviews = append(v.Reviews, rv)
+	}
+	return v, reviews.Err()
+}
+
+func writeAgencyVerification(w http.ResponseWriter, agencyID int, status int) {
+	v, err := fetchAgencyVerification(agencyID)
+	if errors.Is(err, errAgencyNotFound) {
+		sendJSONError(w, "Agency not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading verification for agency %d: %v", agencyID, err)
+		sendJSONError(w, "Failed to load verification", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// serveAgencyDocument streams one of an agency's KYC files
+func serveAgencyDocument(w http.ResponseWriter, r *http.Request, agencyID int) {
+	documentID, err := strconv.Atoi(mux.Vars(r)["documentid"])
+	if err != nil {
+		sendJSONError(w, "Invalid document ID", http.StatusBadRequest)
+		return
+	}
+	var key, filename, contentType string
+	err = config.DB.QueryRow(`
+		SELECT storage_key, original_filename, content_type FROM agency_documents
+		WHERE document_id = $1 AND agency_id = $2
+	`, documentID, agencyID).Scan(&key, &filename, &contentType)
+	if err == sql.ErrNoRows {
+		sendJSONError(w, "Document not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	body, info, err := storage.Default().Open(r.Context(), key)
+	if err != nil {
+		log.Printf("Error opening agency document %s: %v", key, err)
+		sendJSONError(w, "Document file is missing", http.StatusNotFound)
+		return
+	}
+	defer body.Close()
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Cache-Control", "private, no-store")
+	if info.Size > 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	}
+	io.Copy(w, body)
+}
+
+// GetAgencyVerification returns the agency's own verification status,
+// documents and reviewer notes
+func GetAgencyVerification(w http.ResponseWriter, r *http.Request) {
+	agencyID, ok := r.Context().Value("agencyid").(int)
+	if !ok || agencyID == 0 {
+		sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	writeAgencyVerification(w, agencyID, http.StatusOK)
+}
+
+// UploadAgencyDocument stores a KYC document from the multipart fields
+// document_type and file. PDF, JPEG and PNG are accepted.
+func UploadAgencyDocument(w http.ResponseWriter, r *http.Request) {
+	agencyID, ok := r.Context().Value("agencyid").(int)
+	if !ok || agencyID == 0 {
+		sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if !parseUploadForm(w, r) {
+		return
+	}
+
+	docType := r.FormValue("document_type")
+	if !agencyDocumentKinds[docType] {
+		sendJSONError(w, "document_type must be registration_certificate, gst_certificate or other", http.StatusBadRequest)
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		sendJSONError(w, "A file is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	if header.Size > uploadLimits().MaxFileBytes {
+		sendJSONError(w, fmt.Sprintf("File exceeds the %d MB limit", uploadLimits().MaxFileBytes>>20), http.StatusBadRequest)
+		return
+	}
+
+	var count int
+	if err := config.DB.QueryRow(`SELECT COUNT(*) FROM agency_documents WHERE agency_id = $1`, agencyID).Scan(&count); err != nil {
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if count >= maxAgencyDocuments {
+		sendJSONError(w, fmt.Sprintf("At most %d documents; delete one first", maxAgencyDocuments), http.StatusBadRequest)
+		return
+	}
+
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(file, head)
+	contentType := http.DetectContentType(head[:n])
+	ext, allowed := agencyDocumentTypes[contentType]
+	if !allowed {
+		sendJSONError(w, "Upload a PDF, JPEG or PNG file", http.StatusBadRequest)
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		sendJSONError(w, "Error reading upload", http.StatusInternalServerError)
+		return
+	}
+
+	key := agencyDocumentKey(agencyID, newUploadKey("agency_"+strconv.Itoa(agencyID))+ext)
+	if err := storage.Default().Put(r.Context(), key, file, header.Size, contentType); err != nil {
+		log.Printf("Error storing agency document: %v", err)
+		sendJSONError(w, "Error saving document", http.StatusInternalServerError)
+		return
+	}
+	filename := uploadDisplayName(header)
+	if len(filename) > 255 {
+		filename = filename[len(filename)-255:]
+	}
+	_, err = config.DB.Exec(`
+		INSERT INTO agency_documents (agency_id, document_type, storage_key, original_filename, content_type, size_bytes)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, agencyID, docType, key, filename, contentType, header.Size)
+	if err != nil {
+		storage.Default().Delete(r.Context(), key)
+		log.Printf("Error recording agency document: %v", err)
+		sendJSONError(w, "Error saving document", http.StatusInternalServerError)
+		return
+	}
+
+	writeAgencyVerification(w, agencyID, http.StatusCreated)
+}
+
+// DownloadAgencyDocument returns one of the agency's own KYC files
+func DownloadAgencyDocument(w http.ResponseWriter, r *http.Request) {
+	agencyID, ok := r.Context().Value("agencyid").(int)
+	if !ok || agencyID == 0 {
+		sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	serveAgencyDocument(w, r, agencyID)
+}
+
+// DeleteAgencyDocument removes a KYC document. Once an agency is approved
+// its documents are the record of that review and stay.
+func DeleteAgencyDocument(w http.ResponseWriter, r *http.Request) {
+	agencyID, ok := r.Context().Value("agencyid").(int)
+	if !ok || agencyID == 0 {
+		sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	documentID, err := strconv.Atoi(mux.Vars(r)["documentid"])
+	if err != nil {
+		sendJSONError(w, "Invalid document ID", http.StatusBadRequest)
+		return
+	}
+
+	var key string
+	err = config.DB.QueryRow(`
+		DELETE FROM agency_documents d
+		USING travel_agencies a
+		WHERE d.document_id = $1 AND d.agency_id = $2 AND a.agency_id = d.agency_id
+			AND a.status IN ('pending', 'rejected')
+		RETURNING d.storage_key
+	`, documentID, agencyID).Scan(&key)
+	if err == sql.ErrNoRows {
+		sendJSONError(w, "Document not found, or your agency is no longer under review", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if err := storage.Default().Delete(r.Context(), key); err != nil {
+		log.Printf("Error deleting agency document %s: %v", key, err)
+	}
+
+	writeAgencyVerification(w, agencyID, http.StatusOK)
+}
+
+// SubmitAgencyVerification asks for a review. A rejected agency goes back
+// to pending; a registration certificate must be on file.
+func SubmitAgencyVerification(w http.ResponseWriter, r *http.Request) {
+	agencyID, ok := r.Context().Value("agencyid").(int)
+	if !ok || agencyID == 0 {
+		sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var status, name, email string
+	var submittedAt sql.NullTime
+	err = tx.QueryRow(`SELECT status, name, email, submitted_at FROM travel_agencies WHERE agency_id = $1 FOR UPDATE`,
+		agencyID).Scan(&status, &name, &email, &submittedAt)
+	if err != nil {
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	switch {
+	case status == models.AgencyApproved:
+		sendJSONError(w, "Your agency is already approved", http.StatusConflict)
+		return
+	case status == models.AgencySuspended:
+		sendJSONError(w, "Suspended agencies are reinstated by an admin", http.StatusConflict)
+		return
+	case status == models.AgencyPending && submittedAt.Valid:
+		sendJSONError(w, "Your documents are already awaiting review", http.StatusConflict)
+		return
+	}
+
+	var documents, registrations int
+	err = tx.QueryRow(`
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE document_type = $2) FROM agency_documents WHERE agency_id = $1
+	`, agencyID, models.AgencyDocRegistration).Scan(&documents, &registrations)
+	if err != nil {
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if registrations == 0 {
+		sendJSONError(w, "Upload your registration certificate before submitting", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := tx.Exec(`UPDATE travel_agencies SET status = 'pending', submitted_at = NOW() WHERE agency_id = $1`, agencyID); err != nil {
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if status != models.AgencyPending {
+		if _, err := tx.Exec(`
+			INSERT INTO agency_reviews (agency_id, from_status, to_status, reviewed_by) VALUES ($1, $2, 'pending', 'agency')
+		`, agencyID, status); err != nil {
+			sendJSONError(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if adminEmail := os.Getenv("ADMIN_EMAIL"); adminEmail != "" {
+		go func() {
+			if err := utils.SendAgencyReviewRequestEmail(adminEmail, name, email, documents); err != nil {
+				log.Printf("Error notifying admin of agency %d review request: %v", agencyID, err)
+			}
+		}()
+	}
+	writeAgencyVerification(w, agencyID, http.StatusOK)
+}
+
+// AdminGetAgencyVerification returns an agency's documents and review
+// history for an admin reviewer
+func AdminGetAgencyVerification(w http.ResponseWriter, r *http.Request) {
+	agencyID, err := strconv.Atoi(mux.Vars(r)["agencyid"])
+	if err != nil {
+		sendJSONError(w, "Invalid agency ID", http.StatusBadRequest)
+		return
+	}
+	writeAgencyVerification(w, agencyID, http.StatusOK)
+}
+
+// AdminDownloadAgencyDocument returns an agency's KYC file
+func AdminDownloadAgencyDocument(w http.ResponseWriter, r *http.Request) {
+	agencyID, err := strconv.Atoi(mux.Vars(r)["agencyid"])
+	if err != nil {
+		sendJSONError(w, "Invalid agency ID", http.StatusBadRequest)
+		return
+	}
+	serveAgencyDocument(w, r, agencyID)
+}
+
+// changeAgencyStatus moves an agency to status, recording the review
+func changeAgencyStatus(agencyID int, status, notes string) (name, email string, err error) {
+	tx, err := config.DB.Begin()
+	if err != nil {
+		return "", "", err
+	}
+	defer tx.Rollback()
+
+	var current string
+	err = tx.QueryRow(`SELECT status, name, email FROM travel_agencies WHERE agency_id = $1 FOR UPDATE`,
+		agencyID).Scan(&current, &name, &email)
+	if err == sql.ErrNoRows {
+		return "", "", errAgencyNotFound
+	}
+	if err != nil {
+		return "", "", err
+	}
+	allowed := false
+	for _, next := range agencyStatusTransitions[current] {
+		allowed = allowed || next == status
+	}
+	if !allowed {
+		return "", "", fmt.Errorf("%w: %s agencies can't be %s", errAgencyTransition, current, status)
+	}
+	if status == models.AgencyApproved && current == models.AgencyPending {
+		var registered bool
+		err = tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM agency_documents WHERE agency_id = $1 AND document_type = $2)`,
+			agencyID, models.AgencyDocRegistration).Scan(&registered)
+		if err != nil {
+			return "", "", err
+		}
+		if !registered {
+			return "", "", errNoRegistration
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE travel_agencies SET status = $1, reviewer_notes = NULLIF($2, ''), reviewed_at = NOW()
+		WHERE agency_id = $3
+	`, status, notes, agencyID)
+	if err != nil {
+		return "", "", err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO agency_reviews (agency_id, from_status, to_status, notes, reviewed_by)
+		VALUES ($1, $2, $3, NULLIF($4, ''), 'admin')
+	`, agencyID, current, status, notes)
+	if err != nil {
+		return "", "", err
+	}
+	return name, email, tx.Commit()
+}
+
+// AdminSetAgencyStatus approves, rejects, suspends or reinstates an agency
+// and emails it the outcome. Rejections and suspensions need notes.
+func AdminSetAgencyStatus(w http.ResponseWriter, r *http.Request) {
+	agencyID, err := strconv.Atoi(mux.Vars(r)["agencyid"])
+	if err != nil {
+		sendJSONError(w, "Invalid agency ID", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Status string `json:"status"`
+		Notes  string `json:"notes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Notes = strings.TrimSpace(req.Notes)
+	if _, known := agencyStatusTransitions[req.Status]; !known || req.Status == models.AgencyPending {
+		sendJSONError(w, "status must be approved, rejected or suspended", http.StatusBadRequest)
+		return
+	}
+	if (req.Status == models.AgencyRejected || req.Status == models.AgencySuspended) && req.Notes == "" {
+		sendJSONError(w, "Notes are required to reject or suspend an agency", http.StatusBadRequest)
+		return
+	}
+	if len(req.Notes) > 2000 {
+		sendJSONError(w, "Notes can be at most 2000 characters", http.StatusBadRequest)
+		return
+	}
+
+	name, email, err := changeAgencyStatus(agencyID, req.Status, req.Notes)
+	switch {
+	case errors.Is(err, errAgencyNotFound):
+		sendJSONError(w, "Agency not found", http.StatusNotFound)
+		return
+	case errors.Is(err, errAgencyTransition):
+		sendJSONError(w, "Status change not allowed: "+strings.TrimPrefix(err.Error(), errAgencyTransition.Error()+": "), http.StatusConflict)
+		return
+	case errors.Is(err, errNoRegistration):
+		sendJSONError(w, "The agency has not uploaded a registration certificate", http.StatusConflict)
+		return
+	case err != nil:
+		log.Printf("Error changing status of agency %d: %v", agencyID, err)
+		sendJSONError(w, "Failed to update agency status", http.StatusInternalServerError)
+		return
+	}
+
+	go func() {
+		if err := utils.SendAgencyStatusEmail(email, name, req.Status, req.Notes); err != nil {
+			log.Printf("Error emailing agency %d about its status: %v", agencyID, err)
+		}
+	}()
+	writeAgencyVerification(w, agencyID, http.StatusOK)
+}
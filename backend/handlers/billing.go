@@ -0,0 +1,593 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+	"trip-planner-backend/config"
+	"trip-planner-backend/models"
+	"trip-planner-backend/utils"
+
+	"github.com/gorilla/mux"
+)
+
+const defaultGSTRate = 5.0
+
+// GST slabs a tour package can be billed at
+var gstRates = map[float64]bool{0: true, 5: true, 12: true, 18: true}
+
+var (
+	errBillingProfile  = errors.New("agency billing profile is incomplete")
+	errBillingSequence = errors.New("billing document numbers for the year are used up")
+)
+
+const billingDocumentColumns = `
+	document_id, agency_id, package_id, package_title, document_type, document_number, issue_date, valid_until,
+	supplier, customer, place_of_supply, sac_code, prices_include_tax, quote, tax_rate, intra_state,
+	taxable_value, cgst, sgst, igst, total, COALESCE(notes, ''), emailed_at, created_at`
+
+// normalizeGSTIN upper-cases a GSTIN and checks it; empty is allowed
+func normalizeGSTIN(gstin string) (string, bool) {
+	gstin = strings.ToUpper(strings.TrimSpace(gstin))
+	return gstin, gstin == "" || utils.ValidGSTIN(gstin)
+}
+
+// GetAgencyBillingProfile returns the GSTIN and billing address the agency
+// prints on its documents
+func GetAgencyBillingProfile(w http.ResponseWriter, r *http.Request) {
+	agencyID, ok := r.Context().Value("agencyid").(int)
+	if !ok || agencyID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var profile models.AgencyBillingProfile
+	err := config.DB.QueryRow(`
+		SELECT COALESCE(gstin, ''), COALESCE(billing_address, ''), COALESCE(billing_state_code, '')
+		FROM travel_agencies WHERE agency_id = $1
+	`, agencyID).Scan(&profile.GSTIN, &profile.BillingAddress, &profile.BillingStateCode)
+	if err != nil {
+		log.Printf("Error loading billing profile for agency %d: %v", agencyID, err)
+		http.Error(w, "Failed to load billing profile", http.StatusInternalServerError)
+		return
+	}
+	profile.BillingState, _ = utils.GSTStateName(profile.BillingStateCode)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile)
+}
+
+// UpdateAgencyBillingProfile sets the GSTIN, billing address and state. A
+// GSTIN fixes the state to the one it was registered in.
+func UpdateAgencyBillingProfile(w http.ResponseWriter, r *http.Request) {
+	agencyID, ok := r.Context().Value("agencyid").(int)
+	if !ok || agencyID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var profile models.AgencyBillingProfile
+	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	var valid bool
+	if profile.GSTIN, valid = normalizeGSTIN(profile.GSTIN); !valid {
+		http.Error(w, "Invalid GSTIN", http.StatusBadRequest)
+		return
+	}
+	profile.BillingAddress = strings.TrimSpace(profile.BillingAddress)
+	profile.BillingStateCode = strings.TrimSpace(profile.BillingStateCode)
+	if profile.GSTIN != "" {
+		if profile.BillingStateCode != "" && profile.BillingStateCode != profile.GSTIN[:2] {
+			http.Error(w, "The billing state must match the state of the GSTIN", http.StatusBadRequest)
+			return
+		}
+		profile.BillingStateCode = profile.GSTIN[:2]
+	}
+	if profile.BillingStateCode != "" {
+		if profile.BillingState, valid = utils.GSTStateName(profile.BillingStateCode); !valid {
+			http.Error(w, "Invalid billing_state_code", http.StatusBadRequest)
+			return
+		}
+	}
+	if len(profile.BillingAddress) > 500 {
+		http.Error(w, "billing_address can be at most 500 characters", http.StatusBadRequest)
+		return
+	}
+
+	_, err := config.DB.Exec(`
+		UPDATE travel_agencies
+		SET gstin = NULLIF($1, ''), billing_address = NULLIF($2, ''), billing_state_code = NULLIF($3, '')
+		WHERE agency_id = $4
+	`, profile.GSTIN, profile.BillingAddress, profile.BillingStateCode, agencyID)
+	if err != nil {
+		log.Printf("Error updating billing profile for agency %d: %v", agencyID, err)
+		http.Error(w, "Failed to update billing profile", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile)
+}
+
+type billingDocumentRequest struct {
+	DocumentType     string              `json:"document_type"`
+	PackageID        int                 `json:"package_id"`
+	DepartureDate    string              `json:"departure_date"`
+	Adults           int                 `json:"adults"`
+	Children         int                 `json:"children"`
+	Seniors          int                 `json:"seniors"`
+	Customer         models.BillingParty `json:"customer"`
+	TaxRate          *float64            `json:"tax_rate"`
+	PricesIncludeTax *bool               `json:"prices_include_tax"`
+	ValidUntil       string              `json:"valid_until"`
+	Notes            string              `json:"notes"`
+	SendEmail        bool                `json:"send_email"`
+}
+
+// validate normalizes the request and fills in defaults: 5% GST on
+// tax-inclusive prices, and quotations valid for 15 days
+func (req *billingDocumentRequest) validate() string {
+	if req.DocumentType != models.DocumentQuotation && req.DocumentType != models.DocumentInvoice {
+		return "document_type must be quotation or invoice"
+	}
+	if req.PackageID <= 0 {
+		return "package_id is required"
+	}
+	if req.DepartureDate != "" && !validDate(req.DepartureDate) {
+		return "departure_date must be YYYY-MM-DD"
+	}
+	if req.Adults < 0 || req.Children < 0 || req.Seniors < 0 {
+		return "Traveler counts can't be negative"
+	}
+	if req.Adults+req.Children+req.Seniors == 0 {
+		req.Adults = 1
+	}
+	if req.Adults+req.Children+req.Seniors > maxBookingTravelers {
+		return "At most " + strconv.Itoa(maxBookingTravelers) + " travelers per document"
+	}
+
+	c := &req.Customer
+	c.Name = strings.TrimSpace(c.Name)
+	c.Email = strings.TrimSpace(c.Email)
+	c.Phone = strings.TrimSpace(c.Phone)
+	c.Address = strings.TrimSpace(c.Address)
+	c.StateCode = strings.TrimSpace(c.StateCode)
+	if c.Name == "" || len(c.Name) > 255 {
+		return "Customer name is required"
+	}
+	if c.Email != "" {
+		if _, err := mail.ParseAddress(c.Email); err != nil {
+			return "Invalid customer email"
+		}
+	}
+	if req.SendEmail && c.Email == "" {
+		return "A customer email is needed to send the document"
+	}
+	var valid bool
+	if c.GSTIN, valid = normalizeGSTIN(c.GSTIN); !valid {
+		return "Invalid customer GSTIN"
+	}
+	if c.GSTIN != "" {
+		if c.StateCode != "" && c.StateCode != c.GSTIN[:2] {
+			return "Customer state must match the state of their GSTIN"
+		}
+		c.StateCode = c.GSTIN[:2]
+	}
+	if c.StateCode != "" {
+		if c.State, valid = utils.GSTStateName(c.StateCode); !valid {
+			return "Invalid customer state_code"
+		}
+	} else {
+		c.State = ""
+	}
+
+	if req.TaxRate == nil {
+		rate := defaultGSTRate
+		req.TaxRate = &rate
+	}
+	if !gstRates[*req.TaxRate] {
+		return "tax_rate must be 0, 5, 12 or 18"
+	}
+	if req.PricesIncludeTax == nil {
+		inclusive := true
+		req.PricesIncludeTax = &inclusive
+	}
+
+	if req.DocumentType == models.DocumentInvoice {
+		req.ValidUntil = ""
+	} else if req.ValidUntil == "" {
+		req.ValidUntil = time.Now().AddDate(0, 0, 15).Format("2006-01-02")
+	} else if !validDate(req.ValidUntil) {
+		return "valid_until must be YYYY-MM-DD"
+	}
+	req.Notes = strings.TrimSpace(req.Notes)
+	if len(req.Notes) > 2000 {
+		return "notes can be at most 2000 characters"
+	}
+	return ""
+}
+
+func scanBillingDocument(row rowScanner) (models.BillingDocument, error) {
+	var d models.BillingDocument
+	var packageID sql.NullInt64
+	var issueDate time.Time
+	var validUntil sql.NullTime
+	var emailedAt sql.NullTime
+	var supplier, customer, quote []byte
+	err := row.Scan(&d.DocumentID, &d.AgencyID, &packageID, &d.PackageTitle, &d.DocumentType, &d.DocumentNumber, &issueDate,
+		&validUntil, &supplier, &customer, &d.PlaceOfSupply, &d.SACCode, &d.PricesIncludeTax, &quote, &d.Tax.Rate,
+		&d.Tax.IntraState, &d.Tax.TaxableValue, &d.Tax.CGST, &d.Tax.SGST, &d.Tax.IGST, &d.Tax.Total, &d.Notes,
+		&emailedAt, &d.CreatedAt)
+	if err != nil {
+		return d, err
+	}
+	if packageID.Valid {
+		id := int(packageID.Int64)
+		d.PackageID = &id
+	}
+	d.IssueDate = issueDate.Format("2006-01-02")
+	if validUntil.Valid {
+		d.ValidUntil = validUntil.Time.Format("2006-01-02")
+	}
+	if emailedAt.Valid {
+		d.EmailedAt = &emailedAt.Time
+	}
+	d.Tax.TotalTax = d.Tax.CGST + d.Tax.SGST + d.Tax.IGST
+	for _, part := range []struct {
+		data []byte
+		dest interface{}
+	}{{supplier, &d.Supplier}, {customer, &d.Customer}, {quote, &d.Quote}} {
+		if err := json.Unmarshal(part.data, part.dest); err != nil {
+			return d, err
+		}
+	}
+	return d, nil
+}
+
+func fetchBillingDocument(documentID, agencyID int) (models.BillingDocument, error) {
+	return scanBillingDocument(config.DB.QueryRow(`SELECT `+billingDocumentColumns+`
+		FROM billing_documents WHERE document_id = $1 AND agency_id = $2`, documentID, agencyID))
+}
+
+// issueBillingDocument prices the request and stores the numbered
+// document in one transaction. The sequence row stays locked until
+// commit, so concurrent documents get consecutive numbers and a failed
+// insert doesn't use one up.
+func issueBillingDocument(agencyID int, req billingDocumentRequest) (models.BillingDocument, error) {
+	var doc models.BillingDocument
+	tx, err := config.DB.Begin()
+	if err != nil {
+		return doc, err
+	}
+	defer tx.Rollback()
+
+	supplier := &doc.Supplier
+	err = tx.QueryRow(`
+		SELECT name, COALESCE(email, ''), COALESCE(phone, ''), COALESCE(gstin, ''), COALESCE(billing_address, ''),
+			COALESCE(billing_state_code, '')
+		FROM travel_agencies WHERE agency_id = $1
+	`, agencyID).Scan(&supplier.Name, &supplier.Email, &supplier.Phone, &supplier.GSTIN, &supplier.Address, &supplier.StateCode)
+	if err != nil {
+		return doc, err
+	}
+	if supplier.Address == "" || supplier.StateCode == "" || (req.DocumentType == models.DocumentInvoice && supplier.GSTIN == "") {
+		return doc, errBillingProfile
+	}
+	supplier.State, _ = utils.GSTStateName(supplier.StateCode)
+
+	var price float64
+	var maxTravelers int
+	var rules []byte
+	err = tx.QueryRow(`
+		SELECT p.title, p.num_travelers, COALESCE(d.price_override, p.price), p.pricing_rules
+		FROM travel_packages p
+		LEFT JOIN package_departures d
+			ON d.package_id = p.package_id AND d.is_active = TRUE AND d.start_date = NULLIF($3, '')::date
+		WHERE p.package_id = $1 AND p.agency_id = $2
+	`, req.PackageID, agencyID, req.DepartureDate).Scan(&doc.PackageTitle, &maxTravelers, &price, &rules)
+	if err == sql.ErrNoRows {
+		return doc, errPackageUnavailable
+	}
+	if err != nil {
+		return doc, err
+	}
+	party := models.PartySize{Adults: req.Adults, Children: req.Children, Seniors: req.Seniors}
+	if party.Total() > maxTravelers {
+		return doc, errTooManyTravelers
+	}
+	var departure time.Time
+	if req.DepartureDate != "" {
+		departure, _ = time.Parse("2006-01-02", req.DepartureDate)
+	}
+	doc.Quote = utils.QuotePrice(price, decodePricingRules(rules), departure, party, nil)
+
+	// Unregistered customers with no state are billed where the supplier is
+	doc.Customer = req.Customer
+	doc.PlaceOfSupply = doc.Customer.StateCode
+	if doc.PlaceOfSupply == "" {
+		doc.PlaceOfSupply = supplier.StateCode
+	}
+	doc.Tax = utils.SplitGST(doc.Quote.Total, *req.TaxRate, *req.PricesIncludeTax, doc.PlaceOfSupply == supplier.StateCode)
+
+	now := time.Now()
+	financialYear := utils.FinancialYear(now)
+	var sequence int
+	err = tx.QueryRow(`
+		INSERT INTO billing_document_sequences (agency_id, document_type, financial_year, last_number)
+		VALUES ($1, $2, $3, 1)
+		ON CONFLICT (agency_id, document_type, financial_year)
+		DO UPDATE SET last_number = billing_document_sequences.last_number + 1
+		RETURNING last_number
+	`, agencyID, req.DocumentType, financialYear).Scan(&sequence)
+	if err != nil {
+		return doc, err
+	}
+	if sequence > utils.MaxBillingDocumentSequence {
+		return doc, errBillingSequence
+	}
+
+	supplierJSON, _ := json.Marshal(doc.Supplier)
+	customerJSON, _ := json.Marshal(doc.Customer)
+	quoteJSON, _ := json.Marshal(doc.Quote)
+	var documentID int
+	err = tx.QueryRow(`
+		INSERT INTO billing_documents (agency_id, package_id, package_title, document_type, document_number, financial_year,
+			issue_date, valid_until, supplier, customer, customer_name, place_of_supply, sac_code, prices_include_tax, quote,
+			tax_rate, intra_state, taxable_value, cgst, sgst, igst, total, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, '')::date, $9, $10, $11, $12, $13, $14, $15,
+			$16, $17, $18, $19, $20, $21, $22, NULLIF($23, ''))
+		RETURNING document_id
+	`, agencyID, req.PackageID, doc.PackageTitle, req.DocumentType, utils.BillingDocumentNumber(req.DocumentType, financialYear, sequence),
+		financialYear, now.Format("2006-01-02"), req.ValidUntil, string(supplierJSON), string(customerJSON), doc.Customer.Name,
+		doc.PlaceOfSupply, utils.TourOperatorSAC, *req.PricesIncludeTax, string(quoteJSON),
+		doc.Tax.Rate, doc.Tax.IntraState, doc.Tax.TaxableValue, doc.Tax.CGST, doc.Tax.SGST, doc.Tax.IGST, doc.Tax.Total,
+		req.Notes).Scan(&documentID)
+	if err != nil {
+		return doc, err
+	}
+	if err := tx.Commit(); err != nil {
+		return doc, err
+	}
+	return fetchBillingDocument(documentID, agencyID)
+}
+
+// emailBillingDocument renders the PDF, sends it and records when
+func emailBillingDocument(doc *models.BillingDocument, to string) error {
+	pdf, err := utils.RenderBillingDocument(*doc)
+	if err != nil {
+		return err
+	}
+	if err := utils.SendBillingDocumentEmail(to, doc.Customer.Name, doc.Supplier.Name, doc.DocumentType, doc.DocumentNumber,
+		doc.PackageTitle, doc.Tax.Total, pdf); err != nil {
+		return err
+	}
+	now := time.Now()
+	doc.EmailedAt = &now
+	_, err = config.DB.Exec(`UPDATE billing_documents SET emailed_at = $1 WHERE document_id = $2`, now, doc.DocumentID)
+	return err
+}
+
+// CreateBillingDocument issues a numbered quotation or invoice for one of
+// the agency's packages, optionally emailing the PDF to the customer
+func CreateBillingDocument(w http.ResponseWriter, r *http.Request) {
+	agencyID, ok := r.Context().Value("agencyid").(int)
+	if !ok || agencyID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req billingDocumentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	doc, err := issueBillingDocument(agencyID, req)
+	switch {
+	case errors.Is(err, errBillingProfile):
+		http.Error(w, "Add your billing address and state first; invoices also need your GSTIN", http.StatusConflict)
+		return
+	case errors.Is(err, errBillingSequence):
+		http.Error(w, fmt.Sprintf("This financial year already has %d documents of this type", utils.MaxBillingDocumentSequence), http.StatusConflict)
+		return
+	case errors.Is(err, errPackageUnavailable):
+		http.Error(w, "Package not found or not owned by agency", http.StatusNotFound)
+		return
+	case errors.Is(err, errTooManyTravelers):
+		http.Error(w, "This package takes fewer travelers", http.StatusBadRequest)
+		return
+	case err != nil:
+		log.Printf("Error issuing billing document for agency %d: %v", agencyID, err)
+		http.Error(w, "Failed to issue document", http.StatusInternalServerError)
+		return
+	}
+
+	// The document is issued either way; a failed email is reported
+	// alongside it
+	emailError := ""
+	if req.SendEmail {
+		if err := emailBillingDocument(&doc, doc.Customer.Email); err != nil {
+			log.Printf("Error emailing billing document %d: %v", doc.DocumentID, err)
+			emailError = "The document was issued but could not be emailed"
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(struct {
+		models.BillingDocument
+		EmailError string `json:"email_error,omitempty"`
+	}{doc, emailError})
+}
+
+// GetBillingDocuments lists the agency's documents, newest first, filtered
+// by ?type=, ?from= and ?to= (issue dates). ?format=csv exports the tax
+// register for accounting.
+func GetBillingDocuments(w http.ResponseWriter, r *http.Request) {
+	agencyID, ok := r.Context().Value("agencyid").(int)
+	if !ok || agencyID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	query := r.URL.Query()
+
+	where, args := "agency_id = $1", []interface{}{agencyID}
+	if docType := query.Get("type"); docType != "" {
+		if docType != models.DocumentQuotation && docType != models.DocumentInvoice {
+			http.Error(w, "type must be quotation or invoice", http.StatusBadRequest)
+			return
+		}
+		args = append(args, docType)
+		where += fmt.Sprintf(" AND document_type = $%d", len(args))
+	}
+	for _, bound := range []struct{ param, op string }{{"from", ">="}, {"to", "<="}} {
+		if v := query.Get(bound.param); v != "" {
+			if !validDate(v) {
+				http.Error(w, bound.param+" must be YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+			args = append(args, v)
+			where += fmt.Sprintf(" AND issue_date %s $%d::date", bound.op, len(args))
+		}
+	}
+
+	rows, err := config.DB.Query(`SELECT `+billingDocumentColumns+` FROM billing_documents WHERE `+where+`
+		ORDER BY issue_date DESC, document_id DESC`, args...)
+	if err != nil {
+		log.Printf("Error listing billing documents: %v", err)
+		http.Error(w, "Failed to fetch documents", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+	docs := []models.BillingDocument{}
+	for rows.Next() {
+		doc, err := scanBillingDocument(rows)
+		if err != nil {
+			log.Printf("Error reading billing document: %v", err)
+			http.Error(w, "Failed to fetch documents", http.StatusInternalServerError)
+			return
+		}
+		docs = append(docs, doc)
+	}
+
+	if query.Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="billing-documents.csv"`)
+		out := csv.NewWriter(w)
+		out.Write([]string{"Number", "Type", "Issue date", "Customer", "Customer GSTIN", "Place of supply", "Package",
+			"Taxable value", "GST rate", "CGST", "SGST", "IGST", "Total"})
+		money := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+		for _, d := range docs {
+			out.Write([]string{d.DocumentNumber, d.DocumentType, d.IssueDate, d.Customer.Name, d.Customer.GSTIN,
+				d.PlaceOfSupply, d.PackageTitle, money(d.Tax.TaxableValue), strconv.FormatFloat(d.Tax.Rate, 'f', -1, 64),
+				money(d.Tax.CGST), money(d.Tax.SGST), money(d.Tax.IGST), money(d.Tax.Total)})
+		}
+		out.Flush()
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(docs)
+}
+
+// billingDocumentFromRequest loads the agency's document named in the URL,
+// writing the error response when it can't
+func billingDocumentFromRequest(w http.ResponseWriter, r *http.Request) (models.BillingDocument, bool) {
+	agencyID, ok := r.Context().Value("agencyid").(int)
+	if !ok || agencyID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return models.BillingDocument{}, false
+	}
+	documentID, err := strconv.Atoi(mux.Vars(r)["documentid"])
+	if err != nil {
+		http.Error(w, "Invalid document ID", http.StatusBadRequest)
+		return models.BillingDocument{}, false
+	}
+	doc, err := fetchBillingDocument(documentID, agencyID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Document not found", http.StatusNotFound)
+		return doc, false
+	}
+	if err != nil {
+		log.Printf("Error loading billing document %d: %v", documentID, err)
+		http.Error(w, "Failed to load document", http.StatusInternalServerError)
+		return doc, false
+	}
+	return doc, true
+}
+
+// GetBillingDocument returns one of the agency's documents
+func GetBillingDocument(w http.ResponseWriter, r *http.Request) {
+	doc, ok := billingDocumentFromRequest(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(doc)
+}
+
+// DownloadBillingDocument renders one of the agency's documents as a PDF
+func DownloadBillingDocument(w http.ResponseWriter, r *http.Request) {
+	doc, ok := billingDocumentFromRequest(w, r)
+	if !ok {
+		return
+	}
+	pdf, err := utils.RenderBillingDocument(doc)
+	if err != nil {
+		log.Printf("Error rendering billing document %d: %v", doc.DocumentID, err)
+		http.Error(w, "Failed to render document", http.StatusInternalServerError)
+		return
+	}
+	filename := strings.ReplaceAll(doc.DocumentNumber, "/", "-") + ".pdf"
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Write(pdf)
+}
+
+// EmailBillingDocument sends a document to the customer, or to the
+// address in the body
+func EmailBillingDocument(w http.ResponseWriter, r *http.Request) {
+	doc, ok := billingDocumentFromRequest(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		Email string `json:"email"`
+	}
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+	}
+	to := strings.TrimSpace(body.Email)
+	if to == "" {
+		to = doc.Customer.Email
+	}
+	if _, err := mail.ParseAddress(to); err != nil {
+		http.Error(w, "A valid email address is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := emailBillingDocument(&doc, to); err != nil {
+		log.Printf("Error emailing billing document %d: %v", doc.DocumentID, err)
+		http.Error(w, "Failed to send email", http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(doc)
+}
@@ -0,0 +1,655 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+	"trip-planner-backend/config"
+	"trip-planner-backend/models"
+	"trip-planner-backend/utils"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+const maxBookingTravelers = 50
+
+var (
+	errPackageUnavailable  = errors.New("package is not available for booking")
+	errTooManyTravelers    = errors.New("party is larger than the package allows")
+	errBookingStatus       = errors.New("booking cannot change from its current status")
+	errDepartureNotReached = errors.New("departure date has not been reached")
+	errDeparturePassed     = errors.New("departure date has passed")
+	errNoDeparture         = errors.New("package has no departure on that date")
+	errNotEnoughSeats      = errors.New("departure has too few seats left")
+)
+
+const bookingColumns = `
+	b.booking_id, b.userid, b.agency_id, a.name, b.package_id, b.package_title, b.departure_id, b.departure_date, b.num_travelers,
+	b.unit_price, b.total_price, b.discount_total, b.price_breakdown, b.currency, b.contact_name, b.contact_email, b.contact_phone,
+	COALESCE(b.special_requests, ''), b.status, COALESCE(b.cancelled_by, ''), COALESCE(b.cancellation_reason, ''),
+	b.confirmed_at, b.completed_at, b.cancelled_at, b.created_at, b.updated_at`
+
+type bookingRequest struct {
+	PackageID       int                      `json:"package_id"`
+	DepartureDate   string                   `json:"departure_date"`
+	Travelers       []models.BookingTraveler `json:"travelers"`
+	ContactName     string                   `json:"contact_name"`
+	ContactEmail    string                   `json:"contact_email"`
+	ContactPhone    string                   `json:"contact_phone"`
+	SpecialRequests string                   `json:"special_requests"`
+	PromoCode       string                   `json:"promo_code"`
+}
+
+// validate trims the request and checks everything that doesn't need the
+// database. Departures must be at least a day away.
+func (req *bookingRequest) validate() string {
+	if req.PackageID <= 0 {
+		return "package_id is required"
+	}
+	departure, err := time.Parse("2006-01-02", req.DepartureDate)
+	if err != nil {
+		return "departure_date must be YYYY-MM-DD"
+	}
+	today := utils.Today()
+	if !departure.After(today) {
+		return "departure_date must be in the future"
+	}
+	if departure.After(today.AddDate(2, 0, 0)) {
+		return "departure_date can be at most two years ahead"
+	}
+
+	if len(req.Travelers) == 0 {
+		return "At least one traveler is required"
+	}
+	if len(req.Travelers) > maxBookingTravelers {
+		return "At most " + strconv.Itoa(maxBookingTravelers) + " travelers per booking"
+	}
+	for i := range req.Travelers {
+		t := &req.Travelers[i]
+		t.FullName = strings.TrimSpace(t.FullName)
+		t.Gender = strings.TrimSpace(t.Gender)
+		if t.FullName == "" {
+			return "Every traveler needs a full name"
+		}
+		if t.Age < 0 || t.Age > 120 {
+			return "Traveler age must be between 0 and 120"
+		}
+	}
+
+	req.ContactName = strings.TrimSpace(req.ContactName)
+	req.ContactEmail = strings.TrimSpace(req.ContactEmail)
+	req.ContactPhone = strings.TrimSpace(req.ContactPhone)
+	req.SpecialRequests = strings.TrimSpace(req.SpecialRequests)
+	req.PromoCode = strings.TrimSpace(req.PromoCode)
+	if req.ContactName == "" || req.ContactEmail == "" || req.ContactPhone == "" {
+		return "Contact name, email and phone are required"
+	}
+	if _, err := mail.ParseAddress(req.ContactEmail); err != nil {
+		return "Invalid contact email"
+	}
+	if len(req.ContactPhone) < 7 || len(req.ContactPhone) > 20 {
+		return "Invalid contact phone"
+	}
+	return ""
+}
+
+// bookingPackage is the package as seen by the booking transaction
+type bookingPackage struct {
+	AgencyID     int
+	Title        string
+	MaxTravelers int
+	DepartureID  *int
+}
+
+// bookingPrice is the price snapshot stored with a booking. UnitPrice is
+// the adult price per person (or the group price) after seasonal pricing.
+type bookingPrice struct {
+	UnitPrice     float64
+	TotalPrice    float64
+	DiscountTotal float64
+	Currency      string
+	PromoCodeID   *int
+	Breakdown     sql.NullString
+}
+
+// priceBooking locks the package row for the rest of tx and prices the
+// party from it, so an agency editing the price mid-booking can't leave a
+// snapshot that never existed. The package's num_travelers is the largest
+// party it takes. Seats on the departure are reserved in the same
+// transaction, and the departure's price override replaces the package
+// price before the pricing rules apply. A promo code is locked and
+// counted here too.
+func priceBooking(tx *sql.Tx, req bookingRequest) (bookingPackage, bookingPrice, error) {
+	var pkg bookingPackage
+	var price float64
+	var stored []byte
+	err := tx.QueryRow(`
+		SELECT agency_id, title, num_travelers, price, pricing_rules
+		FROM travel_packages p
+		WHERE package_id = $1 AND `+publicPackageCondition+`
+		FOR SHARE
+	`, req.PackageID).Scan(&pkg.AgencyID, &pkg.Title, &pkg.MaxTravelers, &price, &stored)
+	if err == sql.ErrNoRows {
+		return pkg, bookingPrice{}, errPackageUnavailable
+	}
+	if err != nil {
+		return pkg, bookingPrice{}, err
+	}
+	travelers := len(req.Travelers)
+	if travelers > pkg.MaxTravelers {
+		return pkg, bookingPrice{}, errTooManyTravelers
+	}
+
+	departureID, override, err := reserveDeparture(tx, req.PackageID, req.DepartureDate, travelers)
+	if err != nil {
+		return pkg, bookingPrice{}, err
+	}
+	pkg.DepartureID = departureID
+	if override != nil {
+		price = *override
+	}
+
+	var promo *models.PromoCode
+	if req.PromoCode != "" {
+		if promo, err = findPromoCode(tx, req.PromoCode, req.PackageID, pkg.AgencyID, true); err != nil {
+			return pkg, bookingPrice{}, err
+		}
+		if err := usePromoCode(tx, promo.PromoID); err != nil {
+			return pkg, bookingPrice{}, err
+		}
+	}
+
+	rules := decodePricingRules(stored)
+	ages := make([]int, travelers)
+	for i, t := range req.Travelers {
+		ages[i] = t.Age
+	}
+	departure, _ := time.Parse("2006-01-02", req.DepartureDate)
+	quote := utils.QuotePrice(price, rules, departure, utils.ClassifyAges(rules, ages), promo)
+	breakdown, err := json.Marshal(quote)
+	if err != nil {
+		return pkg, bookingPrice{}, err
+	}
+
+	result := bookingPrice{
+		UnitPrice:     quote.UnitPrice,
+		TotalPrice:    quote.Total,
+		DiscountTotal: quote.DiscountTotal,
+		Currency:      quote.Currency,
+		Breakdown:     sql.NullString{String: string(breakdown), Valid: true},
+	}
+	if promo != nil {
+		result.PromoCodeID = &promo.PromoID
+	}
+	return pkg, result, nil
+}
+
+func writeBookingError(w http.ResponseWriter, err error) {
+	switch {
+	case err == sql.ErrNoRows:
+		http.Error(w, "Booking not found", http.StatusNotFound)
+	case errors.Is(err, errPackageUnavailable):
+		http.Error(w, "This package is not available for booking", http.StatusNotFound)
+	case errors.Is(err, errTooManyTravelers):
+		http.Error(w, "This package takes fewer travelers", http.StatusBadRequest)
+	case errors.Is(err, errBookingStatus):
+		http.Error(w, "The booking can't be changed from its current status", http.StatusConflict)
+	case errors.Is(err, errDepartureNotReached):
+		http.Error(w, "The trip can be completed only on or after its departure date", http.StatusConflict)
+	case errors.Is(err, errDeparturePassed):
+		http.Error(w, "The departure date has already passed", http.StatusConflict)
+	case errors.Is(err, errNoDeparture):
+		http.Error(w, "This package doesn't depart on that date; pick one from its calendar", http.StatusBadRequest)
+	case errors.Is(err, errNotEnoughSeats):
+		http.Error(w, "Not enough seats left on this departure", http.StatusConflict)
+	case promoErrorMessage(err) != "":
+		http.Error(w, promoErrorMessage(err), http.StatusBadRequest)
+	default:
+		log.Printf("Booking error: %v", err)
+		http.Error(w, "Error processing booking", http.StatusInternalServerError)
+	}
+}
+
+func scanBooking(row rowScanner) (models.Booking, error) {
+	var b models.Booking
+	var packageID, departureID sql.NullInt64
+	var departure time.Time
+	var confirmedAt, completedAt, cancelledAt sql.NullTime
+	var breakdown []byte
+	err := row.Scan(&b.BookingID, &b.UserID, &b.AgencyID, &b.AgencyName, &packageID, &b.PackageTitle, &departureID, &departure,
+		&b.NumTravelers, &b.UnitPrice, &b.TotalPrice, &b.DiscountTotal, &breakdown, &b.Currency, &b.ContactName, &b.ContactEmail, &b.ContactPhone,
+		&b.SpecialRequests, &b.Status, &b.CancelledBy, &b.CancellationReason,
+		&confirmedAt, &completedAt, &cancelledAt, &b.CreatedAt, &b.UpdatedAt)
+	if err != nil {
+		return b, err
+	}
+	if packageID.Valid {
+		id := int(packageID.Int64)
+		b.PackageID = &id
+	}
+	if departureID.Valid {
+		id := int(departureID.Int64)
+		b.DepartureID = &id
+	}
+	b.DepartureDate = departure.Format("2006-01-02")
+	if len(breakdown) > 0 {
+		var quote models.PriceQuote
+		if err := json.Unmarshal(breakdown, &quote); err == nil {
+			b.PriceBreakdown = &quote
+		}
+	}
+	if confirmedAt.Valid {
+		b.ConfirmedAt = &confirmedAt.Time
+	}
+	if completedAt.Valid {
+		b.CompletedAt = &completedAt.Time
+	}
+	if cancelledAt.Valid {
+		b.CancelledAt = &cancelledAt.Time
+	}
+	b.Travelers = []models.BookingTraveler{}
+	return b, nil
+}
+
+// fetchBookings loads bookings matching where (over b and a) with their
+// travelers, newest first
+func fetchBookings(where string, args ...interface{}) ([]models.Booking, error) {
+	rows, err := config.DB.Query(`SELECT `+bookingColumns+`
+		FROM bookings b
+		INNER JOIN travel_agencies a ON b.agency_id = a.agency_id
+		WHERE `+where+`
+		ORDER BY b.created_at DESC, b.booking_id DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bookings := []models.Booking{}
+	index := make(map[int]int)
+	ids := []int64{}
+	for rows.Next() {
+		b, err := scanBooking(rows)
+		if err != nil {
+			return nil, err
+		}
+		index[b.BookingID] = len(bookings)
+		ids = append(ids, int64(b.BookingID))
+		bookings = append(bookings, b)
+	}
+	if err := rows.Err(); err != nil || len(bookings) == 0 {
+		return bookings, err
+	}
+
+	travelerRows, err := config.DB.Query(`
+		SELECT booking_id, full_name, age, COALESCE(gender, '')
+		FROM booking_travelers
+		WHERE booking_id = ANY($1)
+		ORDER BY booking_id, sort_order, traveler_id
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer travelerRows.Close()
+	for travelerRows.Next() {
+		var bookingID int
+		var t models.BookingTraveler
+		if err := travelerRows.Scan(&bookingID, &t.FullName, &t.Age, &t.Gender); err != nil {
+			return nil, err
+		}
+		i := index[bookingID]
+		bookings[i].Travelers = append(bookings[i].Travelers, t)
+	}
+	return bookings, travelerRows.Err()
+}
+
+func fetchBooking(where string, args ...interface{}) (models.Booking, error) {
+	bookings, err := fetchBookings(where, args...)
+	if err != nil {
+		return models.Booking{}, err
+	}
+	if len(bookings) == 0 {
+		return models.Booking{}, sql.ErrNoRows
+	}
+	return bookings[0], nil
+}
+
+// CreateBooking requests a package departure for the signed-in user
+func CreateBooking(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userid").(int)
+
+	var req bookingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		writeBookingError(w, err)
+		return
+	}
+	defer tx.Rollback()
+
+	pkg, price, err := priceBooking(tx, req)
+	if err != nil {
+		writeBookingError(w, err)
+		return
+	}
+
+	var bookingID int
+	err = tx.QueryRow(`
+		INSERT INTO bookings (userid, agency_id, package_id, package_title, departure_id, departure_date, num_travelers,
+			unit_price, total_price, currency, contact_name, contact_email, contact_phone, special_requests,
+			discount_total, promo_code_id, price_breakdown)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NULLIF($14, ''), $15, $16, $17)
+		RETURNING booking_id
+	`, userID, pkg.AgencyID, req.PackageID, pkg.Title, pkg.DepartureID, req.DepartureDate, len(req.Travelers),
+		price.UnitPrice, price.TotalPrice, price.Currency, req.ContactName, req.ContactEmail, req.ContactPhone,
+		req.SpecialRequests, price.DiscountTotal, price.PromoCodeID, price.Breakdown).Scan(&bookingID)
+	if err != nil {
+		writeBookingError(w, err)
+		return
+	}
+	for i, t := range req.Travelers {
+		if _, err := tx.Exec(`
+			INSERT INTO booking_travelers (booking_id, full_name, age, gender, sort_order)
+			VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+		`, bookingID, t.FullName, t.Age, t.Gender, i); err != nil {
+			writeBookingError(w, err)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		writeBookingError(w, err)
+		return
+	}
+
+	booking, err := fetchBooking("b.booking_id = $1", bookingID)
+	if err != nil {
+		writeBookingError(w, err)
+		return
+	}
+	go notifyAgencyOfBooking(booking)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(booking)
+}
+
+// GetUserBookings lists the signed-in user's bookings
+func GetUserBookings(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userid").(int)
+
+	bookings, err := fetchBookings("b.userid = $1", userID)
+	if err != nil {
+		writeBookingError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(bookings)
+}
+
+// GetUserBooking returns one of the signed-in user's bookings
+func GetUserBooking(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userid").(int)
+	bookingID, err := strconv.Atoi(mux.Vars(r)["bookingid"])
+	if err != nil {
+		http.Error(w, "Invalid booking ID", http.StatusBadRequest)
+		return
+	}
+
+	booking, err := fetchBooking("b.booking_id = $1 AND b.userid = $2", bookingID, userID)
+	if err != nil {
+		writeBookingError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(booking)
+}
+
+// bookingTransition is a status change allowed from the listed statuses.
+// check, when set, validates the departure date first.
+type bookingTransition struct {
+	from  []string
+	to    string
+	by    string
+	check func(departure time.Time) error
+}
+
+func departureNotPassed(departure time.Time) error {
+	if departure.Before(utils.Today()) {
+		return errDeparturePassed
+	}
+	return nil
+}
+
+func departureReached(departure time.Time) error {
+	if departure.After(utils.Today()) {
+		return errDepartureNotReached
+	}
+	return nil
+}
+
+var (
+	userCancelBooking = bookingTransition{
+		from: []string{models.BookingRequested, models.BookingConfirmed}, to: models.BookingCancelled, by: "user",
+		check: departureNotPassed,
+	}
+	agencyAcceptBooking = bookingTransition{
+		from: []string{models.BookingRequested}, to: models.BookingConfirmed, by: "agency",
+		check: departureNotPassed,
+	}
+	agencyRejectBooking = bookingTransition{
+		from: []string{models.BookingRequested}, to: models.BookingCancelled, by: "agency",
+	}
+	agencyCompleteBooking = bookingTransition{
+		from: []string{models.BookingConfirmed}, to: models.BookingCompleted, by: "agency",
+		check: departureReached,
+	}
+)
+
+// applyBookingTransition locks the booking, checks the transition and
+// updates it. ownerColumn limits the change to the caller's bookings.
+func applyBookingTransition(bookingID int, ownerColumn string, ownerID int, t bookingTransition, reason string) error {
+	tx, err := config.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	var departure time.Time
+	err = tx.QueryRow(`SELECT status, departure_date FROM bookings WHERE booking_id = $1 AND `+ownerColumn+` = $2 FOR UPDATE`,
+		bookingID, ownerID).Scan(&status, &departure)
+	if err != nil {
+		return err
+	}
+
+	allowed := false
+	for _, from := range t.from {
+		allowed = allowed || status == from
+	}
+	if !allowed {
+		return errBookingStatus
+	}
+	if t.check != nil {
+		if err := t.check(departure); err != nil {
+			return err
+		}
+	}
+
+	switch t.to {
+	case models.BookingConfirmed:
+		_, err = tx.Exec(`UPDATE bookings SET status = $1, confirmed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE booking_id = $2`,
+			t.to, bookingID)
+	case models.BookingCompleted:
+		_, err = tx.Exec(`UPDATE bookings SET status = $1, completed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE booking_id = $2`,
+			t.to, bookingID)
+	case models.BookingCancelled:
+		_, err = tx.Exec(`
+			UPDATE bookings
+			SET status = $1, cancelled_by = $2, cancellation_reason = NULLIF($3, ''),
+				cancelled_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+			WHERE booking_id = $4
+		`, t.to, t.by, reason, bookingID)
+		if err == nil {
+			err = releaseDepartureSeats(tx, bookingID)
+		}
+		if err == nil {
+			err = releasePromoCode(tx, bookingID)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CancelBooking cancels one of the signed-in user's bookings before departure
+func CancelBooking(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userid").(int)
+	bookingID, err := strconv.Atoi(mux.Vars(r)["bookingid"])
+	if err != nil {
+		http.Error(w, "Invalid booking ID", http.StatusBadRequest)
+		return
+	}
+
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := applyBookingTransition(bookingID, "userid", userID, userCancelBooking, strings.TrimSpace(body.Reason)); err != nil {
+		writeBookingError(w, err)
+		return
+	}
+	booking, err := fetchBooking("b.booking_id = $1", bookingID)
+	if err != nil {
+		writeBookingError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(booking)
+}
+
+// GetAgencyBookings lists bookings for the agency's packages, optionally
+// filtered by ?status=
+func GetAgencyBookings(w http.ResponseWriter, r *http.Request) {
+	agencyID, ok := r.Context().Value("agencyid").(int)
+	if !ok || agencyID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	where, args := "b.agency_id = $1", []interface{}{agencyID}
+	if status := r.URL.Query().Get("status"); status != "" {
+		switch status {
+		case models.BookingRequested, models.BookingConfirmed, models.BookingCompleted, models.BookingCancelled:
+			where += " AND b.status = $2"
+			args = append(args, status)
+		default:
+			http.Error(w, "Invalid status", http.StatusBadRequest)
+			return
+		}
+	}
+
+	bookings, err := fetchBookings(where, args...)
+	if err != nil {
+		writeBookingError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(bookings)
+}
+
+// agencyBookingAction returns a handler applying t to one of the agency's
+// bookings. Rejections must give a reason.
+func agencyBookingAction(t bookingTransition) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		agencyID, ok := r.Context().Value("agencyid").(int)
+		if !ok || agencyID == 0 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		bookingID, err := strconv.Atoi(mux.Vars(r)["bookingid"])
+		if err != nil {
+			http.Error(w, "Invalid booking ID", http.StatusBadRequest)
+			return
+		}
+
+		var body struct {
+			Reason string `json:"reason"`
+		}
+		if r.ContentLength > 0 {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, "Invalid request", http.StatusBadRequest)
+				return
+			}
+		}
+		body.Reason = strings.TrimSpace(body.Reason)
+		if t.to == models.BookingCancelled && body.Reason == "" {
+			http.Error(w, "A reason is required to reject a booking", http.StatusBadRequest)
+			return
+		}
+
+		if err := applyBookingTransition(bookingID, "agency_id", agencyID, t, body.Reason); err != nil {
+			writeBookingError(w, err)
+			return
+		}
+		booking, err := fetchBooking("b.booking_id = $1", bookingID)
+		if err != nil {
+			writeBookingError(w, err)
+			return
+		}
+		go notifyTravelerOfBooking(booking)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(booking)
+	}
+}
+
+var (
+	// AcceptBooking confirms a requested booking
+	AcceptBooking = agencyBookingAction(agencyAcceptBooking)
+	// RejectBooking cancels a requested booking with a reason
+	RejectBooking = agencyBookingAction(agencyRejectBooking)
+	// CompleteBooking marks a confirmed booking as travelled
+	CompleteBooking = agencyBookingAction(agencyCompleteBooking)
+)
+
+func notifyAgencyOfBooking(b models.Booking) {
+	var email string
+	if err := config.DB.QueryRow(`SELECT email FROM travel_agencies WHERE agency_id = $1`, b.AgencyID).Scan(&email); err != nil {
+		log.Printf("Error loading agency email for booking %d: %v", b.BookingID, err)
+		return
+	}
+	if err := utils.SendBookingRequestEmail(email, b.AgencyName, b.PackageTitle, b.DepartureDate, b.NumTravelers, b.TotalPrice, b.ContactName); err != nil {
+		log.Printf("Error sending booking request email for booking %d: %v", b.BookingID, err)
+	}
+}
+
+func notifyTravelerOfBooking(b models.Booking) {
+	if err := utils.SendBookingStatusEmail(b.ContactEmail, b.ContactName, b.PackageTitle, b.DepartureDate, b.Status, b.CancellationReason); err != nil {
+		log.Printf("Error sending booking status email for booking %d: %v", b.BookingID, err)
+	}
+}
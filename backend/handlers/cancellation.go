@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+	"trip-planner-backend/config"
+	"trip-planner-backend/models"
+	"trip-planner-backend/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// parseCancellationPolicy reads the cancellation_policy form value, a JSON
+// array of tiers. An empty value or array clears the policy. It returns
+// the normalized tiers and the JSONB value to store (NULL for no policy).
+func parseCancellationPolicy(value string) ([]models.CancellationTier, sql.NullString, string) {
+	if value == "" || value == "null" {
+		return nil, sql.NullString{}, ""
+	}
+	var tiers []models.CancellationTier
+	if err := json.Unmarshal([]byte(value), &tiers); err != nil {
+		return nil, sql.NullString{}, "Invalid cancellation_policy format"
+	}
+	if len(tiers) == 0 {
+		return nil, sql.NullString{}, ""
+	}
+	tiers, err := utils.NormalizeCancellationPolicy(tiers)
+	if err != nil {
+		return nil, sql.NullString{}, "Invalid cancellation policy: " + err.Error()
+	}
+	stored, err := json.Marshal(tiers)
+	if err != nil {
+		return nil, sql.NullString{}, "Invalid cancellation_policy format"
+	}
+	return tiers, sql.NullString{String: string(stored), Valid: true}, ""
+}
+
+// decodeCancellationPolicy reads a stored policy; NULL gives nil
+func decodeCancellationPolicy(stored []byte) []models.CancellationTier {
+	if len(stored) == 0 {
+		return nil
+	}
+	var tiers []models.CancellationTier
+	if err := json.Unmarshal(stored, &tiers); err != nil {
+		log.Printf("Error decoding cancellation policy: %v", err)
+		return nil
+	}
+	return tiers
+}
+
+// GetPackageRefundQuote computes what an active package's cancellation
+// policy refunds for ?price= (default: the package price per person),
+// ?departure_date= and ?cancellation_date= (default: today)
+func GetPackageRefundQuote(w http.ResponseWriter, r *http.Request) {
+	packageID, err := strconv.Atoi(mux.Vars(r)["packageid"])
+	if err != nil {
+		http.Error(w, "Invalid package ID", http.StatusBadRequest)
+		return
+	}
+	query := r.URL.Query()
+
+	departure, err := time.Parse("2006-01-02", query.Get("departure_date"))
+	if err != nil {
+		http.Error(w, "departure_date must be YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	cancelledOn := time.Now()
+	if v := query.Get("cancellation_date"); v != "" {
+		if cancelledOn, err = time.Parse("2006-01-02", v); err != nil {
+			http.Error(w, "cancellation_date must be YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var packagePrice float64
+	var stored []byte
+	err = config.DB.QueryRow(`SELECT price, cancellation_policy FROM travel_packages p WHERE package_id = $1 AND `+publicPackageCondition,
+		packageID).Scan(&packagePrice, &stored)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Package not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading cancellation policy for package %d: %v", packageID, err)
+		http.Error(w, "Failed to compute refund", http.StatusInternalServerError)
+		return
+	}
+
+	price := packagePrice
+	if v := query.Get("price"); v != "" {
+		if price, err = strconv.ParseFloat(v, 64); err != nil || price < 0 || math.IsNaN(price) || math.IsInf(price, 0) {
+			http.Error(w, "price must be a finite non-negative number", http.StatusBadRequest)
+			return
+		}
+	}
+
+	policy := decodeCancellationPolicy(stored)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		PackageID int `json:"package_id"`
+		models.RefundQuote
+		Policy []models.CancellationTier `json:"cancellation_policy"`
+	}{packageID, utils.QuoteRefund(policy, price, departure, cancelledOn), policy})
+}
@@ -0,0 +1,459 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"trip-planner-backend/config"
+	"trip-planner-backend/models"
+	"trip-planner-backend/utils"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	maxDepartureSeats = 1000
+	// Departures with this many seats or fewer left show as limited
+	limitedDepartureSeats = 3
+)
+
+const departureColumns = `
+	d.departure_id, d.package_id, d.start_date, p.duration_days, d.total_seats, d.seats_available,
+	d.price_override, COALESCE(d.price_override, p.price), d.is_active, d.created_at, d.updated_at`
+
+type departureRequest struct {
+	StartDate     string   `json:"start_date"`
+	TotalSeats    int      `json:"total_seats"`
+	PriceOverride *float64 `json:"price_override"`
+	IsActive      *bool    `json:"is_active"`
+}
+
+// validate checks the request and returns the parsed start date. Like
+// bookings, departures must be at least a day away and within two years.
+func (req *departureRequest) validate() (time.Time, string) {
+	start, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return start, "start_date must be YYYY-MM-DD"
+	}
+	today := utils.Today()
+	if !start.After(today) {
+		return start, "start_date must be in the future"
+	}
+	if start.After(today.AddDate(2, 0, 0)) {
+		return start, "start_date can be at most two years ahead"
+	}
+	if req.TotalSeats <= 0 || req.TotalSeats > maxDepartureSeats {
+		return start, "total_seats must be between 1 and " + strconv.Itoa(maxDepartureSeats)
+	}
+	if req.PriceOverride != nil && *req.PriceOverride < 0 {
+		return start, "price_override can't be negative"
+	}
+	return start, ""
+}
+
+func scanDeparture(row rowScanner) (models.PackageDeparture, error) {
+	var d models.PackageDeparture
+	var start time.Time
+	var duration int
+	var override sql.NullFloat64
+	err := row.Scan(&d.DepartureID, &d.PackageID, &start, &duration, &d.TotalSeats, &d.SeatsAvailable,
+		&override, &d.Price, &d.IsActive, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return d, err
+	}
+	d.StartDate = start.Format("2006-01-02")
+	d.EndDate = start.AddDate(0, 0, max(duration, 1)-1).Format("2006-01-02")
+	d.BookedSeats = d.TotalSeats - d.SeatsAvailable
+	if override.Valid {
+		d.PriceOverride = &override.Float64
+	}
+	switch {
+	case !d.IsActive:
+		d.Status = models.DepartureClosed
+	case d.SeatsAvailable == 0:
+		d.Status = models.DepartureSoldOut
+	case d.SeatsAvailable <= limitedDepartureSeats:
+		d.Status = models.DepartureLimited
+	default:
+		d.Status = models.DepartureAvailable
+	}
+	return d, nil
+}
+
+func fetchDepartures(where string, args ...interface{}) ([]models.PackageDeparture, error) {
+	rows, err := config.DB.Query(`SELECT `+departureColumns+`
+		FROM package_departures d
+		INNER JOIN travel_packages p ON d.package_id = p.package_id
+		WHERE `+where+`
+		ORDER BY d.start_date`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	departures := []models.PackageDeparture{}
+	for rows.Next() {
+		d, err := scanDeparture(rows)
+		if err != nil {
+			return nil, err
+		}
+		departures = append(departures, d)
+	}
+	return departures, rows.Err()
+}
+
+// GetPackageDepartures is the public availability calendar of an active
+// package: its open departures between ?from= and ?to= (default: the next
+// twelve months)
+func GetPackageDepartures(w http.ResponseWriter, r *http.Request) {
+	packageID, err := strconv.Atoi(mux.Vars(r)["packageid"])
+	if err != nil {
+		http.Error(w, "Invalid package ID", http.StatusBadRequest)
+		return
+	}
+
+	today := utils.Today()
+	from, to := today.AddDate(0, 0, 1), today.AddDate(1, 0, 0)
+	query := r.URL.Query()
+	if v := query.Get("from"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			http.Error(w, "from must be YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		if parsed.After(from) {
+			from = parsed
+		}
+	}
+	if v := query.Get("to"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			http.Error(w, "to must be YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		to = parsed
+	}
+	if to.Before(from) {
+		http.Error(w, "to must not be before from", http.StatusBadRequest)
+		return
+	}
+	if to.After(from.AddDate(2, 0, 0)) {
+		http.Error(w, "The calendar covers at most two years", http.StatusBadRequest)
+		return
+	}
+
+	var durationDays int
+	err = config.DB.QueryRow(`SELECT duration_days FROM travel_packages p WHERE package_id = $1 AND `+publicPackageCondition,
+		packageID).Scan(&durationDays)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Package not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading package %d for calendar: %v", packageID, err)
+		http.Error(w, "Failed to load departures", http.StatusInternalServerError)
+		return
+	}
+
+	departures, err := fetchDepartures(`d.package_id = $1 AND d.is_active = TRUE AND d.start_date BETWEEN $2 AND $3`,
+		packageID, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		log.Printf("Error loading departures for package %d: %v", packageID, err)
+		http.Error(w, "Failed to load departures", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"package_id":    packageID,
+		"duration_days": durationDays,
+		"from":          from.Format("2006-01-02"),
+		"to":            to.Format("2006-01-02"),
+		"departures":    departures,
+	})
+}
+
+// GetAgencyDepartures lists every departure of one of the agency's
+// packages, including past and closed ones
+func GetAgencyDepartures(w http.ResponseWriter, r *http.Request) {
+	_, packageID, ok := agencyPackageFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	departures, err := fetchDepartures("d.package_id = $1", packageID)
+	if err != nil {
+		log.Printf("Error loading departures for package %d: %v", packageID, err)
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(departures)
+}
+
+// CreateDeparture schedules a new departure with its seat inventory
+func CreateDeparture(w http.ResponseWriter, r *http.Request) {
+	_, packageID, ok := agencyPackageFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req departureRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	start, msg := req.validate()
+	if msg != "" {
+		sendJSONError(w, msg, http.StatusBadRequest)
+		return
+	}
+	active := req.IsActive == nil || *req.IsActive
+
+	var departureID int
+	err := config.DB.QueryRow(`
+		INSERT INTO package_departures (package_id, start_date, total_seats, seats_available, price_override, is_active)
+		VALUES ($1, $2, $3, $3, $4, $5)
+		RETURNING departure_id
+	`, packageID, start.Format("2006-01-02"), req.TotalSeats, req.PriceOverride, active).Scan(&departureID)
+	if isUniqueViolation(err) {
+		sendJSONError(w, "This package already has a departure on that date", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("Error creating departure for package %d: %v", packageID, err)
+		sendJSONError(w, "Failed to create departure", http.StatusInternalServerError)
+		return
+	}
+
+	writeDeparture(w, departureID, http.StatusCreated)
+}
+
+// UpdateDeparture changes a departure's date, seats, price override or
+// open state. Seats already booked stay booked: the total can't drop below
+// them, and the date can't move while the departure has active bookings.
+func UpdateDeparture(w http.ResponseWriter, r *http.Request) {
+	_, packageID, ok := agencyPackageFromRequest(w, r)
+	if !ok {
+		return
+	}
+	departureID, err := strconv.Atoi(mux.Vars(r)["departureid"])
+	if err != nil {
+		sendJSONError(w, "Invalid departure ID", http.StatusBadRequest)
+		return
+	}
+
+	var req departureRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	start, msg := req.validate()
+	if msg != "" {
+		sendJSONError(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// The same lock the booking transaction takes, so seat counts can't
+	// change underneath us
+	var current time.Time
+	var total, available int
+	var active bool
+	err = tx.QueryRow(`
+		SELECT start_date, total_seats, seats_available, is_active
+		FROM package_departures
+		WHERE departure_id = $1 AND package_id = $2
+		FOR UPDATE
+	`, departureID, packageID).Scan(&current, &total, &available, &active)
+	if err == sql.ErrNoRows {
+		sendJSONError(w, "Departure not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	booked := total - available
+	if req.TotalSeats < booked {
+		sendJSONError(w, "total_seats can't be less than the "+strconv.Itoa(booked)+" seats already booked", http.StatusConflict)
+		return
+	}
+	if !start.Equal(current) {
+		activeBookings, err := countActiveDepartureBookings(tx, departureID)
+		if err != nil {
+			sendJSONError(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		if activeBookings > 0 {
+			sendJSONError(w, "The date can't change while the departure has active bookings", http.StatusConflict)
+			return
+		}
+	}
+	if req.IsActive != nil {
+		active = *req.IsActive
+	}
+
+	_, err = tx.Exec(`
+		UPDATE package_departures
+		SET start_date = $1, total_seats = $2, seats_available = $3, price_override = $4, is_active = $5,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE departure_id = $6
+	`, start.Format("2006-01-02"), req.TotalSeats, req.TotalSeats-booked, req.PriceOverride, active, departureID)
+	if isUniqueViolation(err) {
+		sendJSONError(w, "This package already has a departure on that date", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating departure %d: %v", departureID, err)
+		sendJSONError(w, "Failed to update departure", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		sendJSONError(w, "Failed to update departure", http.StatusInternalServerError)
+		return
+	}
+
+	writeDeparture(w, departureID, http.StatusOK)
+}
+
+// DeleteDeparture removes a departure with no active bookings. Departures
+// with bookings can be closed to new ones through UpdateDeparture instead.
+func DeleteDeparture(w http.ResponseWriter, r *http.Request) {
+	_, packageID, ok := agencyPackageFromRequest(w, r)
+	if !ok {
+		return
+	}
+	departureID, err := strconv.Atoi(mux.Vars(r)["departureid"])
+	if err != nil {
+		sendJSONError(w, "Invalid departure ID", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`SELECT departure_id FROM package_departures WHERE departure_id = $1 AND package_id = $2 FOR UPDATE`,
+		departureID, packageID).Scan(&id)
+	if err == sql.ErrNoRows {
+		sendJSONError(w, "Departure not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	activeBookings, err := countActiveDepartureBookings(tx, departureID)
+	if err != nil {
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if activeBookings > 0 {
+		sendJSONError(w, "This departure has active bookings; close it instead", http.StatusConflict)
+		return
+	}
+
+	if _, err := tx.Exec(`DELETE FROM package_departures WHERE departure_id = $1`, departureID); err != nil {
+		sendJSONError(w, "Failed to delete departure", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		sendJSONError(w, "Failed to delete departure", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Departure deleted successfully"})
+}
+
+func countActiveDepartureBookings(tx *sql.Tx, departureID int) (int, error) {
+	var n int
+	err := tx.QueryRow(`SELECT COUNT(*) FROM bookings WHERE departure_id = $1 AND status IN ($2, $3)`,
+		departureID, models.BookingRequested, models.BookingConfirmed).Scan(&n)
+	return n, err
+}
+
+func writeDeparture(w http.ResponseWriter, departureID int, status int) {
+	departures, err := fetchDepartures("d.departure_id = $1", departureID)
+	if err != nil || len(departures) == 0 {
+		sendJSONError(w, "Failed to load departure", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(departures[0])
+}
+
+// reserveDeparture locks the package's departure on date and takes seats
+// for the party. The FOR UPDATE row lock serialises concurrent bookings of
+// the same departure, so the seat check and decrement can't interleave.
+// Packages with no upcoming departures (open or closed) stay bookable on
+// request for any date and return a nil departure.
+func reserveDeparture(tx *sql.Tx, packageID int, date string, seats int) (*int, *float64, error) {
+	var departureID, available int
+	var override sql.NullFloat64
+	err := tx.QueryRow(`
+		SELECT departure_id, seats_available, price_override
+		FROM package_departures
+		WHERE package_id = $1 AND start_date = $2 AND is_active = TRUE
+		FOR UPDATE
+	`, packageID, date).Scan(&departureID, &available, &override)
+	if err == sql.ErrNoRows {
+		var scheduled bool
+		err = tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM package_departures WHERE package_id = $1 AND start_date >= CURRENT_DATE)`,
+			packageID).Scan(&scheduled)
+		if err != nil {
+			return nil, nil, err
+		}
+		if scheduled {
+			return nil, nil, errNoDeparture
+		}
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if available < seats {
+		return nil, nil, errNotEnoughSeats
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE package_departures SET seats_available = seats_available - $1, updated_at = CURRENT_TIMESTAMP
+		WHERE departure_id = $2
+	`, seats, departureID); err != nil {
+		return nil, nil, err
+	}
+	var price *float64
+	if override.Valid {
+		price = &override.Float64
+	}
+	return &departureID, price, nil
+}
+
+// releaseDepartureSeats gives a cancelled booking's seats back to its
+// departure
+func releaseDepartureSeats(tx *sql.Tx, bookingID int) error {
+	_, err := tx.Exec(`
+		UPDATE package_departures d
+		SET seats_available = LEAST(d.total_seats, d.seats_available + b.num_travelers), updated_at = CURRENT_TIMESTAMP
+		FROM bookings b
+		WHERE b.booking_id = $1 AND d.departure_id = b.departure_id
+	`, bookingID)
+	return err
+}
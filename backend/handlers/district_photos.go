@@ -0,0 +1,424 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"trip-planner-backend/config"
+	"trip-planner-backend/models"
+	"trip-planner-backend/storage"
+	"trip-planner-backend/utils"
+
+	"github.com/gorilla/mux"
+)
+
+func isDistrictImage(filename string) bool {
+	_, ok := utils.AllowedImageExtensions[strings.ToLower(filepath.Ext(filename))]
+	return ok
+}
+
+// resolveDistrictFolder maps a district name as sent by the client to its
+// canonical name and photo folder. ok is false for districts with no
+// record, so no folder is ever made up from client input.
+func resolveDistrictFolder(district string) (name, folder string, ok bool) {
+	d, ok := utils.FindDistrict(district)
+	if !ok {
+		return "", "", false
+	}
+	if d.PhotoFolder == "" {
+		return d.Name, filepath.Base(d.Name), true
+	}
+	return d.Name, d.PhotoFolder, true
+}
+
+func districtPhotoPrefix(folder string) string {
+	return storage.Key("districts", folder)
+}
+
+// setDistrictPhotoURLs fills in the client URLs of a district photo
+func setDistrictPhotoURLs(ctx context.Context, p *models.DistrictPhoto) {
+	p.URL = storageURL(ctx, storage.Key(districtPhotoPrefix(p.Folder), p.Filename))
+	p.Variants = photoVariantURLs(ctx, districtPhotoPrefix(p.Folder), p.Filename)
+}
+
+// fetchDistrictPhotos returns the curated photos of a district, cover first
+func fetchDistrictPhotos(ctx context.Context, district string) ([]models.DistrictPhoto, error) {
+	rows, err := config.DB.Query(`
+		SELECT photo_id, district_name, folder, filename, COALESCE(caption, ''), COALESCE(alt_text, ''),
+			   COALESCE(credit, ''), sort_order, is_cover, created_at
+		FROM district_photos
+		WHERE district_name = $1
+		ORDER BY is_cover DESC, sort_order, photo_id
+	`, district)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	photos := []models.DistrictPhoto{}
+	for rows.Next() {
+		var p models.DistrictPhoto
+		if err := rows.Scan(&p.PhotoID, &p.DistrictName, &p.Folder, &p.Filename, &p.Caption, &p.AltText,
+			&p.Credit, &p.SortOrder, &p.IsCover, &p.CreatedAt); err != nil {
+			continue
+		}
+		setDistrictPhotoURLs(ctx, &p)
+		if p.AltText == "" {
+			p.AltText = defaultDistrictAltText(p)
+		}
+		photos = append(photos, p)
+	}
+	return photos, rows.Err()
+}
+
+func defaultDistrictAltText(p models.DistrictPhoto) string {
+	if p.Caption != "" {
+		return p.Caption
+	}
+	return "Photo of " + p.DistrictName
+}
+
+// legacyDistrictPhotos lists image files copied into the district folder
+// before photos were managed through the admin API
+func legacyDistrictPhotos(ctx context.Context, district, folder string) []models.DistrictPhoto {
+	prefix := districtPhotoPrefix(folder) + "/"
+	objects, err := storage.Default().List(ctx, prefix)
+	if err != nil {
+		log.Printf("Error listing district folder: %v", err)
+		return []models.DistrictPhoto{}
+	}
+
+	names := []string{}
+	for _, obj := range objects {
+		name := strings.TrimPrefix(obj.Key, prefix)
+		if !strings.Contains(name, "/") && isDistrictImage(name) && !utils.IsSecondaryVariant(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	photos := []models.DistrictPhoto{}
+	for i, name := range names {
+		p := models.DistrictPhoto{
+			DistrictName: district,
+			Folder:       folder,
+			Filename:     name,
+			AltText:      fmt.Sprintf("Photo %d of %s", i+1, district),
+			SortOrder:    i,
+			IsCover:      i == 0,
+		}
+		setDistrictPhotoURLs(ctx, &p)
+		photos = append(photos, p)
+	}
+	return photos
+}
+
+// AdminGetDistrictPhotos lists the managed photos of a district along with any
+// files in its folder that have not been registered yet
+func AdminGetDistrictPhotos(w http.ResponseWriter, r *http.Request) {
+	district, folder, ok := resolveDistrictFolder(mux.Vars(r)["district"])
+	if !ok {
+		sendJSONError(w, "District not found", http.StatusNotFound)
+		return
+	}
+
+	photos, err := fetchDistrictPhotos(r.Context(), district)
+	if err != nil {
+		sendJSONError(w, "Error fetching district photos", http.StatusInternalServerError)
+		return
+	}
+
+	registered := make(map[string]bool)
+	for _, p := range photos {
+		registered[p.Filename] = true
+	}
+	unregistered := []string{}
+	for _, p := range legacyDistrictPhotos(r.Context(), district, folder) {
+		if !registered[p.Filename] {
+			unregistered = append(unregistered, p.Filename)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"district":     district,
+		"folder":       folder,
+		"photos":       photos,
+		"unregistered": unregistered,
+	})
+}
+
+// AdminUploadDistrictPhotos stores new photos for a district. Caption, alt
+// text and credit in the form apply to every uploaded file.
+func AdminUploadDistrictPhotos(w http.ResponseWriter, r *http.Request) {
+	district, folder, ok := resolveDistrictFolder(mux.Vars(r)["district"])
+	if !ok {
+		sendJSONError(w, "District not found", http.StatusNotFound)
+		return
+	}
+
+	if !parseUploadForm(w, r) {
+		return
+	}
+
+	files := r.MultipartForm.File["photos"]
+	if len(files) == 0 {
+		sendJSONError(w, "At least one photo is required", http.StatusBadRequest)
+		return
+	}
+
+	caption := r.FormValue("caption")
+	altText := r.FormValue("alt_text")
+	credit := r.FormValue("credit")
+
+	var nextOrder int
+	if err := config.DB.QueryRow(`SELECT COALESCE(MAX(sort_order) + 1, 0) FROM district_photos WHERE district_name = $1`,
+		district).Scan(&nextOrder); err != nil {
+		log.Printf("Error reading photo order for %s: %v", district, err)
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	uploaded := []models.DistrictPhoto{}
+	uploadErrors := []uploadError{}
+	for _, fileHeader := range files {
+		original := uploadDisplayName(fileHeader)
+		filename, err := storeProcessedPhoto(r.Context(), fileHeader, districtPhotoPrefix(folder), newUploadKey("district"))
+		if err != nil {
+			uploadErrors = append(uploadErrors, uploadError{Filename: original, Error: err.Error()})
+			continue
+		}
+
+		var p models.DistrictPhoto
+		err = config.DB.QueryRow(`
+			INSERT INTO district_photos (district_name, folder, filename, caption, alt_text, credit, sort_order)
+			VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), $7)
+			RETURNING photo_id, created_at
+		`, district, folder, filename, caption, altText, credit, nextOrder).Scan(&p.PhotoID, &p.CreatedAt)
+		if err != nil {
+			removePhotoFiles(r.Context(), districtPhotoPrefix(folder), filename)
+			uploadErrors = append(uploadErrors, uploadError{Filename: original, Error: "failed to save photo details"})
+			continue
+		}
+
+		p.DistrictName = district
+		p.Folder = folder
+		p.Filename = filename
+		p.Caption = caption
+		p.AltText = altText
+		p.Credit = credit
+		p.SortOrder = nextOrder
+		setDistrictPhotoURLs(r.Context(), &p)
+		uploaded = append(uploaded, p)
+		nextOrder++
+	}
+
+	status := http.StatusCreated
+	if len(uploaded) == 0 {
+		status = http.StatusBadRequest
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"photos": uploaded,
+		"errors": uploadErrors,
+	})
+}
+
+// AdminImportDistrictPhotos registers files already present in the
+// district folder so they can be captioned and ordered
+func AdminImportDistrictPhotos(w http.ResponseWriter, r *http.Request) {
+	district, folder, ok := resolveDistrictFolder(mux.Vars(r)["district"])
+	if !ok {
+		sendJSONError(w, "District not found", http.StatusNotFound)
+		return
+	}
+
+	var nextOrder int
+	if err := config.DB.QueryRow(`SELECT COALESCE(MAX(sort_order) + 1, 0) FROM district_photos WHERE district_name = $1`,
+		district).Scan(&nextOrder); err != nil {
+		log.Printf("Error reading photo order for %s: %v", district, err)
+		sendJSONError(w, "Error importing district photos", http.StatusInternalServerError)
+		return
+	}
+
+	imported := 0
+	for _, p := range legacyDistrictPhotos(r.Context(), district, folder) {
+		result, err := config.DB.Exec(`
+			INSERT INTO district_photos (district_name, folder, filename, sort_order)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (folder, filename) DO NOTHING
+		`, district, folder, p.Filename, nextOrder)
+		if err != nil {
+			sendJSONError(w, "Error importing district photos", http.StatusInternalServerError)
+			return
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			imported++
+			nextOrder++
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":  fmt.Sprintf("Imported %d photo(s)", imported),
+		"imported": imported,
+	})
+}
+
+// AdminUpdateDistrictPhoto edits the caption, alt text and credit of a photo
+func AdminUpdateDistrictPhoto(w http.ResponseWriter, r *http.Request) {
+	photoID, err := strconv.Atoi(mux.Vars(r)["photoid"])
+	if err != nil {
+		sendJSONError(w, "Invalid photo ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Caption string `json:"caption"`
+		AltText string `json:"alt_text"`
+		Credit  string `json:"credit"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+
+	result, err := config.DB.Exec(`
+		UPDATE district_photos
+		SET caption = NULLIF($1, ''), alt_text = NULLIF($2, ''), credit = NULLIF($3, '')
+		WHERE photo_id = $4
+	`, req.Caption, req.AltText, req.Credit, photoID)
+	if err != nil {
+		sendJSONError(w, "Error updating photo", http.StatusInternalServerError)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		sendJSONError(w, "Photo not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Photo updated successfully"})
+}
+
+// AdminReorderDistrictPhotos sets the display order from a list of photo IDs
+func AdminReorderDistrictPhotos(w http.ResponseWriter, r *http.Request) {
+	district, _, ok := resolveDistrictFolder(mux.Vars(r)["district"])
+	if !ok {
+		sendJSONError(w, "District not found", http.StatusNotFound)
+		return
+	}
+
+	var req struct {
+		PhotoIDs []int `json:"photo_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.PhotoIDs) == 0 {
+		sendJSONError(w, "photo_ids is required", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	for i, photoID := range req.PhotoIDs {
+		result, err := tx.Exec(`UPDATE district_photos SET sort_order = $1 WHERE photo_id = $2 AND district_name = $3`, i, photoID, district)
+		if err != nil {
+			sendJSONError(w, "Error reordering photos", http.StatusInternalServerError)
+			return
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			sendJSONError(w, fmt.Sprintf("Photo %d does not belong to %s", photoID, district), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		sendJSONError(w, "Error reordering photos", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Photos reordered successfully"})
+}
+
+// AdminSetDistrictCoverPhoto makes a photo the district's cover
+func AdminSetDistrictCoverPhoto(w http.ResponseWriter, r *http.Request) {
+	photoID, err := strconv.Atoi(mux.Vars(r)["photoid"])
+	if err != nil {
+		sendJSONError(w, "Invalid photo ID", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var district string
+	err = tx.QueryRow(`SELECT district_name FROM district_photos WHERE photo_id = $1`, photoID).Scan(&district)
+	if err == sql.ErrNoRows {
+		sendJSONError(w, "Photo not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := tx.Exec(`UPDATE district_photos SET is_cover = FALSE WHERE district_name = $1 AND is_cover`, district); err != nil {
+		sendJSONError(w, "Error setting cover photo", http.StatusInternalServerError)
+		return
+	}
+	if _, err := tx.Exec(`UPDATE district_photos SET is_cover = TRUE WHERE photo_id = $1`, photoID); err != nil {
+		sendJSONError(w, "Error setting cover photo", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		sendJSONError(w, "Error setting cover photo", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Cover photo updated successfully"})
+}
+
+// AdminDeleteDistrictPhoto removes the photo record and its file
+func AdminDeleteDistrictPhoto(w http.ResponseWriter, r *http.Request) {
+	photoID, err := strconv.Atoi(mux.Vars(r)["photoid"])
+	if err != nil {
+		sendJSONError(w, "Invalid photo ID", http.StatusBadRequest)
+		return
+	}
+
+	var folder, filename string
+	err = config.DB.QueryRow(`DELETE FROM district_photos WHERE photo_id = $1 RETURNING folder, filename`, photoID).Scan(&folder, &filename)
+	if err == sql.ErrNoRows {
+		sendJSONError(w, "Photo not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendJSONError(w, "Error deleting photo", http.StatusInternalServerError)
+		return
+	}
+
+	if err := removePhotoFiles(r.Context(), districtPhotoPrefix(folder), filename); err != nil {
+		log.Printf("Error removing district photo file: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Photo deleted successfully"})
+}
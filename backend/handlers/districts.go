@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"trip-planner-backend/config"
+	"trip-planner-backend/models"
+	"trip-planner-backend/utils"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+// LoadDistricts reads the districts table for the utils district registry
+func LoadDistricts() ([]models.District, error) {
+	rows, err := config.DB.Query(`
+		SELECT district_id, name, slug, aliases, latitude, longitude, COALESCE(description, ''),
+			   COALESCE(best_season, ''), highlights, photo_folder, created_at, updated_at
+		FROM districts
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	districts := []models.District{}
+	for rows.Next() {
+		var d models.District
+		if err := rows.Scan(&d.DistrictID, &d.Name, &d.Slug, pq.Array(&d.Aliases), &d.Latitude, &d.Longitude,
+			&d.Description, &d.BestSeason, pq.Array(&d.Highlights), &d.PhotoFolder, &d.CreatedAt, &d.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if d.Aliases == nil {
+			d.Aliases = []string{}
+		}
+		if d.Highlights == nil {
+			d.Highlights = []string{}
+		}
+		districts = append(districts, d)
+	}
+	return districts, rows.Err()
+}
+
+// GetDistricts lists every district (public endpoint)
+func GetDistricts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(utils.AllDistricts())
+}
+
+// GetDistrict looks a district up by name, slug or alias (public endpoint)
+func GetDistrict(w http.ResponseWriter, r *http.Request) {
+	district, ok := utils.FindDistrict(mux.Vars(r)["district"])
+	if !ok {
+		sendJSONError(w, "District not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(district)
+}
+
+type districtRequest struct {
+	Name        string   `json:"name"`
+	Slug        string   `json:"slug"`
+	Aliases     []string `json:"aliases"`
+	Latitude    *float64 `json:"latitude"`
+	Longitude   *float64 `json:"longitude"`
+	Description string   `json:"description"`
+	BestSeason  string   `json:"best_season"`
+	Highlights  []string `json:"highlights"`
+	PhotoFolder string   `json:"photo_folder"`
+}
+
+// validate trims the request and fills in the derived fields. districtID is
+// the district being updated, or 0 when creating one.
+func (req *districtRequest) validate(districtID int) string {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return "Name is required"
+	}
+	if req.Latitude == nil || req.Longitude == nil {
+		return "Latitude and longitude are required"
+	}
+	if *req.Latitude < -90 || *req.Latitude > 90 || *req.Longitude < -180 || *req.Longitude > 180 {
+		return "Latitude or longitude is out of range"
+	}
+
+	req.Slug = utils.DistrictSlug(req.Slug)
+	if req.Slug == "" {
+		req.Slug = utils.DistrictSlug(req.Name)
+	}
+	if req.Slug == "" {
+		return "Name must contain letters or digits"
+	}
+
+	primary, _, _ := strings.Cut(req.Name, " (")
+	req.PhotoFolder = strings.TrimSpace(req.PhotoFolder)
+	if req.PhotoFolder == "" {
+		req.PhotoFolder = primary
+	}
+	if strings.ContainsAny(req.PhotoFolder, `/\`) || strings.HasPrefix(req.PhotoFolder, ".") {
+		return "Invalid photo folder"
+	}
+
+	req.Aliases = cleanStringList(req.Aliases)
+	req.Highlights = cleanStringList(req.Highlights)
+
+	// Aliases resolve user input to a district, so no name may point at two
+	for _, name := range append([]string{req.Name, req.Slug}, req.Aliases...) {
+		if other, ok := utils.FindDistrict(name); ok && other.DistrictID != districtID {
+			return "\"" + name + "\" is already used by " + other.Name
+		}
+	}
+	return ""
+}
+
+// cleanStringList trims entries and drops blanks and case-insensitive duplicates
+func cleanStringList(values []string) []string {
+	cleaned := []string{}
+	seen := make(map[string]bool)
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" || seen[strings.ToLower(v)] {
+			continue
+		}
+		seen[strings.ToLower(v)] = true
+		cleaned = append(cleaned, v)
+	}
+	return cleaned
+}
+
+// AdminCreateDistrict adds a district
+func AdminCreateDistrict(w http.ResponseWriter, r *http.Request) {
+	var req districtRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+	if msg := req.validate(0); msg != "" {
+		sendJSONError(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	var districtID int
+	err := config.DB.QueryRow(`
+		INSERT INTO districts (name, slug, aliases, latitude, longitude, description, best_season, highlights, photo_folder)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8, $9)
+		RETURNING district_id
+	`, req.Name, req.Slug, pq.Array(req.Aliases), *req.Latitude, *req.Longitude,
+		req.Description, req.BestSeason, pq.Array(req.Highlights), req.PhotoFolder).Scan(&districtID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			sendJSONError(w, "A district with this name or slug already exists", http.StatusConflict)
+			return
+		}
+		sendJSONError(w, "Error creating district", http.StatusInternalServerError)
+		return
+	}
+	utils.InvalidateDistricts()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":     "District created successfully",
+		"district_id": districtID,
+	})
+}
+
+// AdminUpdateDistrict replaces a district's details. Renaming it also moves
+// its photos, feedback and places so they keep matching the canonical name.
+func AdminUpdateDistrict(w http.ResponseWriter, r *http.Request) {
+	districtID, err := strconv.Atoi(mux.Vars(r)["districtid"])
+	if err != nil {
+		sendJSONError(w, "Invalid district ID", http.StatusBadRequest)
+		return
+	}
+
+	var req districtRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+	if msg := req.validate(districtID); msg != "" {
+		sendJSONError(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		sendJSONError(w, "Error updating district", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var oldName string
+	err = tx.QueryRow(`SELECT name FROM districts WHERE district_id = $1 FOR UPDATE`, districtID).Scan(&oldName)
+	if err == sql.ErrNoRows {
+		sendJSONError(w, "District not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendJSONError(w, "Error updating district", http.StatusInternalServerError)
+		return
+	}
+
+	_, err = tx.Exec(`
+		UPDATE districts
+		SET name = $1, slug = $2, aliases = $3, latitude = $4, longitude = $5, description = NULLIF($6, ''),
+			best_season = NULLIF($7, ''), highlights = $8, photo_folder = $9, updated_at = CURRENT_TIMESTAMP
+		WHERE district_id = $10
+	`, req.Name, req.Slug, pq.Array(req.Aliases), *req.Latitude, *req.Longitude,
+		req.Description, req.BestSeason, pq.Array(req.Highlights), req.PhotoFolder, districtID)
+	if err == nil && oldName != req.Name {
+		_, err = tx.Exec(`UPDATE district_photos SET district_name = $1 WHERE district_name = $2`, req.Name, oldName)
+		if err == nil {
+			_, err = tx.Exec(`UPDATE feedbacks SET district_name = $1 WHERE district_name = $2`, req.Name, oldName)
+		}
+		if err == nil {
+			_, err = tx.Exec(`UPDATE pois SET district_name = $1 WHERE district_name = $2`, req.Name, oldName)
+		}
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		if isUniqueViolation(err) {
+			sendJSONError(w, "A district with this name or slug already exists", http.StatusConflict)
+			return
+		}
+		sendJSONError(w, "Error updating district", http.StatusInternalServerError)
+		return
+	}
+	utils.InvalidateDistricts()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "District updated successfully",
+	})
+}
+
+// AdminDeleteDistrict removes a district. Its photos, feedback and places
+// are kept under the old name.
+func AdminDeleteDistrict(w http.ResponseWriter, r *http.Request) {
+	districtID, err := strconv.Atoi(mux.Vars(r)["districtid"])
+	if err != nil {
+		sendJSONError(w, "Invalid district ID", http.StatusBadRequest)
+		return
+	}
+
+	result, err := config.DB.Exec(`DELETE FROM districts WHERE district_id = $1`, districtID)
+	if err != nil {
+		sendJSONError(w, "Error deleting district", http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		sendJSONError(w, "District not found", http.StatusNotFound)
+		return
+	}
+	utils.InvalidateDistricts()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "District deleted successfully",
+	})
+}
+
+func isUniqueViolation(err error) bool {
+	pqErr, ok := err.(*pq.Error)
+	return ok && pqErr.Code == "23505"
+}
@@ -5,8 +5,12 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 	"trip-planner-backend/config"
 	"trip-planner-backend/models"
+	"trip-planner-backend/utils"
+
+	"github.com/lib/pq"
 )
 
 type FeedbackRequest struct {
@@ -62,6 +66,18 @@ func SubmitFeedback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Store the canonical district name so aliases ("Mysore") and the full
+	// name ("Mysuru (Mysore)") are grouped together
+	if req.FeedbackType == "trip_plan" {
+		district, ok := utils.FindDistrict(req.DistrictName)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Unknown district"})
+			return
+		}
+		req.DistrictName = district.Name
+	}
+
 	// Validate hotel rating if provided
 	if req.HotelRating != nil && (*req.HotelRating < 0 || *req.HotelRating > 5) {
 		w.WriteHeader(http.StatusBadRequest)
@@ -400,10 +416,10 @@ func GetFeedbacksByDistrict(w http.ResponseWriter, r *http.Request) {
 			   COALESCE(f.hotel_feedback, '') as hotel_feedback, f.hotel_rating, f.created_at
 		FROM feedbacks f
 		JOIN users u ON f.user_id = u.userid
-		WHERE f.feedback_type = 'trip_plan' AND LOWER(f.district_name) = LOWER($1)
+		WHERE f.feedback_type = 'trip_plan' AND LOWER(f.district_name) = ANY($1)
 		ORDER BY f.created_at DESC
 		LIMIT 10
-	`, district)
+	`, pq.Array(districtNameKeys(district)))
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to fetch feedbacks"})
@@ -530,3 +546,17 @@ func nullInt(i *int) sql.NullInt64 {
 	}
 	return sql.NullInt64{Int64: int64(*i), Valid: true}
 }
+
+// districtNameKeys lists the lowercased names feedback for a district may
+// have been stored under before names were normalised
+func districtNameKeys(district string) []string {
+	d, ok := utils.FindDistrict(district)
+	if !ok {
+		return []string{strings.ToLower(district)}
+	}
+	keys := []string{}
+	for _, name := range utils.DistrictNames(d) {
+		keys = append(keys, strings.ToLower(name))
+	}
+	return keys
+}
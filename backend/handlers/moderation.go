@@ -0,0 +1,432 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"trip-planner-backend/config"
+	"trip-planner-backend/models"
+	"trip-planner-backend/utils"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+// moderationActions maps each admin action to the status it sets and the
+// statuses it may be taken from. Approved packages can still be sent back
+// or taken down.
+var moderationActions = map[string]struct {
+	to   string
+	from []string
+}{
+	"approve":         {models.PackageApproved, []string{models.PackagePendingReview, models.PackageChangesRequested}},
+	"request_changes": {models.PackageChangesRequested, []string{models.PackagePendingReview, models.PackageApproved}},
+	"reject":          {models.PackageRejected, []string{models.PackagePendingReview, models.PackageChangesRequested, models.PackageApproved}},
+}
+
+var (
+	errModerationNotFound   = errors.New("package not found")
+	errModerationTransition = errors.New("moderation action not allowed")
+)
+
+const moderationItemColumns = `
+	p.package_id, p.agency_id, a.name, a.status, p.title, p.description, p.location, p.initial_destination,
+	p.locations, p.duration_days, p.num_travelers, p.transport_mode, p.price,
+	COALESCE(p.pricing_rules->>'mode', 'per_person'), p.is_active,
+	(SELECT COUNT(*) FROM package_photos ph WHERE ph.package_id = p.package_id),
+	p.moderation_status, COALESCE(p.moderation_notes, ''), p.moderation_flags, p.submitted_at, p.moderated_at`
+
+func scanModerationItem(row rowScanner) (models.ModerationItem, error) {
+	var item models.ModerationItem
+	var flags []byte
+	var submittedAt, moderatedAt sql.NullTime
+	err := row.Scan(&item.PackageID, &item.AgencyID, &item.AgencyName, &item.AgencyStatus, &item.Title, &item.Description,
+		&item.Location, &item.InitialDestination, pq.Array(&item.Locations), &item.DurationDays, &item.NumTravelers,
+		&item.TransportMode, &item.Price, &item.PricingMode, &item.IsActive, &item.PhotoCount, &item.ModerationStatus,
+		&item.ModerationNotes, &flags, &submittedAt, &moderatedAt)
+	if err != nil {
+		return item, err
+	}
+	item.Flags = []models.ModerationFlag{}
+	if flags == nil {
+		item.ChecksPending = true
+	} else if err := json.Unmarshal(flags, &item.Flags); err != nil {
+		return item, err
+	}
+	if item.Locations == nil {
+		item.Locations = []string{}
+	}
+	if submittedAt.Valid {
+		item.SubmittedAt = &submittedAt.Time
+	}
+	if moderatedAt.Valid {
+		item.ModeratedAt = &moderatedAt.Time
+	}
+	return item, nil
+}
+
+func fetchModerationItem(packageID int) (models.ModerationItem, error) {
+	item, err := scanModerationItem(config.DB.QueryRow(`SELECT `+moderationItemColumns+`
+		FROM travel_packages p JOIN travel_agencies a ON a.agency_id = p.agency_id
+		WHERE p.package_id = $1`, packageID))
+	if err == sql.ErrNoRows {
+		return item, errModerationNotFound
+	}
+	if err != nil {
+		return item, err
+	}
+
+	rows, err := config.DB.Query(`
+		SELECT review_id, from_status, to_status, COALESCE(notes, ''), reviewed_by, created_at
+		FROM package_reviews WHERE package_id = $1
+		ORDER BY created_at DESC, review_id DESC
+	`, packageID)
+	if err != nil {
+		return item, err
+	}
+	defer rows.Close()
+	item.Reviews = []models.PackageReview{}
+	for rows.Next() {
+		var rv models.PackageReview
+		if err := rows.Scan(&rv.ReviewID, &rv.FromStatus, &rv.ToStatus, &rv.Notes, &rv.ReviewedBy, &rv.CreatedAt); err != nil {
+			return item, err
+		}
+		item.Reviews = append(item.Reviews, rv)
+	}
+	return item, rows.Err()
+}
+
+// resubmitPackage sends an edited package back to the review queue. It
+// keeps its place if it was already waiting; rejected packages stay
+// rejected. Reports whether the package is now in review.
+func resubmitPackage(tx *sql.Tx, packageID int) (bool, error) {
+	var from string
+	err := tx.QueryRow(`
+		UPDATE travel_packages p
+		SET moderation_status = 'pending_review', moderation_flags = NULL,
+			submitted_at = CASE WHEN old.moderation_status = 'pending_review' THEN old.submitted_at ELSE NOW() END
+		FROM (SELECT package_id, moderation_status, submitted_at FROM travel_packages WHERE package_id = $1 FOR UPDATE) old
+		WHERE p.package_id = old.package_id AND old.moderation_status <> 'rejected'
+		RETURNING old.moderation_status
+	`, packageID).Scan(&from)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if from != models.PackagePendingReview {
+		_, err = tx.Exec(`
+			INSERT INTO package_reviews (package_id, from_status, to_status, reviewed_by)
+			VALUES ($1, $2, 'pending_review', 'agency')
+		`, packageID, from)
+	}
+	return true, err
+}
+
+func loadBannedWords() ([]string, error) {
+	rows, err := config.DB.Query(`SELECT word FROM moderation_banned_words ORDER BY word`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	words := []string{}
+	for rows.Next() {
+		var word string
+		if err := rows.Scan(&word); err != nil {
+			return nil, err
+		}
+		words = append(words, word)
+	}
+	return words, rows.Err()
+}
+
+// runPackageChecks runs the automated checks on a package and stores the
+// flags. The travel cost lookup can call out to the routing API, so
+// handlers run this after their response is decided.
+func runPackageChecks(packageID int) {
+	item, err := scanModerationItem(config.DB.QueryRow(`SELECT `+moderationItemColumns+`
+		FROM travel_packages p JOIN travel_agencies a ON a.agency_id = p.agency_id
+		WHERE p.package_id = $1`, packageID))
+	if err != nil {
+		log.Printf("Error loading package %d for moderation checks: %v", packageID, err)
+		return
+	}
+	banned, err := loadBannedWords()
+	if err != nil {
+		log.Printf("Error loading banned words: %v", err)
+		return
+	}
+
+	flags := utils.CheckPackage(utils.PackageCheckInput{
+		Title:              item.Title,
+		Description:        item.Description,
+		Location:           item.Location,
+		InitialDestination: item.InitialDestination,
+		Locations:          item.Locations,
+		DurationDays:       item.DurationDays,
+		NumTravelers:       item.NumTravelers,
+		TransportMode:      item.TransportMode,
+		Price:              item.Price,
+		PerGroup:           item.PricingMode == models.PricingPerGroup,
+		PhotoCount:         item.PhotoCount,
+	}, banned)
+	stored, _ := json.Marshal(flags)
+	if _, err := config.DB.Exec(`UPDATE travel_packages SET moderation_flags = $1 WHERE package_id = $2`,
+		string(stored), packageID); err != nil {
+		log.Printf("Error storing moderation flags for package %d: %v", packageID, err)
+	}
+}
+
+// AdminGetModerationQueue lists packages by moderation status, oldest
+// submission first. ?status= defaults to pending_review; ?flagged=true
+// keeps only packages the automated checks flagged.
+func AdminGetModerationQueue(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = models.PackagePendingReview
+	}
+	switch status {
+	case models.PackagePendingReview, models.PackageApproved, models.PackageChangesRequested, models.PackageRejected:
+	default:
+		sendJSONError(w, "status must be pending_review, approved, changes_requested or rejected", http.StatusBadRequest)
+		return
+	}
+	where := "p.moderation_status = $1"
+	if r.URL.Query().Get("flagged") == "true" {
+		where += " AND jsonb_array_length(COALESCE(p.moderation_flags, '[]'::jsonb)) > 0"
+	}
+
+	rows, err := config.DB.Query(`SELECT `+moderationItemColumns+`
+		FROM travel_packages p JOIN travel_agencies a ON a.agency_id = p.agency_id
+		WHERE `+where+`
+		ORDER BY p.submitted_at ASC NULLS LAST, p.package_id
+		LIMIT 200`, status)
+	if err != nil {
+		log.Printf("Error loading moderation queue: %v", err)
+		sendJSONError(w, "Failed to load moderation queue", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+	items := []models.ModerationItem{}
+	for rows.Next() {
+		item, err := scanModerationItem(rows)
+		if err != nil {
+			log.Printf("Error reading moderation item: %v", err)
+			sendJSONError(w, "Failed to load moderation queue", http.StatusInternalServerError)
+			return
+		}
+		items = append(items, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+// AdminGetModerationPackage returns one package with its flags and review history
+func AdminGetModerationPackage(w http.ResponseWriter, r *http.Request) {
+	packageID, err := strconv.Atoi(mux.Vars(r)["packageid"])
+	if err != nil {
+		sendJSONError(w, "Invalid package ID", http.StatusBadRequest)
+		return
+	}
+	item, err := fetchModerationItem(packageID)
+	if errors.Is(err, errModerationNotFound) {
+		sendJSONError(w, "Package not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading package %d for moderation: %v", packageID, err)
+		sendJSONError(w, "Failed to load package", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// AdminRecheckPackage runs the automated checks again, e.g. after the banned
+// word list changed
+func AdminRecheckPackage(w http.ResponseWriter, r *http.Request) {
+	packageID, err := strconv.Atoi(mux.Vars(r)["packageid"])
+	if err != nil {
+		sendJSONError(w, "Invalid package ID", http.StatusBadRequest)
+		return
+	}
+	runPackageChecks(packageID)
+	AdminGetModerationPackage(w, r)
+}
+
+// moderatePackage applies an admin decision and records it with the flags
+// the package had
+func moderatePackage(packageID int, action, notes string) (title, agencyName, agencyEmail string, err error) {
+	rule := moderationActions[action]
+	tx, err := config.DB.Begin()
+	if err != nil {
+		return "", "", "", err
+	}
+	defer tx.Rollback()
+
+	var current string
+	var flags []byte
+	err = tx.QueryRow(`
+		SELECT p.moderation_status, p.moderation_flags, p.title, a.name, a.email
+		FROM travel_packages p JOIN travel_agencies a ON a.agency_id = p.agency_id
+		WHERE p.package_id = $1
+		FOR UPDATE OF p
+	`, packageID).Scan(&current, &flags, &title, &agencyName, &agencyEmail)
+	if err == sql.ErrNoRows {
+		return "", "", "", errModerationNotFound
+	}
+	if err != nil {
+		return "", "", "", err
+	}
+	allowed := false
+	for _, from := range rule.from {
+		allowed = allowed || from == current
+	}
+	if !allowed {
+		return "", "", "", fmt.Errorf("%w: can't %s a package that is %s", errModerationTransition,
+			strings.ReplaceAll(action, "_", " "), strings.ReplaceAll(current, "_", " "))
+	}
+
+	var storedFlags sql.NullString
+	if flags != nil {
+		storedFlags = sql.NullString{String: string(flags), Valid: true}
+	}
+	_, err = tx.Exec(`
+		UPDATE travel_packages SET moderation_status = $1, moderation_notes = NULLIF($2, ''), moderated_at = NOW()
+		WHERE package_id = $3
+	`, rule.to, notes, packageID)
+	if err != nil {
+		return "", "", "", err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO package_reviews (package_id, from_status, to_status, notes, flags, reviewed_by)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, 'admin')
+	`, packageID, current, rule.to, notes, storedFlags)
+	if err != nil {
+		return "", "", "", err
+	}
+	return title, agencyName, agencyEmail, tx.Commit()
+}
+
+// AdminModeratePackage approves a package, rejects it or asks the agency for
+// changes, and emails the agency. Rejections and change requests need
+// notes.
+func AdminModeratePackage(w http.ResponseWriter, r *http.Request) {
+	packageID, err := strconv.Atoi(mux.Vars(r)["packageid"])
+	if err != nil {
+		sendJSONError(w, "Invalid package ID", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		Action string `json:"action"`
+		Notes  string `json:"notes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		sendJSONError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Notes = strings.TrimSpace(req.Notes)
+	rule, ok := moderationActions[req.Action]
+	if !ok {
+		sendJSONError(w, "action must be approve, request_changes or reject", http.StatusBadRequest)
+		return
+	}
+	if req.Action != "approve" && req.Notes == "" {
+		sendJSONError(w, "Notes are required to reject a package or request changes", http.StatusBadRequest)
+		return
+	}
+	if len(req.Notes) > 2000 {
+		sendJSONError(w, "Notes can be at most 2000 characters", http.StatusBadRequest)
+		return
+	}
+
+	title, agencyName, agencyEmail, err := moderatePackage(packageID, req.Action, req.Notes)
+	switch {
+	case errors.Is(err, errModerationNotFound):
+		sendJSONError(w, "Package not found", http.StatusNotFound)
+		return
+	case errors.Is(err, errModerationTransition):
+		sendJSONError(w, strings.TrimPrefix(err.Error(), errModerationTransition.Error()+": "), http.StatusConflict)
+		return
+	case err != nil:
+		log.Printf("Error moderating package %d: %v", packageID, err)
+		sendJSONError(w, "Failed to update package", http.StatusInternalServerError)
+		return
+	}
+
+	go func() {
+		if err := utils.SendPackageModerationEmail(agencyEmail, agencyName, title, rule.to, req.Notes); err != nil {
+			log.Printf("Error emailing agency about package %d: %v", packageID, err)
+		}
+	}()
+	AdminGetModerationPackage(w, r)
+}
+
+// AdminGetBannedWords lists the words the automated checks flag
+func AdminGetBannedWords(w http.ResponseWriter, r *http.Request) {
+	words, err := loadBannedWords()
+	if err != nil {
+		sendJSONError(w, "Failed to load banned words", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(words)
+}
+
+// AdminAddBannedWords adds {"words": [...]} to the list. Packages already in
+// the queue are checked again when they are next edited or rechecked.
+func AdminAddBannedWords(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Words []string `json:"words"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Words) == 0 {
+		sendJSONError(w, "words is required", http.StatusBadRequest)
+		return
+	}
+	cleaned := []string{}
+	for _, word := range req.Words {
+		word = strings.ToLower(strings.Join(strings.Fields(word), " "))
+		if word == "" {
+			continue
+		}
+		if len(word) > 100 {
+			sendJSONError(w, "Banned words can be at most 100 characters", http.StatusBadRequest)
+			return
+		}
+		cleaned = append(cleaned, word)
+	}
+	if len(cleaned) == 0 {
+		sendJSONError(w, "words is required", http.StatusBadRequest)
+		return
+	}
+	if _, err := config.DB.Exec(`
+		INSERT INTO moderation_banned_words (word) SELECT unnest($1::text[]) ON CONFLICT (word) DO NOTHING
+	`, pq.Array(cleaned)); err != nil {
+		log.Printf("Error adding banned words: %v", err)
+		sendJSONError(w, "Failed to add banned words", http.StatusInternalServerError)
+		return
+	}
+	AdminGetBannedWords(w, r)
+}
+
+// AdminDeleteBannedWord removes a word from the list
+func AdminDeleteBannedWord(w http.ResponseWriter, r *http.Request) {
+	word := strings.ToLower(strings.TrimSpace(mux.Vars(r)["word"]))
+	result, err := config.DB.Exec(`DELETE FROM moderation_banned_words WHERE word = $1`, word)
+	if err != nil {
+		sendJSONError(w, "Failed to delete banned word", http.StatusInternalServerError)
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		sendJSONError(w, "Banned word not found", http.StatusNotFound)
+		return
+	}
+	AdminGetBannedWords(w, r)
+}
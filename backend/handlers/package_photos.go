@@ -0,0 +1,428 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"trip-planner-backend/config"
+	"trip-planner-backend/models"
+	"trip-planner-backend/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// agencyPackageFromRequest reads the agency and package IDs and checks that
+// the package belongs to the agency. On failure it writes the error response.
+func agencyPackageFromRequest(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	agencyID, ok := r.Context().Value("agencyid").(int)
+	if !ok || agencyID == 0 {
+		sendJSONError(w, "Unauthorized", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+	packageID, err := strconv.Atoi(mux.Vars(r)["packageid"])
+	if err != nil {
+		sendJSONError(w, "Invalid package ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+
+	var exists bool
+	err = config.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM travel_packages WHERE package_id = $1 AND agency_id = $2)`, packageID, agencyID).Scan(&exists)
+	if err != nil {
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return 0, 0, false
+	}
+	if !exists {
+		sendJSONError(w, "Package not found or not owned by agency", http.StatusNotFound)
+		return 0, 0, false
+	}
+	return agencyID, packageID, true
+}
+
+func fetchPackagePhotos(ctx context.Context, packageID int) ([]models.PackagePhoto, error) {
+	rows, err := config.DB.Query(`
+		SELECT photo_id, package_id, filename, sort_order, is_cover, created_at
+		FROM package_photos
+		WHERE package_id = $1
+		ORDER BY is_cover DESC, sort_order, photo_id
+	`, packageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	photos := []models.PackagePhoto{}
+	for rows.Next() {
+		var p models.PackagePhoto
+		if err := rows.Scan(&p.PhotoID, &p.PackageID, &p.Filename, &p.SortOrder, &p.IsCover, &p.CreatedAt); err != nil {
+			continue
+		}
+		p.URL = storageURL(ctx, storage.Key(packagePhotoPrefix, p.Filename))
+		p.Variants = photoVariantURLs(ctx, packagePhotoPrefix, p.Filename)
+		photos = append(photos, p)
+	}
+	return photos, rows.Err()
+}
+
+// countPackagePhotos counts a package's photos. Checks against the photo
+// limit must count again inside the transaction that adds photos, after
+// locking the package row, or two uploads can both pass the check.
+func countPackagePhotos(q interface {
+	QueryRow(string, ...interface{}) *sql.Row
+}, packageID int) (int, error) {
+	var count int
+	err := q.QueryRow(`SELECT COUNT(*) FROM package_photos WHERE package_id = $1`, packageID).Scan(&count)
+	return count, err
+}
+
+// addPackagePhotos appends stored photos to a package's gallery. The first
+// photo of a package without a cover becomes the cover.
+func addPackagePhotos(tx *sql.Tx, packageID int, filenames []string) error {
+	if len(filenames) == 0 {
+		return nil
+	}
+
+	var nextOrder int
+	var hasCover bool
+	if err := tx.QueryRow(`
+		SELECT COALESCE(MAX(sort_order) + 1, 0), COALESCE(BOOL_OR(is_cover), FALSE)
+		FROM package_photos WHERE package_id = $1
+	`, packageID).Scan(&nextOrder, &hasCover); err != nil {
+		return err
+	}
+
+	for i, filename := range filenames {
+		if _, err := tx.Exec(`
+			INSERT INTO package_photos (package_id, filename, sort_order, is_cover)
+			VALUES ($1, $2, $3, $4)
+		`, packageID, filename, nextOrder+i, !hasCover && i == 0); err != nil {
+			return err
+		}
+	}
+	return syncPackagePhotoArray(tx, packageID)
+}
+
+// syncPackagePhotoArray rewrites travel_packages.photos from package_photos,
+// cover first, so list endpoints and older clients see the current gallery
+func syncPackagePhotoArray(tx *sql.Tx, packageID int) error {
+	_, err := tx.Exec(`
+		UPDATE travel_packages
+		SET photos = ARRAY(
+			SELECT filename FROM package_photos
+			WHERE package_id = $1
+			ORDER BY is_cover DESC, sort_order, photo_id
+		), updated_at = NOW()
+		WHERE package_id = $1
+	`, packageID)
+	return err
+}
+
+// removeAllPackagePhotos deletes every photo row of a package and returns
+// the filenames so the caller can remove them from storage after commit
+func removeAllPackagePhotos(tx *sql.Tx, packageID int) ([]string, error) {
+	rows, err := tx.Query(`DELETE FROM package_photos WHERE package_id = $1 RETURNING filename`, packageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	filenames := []string{}
+	for rows.Next() {
+		var filename string
+		if err := rows.Scan(&filename); err == nil {
+			filenames = append(filenames, filename)
+		}
+	}
+	return filenames, rows.Err()
+}
+
+// removeStoredPackagePhotos deletes photo files that are no longer referenced
+func removeStoredPackagePhotos(ctx context.Context, filenames []string) {
+	for _, filename := range filenames {
+		if err := removePhotoFiles(ctx, packagePhotoPrefix, filename); err != nil {
+			log.Printf("Error removing package photo %s: %v", filename, err)
+		}
+	}
+}
+
+func writePackagePhotos(w http.ResponseWriter, r *http.Request, packageID int, status int, extra map[string]interface{}) {
+	photos, err := fetchPackagePhotos(r.Context(), packageID)
+	if err != nil {
+		sendJSONError(w, "Error fetching package photos", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]interface{}{"package_id": packageID, "photos": photos}
+	for k, v := range extra {
+		response[k] = v
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetPackagePhotos lists a package's photos with their IDs, cover first
+func GetPackagePhotos(w http.ResponseWriter, r *http.Request) {
+	_, packageID, ok := agencyPackageFromRequest(w, r)
+	if !ok {
+		return
+	}
+	writePackagePhotos(w, r, packageID, http.StatusOK, nil)
+}
+
+// AddPackagePhotos uploads photos and appends them to the gallery
+func AddPackagePhotos(w http.ResponseWriter, r *http.Request) {
+	agencyID, packageID, ok := agencyPackageFromRequest(w, r)
+	if !ok {
+		return
+	}
+	if !parseUploadForm(w, r) {
+		return
+	}
+
+	files := r.MultipartForm.File["photos"]
+	if len(files) == 0 {
+		sendJSONError(w, "At least one photo is required", http.StatusBadRequest)
+		return
+	}
+	// Checked here to refuse early, and again under the lock below
+	existing, err := countPackagePhotos(config.DB, packageID)
+	if err != nil {
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if existing+len(files) > uploadLimits().MaxPhotosPerPackage {
+		sendJSONError(w, fmt.Sprintf("A package can have at most %d photos (it has %d)", uploadLimits().MaxPhotosPerPackage, existing), http.StatusBadRequest)
+		return
+	}
+
+	photos, photoErrors := savePackagePhotos(r.Context(), agencyID, files)
+	if len(photos) == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":        "No photos could be saved",
+			"photo_errors": photoErrors,
+		})
+		return
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		removeStoredPackagePhotos(r.Context(), photos)
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`SELECT package_id FROM travel_packages WHERE package_id = $1 FOR UPDATE`, packageID).Scan(&packageID)
+	if err == nil {
+		existing, err = countPackagePhotos(tx, packageID)
+	}
+	if err != nil {
+		removeStoredPackagePhotos(r.Context(), photos)
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if existing+len(photos) > uploadLimits().MaxPhotosPerPackage {
+		removeStoredPackagePhotos(r.Context(), photos)
+		sendJSONError(w, fmt.Sprintf("A package can have at most %d photos (it has %d)", uploadLimits().MaxPhotosPerPackage, existing), http.StatusBadRequest)
+		return
+	}
+
+	if err := addPackagePhotos(tx, packageID, photos); err != nil {
+		removeStoredPackagePhotos(r.Context(), photos)
+		sendJSONError(w, "Error saving package photos", http.StatusInternalServerError)
+		return
+	}
+	// New photos need a moderator to look at them
+	inReview, err := resubmitPackage(tx, packageID)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		removeStoredPackagePhotos(r.Context(), photos)
+		sendJSONError(w, "Error saving package photos", http.StatusInternalServerError)
+		return
+	}
+	if inReview {
+		go runPackageChecks(packageID)
+	}
+
+	writePackagePhotos(w, r, packageID, http.StatusCreated, map[string]interface{}{"photo_errors": photoErrors, "in_review": inReview})
+}
+
+// DeletePackagePhoto removes a photo from the gallery and from storage. If it
+// was the cover, the next photo in order takes its place.
+func DeletePackagePhoto(w http.ResponseWriter, r *http.Request) {
+	_, packageID, ok := agencyPackageFromRequest(w, r)
+	if !ok {
+		return
+	}
+	photoID, err := strconv.Atoi(mux.Vars(r)["photoid"])
+	if err != nil {
+		sendJSONError(w, "Invalid photo ID", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var filename string
+	var wasCover bool
+	err = tx.QueryRow(`
+		DELETE FROM package_photos WHERE photo_id = $1 AND package_id = $2
+		RETURNING filename, is_cover
+	`, photoID, packageID).Scan(&filename, &wasCover)
+	if err == sql.ErrNoRows {
+		sendJSONError(w, "Photo not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendJSONError(w, "Error deleting photo", http.StatusInternalServerError)
+		return
+	}
+
+	if wasCover {
+		if _, err := tx.Exec(`
+			UPDATE package_photos SET is_cover = TRUE
+			WHERE photo_id = (SELECT photo_id FROM package_photos WHERE package_id = $1 ORDER BY sort_order, photo_id LIMIT 1)
+		`, packageID); err != nil {
+			sendJSONError(w, "Error deleting photo", http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := syncPackagePhotoArray(tx, packageID); err != nil {
+		sendJSONError(w, "Error deleting photo", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		sendJSONError(w, "Error deleting photo", http.StatusInternalServerError)
+		return
+	}
+
+	removeStoredPackagePhotos(r.Context(), []string{filename})
+	// Removing photos doesn't need another review, but the flags may change
+	go runPackageChecks(packageID)
+	writePackagePhotos(w, r, packageID, http.StatusOK, nil)
+}
+
+// ReorderPackagePhotos sets the gallery order from a list of photo IDs. The
+// list must contain every photo of the package exactly once.
+func ReorderPackagePhotos(w http.ResponseWriter, r *http.Request) {
+	_, packageID, ok := agencyPackageFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		PhotoIDs []int `json:"photo_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.PhotoIDs) == 0 {
+		sendJSONError(w, "photo_ids is required", http.StatusBadRequest)
+		return
+	}
+
+	existing, err := countPackagePhotos(config.DB, packageID)
+	if err != nil {
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	seen := make(map[int]bool)
+	for _, id := range req.PhotoIDs {
+		if seen[id] {
+			sendJSONError(w, fmt.Sprintf("Photo %d is listed more than once", id), http.StatusBadRequest)
+			return
+		}
+		seen[id] = true
+	}
+	if len(req.PhotoIDs) != existing {
+		sendJSONError(w, fmt.Sprintf("photo_ids must list all %d photos of the package", existing), http.StatusBadRequest)
+		return
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	for i, photoID := range req.PhotoIDs {
+		result, err := tx.Exec(`UPDATE package_photos SET sort_order = $1 WHERE photo_id = $2 AND package_id = $3`, i, photoID, packageID)
+		if err != nil {
+			sendJSONError(w, "Error reordering photos", http.StatusInternalServerError)
+			return
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			sendJSONError(w, fmt.Sprintf("Photo %d does not belong to this package", photoID), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := syncPackagePhotoArray(tx, packageID); err != nil {
+		sendJSONError(w, "Error reordering photos", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		sendJSONError(w, "Error reordering photos", http.StatusInternalServerError)
+		return
+	}
+
+	writePackagePhotos(w, r, packageID, http.StatusOK, nil)
+}
+
+// SetPackageCoverPhoto makes a photo the package's cover
+func SetPackageCoverPhoto(w http.ResponseWriter, r *http.Request) {
+	_, packageID, ok := agencyPackageFromRequest(w, r)
+	if !ok {
+		return
+	}
+	photoID, err := strconv.Atoi(mux.Vars(r)["photoid"])
+	if err != nil {
+		sendJSONError(w, "Invalid photo ID", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM package_photos WHERE photo_id = $1 AND package_id = $2)`, photoID, packageID).Scan(&exists); err != nil {
+		sendJSONError(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if !exists {
+		sendJSONError(w, "Photo not found", http.StatusNotFound)
+		return
+	}
+
+	if _, err := tx.Exec(`UPDATE package_photos SET is_cover = FALSE WHERE package_id = $1 AND is_cover`, packageID); err != nil {
+		sendJSONError(w, "Error setting cover photo", http.StatusInternalServerError)
+		return
+	}
+	if _, err := tx.Exec(`UPDATE package_photos SET is_cover = TRUE WHERE photo_id = $1`, photoID); err != nil {
+		sendJSONError(w, "Error setting cover photo", http.StatusInternalServerError)
+		return
+	}
+	if err := syncPackagePhotoArray(tx, packageID); err != nil {
+		sendJSONError(w, "Error setting cover photo", http.StatusInternalServerError)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		sendJSONError(w, "Error setting cover photo", http.StatusInternalServerError)
+		return
+	}
+
+	writePackagePhotos(w, r, packageID, http.StatusOK, nil)
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"encoding/json"
+	"html"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"trip-planner-backend/config"
+	"trip-planner-backend/models"
+)
+
+// Suggestions are offered when a search finds fewer results than this
+const suggestionThreshold = 3
+
+const (
+	titleHeadlineOptions   = `StartSel=<mark>, StopSel=</mark>, HighlightAll=TRUE`
+	snippetHeadlineOptions = `StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=" … "`
+)
+
+// SearchTravelPackages ranks active packages against a web-style query
+// ("jog falls", "coorg -trek", "\"coffee estate\"") over title, destination,
+// stops and description. It accepts the same filters as the listing.
+func SearchTravelPackages(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+	if len(q) > 200 {
+		http.Error(w, "Search query is too long", http.StatusBadRequest)
+		return
+	}
+
+	args := []interface{}{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+	conds, msg := packageFilterConditions(query, arg)
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	tsQuery := "websearch_to_tsquery('english', " + arg(q) + ")"
+	conds = append(conds, "p.search_vector @@ "+tsQuery)
+
+	limit := 20
+	if v := query.Get("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 || parsed > 50 {
+			http.Error(w, "limit must be between 1 and 50", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+	offset := 0
+	if v := query.Get("offset"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 {
+			http.Error(w, "offset must be zero or more", http.StatusBadRequest)
+			return
+		}
+		offset = parsed
+	}
+
+	where := strings.Join(conds, " AND ")
+	var total int
+	if err := config.DB.QueryRow(`SELECT COUNT(*) FROM travel_packages p INNER JOIN travel_agencies a ON p.agency_id = a.agency_id WHERE `+
+		where, args...).Scan(&total); err != nil {
+		log.Printf("Error counting package search results: %v", err)
+		http.Error(w, "Failed to search travel packages", http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := config.DB.Query(`SELECT `+publicPackageColumns+`,
+			ts_rank(p.search_vector, `+tsQuery+`) AS rank,
+			ts_headline('english', p.title, `+tsQuery+`, '`+titleHeadlineOptions+`'),
+			ts_headline('english', concat_ws(' Stops: ', p.description, NULLIF(array_to_string(p.locations, ', '), '')), `+tsQuery+`, '`+snippetHeadlineOptions+`')
+		`+publicPackageFrom+`
+		WHERE `+where+`
+		ORDER BY rank DESC, p.package_id DESC
+		LIMIT `+arg(limit)+` OFFSET `+arg(offset), args...)
+	if err != nil {
+		log.Printf("Error searching packages: %v", err)
+		http.Error(w, "Failed to search travel packages", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	results := []models.PackageSearchResult{}
+	for rows.Next() {
+		var result models.PackageSearchResult
+		pkg, err := scanPublicPackage(rows, &result.Rank, &result.TitleHighlight, &result.Snippet)
+		if err != nil {
+			continue
+		}
+		setPackagePhotoVariants(r.Context(), &pkg)
+		result.TravelPackage = pkg
+		result.TitleHighlight = safeHeadline(result.TitleHighlight)
+		result.Snippet = safeHeadline(result.Snippet)
+		results = append(results, result)
+	}
+
+	suggestions := []string{}
+	if total < suggestionThreshold {
+		suggestions = packageSearchSuggestions(q)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"query":       q,
+		"results":     results,
+		"total":       total,
+		"limit":       limit,
+		"offset":      offset,
+		"suggestions": suggestions,
+	})
+}
+
+// safeHeadline HTML-escapes a ts_headline result while keeping its <mark>
+// tags, since package text is written by agencies
+func safeHeadline(text string) string {
+	text = strings.NewReplacer("<mark>", "\x00", "</mark>", "\x01").Replace(text)
+	text = html.EscapeString(text)
+	return strings.NewReplacer("\x00", "<mark>", "\x01", "</mark>").Replace(text)
+}
+
+// packageSearchSuggestions returns package titles, destinations and stops
+// that look like a misspelling of the query ("Corg cofee" → "Coorg Coffee
+// Trail"), using pg_trgm similarity. The % and %> filters let the trigram
+// indexes pick the candidates; their thresholds are set to the score cut
+// off for this transaction only.
+func packageSearchSuggestions(q string) []string {
+	tx, err := config.DB.Begin()
+	if err != nil {
+		log.Printf("Error loading search suggestions: %v", err)
+		return []string{}
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SET LOCAL pg_trgm.similarity_threshold = 0.4; SET LOCAL pg_trgm.word_similarity_threshold = 0.4`); err != nil {
+		log.Printf("Error loading search suggestions: %v", err)
+		return []string{}
+	}
+	// A stop as similar as the cut off always matches the package's joined
+	// stops at least as well, so the stops filter only narrows the scan
+	rows, err := tx.Query(`
+		SELECT term FROM (
+			SELECT term, GREATEST(similarity(LOWER(term), LOWER($1)), word_similarity(LOWER($1), LOWER(term))) AS score
+			FROM (
+				SELECT title AS term FROM travel_packages p
+				WHERE (LOWER(title) % LOWER($1) OR LOWER(title) %> LOWER($1)) AND `+publicPackageCondition+`
+				UNION SELECT location FROM travel_packages p
+				WHERE (LOWER(location) % LOWER($1) OR LOWER(location) %> LOWER($1)) AND `+publicPackageCondition+`
+				UNION SELECT unnest(locations) FROM travel_packages p
+				WHERE travel_packages_stops_text(locations) %> LOWER($1) AND `+publicPackageCondition+`
+			) terms
+			WHERE term <> '' AND LOWER(term) <> LOWER($1)
+		) scored
+		WHERE score >= 0.4
+		ORDER BY score DESC, term
+		LIMIT 5
+	`, q)
+	if err != nil {
+		log.Printf("Error loading search suggestions: %v", err)
+		return []string{}
+	}
+	defer rows.Close()
+
+	suggestions := []string{}
+	for rows.Next() {
+		var term string
+		if err := rows.Scan(&term); err == nil {
+			suggestions = append(suggestions, term)
+		}
+	}
+	return suggestions
+}
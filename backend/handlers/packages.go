@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"trip-planner-backend/config"
+	"trip-planner-backend/models"
+	"trip-planner-backend/utils"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+// publicPackageFrom joins each package with its agency and the agency's
+// average review rating. Ratings are rounded in SQL so cursors compare
+// exactly.
+const publicPackageFrom = `
+	FROM travel_packages p
+	INNER JOIN travel_agencies a ON p.agency_id = a.agency_id
+	LEFT JOIN (
+		SELECT agency_id, ROUND(AVG(rating), 2) AS avg_rating, COUNT(*) AS review_count
+		FROM feedbacks
+		WHERE feedback_type = 'travel_agency' AND agency_id IS NOT NULL
+		GROUP BY agency_id
+	) r ON r.agency_id = p.agency_id`
+
+// publicPackageCondition holds for packages travelers may see: active,
+// approved by moderation and listed by an approved agency. p is
+// travel_packages.
+const publicPackageCondition = `p.is_active = TRUE AND p.moderation_status = 'approved' AND EXISTS (
+	SELECT 1 FROM travel_agencies pa WHERE pa.agency_id = p.agency_id AND pa.status = 'approved')`
+
+const publicPackageColumns = `
+	p.package_id, p.title, p.description, p.location, p.initial_destination, p.duration_days,
+	p.num_travelers, p.transport_mode, p.price, p.is_active, p.locations, p.photos, p.created_at,
+	p.updated_at, a.agency_id, a.name, COALESCE(a.email, ''), COALESCE(a.phone, ''),
+	r.avg_rating, COALESCE(r.review_count, 0), p.cancellation_policy, p.pricing_rules`
+
+// packageSort is a sort order with the package ID as tie-breaker. cast
+// turns a cursor's text key back into the column's type.
+type packageSort struct {
+	key  string
+	cast string
+	desc bool
+}
+
+var packageSorts = map[string]packageSort{
+	"newest":        {"p.created_at", "timestamp", true},
+	"price_asc":     {"p.price", "numeric", false},
+	"price_desc":    {"p.price", "numeric", true},
+	"duration_asc":  {"p.duration_days", "numeric", false},
+	"duration_desc": {"p.duration_days", "numeric", true},
+	"rating":        {"COALESCE(r.avg_rating, 0)", "numeric", true},
+}
+
+// packageCursor marks the last package of a page. It is tied to the sort
+// it was issued for.
+type packageCursor struct {
+	Sort string `json:"s"`
+	Key  string `json:"k"`
+	ID   int    `json:"id"`
+}
+
+func encodePackageCursor(c packageCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePackageCursor(value string) (packageCursor, bool) {
+	var c packageCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || json.Unmarshal(data, &c) != nil || c.ID <= 0 {
+		return packageCursor{}, false
+	}
+	return c, true
+}
+
+func scanPublicPackage(row rowScanner, extra ...interface{}) (models.TravelPackage, error) {
+	var pkg models.TravelPackage
+	var rating sql.NullFloat64
+	var policy, pricing []byte
+	dest := []interface{}{
+		&pkg.PackageID, &pkg.Title, &pkg.Description, &pkg.Location, &pkg.InitialDestination, &pkg.DurationDays,
+		&pkg.NumTravelers, &pkg.TransportMode, &pkg.Price, &pkg.IsActive, pq.Array(&pkg.Locations), pq.Array(&pkg.Photos),
+		&pkg.CreatedAt, &pkg.UpdatedAt, &pkg.AgencyID, &pkg.AgencyName, &pkg.AgencyEmail, &pkg.AgencyPhone,
+		&rating, &pkg.AgencyReviewCount, &policy, &pricing,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return pkg, err
+	}
+	if rating.Valid {
+		pkg.AgencyRating = &rating.Float64
+	}
+	pkg.CancellationPolicy = decodeCancellationPolicy(policy)
+	pkg.PricingRules = decodePricingRules(pricing)
+	return pkg, nil
+}
+
+// GetPublicTravelPackages lists active packages with filters, a sort order
+// and cursor pagination. total counts every match, not just the page.
+func GetPublicTravelPackages(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	args := []interface{}{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	conds, msg := packageFilterConditions(query, arg)
+	if msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+	if q := strings.TrimSpace(query.Get("q")); q != "" {
+		conds = append(conds, "(p.search_vector @@ websearch_to_tsquery('english', "+arg(q)+") OR a.name ILIKE "+arg("%"+q+"%")+")")
+	}
+
+	sortName := query.Get("sort")
+	if sortName == "" {
+		sortName = "newest"
+	}
+	sortBy, ok := packageSorts[sortName]
+	if !ok {
+		http.Error(w, "sort must be one of newest, price_asc, price_desc, duration_asc, duration_desc, rating", http.StatusBadRequest)
+		return
+	}
+	direction, compare := "ASC", ">"
+	if sortBy.desc {
+		direction, compare = "DESC", "<"
+	}
+
+	var cursor packageCursor
+	hasCursor := query.Get("cursor") != ""
+	if hasCursor {
+		if cursor, ok = decodePackageCursor(query.Get("cursor")); !ok || cursor.Sort != sortName {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+	}
+
+	limit := 20
+	if v := query.Get("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 || parsed > 100 {
+			http.Error(w, "limit must be between 1 and 100", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	var total int
+	if err := config.DB.QueryRow(`SELECT COUNT(*) FROM travel_packages p INNER JOIN travel_agencies a ON p.agency_id = a.agency_id WHERE `+
+		strings.Join(conds, " AND "), args...).Scan(&total); err != nil {
+		http.Error(w, "Failed to load travel packages", http.StatusInternalServerError)
+		return
+	}
+
+	if hasCursor {
+		conds = append(conds, "("+sortBy.key+", p.package_id) "+compare+" ("+arg(cursor.Key)+"::"+sortBy.cast+", "+arg(cursor.ID)+")")
+	}
+
+	rows, err := config.DB.Query(`SELECT `+publicPackageColumns+`, (`+sortBy.key+`)::text`+publicPackageFrom+`
+		WHERE `+strings.Join(conds, " AND ")+`
+		ORDER BY `+sortBy.key+` `+direction+`, p.package_id `+direction+`
+		LIMIT `+arg(limit+1), args...)
+	if err != nil {
+		http.Error(w, "Failed to load travel packages", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	packages := []models.TravelPackage{}
+	keys := []string{}
+	for rows.Next() {
+		var key string
+		pkg, err := scanPublicPackage(rows, &key)
+		if err != nil {
+			continue
+		}
+		packages = append(packages, pkg)
+		keys = append(keys, key)
+	}
+
+	var nextCursor *string
+	if len(packages) > limit {
+		packages = packages[:limit]
+		next := encodePackageCursor(packageCursor{Sort: sortName, Key: keys[limit-1], ID: packages[limit-1].PackageID})
+		nextCursor = &next
+	}
+	for i := range packages {
+		setPackagePhotoVariants(r.Context(), &packages[i])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"packages":    packages,
+		"total":       total,
+		"limit":       limit,
+		"sort":        sortName,
+		"next_cursor": nextCursor,
+	})
+}
+
+// GetPublicTravelPackage returns one active package
+func GetPublicTravelPackage(w http.ResponseWriter, r *http.Request) {
+	packageID, err := strconv.Atoi(mux.Vars(r)["packageid"])
+	if err != nil {
+		http.Error(w, "Invalid package ID", http.StatusBadRequest)
+		return
+	}
+
+	pkg, err := scanPublicPackage(config.DB.QueryRow(`SELECT `+publicPackageColumns+publicPackageFrom+`
+		WHERE p.package_id = $1 AND `+publicPackageCondition, packageID))
+	if err == sql.ErrNoRows {
+		http.Error(w, "Package not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to load travel package", http.StatusInternalServerError)
+		return
+	}
+	setPackagePhotoVariants(r.Context(), &pkg)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pkg)
+}
+
+// packageFilterConditions turns the listing filters in the query string
+// into SQL conditions on p (travel_packages). It returns a message for the
+// first invalid parameter.
+func packageFilterConditions(query url.Values, arg func(interface{}) string) ([]string, string) {
+	conds := []string{publicPackageCondition}
+	if name := strings.TrimSpace(query.Get("district")); name != "" {
+		if _, ok := utils.FindDistrict(name); !ok {
+			return nil, "Unknown district"
+		}
+		keys := arg(pq.Array(districtNameKeys(name)))
+		conds = append(conds, "(LOWER(p.location) = ANY("+keys+") OR EXISTS (SELECT 1 FROM unnest(p.locations) AS l(name) WHERE LOWER(l.name) = ANY("+keys+")))")
+	}
+	if location := strings.TrimSpace(query.Get("location")); location != "" {
+		pattern := arg("%" + location + "%")
+		conds = append(conds, "(p.location ILIKE "+pattern+" OR array_to_string(p.locations, ' ') ILIKE "+pattern+")")
+	}
+	if from := strings.TrimSpace(query.Get("initial_destination")); from != "" {
+		if _, ok := utils.FindDistrict(from); ok {
+			conds = append(conds, "LOWER(p.initial_destination) = ANY("+arg(pq.Array(districtNameKeys(from)))+")")
+		} else {
+			conds = append(conds, "p.initial_destination ILIKE "+arg("%"+from+"%"))
+		}
+	}
+	if modes := splitList(query.Get("transport_mode")); len(modes) > 0 {
+		conds = append(conds, "LOWER(p.transport_mode) = ANY("+arg(pq.Array(modes))+")")
+	}
+
+	minPrice, maxPrice, ok := parseRange(query.Get("min_price"), query.Get("max_price"))
+	if !ok {
+		return nil, "min_price and max_price must be non-negative numbers with min_price <= max_price"
+	}
+	if minPrice != nil {
+		conds = append(conds, "p.price >= "+arg(*minPrice))
+	}
+	if maxPrice != nil {
+		conds = append(conds, "p.price <= "+arg(*maxPrice))
+	}
+
+	minDays, maxDays, ok := parseRange(query.Get("min_days"), query.Get("max_days"))
+	if !ok {
+		return nil, "min_days and max_days must be non-negative numbers with min_days <= max_days"
+	}
+	if minDays != nil {
+		conds = append(conds, "p.duration_days >= "+arg(*minDays))
+	}
+	if maxDays != nil {
+		conds = append(conds, "p.duration_days <= "+arg(*maxDays))
+	}
+
+	if v := query.Get("travelers"); v != "" {
+		travelers, err := strconv.Atoi(v)
+		if err != nil || travelers <= 0 {
+			return nil, "travelers must be a positive number"
+		}
+		conds = append(conds, "p.num_travelers >= "+arg(travelers))
+	}
+	if v := query.Get("agency_id"); v != "" {
+		agencyID, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, "Invalid agency ID"
+		}
+		conds = append(conds, "p.agency_id = "+arg(agencyID))
+	}
+	return conds, ""
+}
+
+// parseRange reads optional min/max query values. ok is false when either
+// is not a non-negative number or min exceeds max.
+func parseRange(minValue, maxValue string) (*float64, *float64, bool) {
+	parse := func(v string) (*float64, bool) {
+		if v == "" {
+			return nil, true
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 {
+			return nil, false
+		}
+		return &f, true
+	}
+	lo, ok1 := parse(minValue)
+	hi, ok2 := parse(maxValue)
+	if !ok1 || !ok2 || (lo != nil && hi != nil && *lo > *hi) {
+		return nil, nil, false
+	}
+	return lo, hi, true
+}
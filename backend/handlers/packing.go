@@ -0,0 +1,364 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"trip-planner-backend/config"
+	"trip-planner-backend/models"
+	"trip-planner-backend/utils"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	errTripDetailsMissing = errors.New("trip was generated before trip details were stored")
+	errTripWindowTooLong  = errors.New("trip window is too long")
+)
+
+type packingListRequest struct {
+	models.TripRequest
+	WithChildren bool `json:"with_children"`
+	WithSeniors  bool `json:"with_seniors"`
+}
+
+func (req packingListRequest) options() utils.PackingOptions {
+	opts := utils.PackingOptionsForTrip(req.TripRequest)
+	opts.WithChildren = req.WithChildren
+	opts.WithSeniors = req.WithSeniors
+	return opts
+}
+
+func buildPackingList(tripReq models.TripRequest, opts utils.PackingOptions) (models.PackingList, error) {
+	start, err := time.Parse("2006-01-02", tripReq.StartDate)
+	if err != nil {
+		return models.PackingList{}, err
+	}
+	end, err := time.Parse("2006-01-02", tripReq.EndDate)
+	if err != nil || end.Before(start) {
+		end = start
+	}
+	// Every day in the window is a forecast or climate lookup
+	if end.Sub(start) > maxTripWeatherDays*24*time.Hour {
+		return models.PackingList{}, errTripWindowTooLong
+	}
+
+	weather := utils.GetTripWeather(tripReq.FinalDestination, start, end)
+	return models.PackingList{
+		Destination: tripReq.FinalDestination,
+		StartDate:   tripReq.StartDate,
+		EndDate:     end.Format("2006-01-02"),
+		Items:       utils.GeneratePackingList(weather, opts),
+		GeneratedAt: time.Now(),
+	}, nil
+}
+
+// GeneratePackingList returns a checklist for an unsaved trip request
+func GeneratePackingList(w http.ResponseWriter, r *http.Request) {
+	var req packingListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if req.FinalDestination == "" || req.StartDate == "" {
+		http.Error(w, "Destination and start date are required", http.StatusBadRequest)
+		return
+	}
+
+	list, err := buildPackingList(req.TripRequest, req.options())
+	if errors.Is(err, errTripWindowTooLong) {
+		http.Error(w, tripWindowMessage, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Invalid start date", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// loadSavedTripRequest reads the stored parameters of a trip the user has saved
+func loadSavedTripRequest(userID, tripID int) (models.TripRequest, error) {
+	var (
+		initial, final, mood sql.NullString
+		startDate, endDate   sql.NullTime
+		numTravelers         sql.NullInt64
+	)
+	err := config.DB.QueryRow(`
+		SELECT t.initial_destination, t.final_destination, t.start_date, t.end_date, t.num_travelers, t.mood
+		FROM trips t
+		INNER JOIN saved s ON t.tripid = s.tripid
+		WHERE s.userid = $1 AND t.tripid = $2
+	`, userID, tripID).Scan(&initial, &final, &startDate, &endDate, &numTravelers, &mood)
+	if err != nil {
+		return models.TripRequest{}, err
+	}
+
+	if !final.Valid || !startDate.Valid {
+		return models.TripRequest{}, errTripDetailsMissing
+	}
+
+	tripReq := models.TripRequest{
+		InitialDestination: initial.String,
+		FinalDestination:   final.String,
+		StartDate:          startDate.Time.Format("2006-01-02"),
+		EndDate:            startDate.Time.Format("2006-01-02"),
+		NumTravelers:       int(numTravelers.Int64),
+		Mood:               mood.String,
+	}
+	if endDate.Valid {
+		tripReq.EndDate = endDate.Time.Format("2006-01-02")
+	}
+	return tripReq, nil
+}
+
+func writeSavedTripError(w http.ResponseWriter, err error) {
+	switch {
+	case err == sql.ErrNoRows:
+		http.Error(w, "Trip not found", http.StatusNotFound)
+	case errors.Is(err, errTripDetailsMissing):
+		http.Error(w, "This trip has no stored destination or dates. Please generate it again.", http.StatusConflict)
+	case errors.Is(err, errTripWindowTooLong):
+		http.Error(w, tripWindowMessage, http.StatusBadRequest)
+	default:
+		log.Printf("Error loading saved trip: %v", err)
+		http.Error(w, "Error loading trip", http.StatusInternalServerError)
+	}
+}
+
+func fetchPackingItems(userID, tripID int) ([]models.PackingItem, error) {
+	rows, err := config.DB.Query(`
+		SELECT item_id, category, name, quantity, COALESCE(reason, ''), checked, sort_order
+		FROM packing_list_items
+		WHERE userid = $1 AND tripid = $2
+		ORDER BY sort_order, item_id
+	`, userID, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.PackingItem{}
+	for rows.Next() {
+		var item models.PackingItem
+		if err := rows.Scan(&item.ItemID, &item.Category, &item.Name, &item.Quantity, &item.Reason, &item.Checked, &item.SortOrder); err != nil {
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// savePackingItems replaces the trip's checklist. Items that were already
+// checked off keep their state when the list is regenerated.
+func savePackingItems(userID, tripID int, items []models.PackingItem) error {
+	tx, err := config.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	checked := make(map[string]bool)
+	rows, err := tx.Query(`SELECT name FROM packing_list_items WHERE userid = $1 AND tripid = $2 AND checked = TRUE`, userID, tripID)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err == nil {
+			checked[name] = true
+		}
+	}
+	rows.Close()
+
+	if _, err := tx.Exec(`DELETE FROM packing_list_items WHERE userid = $1 AND tripid = $2`, userID, tripID); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if _, err := tx.Exec(`
+			INSERT INTO packing_list_items (tripid, userid, category, name, quantity, reason, checked, sort_order)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8)
+		`, tripID, userID, item.Category, item.Name, item.Quantity, item.Reason, checked[item.Name], item.SortOrder); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func writePackingList(w http.ResponseWriter, tripID int, tripReq models.TripRequest, items []models.PackingItem) {
+	list := models.PackingList{
+		TripID:      tripID,
+		Destination: tripReq.FinalDestination,
+		StartDate:   tripReq.StartDate,
+		EndDate:     tripReq.EndDate,
+		Items:       items,
+		GeneratedAt: time.Now(),
+	}
+	for _, item := range items {
+		if item.Checked {
+			list.CheckedCount++
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// GetTripPackingList returns the saved checklist for a trip, generating and
+// storing it on first access
+func GetTripPackingList(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userid").(int)
+	tripID, err := strconv.Atoi(mux.Vars(r)["tripid"])
+	if err != nil {
+		http.Error(w, "Invalid trip ID", http.StatusBadRequest)
+		return
+	}
+
+	tripReq, err := loadSavedTripRequest(userID, tripID)
+	if err != nil {
+		writeSavedTripError(w, err)
+		return
+	}
+
+	items, err := fetchPackingItems(userID, tripID)
+	if err != nil {
+		http.Error(w, "Error fetching packing list", http.StatusInternalServerError)
+		return
+	}
+
+	if len(items) == 0 {
+		list, err := buildPackingList(tripReq, utils.PackingOptionsForTrip(tripReq))
+		if err != nil {
+			if !errors.Is(err, errTripWindowTooLong) {
+				err = errTripDetailsMissing
+			}
+			writeSavedTripError(w, err)
+			return
+		}
+		if err := savePackingItems(userID, tripID, list.Items); err != nil {
+			log.Printf("Error saving packing list: %v", err)
+			http.Error(w, "Error saving packing list", http.StatusInternalServerError)
+			return
+		}
+		if items, err = fetchPackingItems(userID, tripID); err != nil {
+			http.Error(w, "Error fetching packing list", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	writePackingList(w, tripID, tripReq, items)
+}
+
+// RegenerateTripPackingList rebuilds the checklist from the latest weather
+func RegenerateTripPackingList(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userid").(int)
+	tripID, err := strconv.Atoi(mux.Vars(r)["tripid"])
+	if err != nil {
+		http.Error(w, "Invalid trip ID", http.StatusBadRequest)
+		return
+	}
+
+	var profile struct {
+		WithChildren bool `json:"with_children"`
+		WithSeniors  bool `json:"with_seniors"`
+	}
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+	}
+
+	tripReq, err := loadSavedTripRequest(userID, tripID)
+	if err != nil {
+		writeSavedTripError(w, err)
+		return
+	}
+
+	req := packingListRequest{TripRequest: tripReq, WithChildren: profile.WithChildren, WithSeniors: profile.WithSeniors}
+	list, err := buildPackingList(tripReq, req.options())
+	if err != nil {
+		if !errors.Is(err, errTripWindowTooLong) {
+			err = errTripDetailsMissing
+		}
+		writeSavedTripError(w, err)
+		return
+	}
+
+	if err := savePackingItems(userID, tripID, list.Items); err != nil {
+		log.Printf("Error saving packing list: %v", err)
+		http.Error(w, "Error saving packing list", http.StatusInternalServerError)
+		return
+	}
+
+	items, err := fetchPackingItems(userID, tripID)
+	if err != nil {
+		http.Error(w, "Error fetching packing list", http.StatusInternalServerError)
+		return
+	}
+
+	writePackingList(w, tripID, tripReq, items)
+}
+
+// UpdatePackingItem checks or unchecks an item, or changes its quantity
+func UpdatePackingItem(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userid").(int)
+	vars := mux.Vars(r)
+	tripID, err := strconv.Atoi(vars["tripid"])
+	if err != nil {
+		http.Error(w, "Invalid trip ID", http.StatusBadRequest)
+		return
+	}
+	itemID, err := strconv.Atoi(vars["itemid"])
+	if err != nil {
+		http.Error(w, "Invalid item ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Checked  *bool `json:"checked"`
+		Quantity *int  `json:"quantity"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.Checked == nil && req.Quantity == nil {
+		http.Error(w, "Nothing to update", http.StatusBadRequest)
+		return
+	}
+	if req.Quantity != nil && *req.Quantity <= 0 {
+		http.Error(w, "Quantity must be positive", http.StatusBadRequest)
+		return
+	}
+
+	var item models.PackingItem
+	err = config.DB.QueryRow(`
+		UPDATE packing_list_items
+		SET checked = COALESCE($1, checked), quantity = COALESCE($2, quantity)
+		WHERE item_id = $3 AND tripid = $4 AND userid = $5
+		RETURNING item_id, category, name, quantity, COALESCE(reason, ''), checked, sort_order
+	`, req.Checked, req.Quantity, itemID, tripID, userID).Scan(
+		&item.ItemID, &item.Category, &item.Name, &item.Quantity, &item.Reason, &item.Checked, &item.SortOrder,
+	)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Packing item not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error updating packing item", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
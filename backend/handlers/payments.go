@@ -0,0 +1,879 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"trip-planner-backend/config"
+	"trip-planner-backend/models"
+	"trip-planner-backend/payments"
+	"trip-planner-backend/utils"
+
+	"github.com/gorilla/mux"
+)
+
+const maxWebhookBytes = 1 << 20
+
+var (
+	errPaymentBooking    = errors.New("booking to pay was not found")
+	errAlreadyPaid       = errors.New("booking is already paid")
+	errDuplicateCapture  = errors.New("payment captured for a booking that was already paid")
+	errBookingNotPayable = errors.New("booking can't be paid in its current status")
+	errPaymentStatus     = errors.New("payment can't change from its current status")
+	errRefundAmount      = errors.New("refund amount is more than what is left")
+)
+
+const paymentColumns = `
+	py.payment_id, py.userid, py.agency_id, py.package_id, COALESCE(p.title, ''), py.booking_id, py.num_travelers,
+	py.amount, py.amount_refunded, py.currency, py.gateway, py.gateway_order_id, COALESCE(py.gateway_payment_id, ''),
+	py.status, (py.status = 'duplicate' OR (py.status IN ('paid', 'partially_refunded') AND b.status = 'cancelled')),
+	py.paid_at, py.created_at, py.updated_at`
+
+// toPaise converts rupees to the gateway's smallest currency unit
+func toPaise(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func fromPaise(amount int64) float64 {
+	return float64(amount) / 100
+}
+
+func writePaymentError(w http.ResponseWriter, err error) {
+	switch {
+	case err == sql.ErrNoRows:
+		http.Error(w, "Payment not found", http.StatusNotFound)
+	case errors.Is(err, payments.ErrDisabled):
+		http.Error(w, "Online payments are not available", http.StatusServiceUnavailable)
+	case errors.Is(err, payments.ErrInvalidSignature):
+		http.Error(w, "Payment signature could not be verified", http.StatusBadRequest)
+	case errors.Is(err, errPaymentBooking):
+		http.Error(w, "Booking not found", http.StatusNotFound)
+	case errors.Is(err, errAlreadyPaid):
+		http.Error(w, "This booking is already paid", http.StatusConflict)
+	case errors.Is(err, errDuplicateCapture):
+		http.Error(w, "This booking was already paid by another payment; this one will be refunded", http.StatusConflict)
+	case errors.Is(err, errBookingNotPayable):
+		http.Error(w, "Only requested or confirmed bookings can be paid", http.StatusConflict)
+	case errors.Is(err, errPaymentStatus):
+		http.Error(w, "The payment can't be changed from its current status", http.StatusConflict)
+	case errors.Is(err, errRefundAmount):
+		http.Error(w, "The refund is larger than the amount left to refund", http.StatusBadRequest)
+	case errors.Is(err, errPackageUnavailable), errors.Is(err, errTooManyTravelers):
+		writeBookingError(w, err)
+	default:
+		log.Printf("Payment error: %v", err)
+		http.Error(w, "Error processing payment", http.StatusInternalServerError)
+	}
+}
+
+func scanPayment(row rowScanner) (models.Payment, error) {
+	var p models.Payment
+	var packageID, bookingID sql.NullInt64
+	var paidAt sql.NullTime
+	err := row.Scan(&p.PaymentID, &p.UserID, &p.AgencyID, &packageID, &p.PackageTitle, &bookingID, &p.NumTravelers,
+		&p.Amount, &p.AmountRefunded, &p.Currency, &p.Gateway, &p.GatewayOrderID, &p.GatewayPaymentID,
+		&p.Status, &p.RefundDue, &paidAt, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return p, err
+	}
+	if packageID.Valid {
+		id := int(packageID.Int64)
+		p.PackageID = &id
+	}
+	if bookingID.Valid {
+		id := int(bookingID.Int64)
+		p.BookingID = &id
+	}
+	if paidAt.Valid {
+		p.PaidAt = &paidAt.Time
+	}
+	return p, nil
+}
+
+// fetchPayments loads payments matching where (over py and p), newest first
+func fetchPayments(where string, args ...interface{}) ([]models.Payment, error) {
+	rows, err := config.DB.Query(`SELECT `+paymentColumns+`
+		FROM payments py
+		LEFT JOIN travel_packages p ON py.package_id = p.package_id
+		LEFT JOIN bookings b ON py.booking_id = b.booking_id
+		WHERE `+where+`
+		ORDER BY py.created_at DESC, py.payment_id DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.Payment{}
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+// fetchPayment loads one payment with its attempts and refunds
+func fetchPayment(where string, args ...interface{}) (models.Payment, error) {
+	list, err := fetchPayments(where, args...)
+	if err != nil {
+		return models.Payment{}, err
+	}
+	if len(list) == 0 {
+		return models.Payment{}, sql.ErrNoRows
+	}
+	p := list[0]
+
+	rows, err := config.DB.Query(`
+		SELECT attempt_id, source, COALESCE(event, ''), COALESCE(gateway_payment_id, ''), outcome,
+			COALESCE(error_message, ''), created_at
+		FROM payment_attempts WHERE payment_id = $1 ORDER BY created_at, attempt_id
+	`, p.PaymentID)
+	if err != nil {
+		return p, err
+	}
+	defer rows.Close()
+	p.Attempts = []models.PaymentAttempt{}
+	for rows.Next() {
+		var a models.PaymentAttempt
+		if err := rows.Scan(&a.AttemptID, &a.Source, &a.Event, &a.GatewayPaymentID, &a.Outcome, &a.ErrorMessage, &a.CreatedAt); err != nil {
+			return p, err
+		}
+		p.Attempts = append(p.Attempts, a)
+	}
+
+	refundRows, err := config.DB.Query(`
+		SELECT refund_id, COALESCE(gateway_refund_id, ''), amount, COALESCE(reason, ''), status, requested_by, created_at
+		FROM payment_refunds WHERE payment_id = $1 ORDER BY created_at, refund_id
+	`, p.PaymentID)
+	if err != nil {
+		return p, err
+	}
+	defer refundRows.Close()
+	p.Refunds = []models.PaymentRefund{}
+	for refundRows.Next() {
+		var rf models.PaymentRefund
+		if err := refundRows.Scan(&rf.RefundID, &rf.GatewayRefundID, &rf.Amount, &rf.Reason, &rf.Status, &rf.RequestedBy, &rf.CreatedAt); err != nil {
+			return p, err
+		}
+		p.Refunds = append(p.Refunds, rf)
+	}
+	return p, refundRows.Err()
+}
+
+// paymentOrderRequest pays either an existing booking or a package for a
+// party size (and optionally a departure date, for its price override)
+type paymentOrderRequest struct {
+	BookingID     int    `json:"booking_id"`
+	PackageID     int    `json:"package_id"`
+	NumTravelers  int    `json:"num_travelers"`
+	DepartureDate string `json:"departure_date"`
+}
+
+// paymentTarget is what an order is for and how much it charges
+type paymentTarget struct {
+	AgencyID     int
+	PackageID    *int
+	BookingID    *int
+	NumTravelers int
+	Amount       float64
+	Currency     string
+}
+
+// bookingPaymentTarget charges the price snapshot of one of the user's
+// open bookings, unless it is already paid. The booking row stays locked
+// until tx ends, so two orders for it are opened one at a time.
+func bookingPaymentTarget(tx *sql.Tx, userID, bookingID int) (paymentTarget, error) {
+	var t paymentTarget
+	var packageID sql.NullInt64
+	var status string
+	err := tx.QueryRow(`
+		SELECT agency_id, package_id, num_travelers, total_price, currency, status
+		FROM bookings WHERE booking_id = $1 AND userid = $2
+		FOR UPDATE
+	`, bookingID, userID).Scan(&t.AgencyID, &packageID, &t.NumTravelers, &t.Amount, &t.Currency, &status)
+	if err == sql.ErrNoRows {
+		return t, errPaymentBooking
+	}
+	if err != nil {
+		return t, err
+	}
+	if status != models.BookingRequested && status != models.BookingConfirmed {
+		return t, errBookingNotPayable
+	}
+
+	var paid bool
+	err = tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM payments WHERE booking_id = $1 AND status IN ($2, $3))`,
+		bookingID, models.PaymentPaid, models.PaymentPartiallyRefunded).Scan(&paid)
+	if err != nil {
+		return t, err
+	}
+	if paid {
+		return t, errAlreadyPaid
+	}
+
+	if packageID.Valid {
+		id := int(packageID.Int64)
+		t.PackageID = &id
+	}
+	t.BookingID = &bookingID
+	return t, nil
+}
+
+// openBookingPayment finds an order already open for the booking with the
+// same amount, so paying again resumes it instead of opening a second one
+// that could be captured as well
+func openBookingPayment(tx *sql.Tx, t paymentTarget, gatewayName string) (int, string, error) {
+	var paymentID int
+	var orderID string
+	err := tx.QueryRow(`
+		SELECT payment_id, gateway_order_id FROM payments
+		WHERE booking_id = $1 AND gateway = $2 AND status IN ($3, $4) AND amount = $5 AND currency = $6
+		ORDER BY created_at DESC, payment_id DESC
+		LIMIT 1
+	`, *t.BookingID, gatewayName, models.PaymentCreated, models.PaymentFailed, t.Amount, t.Currency).Scan(&paymentID, &orderID)
+	if err == sql.ErrNoRows {
+		return 0, "", nil
+	}
+	return paymentID, orderID, err
+}
+
+// packagePaymentTarget prices a party for an active package the same way
+// a booking would, using the departure's price override when the date
+// matches an open departure
+func packagePaymentTarget(packageID, travelers int, departureDate string) (paymentTarget, error) {
+	t := paymentTarget{PackageID: &packageID, NumTravelers: travelers, Currency: "INR"}
+	var maxTravelers int
+	var price float64
+	var stored []byte
+	err := config.DB.QueryRow(`
+		SELECT p.agency_id, p.num_travelers, COALESCE(d.price_override, p.price), p.pricing_rules
+		FROM travel_packages p
+		LEFT JOIN package_departures d
+			ON d.package_id = p.package_id AND d.is_active = TRUE AND d.start_date = NULLIF($2, '')::date
+		WHERE p.package_id = $1 AND `+publicPackageCondition, packageID, departureDate).Scan(&t.AgencyID, &maxTravelers, &price, &stored)
+	if err == sql.ErrNoRows {
+		return t, errPackageUnavailable
+	}
+	if err != nil {
+		return t, err
+	}
+	if travelers > maxTravelers {
+		return t, errTooManyTravelers
+	}
+	// Without traveler ages everyone pays the adult price
+	departure, _ := time.Parse("2006-01-02", departureDate)
+	quote := utils.QuotePrice(price, decodePricingRules(stored), departure, models.PartySize{Adults: travelers}, nil)
+	t.Amount = quote.Total
+	return t, nil
+}
+
+// CreatePaymentOrder opens a gateway order for a booking or for a package
+// and party size. The amount is always computed here, never taken from
+// the client.
+func CreatePaymentOrder(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userid").(int)
+	gateway, err := payments.Default()
+	if err != nil {
+		writePaymentError(w, err)
+		return
+	}
+
+	var req paymentOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		writePaymentError(w, err)
+		return
+	}
+	defer tx.Rollback()
+
+	var target paymentTarget
+	switch {
+	case req.BookingID > 0:
+		target, err = bookingPaymentTarget(tx, userID, req.BookingID)
+	case req.PackageID > 0:
+		if req.NumTravelers <= 0 || req.NumTravelers > maxBookingTravelers {
+			http.Error(w, "num_travelers must be between 1 and "+strconv.Itoa(maxBookingTravelers), http.StatusBadRequest)
+			return
+		}
+		if req.DepartureDate != "" && !validDate(req.DepartureDate) {
+			http.Error(w, "departure_date must be YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		target, err = packagePaymentTarget(req.PackageID, req.NumTravelers, req.DepartureDate)
+	default:
+		http.Error(w, "booking_id or package_id is required", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		writePaymentError(w, err)
+		return
+	}
+	if target.Amount <= 0 {
+		http.Error(w, "This package has no price to pay", http.StatusBadRequest)
+		return
+	}
+
+	var paymentID int
+	var orderID string
+	status := http.StatusCreated
+	if target.BookingID != nil {
+		paymentID, orderID, err = openBookingPayment(tx, target, gateway.Name())
+		if err != nil {
+			writePaymentError(w, err)
+			return
+		}
+	}
+	if paymentID != 0 {
+		status = http.StatusOK
+	} else {
+		notes := map[string]string{"userid": strconv.Itoa(userID)}
+		if target.BookingID != nil {
+			notes["booking_id"] = strconv.Itoa(*target.BookingID)
+		}
+		order, err := gateway.CreateOrder(r.Context(), payments.OrderRequest{
+			Amount:   toPaise(target.Amount),
+			Currency: target.Currency,
+			Notes:    notes,
+		})
+		if err != nil {
+			log.Printf("Error creating %s order: %v", gateway.Name(), err)
+			http.Error(w, "The payment gateway could not create an order", http.StatusBadGateway)
+			return
+		}
+		orderID = order.ID
+
+		err = tx.QueryRow(`
+			INSERT INTO payments (userid, agency_id, package_id, booking_id, num_travelers, amount, currency, gateway, gateway_order_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			RETURNING payment_id
+		`, userID, target.AgencyID, target.PackageID, target.BookingID, target.NumTravelers, target.Amount,
+			target.Currency, gateway.Name(), orderID).Scan(&paymentID)
+		if err != nil {
+			writePaymentError(w, err)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		writePaymentError(w, err)
+		return
+	}
+
+	payment, err := fetchPayment("py.payment_id = $1", paymentID)
+	if err != nil {
+		writePaymentError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"payment": payment,
+		"checkout": map[string]interface{}{
+			"gateway":  gateway.Name(),
+			"key_id":   gateway.KeyID(),
+			"order_id": orderID,
+			"amount":   toPaise(payment.Amount),
+			"currency": payment.Currency,
+		},
+	})
+}
+
+func validDate(value string) bool {
+	_, err := time.Parse("2006-01-02", value)
+	return err == nil
+}
+
+// recordPaymentAttempt stores a callback or webhook received for a payment
+func recordPaymentAttempt(q interface {
+	Exec(string, ...interface{}) (sql.Result, error)
+}, paymentID int, source, event, gatewayPaymentID, outcome, message string) error {
+	_, err := q.Exec(`
+		INSERT INTO payment_attempts (payment_id, source, event, gateway_payment_id, outcome, error_message)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5, NULLIF($6, ''))
+	`, paymentID, source, event, gatewayPaymentID, outcome, message)
+	return err
+}
+
+// markPaymentPaid captures a payment unless it was already captured. It
+// is called from both the checkout callback and the webhook, whichever
+// arrives first, with the payment row locked. The booking is locked after
+// it, so captures of two orders for one booking are applied one at a time;
+// if another payment already paid the booking, this one is marked
+// duplicate and errDuplicateCapture returned for the caller to record.
+func markPaymentPaid(tx *sql.Tx, paymentID int, gatewayPaymentID string) error {
+	var bookingID sql.NullInt64
+	var status string
+	err := tx.QueryRow(`SELECT booking_id, status FROM payments WHERE payment_id = $1`, paymentID).Scan(&bookingID, &status)
+	if err != nil {
+		return err
+	}
+	if status != models.PaymentCreated && status != models.PaymentFailed {
+		return nil
+	}
+
+	newStatus := models.PaymentPaid
+	if bookingID.Valid {
+		var paid bool
+		err = tx.QueryRow(`SELECT booking_id FROM bookings WHERE booking_id = $1 FOR UPDATE`, bookingID.Int64).Scan(new(int))
+		if err == nil {
+			err = tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM payments WHERE booking_id = $1 AND payment_id <> $2 AND status IN ($3, $4))`,
+				bookingID.Int64, paymentID, models.PaymentPaid, models.PaymentPartiallyRefunded).Scan(&paid)
+		}
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if paid {
+			newStatus = models.PaymentDuplicate
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE payments
+		SET status = $1, gateway_payment_id = $2, paid_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE payment_id = $3
+	`, newStatus, gatewayPaymentID, paymentID)
+	if err != nil {
+		return err
+	}
+	if newStatus == models.PaymentDuplicate {
+		log.Printf("Payment %d captured %s for booking %d, which another payment already paid; it needs a refund",
+			paymentID, gatewayPaymentID, bookingID.Int64)
+		return errDuplicateCapture
+	}
+	return nil
+}
+
+// VerifyPayment checks the signature the client got back from checkout
+// and marks the payment as paid. Every call is recorded as an attempt,
+// including ones with a bad signature.
+func VerifyPayment(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userid").(int)
+
+	var req struct {
+		OrderID   string `json:"order_id"`
+		PaymentID string `json:"payment_id"`
+		Signature string `json:"signature"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+	if req.OrderID == "" || req.PaymentID == "" || req.Signature == "" {
+		http.Error(w, "order_id, payment_id and signature are required", http.StatusBadRequest)
+		return
+	}
+
+	gateway, err := payments.Default()
+	if err != nil {
+		writePaymentError(w, err)
+		return
+	}
+	tx, err := config.DB.Begin()
+	if err != nil {
+		writePaymentError(w, err)
+		return
+	}
+	defer tx.Rollback()
+
+	var paymentID int
+	err = tx.QueryRow(`SELECT payment_id FROM payments WHERE gateway = $1 AND gateway_order_id = $2 AND userid = $3 FOR UPDATE`,
+		gateway.Name(), req.OrderID, userID).Scan(&paymentID)
+	if err != nil {
+		writePaymentError(w, err)
+		return
+	}
+
+	verifyErr := gateway.VerifyPayment(req.OrderID, req.PaymentID, req.Signature)
+	outcome, message := "success", ""
+	if verifyErr != nil {
+		outcome, message = "invalid_signature", verifyErr.Error()
+	} else if verifyErr = markPaymentPaid(tx, paymentID, req.PaymentID); errors.Is(verifyErr, errDuplicateCapture) {
+		outcome, message = "duplicate_capture", verifyErr.Error()
+	} else if verifyErr != nil {
+		writePaymentError(w, verifyErr)
+		return
+	}
+	if err := recordPaymentAttempt(tx, paymentID, "callback", "", req.PaymentID, outcome, message); err != nil {
+		writePaymentError(w, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		writePaymentError(w, err)
+		return
+	}
+	if verifyErr != nil {
+		writePaymentError(w, verifyErr)
+		return
+	}
+
+	payment, err := fetchPayment("py.payment_id = $1", paymentID)
+	if err != nil {
+		writePaymentError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payment)
+}
+
+// PaymentWebhook receives signed gateway events. Events for orders we
+// don't know, and event types we don't handle, are acknowledged so the
+// gateway stops retrying them.
+func PaymentWebhook(w http.ResponseWriter, r *http.Request) {
+	gateway, err := payments.Default()
+	if err != nil {
+		writePaymentError(w, err)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBytes))
+	if err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	event, err := gateway.ParseWebhook(body, r.Header)
+	if errors.Is(err, payments.ErrUnknownEvent) {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if err != nil {
+		log.Printf("Rejected %s webhook: %v", gateway.Name(), err)
+		http.Error(w, "Invalid webhook", http.StatusBadRequest)
+		return
+	}
+
+	if err := applyPaymentEvent(gateway.Name(), event); err != nil && err != sql.ErrNoRows {
+		log.Printf("Error applying %s webhook %s for order %s: %v", gateway.Name(), event.Type, event.OrderID, err)
+		http.Error(w, "Error processing webhook", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func applyPaymentEvent(gatewayName string, event payments.WebhookEvent) error {
+	tx, err := config.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if event.Type == payments.EventRefundProcessed {
+		_, err := tx.Exec(`UPDATE payment_refunds SET status = 'processed', updated_at = CURRENT_TIMESTAMP WHERE gateway_refund_id = $1`,
+			event.RefundID)
+		if err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	var paymentID int
+	var amount float64
+	var currency string
+	err = tx.QueryRow(`SELECT payment_id, amount, currency FROM payments WHERE gateway = $1 AND gateway_order_id = $2 FOR UPDATE`,
+		gatewayName, event.OrderID).Scan(&paymentID, &amount, &currency)
+	if err != nil {
+		return err
+	}
+
+	switch event.Type {
+	case payments.EventPaymentCaptured:
+		// A capture for anything but the order total doesn't pay the
+		// booking; it is kept as an attempt for someone to look into
+		if event.Amount != toPaise(amount) || (event.Currency != "" && !strings.EqualFold(event.Currency, currency)) {
+			message := fmt.Sprintf("captured %d %s, expected %d %s", event.Amount, event.Currency, toPaise(amount), currency)
+			log.Printf("Payment %d: %s webhook amount mismatch: %s", paymentID, gatewayName, message)
+			err = recordPaymentAttempt(tx, paymentID, "webhook", event.Type, event.PaymentID, "amount_mismatch", message)
+			break
+		}
+		outcome, message := "success", ""
+		err = markPaymentPaid(tx, paymentID, event.PaymentID)
+		if errors.Is(err, errDuplicateCapture) {
+			outcome, message, err = "duplicate_capture", err.Error(), nil
+		}
+		if err == nil {
+			err = recordPaymentAttempt(tx, paymentID, "webhook", event.Type, event.PaymentID, outcome, message)
+		}
+	case payments.EventPaymentFailed:
+		err = recordPaymentAttempt(tx, paymentID, "webhook", event.Type, event.PaymentID, "failed", event.Error)
+		if err == nil {
+			_, err = tx.Exec(`UPDATE payments SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE payment_id = $2 AND status = $3`,
+				models.PaymentFailed, paymentID, models.PaymentCreated)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MockCheckout stands in for the hosted payment page when the mock gateway
+// is configured: it pays the order and returns what the client would get
+// back from a real checkout, to be sent to VerifyPayment
+func MockCheckout(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userid").(int)
+	gateway, _ := payments.Default()
+	mock, ok := gateway.(*payments.Mock)
+	if !ok {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	paymentID, err := strconv.Atoi(mux.Vars(r)["paymentid"])
+	if err != nil {
+		http.Error(w, "Invalid payment ID", http.StatusBadRequest)
+		return
+	}
+
+	payment, err := fetchPayment("py.payment_id = $1 AND py.userid = $2", paymentID, userID)
+	if err != nil {
+		writePaymentError(w, err)
+		return
+	}
+	if payment.Status != models.PaymentCreated && payment.Status != models.PaymentFailed {
+		writePaymentError(w, errPaymentStatus)
+		return
+	}
+
+	gatewayPaymentID, signature := mock.Checkout(payment.GatewayOrderID)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"order_id":   payment.GatewayOrderID,
+		"payment_id": gatewayPaymentID,
+		"signature":  signature,
+	})
+}
+
+// GetUserPayments lists the signed-in user's payments
+func GetUserPayments(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userid").(int)
+
+	list, err := fetchPayments("py.userid = $1", userID)
+	if err != nil {
+		writePaymentError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// GetUserPayment returns one of the user's payments with its attempts and
+// refunds
+func GetUserPayment(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userid").(int)
+	paymentID, err := strconv.Atoi(mux.Vars(r)["paymentid"])
+	if err != nil {
+		http.Error(w, "Invalid payment ID", http.StatusBadRequest)
+		return
+	}
+
+	payment, err := fetchPayment("py.payment_id = $1 AND py.userid = $2", paymentID, userID)
+	if err != nil {
+		writePaymentError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payment)
+}
+
+// GetAgencyPayments lists payments made for the agency's packages
+func GetAgencyPayments(w http.ResponseWriter, r *http.Request) {
+	agencyID, ok := r.Context().Value("agencyid").(int)
+	if !ok || agencyID == 0 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	list, err := fetchPayments("py.agency_id = $1", agencyID)
+	if err != nil {
+		writePaymentError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// refundPayment refunds part or all of what is left on a captured payment.
+// The refund is recorded as pending and its amount reserved on the payment
+// under the row lock, so two refunds can't both spend the same balance;
+// the gateway is only called after that commits. If the gateway refuses,
+// the refund is marked failed and the amount released again. agencyID
+// limits the refund to the agency's payments; admins pass 0. A duplicate
+// capture can only be refunded in full.
+func refundPayment(r *http.Request, paymentID, agencyID int, amount float64, reason, by string) error {
+	tx, err := config.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var total, refunded float64
+	var status, gatewayName, gatewayPaymentID string
+	err = tx.QueryRow(`
+		SELECT amount, amount_refunded, status, gateway, COALESCE(gateway_payment_id, '')
+		FROM payments WHERE payment_id = $1 AND ($2 = 0 OR agency_id = $2)
+		FOR UPDATE
+	`, paymentID, agencyID).Scan(&total, &refunded, &status, &gatewayName, &gatewayPaymentID)
+	if err != nil {
+		return err
+	}
+	if status != models.PaymentPaid && status != models.PaymentPartiallyRefunded && status != models.PaymentDuplicate {
+		return errPaymentStatus
+	}
+	gateway, err := payments.Default()
+	if err != nil {
+		return err
+	}
+	if gatewayName != gateway.Name() {
+		return errors.New("payment was made through " + gatewayName + ", which is not configured")
+	}
+
+	remaining := toPaise(total) - toPaise(refunded)
+	refundPaise := remaining
+	if amount > 0 {
+		refundPaise = toPaise(amount)
+	}
+	if refundPaise <= 0 || refundPaise > remaining || (status == models.PaymentDuplicate && refundPaise != remaining) {
+		return errRefundAmount
+	}
+
+	newStatus := models.PaymentPartiallyRefunded
+	if refundPaise == remaining {
+		newStatus = models.PaymentRefunded
+	}
+	var refundID int
+	if err := tx.QueryRow(`
+		INSERT INTO payment_refunds (payment_id, amount, reason, status, requested_by)
+		VALUES ($1, $2, NULLIF($3, ''), 'pending', $4)
+		RETURNING refund_id
+	`, paymentID, fromPaise(refundPaise), reason, by).Scan(&refundID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		UPDATE payments SET amount_refunded = amount_refunded + $1, status = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE payment_id = $3
+	`, fromPaise(refundPaise), newStatus, paymentID); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	refund, err := gateway.Refund(r.Context(), gatewayPaymentID, refundPaise, map[string]string{"reason": reason})
+	if err != nil {
+		if releaseErr := releaseRefund(refundID, paymentID, refundPaise, status); releaseErr != nil {
+			log.Printf("Refund %d failed at the gateway and could not be released for payment %d: %v", refundID, paymentID, releaseErr)
+		}
+		return err
+	}
+	refundStatus := "pending"
+	if refund.Status == "processed" {
+		refundStatus = "processed"
+	}
+	if _, err := config.DB.Exec(`
+		UPDATE payment_refunds SET gateway_refund_id = $1, status = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE refund_id = $3
+	`, refund.ID, refundStatus, refundID); err != nil {
+		log.Printf("Refund %s was issued but could not be recorded for payment %d: %v", refund.ID, paymentID, err)
+		return err
+	}
+	return nil
+}
+
+// releaseRefund marks a refund the gateway refused as failed and gives its
+// amount back to the payment's refundable balance. A payment with nothing
+// refunded any more goes back to paid, or to duplicate if it was one.
+func releaseRefund(refundID, paymentID int, refundPaise int64, status string) error {
+	fullStatus := models.PaymentPaid
+	if status == models.PaymentDuplicate {
+		fullStatus = models.PaymentDuplicate
+	}
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE payment_refunds SET status = 'failed', updated_at = CURRENT_TIMESTAMP WHERE refund_id = $1`,
+		refundID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		UPDATE payments
+		SET amount_refunded = amount_refunded - $1,
+			status = CASE WHEN amount_refunded - $1 = 0 THEN $2 ELSE $3 END,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE payment_id = $4
+	`, fromPaise(refundPaise), fullStatus, models.PaymentPartiallyRefunded, paymentID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// paymentRefundAction returns a handler refunding a payment. Without an
+// amount the whole remaining balance is refunded.
+func paymentRefundAction(by string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		agencyID := 0
+		if by == "agency" {
+			id, ok := r.Context().Value("agencyid").(int)
+			if !ok || id == 0 {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			agencyID = id
+		}
+		paymentID, err := strconv.Atoi(mux.Vars(r)["paymentid"])
+		if err != nil {
+			http.Error(w, "Invalid payment ID", http.StatusBadRequest)
+			return
+		}
+
+		var body struct {
+			Amount float64 `json:"amount"`
+			Reason string  `json:"reason"`
+		}
+		if r.ContentLength > 0 {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, "Invalid request", http.StatusBadRequest)
+				return
+			}
+		}
+		if body.Amount < 0 {
+			writePaymentError(w, errRefundAmount)
+			return
+		}
+
+		if err := refundPayment(r, paymentID, agencyID, body.Amount, strings.TrimSpace(body.Reason), by); err != nil {
+			writePaymentError(w, err)
+			return
+		}
+		payment, err := fetchPayment("py.payment_id = $1", paymentID)
+		if err != nil {
+			writePaymentError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(payment)
+	}
+}
+
+var (
+	// AgencyRefundPayment refunds a payment for one of the agency's packages
+	AgencyRefundPayment = paymentRefundAction("agency")
+	// AdminRefundPayment refunds any payment
+	AdminRefundPayment = paymentRefundAction("admin")
+)
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"trip-planner-backend/models"
+	"trip-planner-backend/storage"
+	"trip-planner-backend/utils"
+)
+
+// storeProcessedPhoto validates an upload, runs it through the image pipeline
+// and stores every variant under prefix. It returns the name to record.
+func storeProcessedPhoto(ctx context.Context, fileHeader *multipart.FileHeader, prefix, base string) (string, error) {
+	if err := validateImageUpload(fileHeader); err != nil {
+		return "", err
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return "", fmt.Errorf("could not read upload")
+	}
+	defer file.Close()
+
+	// The header size comes from the client, so cap the read as well
+	data, err := io.ReadAll(io.LimitReader(file, uploadLimits().MaxFileBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("could not read upload")
+	}
+	if int64(len(data)) > uploadLimits().MaxFileBytes {
+		return "", fmt.Errorf("file exceeds the %d MB limit", uploadLimits().MaxFileBytes>>20)
+	}
+
+	contentType, err := utils.SniffImageType(data)
+	if err != nil {
+		return "", fmt.Errorf("file content is not a supported image")
+	}
+	ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
+	if utils.AllowedImageExtensions[ext] != contentType {
+		return "", fmt.Errorf("file extension %s does not match its %s content", ext, contentType)
+	}
+
+	processed, err := utils.ProcessImage(bytes.NewReader(data))
+	if err != nil {
+		if errors.Is(err, utils.ErrImageTooLarge) {
+			return "", fmt.Errorf("image dimensions are too large")
+		}
+		return "", fmt.Errorf("file could not be decoded as an image")
+	}
+
+	store := storage.Default()
+	written := []string{}
+	for _, output := range processed.Outputs {
+		key := storage.Key(prefix, utils.VariantFilename(base, output.Variant, output.Ext))
+		if err := store.Put(ctx, key, bytes.NewReader(output.Data), int64(len(output.Data)), output.ContentType); err != nil {
+			log.Printf("Error storing %s: %v", key, err)
+			for _, k := range written {
+				store.Delete(ctx, k)
+			}
+			return "", fmt.Errorf("could not store file")
+		}
+		written = append(written, key)
+	}
+	return utils.StoredPhotoName(base), nil
+}
+
+// removePhotoFiles deletes every variant of a stored photo under prefix
+func removePhotoFiles(ctx context.Context, prefix, stored string) error {
+	var firstErr error
+	for _, name := range utils.PhotoFileNames(stored) {
+		if err := storage.Default().Delete(ctx, storage.Key(prefix, name)); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// storageURL returns the client URL of a stored object, or "" if the
+// backend can't produce one
+func storageURL(ctx context.Context, key string) string {
+	link, err := storage.Default().URL(ctx, key)
+	if err != nil {
+		log.Printf("Error building URL for %s: %v", key, err)
+		return ""
+	}
+	return link
+}
+
+// photoVariantURLs maps the variant filenames of a stored photo to URLs
+func photoVariantURLs(ctx context.Context, prefix, stored string) models.PhotoVariants {
+	variants := utils.PhotoVariantFiles(stored)
+	for _, urls := range []*models.ImageURLs{&variants.Thumb, &variants.Medium, &variants.Large} {
+		urls.JPEG = storageURL(ctx, storage.Key(prefix, urls.JPEG))
+	}
+	return variants
+}
+
+const packagePhotoPrefix = "packages"
+
+// setPackagePhotoVariants fills in the variant URLs of a package's photos
+func setPackagePhotoVariants(ctx context.Context, pkg *models.TravelPackage) {
+	pkg.PhotoVariants = make([]models.PhotoVariants, 0, len(pkg.Photos))
+	for _, photo := range pkg.Photos {
+		pkg.PhotoVariants = append(pkg.PhotoVariants, photoVariantURLs(ctx, packagePhotoPrefix, photo))
+	}
+}
+
+// savePackagePhotos processes uploaded package photos into storage. It
+// returns the stored names and an error entry for every rejected file.
+func savePackagePhotos(ctx context.Context, agencyID int, files []*multipart.FileHeader) ([]string, []uploadError) {
+	photos := []string{}
+	uploadErrors := []uploadError{}
+	for _, fileHeader := range files {
+		stored, err := storeProcessedPhoto(ctx, fileHeader, packagePhotoPrefix, newUploadKey(strconv.Itoa(agencyID)))
+		if err != nil {
+			uploadErrors = append(uploadErrors, uploadError{Filename: uploadDisplayName(fileHeader), Error: err.Error()})
+			continue
+		}
+		photos = append(photos, stored)
+	}
+	return photos, uploadErrors
+}
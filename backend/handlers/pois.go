@@ -0,0 +1,469 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"trip-planner-backend/config"
+	"trip-planner-backend/models"
+	"trip-planner-backend/utils"
+
+	"github.com/gorilla/mux"
+	"github.com/lib/pq"
+)
+
+const poiColumns = `poi_id, district_name, name, category, COALESCE(description, ''), latitude, longitude,
+	opening_hours, visit_duration_minutes, entry_fee_min, entry_fee_max, accessibility, tags, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanPOI reads the poiColumns, plus the distance when extra is given
+func scanPOI(row rowScanner, extra ...interface{}) (models.POI, error) {
+	var p models.POI
+	var hours []byte
+	var feeMin, feeMax sql.NullFloat64
+	dest := append([]interface{}{&p.POIID, &p.DistrictName, &p.Name, &p.Category, &p.Description, &p.Latitude, &p.Longitude,
+		&hours, &p.VisitDurationMinutes, &feeMin, &feeMax, pq.Array(&p.Accessibility), pq.Array(&p.Tags), &p.CreatedAt, &p.UpdatedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return p, err
+	}
+
+	if err := json.Unmarshal(hours, &p.OpeningHours); err != nil || p.OpeningHours == nil {
+		p.OpeningHours = []models.OpeningPeriod{}
+	}
+	if feeMin.Valid {
+		p.EntryFeeMin = &feeMin.Float64
+	}
+	if feeMax.Valid {
+		p.EntryFeeMax = &feeMax.Float64
+	}
+	if p.Accessibility == nil {
+		p.Accessibility = []string{}
+	}
+	if p.Tags == nil {
+		p.Tags = []string{}
+	}
+	return p, nil
+}
+
+// SearchPOIs searches the catalog (public endpoint). Filters: district,
+// category and accessibility (comma-separated), q (name, description or
+// tags), and lat/lon with radius_km. A radius without lat/lon is measured
+// from the district centre and also returns places just across the border.
+func SearchPOIs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	conds := []string{}
+	args := []interface{}{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	var district models.District
+	if name := query.Get("district"); name != "" {
+		var ok bool
+		if district, ok = utils.FindDistrict(name); !ok {
+			sendJSONError(w, "Unknown district", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if categories := splitList(query.Get("category")); len(categories) > 0 {
+		for _, c := range categories {
+			if !utils.POICategories[c] {
+				sendJSONError(w, "Unknown category: "+c, http.StatusBadRequest)
+				return
+			}
+		}
+		conds = append(conds, "category = ANY("+arg(pq.Array(categories))+")")
+	}
+	if flags := splitList(query.Get("accessibility")); len(flags) > 0 {
+		for _, f := range flags {
+			if !utils.POIAccessibilityFlags[f] {
+				sendJSONError(w, "Unknown accessibility flag: "+f, http.StatusBadRequest)
+				return
+			}
+		}
+		conds = append(conds, "accessibility @> "+arg(pq.Array(flags)))
+	}
+	if q := strings.TrimSpace(query.Get("q")); q != "" {
+		pattern := arg("%" + q + "%")
+		conds = append(conds, "(name ILIKE "+pattern+" OR description ILIKE "+pattern+" OR array_to_string(tags, ' ') ILIKE "+pattern+")")
+	}
+
+	limit := 50
+	if v, err := strconv.Atoi(query.Get("limit")); err == nil && v > 0 && v <= 200 {
+		limit = v
+	}
+
+	// Radius search
+	distance := "NULL::DOUBLE PRECISION"
+	order := "district_name, name"
+	hasCenter := query.Get("lat") != "" || query.Get("lon") != ""
+	if hasCenter || query.Get("radius_km") != "" {
+		radius := 25.0
+		if v := query.Get("radius_km"); v != "" {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil || parsed <= 0 || parsed > 300 {
+				sendJSONError(w, "radius_km must be between 0 and 300", http.StatusBadRequest)
+				return
+			}
+			radius = parsed
+		}
+
+		var lat, lon float64
+		if hasCenter {
+			var err1, err2 error
+			lat, err1 = strconv.ParseFloat(query.Get("lat"), 64)
+			lon, err2 = strconv.ParseFloat(query.Get("lon"), 64)
+			if err1 != nil || err2 != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+				sendJSONError(w, "Valid lat and lon are required", http.StatusBadRequest)
+				return
+			}
+		} else if district.Name != "" {
+			lat, lon = district.Latitude, district.Longitude
+			district = models.District{}
+		} else {
+			sendJSONError(w, "radius_km needs lat/lon or a district", http.StatusBadRequest)
+			return
+		}
+
+		latArg, lonArg := arg(lat), arg(lon)
+		distance = fmt.Sprintf(`(6371 * 2 * ASIN(SQRT(POWER(SIN(RADIANS(latitude - %[1]s) / 2), 2) +
+			COS(RADIANS(%[1]s)) * COS(RADIANS(latitude)) * POWER(SIN(RADIANS(longitude - %[2]s) / 2), 2))))`, latArg, lonArg)
+		order = "distance_km, name"
+
+		// Bounding box first so the location index narrows the scan
+		latSpan := radius / 111.0
+		lonSpan := radius / (111.0 * math.Max(math.Cos(lat*math.Pi/180), 0.01))
+		conds = append(conds,
+			"latitude BETWEEN "+arg(lat-latSpan)+" AND "+arg(lat+latSpan),
+			"longitude BETWEEN "+arg(lon-lonSpan)+" AND "+arg(lon+lonSpan),
+			distance+" <= "+arg(radius))
+	}
+
+	if district.Name != "" {
+		conds = append(conds, "district_name = "+arg(district.Name))
+	}
+
+	where := ""
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+	rows, err := config.DB.Query(fmt.Sprintf(`
+		SELECT %s, %s AS distance_km
+		FROM pois
+		%s
+		ORDER BY %s
+		LIMIT %d
+	`, poiColumns, distance, where, order, limit), args...)
+	if err != nil {
+		sendJSONError(w, "Error searching places", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	pois := []models.POI{}
+	for rows.Next() {
+		var km sql.NullFloat64
+		p, err := scanPOI(rows, &km)
+		if err != nil {
+			continue
+		}
+		if km.Valid {
+			rounded := math.Round(km.Float64*10) / 10
+			p.DistanceKm = &rounded
+		}
+		pois = append(pois, p)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pois)
+}
+
+// LoadPlaceCatalog reads every POI for itinerary grounding, along with trip
+// feedback from the same district that mentions the place by name
+func LoadPlaceCatalog() ([]utils.CatalogPlace, error) {
+	rows, err := config.DB.Query(`SELECT ` + poiColumns + ` FROM pois ORDER BY poi_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	places := []utils.CatalogPlace{}
+	index := make(map[int]int)
+	for rows.Next() {
+		p, err := scanPOI(rows)
+		if err != nil {
+			return nil, err
+		}
+		index[p.POIID] = len(places)
+		places = append(places, utils.CatalogPlace{POI: p})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	feedbackRows, err := config.DB.Query(`
+		SELECT p.poi_id, f.feedback_text, COALESCE(f.hotel_feedback, '')
+		FROM pois p
+		JOIN feedbacks f ON f.feedback_type = 'trip_plan' AND f.district_name = p.district_name
+		WHERE f.feedback_text ILIKE '%' || p.name || '%'
+		   OR f.hotel_name ILIKE p.name
+		   OR f.hotel_feedback ILIKE '%' || p.name || '%'
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer feedbackRows.Close()
+	for feedbackRows.Next() {
+		var poiID int
+		var text, hotelText string
+		if err := feedbackRows.Scan(&poiID, &text, &hotelText); err != nil {
+			continue
+		}
+		i, ok := index[poiID]
+		if !ok {
+			continue
+		}
+		for _, t := range []string{text, hotelText} {
+			if t != "" && t != "No additional comments" {
+				places[i].Feedback = append(places[i].Feedback, t)
+			}
+		}
+	}
+	return places, feedbackRows.Err()
+}
+
+// splitList splits a comma-separated query value into lowercased entries
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// GetPOI returns one catalog entry (public endpoint)
+func GetPOI(w http.ResponseWriter, r *http.Request) {
+	poiID, err := strconv.Atoi(mux.Vars(r)["poiid"])
+	if err != nil {
+		sendJSONError(w, "Invalid place ID", http.StatusBadRequest)
+		return
+	}
+
+	p, err := scanPOI(config.DB.QueryRow(`SELECT `+poiColumns+` FROM pois WHERE poi_id = $1`, poiID))
+	if err == sql.ErrNoRows {
+		sendJSONError(w, "Place not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendJSONError(w, "Error fetching place", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(p)
+}
+
+// AdminCreatePOI adds a catalog entry
+func AdminCreatePOI(w http.ResponseWriter, r *http.Request) {
+	var p models.POI
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		sendJSONError(w, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+	if err := utils.NormalizePOI(&p); err != nil {
+		sendJSONError(w, "Invalid place: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hours, _ := json.Marshal(p.OpeningHours)
+	err := config.DB.QueryRow(`
+		INSERT INTO pois (district_name, name, category, description, latitude, longitude, opening_hours,
+						  visit_duration_minutes, entry_fee_min, entry_fee_max, accessibility, tags)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING poi_id, created_at, updated_at
+	`, p.DistrictName, p.Name, p.Category, p.Description, p.Latitude, p.Longitude, hours,
+		p.VisitDurationMinutes, p.EntryFeeMin, p.EntryFeeMax, pq.Array(p.Accessibility), pq.Array(p.Tags)).Scan(&p.POIID, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			sendJSONError(w, "A place with this name already exists in the district", http.StatusConflict)
+			return
+		}
+		sendJSONError(w, "Error creating place", http.StatusInternalServerError)
+		return
+	}
+
+	utils.InvalidatePlaceCatalog()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(p)
+}
+
+// AdminUpdatePOI replaces a catalog entry
+func AdminUpdatePOI(w http.ResponseWriter, r *http.Request) {
+	poiID, err := strconv.Atoi(mux.Vars(r)["poiid"])
+	if err != nil {
+		sendJSONError(w, "Invalid place ID", http.StatusBadRequest)
+		return
+	}
+
+	var p models.POI
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		sendJSONError(w, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+	if err := utils.NormalizePOI(&p); err != nil {
+		sendJSONError(w, "Invalid place: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hours, _ := json.Marshal(p.OpeningHours)
+	result, err := config.DB.Exec(`
+		UPDATE pois
+		SET district_name = $1, name = $2, category = $3, description = NULLIF($4, ''), latitude = $5, longitude = $6,
+			opening_hours = $7, visit_duration_minutes = $8, entry_fee_min = $9, entry_fee_max = $10,
+			accessibility = $11, tags = $12, updated_at = CURRENT_TIMESTAMP
+		WHERE poi_id = $13
+	`, p.DistrictName, p.Name, p.Category, p.Description, p.Latitude, p.Longitude, hours,
+		p.VisitDurationMinutes, p.EntryFeeMin, p.EntryFeeMax, pq.Array(p.Accessibility), pq.Array(p.Tags), poiID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			sendJSONError(w, "A place with this name already exists in the district", http.StatusConflict)
+			return
+		}
+		sendJSONError(w, "Error updating place", http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		sendJSONError(w, "Place not found", http.StatusNotFound)
+		return
+	}
+
+	utils.InvalidatePlaceCatalog()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Place updated successfully",
+	})
+}
+
+// AdminDeletePOI removes a catalog entry
+func AdminDeletePOI(w http.ResponseWriter, r *http.Request) {
+	poiID, err := strconv.Atoi(mux.Vars(r)["poiid"])
+	if err != nil {
+		sendJSONError(w, "Invalid place ID", http.StatusBadRequest)
+		return
+	}
+
+	result, err := config.DB.Exec(`DELETE FROM pois WHERE poi_id = $1`, poiID)
+	if err != nil {
+		sendJSONError(w, "Error deleting place", http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		sendJSONError(w, "Place not found", http.StatusNotFound)
+		return
+	}
+
+	utils.InvalidatePlaceCatalog()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Place deleted successfully",
+	})
+}
+
+// AdminImportPOIs upserts places from an uploaded CSV or GeoJSON "file", or
+// from the bundled seed data with ?source=bundled. Places are matched on
+// district and name; invalid rows are reported and skipped.
+func AdminImportPOIs(w http.ResponseWriter, r *http.Request) {
+	var pois []models.POI
+	var rowErrors []utils.POIImportError
+	var err error
+
+	if r.URL.Query().Get("source") == "bundled" {
+		pois, rowErrors, err = utils.BundledPOIs()
+	} else {
+		if !parseUploadForm(w, r) {
+			return
+		}
+		file, header, ferr := r.FormFile("file")
+		if ferr != nil {
+			sendJSONError(w, "A CSV or GeoJSON file is required", http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		pois, rowErrors, err = utils.ParsePOIFile(header.Filename, file)
+	}
+	if err != nil {
+		sendJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	inserted, updated, err := UpsertPOIs(pois)
+	if err != nil {
+		sendJSONError(w, "Error importing places", http.StatusInternalServerError)
+		return
+	}
+	utils.InvalidatePlaceCatalog()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"inserted": inserted,
+		"updated":  updated,
+		"errors":   rowErrors,
+	})
+}
+
+// UpsertPOIs writes validated places in one transaction, matching existing
+// rows on district and name
+func UpsertPOIs(pois []models.POI) (inserted, updated int, err error) {
+	tx, err := config.DB.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO pois (district_name, name, category, description, latitude, longitude, opening_hours,
+						  visit_duration_minutes, entry_fee_min, entry_fee_max, accessibility, tags)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (district_name, name) DO UPDATE
+		SET category = EXCLUDED.category, description = EXCLUDED.description, latitude = EXCLUDED.latitude,
+			longitude = EXCLUDED.longitude, opening_hours = EXCLUDED.opening_hours,
+			visit_duration_minutes = EXCLUDED.visit_duration_minutes, entry_fee_min = EXCLUDED.entry_fee_min,
+			entry_fee_max = EXCLUDED.entry_fee_max, accessibility = EXCLUDED.accessibility,
+			tags = EXCLUDED.tags, updated_at = CURRENT_TIMESTAMP
+		RETURNING (xmax = 0)
+	`)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer stmt.Close()
+
+	for _, p := range pois {
+		hours, _ := json.Marshal(p.OpeningHours)
+		var isNew bool
+		if err := stmt.QueryRow(p.DistrictName, p.Name, p.Category, p.Description, p.Latitude, p.Longitude, hours,
+			p.VisitDurationMinutes, p.EntryFeeMin, p.EntryFeeMax, pq.Array(p.Accessibility), pq.Array(p.Tags)).Scan(&isNew); err != nil {
+			return 0, 0, err
+		}
+		if isNew {
+			inserted++
+		} else {
+			updated++
+		}
+	}
+	return inserted, updated, tx.Commit()
+}
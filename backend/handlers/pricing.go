@@ -0,0 +1,543 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"trip-planner-backend/config"
+	"trip-planner-backend/models"
+	"trip-planner-backend/utils"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	errPromoInvalid    = errors.New("promo code does not exist or does not apply")
+	errPromoNotStarted = errors.New("promo code is not valid yet")
+	errPromoExpired    = errors.New("promo code has expired")
+	errPromoExhausted  = errors.New("promo code has been used up")
+)
+
+var promoCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{4,30}$`)
+
+const promoColumns = `
+	promo_id, code, agency_id, package_id, discount_type, discount_value, max_discount, usage_limit,
+	used_count, starts_at, expires_at, is_active, created_by, created_at`
+
+// parsePricingRules reads the pricing_rules form value, a JSON object. An
+// empty value clears the rules. It returns the normalized rules and the
+// JSONB value to store (NULL for no rules).
+func parsePricingRules(value string) (*models.PricingRules, sql.NullString, string) {
+	if value == "" || value == "null" {
+		return nil, sql.NullString{}, ""
+	}
+	var rules models.PricingRules
+	if err := json.Unmarshal([]byte(value), &rules); err != nil {
+		return nil, sql.NullString{}, "Invalid pricing_rules format"
+	}
+	rules, err := utils.NormalizePricingRules(rules)
+	if err != nil {
+		return nil, sql.NullString{}, "Invalid pricing rules: " + err.Error()
+	}
+	stored, err := json.Marshal(rules)
+	if err != nil {
+		return nil, sql.NullString{}, "Invalid pricing_rules format"
+	}
+	return &rules, sql.NullString{String: string(stored), Valid: true}, ""
+}
+
+// decodePricingRules reads stored rules; NULL gives nil
+func decodePricingRules(stored []byte) *models.PricingRules {
+	if len(stored) == 0 {
+		return nil
+	}
+	var rules models.PricingRules
+	if err := json.Unmarshal(stored, &rules); err != nil {
+		log.Printf("Error decoding pricing rules: %v", err)
+		return nil
+	}
+	return &rules
+}
+
+// promoErrorMessage is the client-facing message for a promo error, or ""
+// for anything else
+func promoErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, errPromoInvalid):
+		return "This promo code is not valid for this package"
+	case errors.Is(err, errPromoNotStarted):
+		return "This promo code is not active yet"
+	case errors.Is(err, errPromoExpired):
+		return "This promo code has expired"
+	case errors.Is(err, errPromoExhausted):
+		return "This promo code has reached its usage limit"
+	}
+	return ""
+}
+
+func scanPromoCode(row rowScanner) (models.PromoCode, error) {
+	var p models.PromoCode
+	var agencyID, packageID, usageLimit sql.NullInt64
+	var maxDiscount sql.NullFloat64
+	var startsAt sql.NullTime
+	err := row.Scan(&p.PromoID, &p.Code, &agencyID, &packageID, &p.DiscountType, &p.DiscountValue, &maxDiscount,
+		&usageLimit, &p.UsedCount, &startsAt, &p.ExpiresAt, &p.IsActive, &p.CreatedBy, &p.CreatedAt)
+	if err != nil {
+		return p, err
+	}
+	if agencyID.Valid {
+		id := int(agencyID.Int64)
+		p.AgencyID = &id
+	}
+	if packageID.Valid {
+		id := int(packageID.Int64)
+		p.PackageID = &id
+	}
+	if maxDiscount.Valid {
+		p.MaxDiscount = &maxDiscount.Float64
+	}
+	if usageLimit.Valid {
+		limit := int(usageLimit.Int64)
+		p.UsageLimit = &limit
+	}
+	if startsAt.Valid {
+		p.StartsAt = &startsAt.Time
+	}
+	return p, nil
+}
+
+// findPromoCode looks up code for a package of agencyID and checks that it
+// can be used now. Admin-issued codes apply to every agency. With lock
+// set the row stays locked for the rest of tx, so the usage check and the
+// increment in usePromoCode can't interleave with another booking.
+func findPromoCode(q interface {
+	QueryRow(string, ...interface{}) *sql.Row
+}, code string, packageID, agencyID int, lock bool) (*models.PromoCode, error) {
+	query := `SELECT ` + promoColumns + ` FROM promo_codes WHERE code = $1`
+	if lock {
+		query += ` FOR UPDATE`
+	}
+	promo, err := scanPromoCode(q.QueryRow(query, strings.ToUpper(strings.TrimSpace(code))))
+	if err == sql.ErrNoRows {
+		return nil, errPromoInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !promo.IsActive ||
+		(promo.AgencyID != nil && *promo.AgencyID != agencyID) ||
+		(promo.PackageID != nil && *promo.PackageID != packageID) {
+		return nil, errPromoInvalid
+	}
+	now := time.Now()
+	if promo.StartsAt != nil && now.Before(*promo.StartsAt) {
+		return nil, errPromoNotStarted
+	}
+	if now.After(promo.ExpiresAt) {
+		return nil, errPromoExpired
+	}
+	if promo.UsageLimit != nil && promo.UsedCount >= *promo.UsageLimit {
+		return nil, errPromoExhausted
+	}
+	return &promo, nil
+}
+
+// usePromoCode counts one use of a promo code locked by findPromoCode
+func usePromoCode(tx *sql.Tx, promoID int) error {
+	_, err := tx.Exec(`UPDATE promo_codes SET used_count = used_count + 1, updated_at = CURRENT_TIMESTAMP WHERE promo_id = $1`, promoID)
+	return err
+}
+
+// releasePromoCode gives a cancelled booking's promo use back
+func releasePromoCode(tx *sql.Tx, bookingID int) error {
+	_, err := tx.Exec(`
+		UPDATE promo_codes pc
+		SET used_count = GREATEST(pc.used_count - 1, 0), updated_at = CURRENT_TIMESTAMP
+		FROM bookings b
+		WHERE b.booking_id = $1 AND pc.promo_id = b.promo_code_id
+	`, bookingID)
+	return err
+}
+
+// GetPackageQuote itemizes the price of an active package for
+// ?departure_date=, a party of ?adults=, ?children= and ?seniors=, and an
+// optional ?promo_code=. A departure's price override on that date
+// replaces the package price.
+func GetPackageQuote(w http.ResponseWriter, r *http.Request) {
+	packageID, err := strconv.Atoi(mux.Vars(r)["packageid"])
+	if err != nil {
+		http.Error(w, "Invalid package ID", http.StatusBadRequest)
+		return
+	}
+	query := r.URL.Query()
+
+	departure, err := time.Parse("2006-01-02", query.Get("departure_date"))
+	if err != nil {
+		http.Error(w, "departure_date must be YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	var party models.PartySize
+	for _, field := range []struct {
+		name string
+		dest *int
+	}{{"adults", &party.Adults}, {"children", &party.Children}, {"seniors", &party.Seniors}} {
+		v := query.Get(field.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, field.name+" must be a non-negative number", http.StatusBadRequest)
+			return
+		}
+		*field.dest = n
+	}
+	if party.Total() == 0 {
+		party.Adults = 1
+	}
+	if party.Total() > maxBookingTravelers {
+		http.Error(w, "At most "+strconv.Itoa(maxBookingTravelers)+" travelers per booking", http.StatusBadRequest)
+		return
+	}
+
+	var agencyID, maxTravelers int
+	var price float64
+	var stored []byte
+	err = config.DB.QueryRow(`
+		SELECT p.agency_id, p.num_travelers, COALESCE(d.price_override, p.price), p.pricing_rules
+		FROM travel_packages p
+		LEFT JOIN package_departures d
+			ON d.package_id = p.package_id AND d.is_active = TRUE AND d.start_date = $2::date
+		WHERE p.package_id = $1 AND `+publicPackageCondition, packageID, departure.Format("2006-01-02")).Scan(&agencyID, &maxTravelers, &price, &stored)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Package not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading package %d for a quote: %v", packageID, err)
+		http.Error(w, "Failed to compute quote", http.StatusInternalServerError)
+		return
+	}
+	if party.Total() > maxTravelers {
+		http.Error(w, "This package takes at most "+strconv.Itoa(maxTravelers)+" travelers", http.StatusBadRequest)
+		return
+	}
+
+	var promo *models.PromoCode
+	if code := query.Get("promo_code"); code != "" {
+		promo, err = findPromoCode(config.DB, code, packageID, agencyID, false)
+		if msg := promoErrorMessage(err); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Printf("Error loading promo code: %v", err)
+			http.Error(w, "Failed to compute quote", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		PackageID int `json:"package_id"`
+		models.PriceQuote
+	}{packageID, utils.QuotePrice(price, decodePricingRules(stored), departure, party, promo)})
+}
+
+type promoCodeRequest struct {
+	Code          string   `json:"code"`
+	PackageID     *int     `json:"package_id"`
+	DiscountType  string   `json:"discount_type"`
+	DiscountValue float64  `json:"discount_value"`
+	MaxDiscount   *float64 `json:"max_discount"`
+	UsageLimit    *int     `json:"usage_limit"`
+	StartsAt      string   `json:"starts_at"`
+	ExpiresAt     string   `json:"expires_at"`
+}
+
+// parsePromoTime accepts RFC 3339 or YYYY-MM-DD; a bare date ending a
+// validity window covers the whole day
+func parsePromoTime(value string, endOfDay bool) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	return t, true
+}
+
+// validate normalizes the request and returns the validity window
+func (req *promoCodeRequest) validate() (startsAt *time.Time, expiresAt time.Time, msg string) {
+	req.Code = strings.ToUpper(strings.TrimSpace(req.Code))
+	if !promoCodePattern.MatchString(req.Code) {
+		return nil, expiresAt, "code must be 4 to 30 letters, digits, '-' or '_'"
+	}
+	switch req.DiscountType {
+	case models.PromoPercent:
+		if req.DiscountValue <= 0 || req.DiscountValue > 100 {
+			return nil, expiresAt, "A percent discount must be above 0 and at most 100"
+		}
+	case models.PromoFlat:
+		if req.DiscountValue <= 0 {
+			return nil, expiresAt, "A flat discount must be positive"
+		}
+		if req.MaxDiscount != nil {
+			return nil, expiresAt, "max_discount applies only to percent discounts"
+		}
+	default:
+		return nil, expiresAt, "discount_type must be percent or flat"
+	}
+	if req.MaxDiscount != nil && *req.MaxDiscount <= 0 {
+		return nil, expiresAt, "max_discount must be positive"
+	}
+	if req.UsageLimit != nil && *req.UsageLimit <= 0 {
+		return nil, expiresAt, "usage_limit must be positive"
+	}
+
+	expiresAt, ok := parsePromoTime(req.ExpiresAt, true)
+	if !ok {
+		return nil, expiresAt, "expires_at is required (YYYY-MM-DD or RFC 3339)"
+	}
+	if !expiresAt.After(time.Now()) {
+		return nil, expiresAt, "expires_at must be in the future"
+	}
+	if req.StartsAt != "" {
+		t, ok := parsePromoTime(req.StartsAt, false)
+		if !ok {
+			return nil, expiresAt, "starts_at must be YYYY-MM-DD or RFC 3339"
+		}
+		if !t.Before(expiresAt) {
+			return nil, expiresAt, "starts_at must be before expires_at"
+		}
+		startsAt = &t
+	}
+	return startsAt, expiresAt, ""
+}
+
+// promoCaller is the agency behind a promo request, or 0 for the admin.
+// ok is false when an agency request has no agency.
+func promoCaller(r *http.Request, by string) (agencyID int, ok bool) {
+	if by != "agency" {
+		return 0, true
+	}
+	agencyID, ok = r.Context().Value("agencyid").(int)
+	return agencyID, ok && agencyID != 0
+}
+
+func fetchPromoCodes(where string, args ...interface{}) ([]models.PromoCode, error) {
+	rows, err := config.DB.Query(`SELECT `+promoColumns+` FROM promo_codes WHERE `+where+` ORDER BY created_at DESC, promo_id DESC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promos := []models.PromoCode{}
+	for rows.Next() {
+		p, err := scanPromoCode(rows)
+		if err != nil {
+			return nil, err
+		}
+		promos = append(promos, p)
+	}
+	return promos, rows.Err()
+}
+
+// promoListAction lists the agency's own codes, or every code for the admin
+func promoListAction(by string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		agencyID, ok := promoCaller(r, by)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		where, args := "TRUE", []interface{}{}
+		if by == "agency" {
+			where, args = "agency_id = $1", []interface{}{agencyID}
+		}
+		promos, err := fetchPromoCodes(where, args...)
+		if err != nil {
+			log.Printf("Error listing promo codes: %v", err)
+			http.Error(w, "Failed to fetch promo codes", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(promos)
+	}
+}
+
+// promoCreateAction issues a code. Agency codes apply to the agency's
+// packages, admin codes to every package; either may be limited to one.
+func promoCreateAction(by string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		agencyID, ok := promoCaller(r, by)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req promoCodeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		startsAt, expiresAt, msg := req.validate()
+		if msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+		if req.PackageID != nil {
+			var owner int
+			err := config.DB.QueryRow(`SELECT agency_id FROM travel_packages WHERE package_id = $1`, *req.PackageID).Scan(&owner)
+			if err == sql.ErrNoRows || (err == nil && by == "agency" && owner != agencyID) {
+				http.Error(w, "Package not found", http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				log.Printf("Error checking promo package: %v", err)
+				http.Error(w, "Failed to create promo code", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		var owner *int
+		if by == "agency" {
+			owner = &agencyID
+		}
+		promo, err := scanPromoCode(config.DB.QueryRow(`
+			INSERT INTO promo_codes (code, agency_id, package_id, discount_type, discount_value, max_discount, usage_limit,
+				starts_at, expires_at, created_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			RETURNING `+promoColumns,
+			req.Code, owner, req.PackageID, req.DiscountType, req.DiscountValue, req.MaxDiscount, req.UsageLimit,
+			startsAt, expiresAt, by))
+		if err != nil {
+			if isUniqueViolation(err) {
+				http.Error(w, "A promo code with this code already exists", http.StatusConflict)
+				return
+			}
+			log.Printf("Error creating promo code: %v", err)
+			http.Error(w, "Failed to create promo code", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(promo)
+	}
+}
+
+// promoUpdateAction turns a code on or off, or changes its usage limit or
+// expiry. The limit can't drop below the uses already made.
+func promoUpdateAction(by string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		agencyID, ok := promoCaller(r, by)
+		if !ok {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		promoID, err := strconv.Atoi(mux.Vars(r)["promoid"])
+		if err != nil {
+			http.Error(w, "Invalid promo code ID", http.StatusBadRequest)
+			return
+		}
+
+		var req struct {
+			IsActive   *bool   `json:"is_active"`
+			UsageLimit *int    `json:"usage_limit"`
+			ExpiresAt  *string `json:"expires_at"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		var expiresAt *time.Time
+		if req.ExpiresAt != nil {
+			t, ok := parsePromoTime(*req.ExpiresAt, true)
+			if !ok {
+				http.Error(w, "expires_at must be YYYY-MM-DD or RFC 3339", http.StatusBadRequest)
+				return
+			}
+			expiresAt = &t
+		}
+		if req.UsageLimit != nil && *req.UsageLimit <= 0 {
+			http.Error(w, "usage_limit must be positive", http.StatusBadRequest)
+			return
+		}
+
+		where, args := "promo_id = $1", []interface{}{promoID}
+		if by == "agency" {
+			where, args = "promo_id = $1 AND agency_id = $2", append(args, agencyID)
+		}
+		tx, err := config.DB.Begin()
+		if err != nil {
+			http.Error(w, "Failed to update promo code", http.StatusInternalServerError)
+			return
+		}
+		defer tx.Rollback()
+
+		promo, err := scanPromoCode(tx.QueryRow(`SELECT `+promoColumns+` FROM promo_codes WHERE `+where+` FOR UPDATE`, args...))
+		if err == sql.ErrNoRows {
+			http.Error(w, "Promo code not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("Error loading promo code %d: %v", promoID, err)
+			http.Error(w, "Failed to update promo code", http.StatusInternalServerError)
+			return
+		}
+		if req.UsageLimit != nil && *req.UsageLimit < promo.UsedCount {
+			http.Error(w, "usage_limit can't be below the "+strconv.Itoa(promo.UsedCount)+" uses already made", http.StatusConflict)
+			return
+		}
+		if expiresAt != nil && promo.StartsAt != nil && !expiresAt.After(*promo.StartsAt) {
+			http.Error(w, "expires_at must be after starts_at", http.StatusBadRequest)
+			return
+		}
+
+		promo, err = scanPromoCode(tx.QueryRow(`
+			UPDATE promo_codes
+			SET is_active = COALESCE($1, is_active), usage_limit = COALESCE($2, usage_limit),
+				expires_at = COALESCE($3, expires_at), updated_at = CURRENT_TIMESTAMP
+			WHERE promo_id = $4
+			RETURNING `+promoColumns, req.IsActive, req.UsageLimit, expiresAt, promoID))
+		if err == nil {
+			err = tx.Commit()
+		}
+		if err != nil {
+			log.Printf("Error updating promo code %d: %v", promoID, err)
+			http.Error(w, "Failed to update promo code", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(promo)
+	}
+}
+
+var (
+	// GetAgencyPromoCodes lists the agency's promo codes
+	GetAgencyPromoCodes = promoListAction("agency")
+	// CreateAgencyPromoCode issues a code for the agency's packages
+	CreateAgencyPromoCode = promoCreateAction("agency")
+	// UpdateAgencyPromoCode changes one of the agency's codes
+	UpdateAgencyPromoCode = promoUpdateAction("agency")
+	// GetAdminPromoCodes lists every promo code
+	GetAdminPromoCodes = promoListAction("admin")
+	// CreateAdminPromoCode issues a code valid on every agency's packages
+	CreateAdminPromoCode = promoCreateAction("admin")
+	// UpdateAdminPromoCode changes any code
+	UpdateAdminPromoCode = promoUpdateAction("admin")
+)
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"trip-planner-backend/config"
+	"trip-planner-backend/models"
+	"trip-planner-backend/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// maxRouteStops bounds the stops per day so a request cannot ask for a huge
+// routing matrix, and maxRouteDays bounds how many matrices one request
+// can ask for
+const (
+	maxRouteStops = 15
+	maxRouteDays  = 30
+)
+
+type routeOptimizeRequest struct {
+	Destination    string   `json:"destination"`
+	StartDate      string   `json:"start_date"`
+	StartTime      string   `json:"start_time"`
+	StartName      string   `json:"start_name"`
+	StartLatitude  *float64 `json:"start_latitude"`
+	StartLongitude *float64 `json:"start_longitude"`
+	Days           []struct {
+		Day   int      `json:"day"`
+		Stops []string `json:"stops"`
+	} `json:"days"`
+}
+
+// OptimizeRoutes orders each requested day's stops. Stops are place names,
+// geocoded against the catalog near the destination.
+func OptimizeRoutes(w http.ResponseWriter, r *http.Request) {
+	var req routeOptimizeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	district, ok := utils.FindDistrict(req.Destination)
+	if !ok {
+		http.Error(w, "Unknown destination", http.StatusBadRequest)
+		return
+	}
+	if len(req.Days) == 0 {
+		http.Error(w, "At least one day is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Days) > maxRouteDays {
+		http.Error(w, "At most "+strconv.Itoa(maxRouteDays)+" days per request", http.StatusBadRequest)
+		return
+	}
+
+	opts := utils.RouteOptions{}
+	var startDate time.Time
+	if req.StartDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.StartDate)
+		if err != nil {
+			http.Error(w, "start_date must be YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		startDate = parsed
+	}
+	if req.StartTime != "" {
+		minutes, ok := utils.ParseClock(req.StartTime)
+		if !ok || minutes >= 24*60 {
+			http.Error(w, "start_time must be HH:MM", http.StatusBadRequest)
+			return
+		}
+		opts.StartMinutes = minutes
+	}
+	if req.StartLatitude != nil || req.StartLongitude != nil {
+		if req.StartLatitude == nil || req.StartLongitude == nil ||
+			*req.StartLatitude < -90 || *req.StartLatitude > 90 || *req.StartLongitude < -180 || *req.StartLongitude > 180 {
+			http.Error(w, "start_latitude and start_longitude must both be valid coordinates", http.StatusBadRequest)
+			return
+		}
+		name := strings.TrimSpace(req.StartName)
+		if name == "" {
+			name = "Start"
+		}
+		opts.Start = &models.RoutePoint{Name: name, Latitude: *req.StartLatitude, Longitude: *req.StartLongitude}
+	}
+
+	routes := []models.DayRoute{}
+	for i, day := range req.Days {
+		if day.Day <= 0 {
+			day.Day = i + 1
+		}
+		if len(day.Stops) > maxRouteStops {
+			http.Error(w, "At most "+strconv.Itoa(maxRouteStops)+" stops per day", http.StatusBadRequest)
+			return
+		}
+		dayOpts := opts
+		if !startDate.IsZero() {
+			dayOpts.Date = startDate.AddDate(0, 0, day.Day-1)
+		}
+		route, err := utils.OptimizeDayRoute(day.Day, district.Name, day.Stops, dayOpts)
+		if err != nil {
+			http.Error(w, "Error planning route", http.StatusInternalServerError)
+			return
+		}
+		routes = append(routes, route)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"destination": district.Name,
+		"days":        routes,
+	})
+}
+
+// GetTripRoutes plans day routes for a saved trip from the verified places
+// in its itinerary
+func GetTripRoutes(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userid").(int)
+	tripID, err := strconv.Atoi(mux.Vars(r)["tripid"])
+	if err != nil {
+		http.Error(w, "Invalid trip ID", http.StatusBadRequest)
+		return
+	}
+
+	tripReq, err := loadSavedTripRequest(userID, tripID)
+	if err != nil {
+		writeSavedTripError(w, err)
+		return
+	}
+
+	var groundingJSON []byte
+	if err := config.DB.QueryRow(`SELECT grounding FROM trips WHERE tripid = $1`, tripID).Scan(&groundingJSON); err != nil {
+		http.Error(w, "Error loading trip", http.StatusInternalServerError)
+		return
+	}
+	var grounding utils.ItineraryGrounding
+	if len(groundingJSON) > 0 {
+		if err := json.Unmarshal(groundingJSON, &grounding); err != nil {
+			http.Error(w, "Error loading trip", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	startDate, _ := time.Parse("2006-01-02", tripReq.StartDate)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"tripid":      tripID,
+		"destination": tripReq.FinalDestination,
+		"days":        utils.PlanTripRoutes(tripReq.FinalDestination, startDate, grounding.Items),
+	})
+}
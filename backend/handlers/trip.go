@@ -4,10 +4,8 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
-	"strings"
+	"time"
 	"trip-planner-backend/config"
 	"trip-planner-backend/models"
 	"trip-planner-backend/utils"
@@ -24,15 +22,39 @@ func GenerateTrip(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tripDetails, err := utils.GenerateTripWithGroq(tripReq)
+	// The prompt carries one weather line per trip day, so the window is
+	// checked before the model is called
+	start, err := time.Parse("2006-01-02", tripReq.StartDate)
+	if err != nil {
+		http.Error(w, "start_date must be YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	end, err := time.Parse("2006-01-02", tripReq.EndDate)
+	if err != nil || end.Before(start) {
+		http.Error(w, "end_date must be YYYY-MM-DD and not before start_date", http.StatusBadRequest)
+		return
+	}
+	if end.Sub(start) > maxTripWeatherDays*24*time.Hour {
+		http.Error(w, tripWindowMessage, http.StatusBadRequest)
+		return
+	}
+
+	tripDetails, grounding, err := utils.GenerateTripWithGroq(tripReq)
 	if err != nil {
 		http.Error(w, "Error generating trip: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	groundingJSON, _ := json.Marshal(grounding)
 
+	// Keep the request parameters so packing lists and weather alerts can be
+	// derived from the saved trip later
 	var tripID int
-	query := `INSERT INTO trips (tripdetails) VALUES ($1) RETURNING tripid`
-	err = config.DB.QueryRow(query, tripDetails).Scan(&tripID)
+	query := `INSERT INTO trips (tripdetails, initial_destination, final_destination, start_date, end_date, num_travelers, mood, grounding)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING tripid`
+	err = config.DB.QueryRow(query,
+		tripDetails, tripReq.InitialDestination, tripReq.FinalDestination,
+		parseDateOrNil(tripReq.StartDate), parseDateOrNil(tripReq.EndDate), tripReq.NumTravelers, tripReq.Mood, groundingJSON,
+	).Scan(&tripID)
 	if err != nil {
 		http.Error(w, "Error saving trip", http.StatusInternalServerError)
 		return
@@ -48,15 +70,25 @@ func GenerateTrip(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"tripid":      tripID,
 		"tripdetails": tripDetails,
+		"grounding":   grounding,
 		"message":     "Trip generated successfully",
 	})
 }
 
+// parseDateOrNil turns a YYYY-MM-DD string into a value for a nullable DATE column
+func parseDateOrNil(value string) interface{} {
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil
+	}
+	return date
+}
+
 func GetSavedTrips(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("userid").(int)
 
 	query := `
-		SELECT t.tripid, t.tripdetails, s.saved_at
+		SELECT t.tripid, t.tripdetails, t.grounding, s.saved_at
 		FROM trips t
 		INNER JOIN saved s ON t.tripid = s.tripid
 		WHERE s.userid = $1
@@ -73,9 +105,13 @@ func GetSavedTrips(w http.ResponseWriter, r *http.Request) {
 	var trips []models.SavedTripResponse
 	for rows.Next() {
 		var trip models.SavedTripResponse
-		if err := rows.Scan(&trip.TripID, &trip.TripDetails, &trip.SavedAt); err != nil {
+		var grounding []byte
+		if err := rows.Scan(&trip.TripID, &trip.TripDetails, &grounding, &trip.SavedAt); err != nil {
 			continue
 		}
+		if len(grounding) > 0 {
+			trip.Grounding = grounding
+		}
 		trips = append(trips, trip)
 	}
 
@@ -147,6 +183,12 @@ func DeleteSavedTrip(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// maxTripWeatherDays caps the date window of weather and packing lookups;
+// every day in it is a forecast or climate lookup
+const maxTripWeatherDays = 60
+
+const tripWindowMessage = "Trip window cannot exceed 60 days"
+
 // Add this function to handlers/trip.go
 
 func GetWeather(w http.ResponseWriter, r *http.Request) {
@@ -158,6 +200,34 @@ func GetWeather(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// With a trip window, answer with forecasts plus climate normals for the
+	// days beyond the forecast horizon
+	startDate := r.URL.Query().Get("start_date")
+	endDate := r.URL.Query().Get("end_date")
+	if startDate != "" {
+		start, err := time.Parse("2006-01-02", startDate)
+		if err != nil {
+			http.Error(w, "Invalid start_date", http.StatusBadRequest)
+			return
+		}
+		end := start
+		if endDate != "" {
+			end, err = time.Parse("2006-01-02", endDate)
+			if err != nil || end.Before(start) {
+				http.Error(w, "Invalid end_date", http.StatusBadRequest)
+				return
+			}
+		}
+		if end.Sub(start) > maxTripWeatherDays*24*time.Hour {
+			http.Error(w, tripWindowMessage, http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(utils.GetTripWeather(destination, start, end))
+		return
+	}
+
 	weather, err := utils.GetWeatherForecast(destination)
 	if err != nil {
 		log.Printf("Weather error: %v", err)
@@ -171,41 +241,6 @@ func GetWeather(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(weather)
 }
 
-// District name mapping from dropdown values to folder names
-var districtFolderMap = map[string]string{
-	"Bagalkot":                "Bagalkot",
-	"Ballari (Bellary)":       "Ballari",
-	"Belagavi (Belgaum)":      "Belagavi",
-	"Bengaluru Rural":         "Bengaluru Rural",
-	"Bengaluru Urban":         "Bengaluru Urban",
-	"Bidar":                   "Bidar",
-	"Chamarajanagar":          "Chamarajanagar",
-	"Chikkaballapur":          "Chikkaballapur",
-	"Chikkamagaluru":          "Chikkamagaluru",
-	"Chitradurga":             "Chitradurga",
-	"Dakshina Kannada":        "Dakshina Kannada",
-	"Davanagere":              "Davanagere",
-	"Dharwad":                 "Dharwad",
-	"Gadag":                   "Gadag",
-	"Hassan":                  "Hassan",
-	"Haveri":                  "Haveri",
-	"Kalaburagi (Gulbarga)":   "Kalaburagi",
-	"Kodagu (Coorg)":          "Kodagu",
-	"Kolar":                   "Kolar",
-	"Koppal":                  "Koppal",
-	"Mandya":                  "Mandya",
-	"Mysuru (Mysore)":         "Mysuru",
-	"Raichur":                 "Raichur",
-	"Ramanagara":              "Ramanagara",
-	"Shivamogga (Shimoga)":    "Shivamogga",
-	"Tumakuru (Tumkur)":       "Tumakuru",
-	"Udupi":                   "Udupi",
-	"Uttara Kannada (Karwar)": "Uttara Kannada",
-	"Vijayapura (Bijapur)":    "Vijayapura",
-	"Yadgir":                  "Yadgir",
-	"Vijayanagara":            "Vijayanagara",
-}
-
 func GetDistrictPhotos(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	district := vars["district"]
@@ -215,55 +250,27 @@ func GetDistrictPhotos(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Map the district name to folder name
-	folderName, exists := districtFolderMap[district]
-	if !exists {
-		// Try using the district name directly
-		folderName = district
-	}
-
-	districtPath := filepath.Join("uploads", "districts", folderName)
-
-	// Check if directory exists
-	if _, err := os.Stat(districtPath); os.IsNotExist(err) {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"photos":   []string{},
-			"district": district,
-		})
-		return
-	}
+	// Map the district name to its canonical name and folder. Places that
+	// aren't districts have no photos.
+	photos := []models.DistrictPhoto{}
+	canonical, folderName, ok := resolveDistrictFolder(district)
+	if ok {
+		var err error
+		photos, err = fetchDistrictPhotos(r.Context(), canonical)
+		if err != nil {
+			log.Printf("Error fetching district photos: %v", err)
+			photos = nil
+		}
 
-	// Read directory
-	files, err := os.ReadDir(districtPath)
-	if err != nil {
-		log.Printf("Error reading district folder: %v", err)
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"photos":   []string{},
-			"district": district,
-		})
-		return
+		// Districts nobody has curated yet fall back to the files in their folder
+		if len(photos) == 0 {
+			photos = legacyDistrictPhotos(r.Context(), canonical, folderName)
+		}
 	}
 
-	// Filter image files and limit to 5
-	var photos []string
-	imageExtensions := []string{".jpg", ".jpeg", ".png", ".gif", ".webp"}
-	for _, file := range files {
-		if file.IsDir() {
-			continue
-		}
-		ext := strings.ToLower(filepath.Ext(file.Name()))
-		for _, imgExt := range imageExtensions {
-			if ext == imgExt {
-				photos = append(photos, file.Name())
-				break
-			}
-		}
-		// Limit to 5 photos
-		if len(photos) >= 5 {
-			break
-		}
+	// Limit to 5 photos
+	if len(photos) > 5 {
+		photos = photos[:5]
 	}
 
 	w.Header().Set("Content-Type", "application/json")
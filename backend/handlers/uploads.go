@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"trip-planner-backend/utils"
+)
+
+// uploadError reports why one file in a multi-file upload was rejected
+type uploadError struct {
+	Filename string `json:"filename"`
+	Error    string `json:"error"`
+}
+
+// uploadLimits returns the configured upload limits. They are read lazily
+// so values from the .env file are picked up.
+func uploadLimits() utils.UploadLimits {
+	return utils.DefaultUploadLimits()
+}
+
+// parseUploadForm caps the request body at the per-request limit and parses
+// the multipart form. On failure it writes the error response itself.
+func parseUploadForm(w http.ResponseWriter, r *http.Request) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, uploadLimits().MaxRequestBytes)
+	if err := r.ParseMultipartForm(8 << 20); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			sendJSONError(w, fmt.Sprintf("Upload exceeds the %d MB request limit", uploadLimits().MaxRequestBytes>>20), http.StatusRequestEntityTooLarge)
+			return false
+		}
+		sendJSONError(w, "Error parsing form data: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// uploadDisplayName is the client's filename, reduced to its base name so it
+// is safe to echo back in error messages
+func uploadDisplayName(fileHeader *multipart.FileHeader) string {
+	name := filepath.Base(strings.ReplaceAll(fileHeader.Filename, "\\", "/"))
+	if name == "." || name == "/" {
+		return "upload"
+	}
+	return name
+}
+
+// validateImageUpload checks the extension allow-list and per-file size
+// limit before the file is read. Content is sniffed when it's processed.
+func validateImageUpload(fileHeader *multipart.FileHeader) error {
+	ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
+	if _, ok := utils.AllowedImageExtensions[ext]; !ok {
+		return fmt.Errorf("file type %q is not allowed; use JPEG, PNG, GIF or WebP", ext)
+	}
+	if fileHeader.Size > uploadLimits().MaxFileBytes {
+		return fmt.Errorf("file exceeds the %d MB limit", uploadLimits().MaxFileBytes>>20)
+	}
+	return nil
+}
+
+// newUploadKey generates a storage base name that reveals nothing of the
+// client's filename and can't be guessed from the upload time
+func newUploadKey(owner string) string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return fmt.Sprintf("%s_%d_%s", owner, time.Now().Unix(), hex.EncodeToString(buf))
+}
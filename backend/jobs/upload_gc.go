@@ -0,0 +1,193 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"trip-planner-backend/config"
+	"trip-planner-backend/storage"
+	"trip-planner-backend/utils"
+)
+
+// UploadGCConfig controls the orphaned upload cleanup
+type UploadGCConfig struct {
+	// GracePeriod protects files uploaded moments before their database
+	// row is written, and gives admins time to review a dry-run report
+	GracePeriod time.Duration
+	Interval    time.Duration
+	DryRun      bool
+}
+
+// UploadGCConfigFromEnv reads UPLOAD_GC_* overrides on top of defaults
+func UploadGCConfigFromEnv() UploadGCConfig {
+	cfg := UploadGCConfig{
+		GracePeriod: 72 * time.Hour,
+		Interval:    24 * time.Hour,
+		DryRun:      os.Getenv("UPLOAD_GC_DRY_RUN") == "true",
+	}
+
+	if hours, err := strconv.Atoi(os.Getenv("UPLOAD_GC_GRACE_HOURS")); err == nil && hours >= 0 {
+		cfg.GracePeriod = time.Duration(hours) * time.Hour
+	}
+	if hours, err := strconv.Atoi(os.Getenv("UPLOAD_GC_INTERVAL_HOURS")); err == nil && hours > 0 {
+		cfg.Interval = time.Duration(hours) * time.Hour
+	}
+
+	return cfg
+}
+
+// OrphanedUpload is a stored file no package or district photo refers to
+type OrphanedUpload struct {
+	Key     string    `json:"key"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modified_at"`
+	// InGracePeriod files are reported but not deleted yet
+	InGracePeriod bool `json:"in_grace_period"`
+	Deleted       bool `json:"deleted"`
+}
+
+// UploadGCReport summarises one reconciliation run
+type UploadGCReport struct {
+	DryRun           bool             `json:"dry_run"`
+	GracePeriodHours float64          `json:"grace_period_hours"`
+	Scanned          int              `json:"scanned"`
+	Referenced       int              `json:"referenced"`
+	Orphaned         []OrphanedUpload `json:"orphaned"`
+	Deleted          int              `json:"deleted"`
+	DeletedBytes     int64            `json:"deleted_bytes"`
+	Errors           []string         `json:"errors"`
+}
+
+// StartUploadGC runs the cleanup on every interval, starting one interval
+// after boot so a restart loop can't delete anything early
+func StartUploadGC(cfg UploadGCConfig) {
+	if os.Getenv("UPLOAD_GC_ENABLED") == "false" {
+		log.Println("Upload garbage collection disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(cfg.Interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			report, err := RunUploadGC(context.Background(), cfg)
+			if err != nil {
+				log.Printf("Upload GC failed: %v", err)
+				continue
+			}
+			if len(report.Orphaned) > 0 {
+				log.Printf("Upload GC: %d orphaned file(s), %d deleted (%d bytes), dry run: %v",
+					len(report.Orphaned), report.Deleted, report.DeletedBytes, report.DryRun)
+			}
+		}
+	}()
+}
+
+// Generated district upload names, e.g. district_1700000000_0a1b2c3d4e5f6a7b_large.jpg
+// Older uploads may also have .webp variants or a _jpeg tag before the size.
+// Other files in district folders are hand-copied legacy photos that are
+// shown without a database row, so they are never collected.
+var generatedDistrictUpload = regexp.MustCompile(`^district_\d+_[0-9a-f]+(_jpeg)?_(thumb|medium|large)\.(jpg|webp)$`)
+
+// RunUploadGC finds stored package and district files that no database row
+// refers to. Orphans older than the grace period are deleted unless DryRun.
+func RunUploadGC(ctx context.Context, cfg UploadGCConfig) (UploadGCReport, error) {
+	report := UploadGCReport{
+		DryRun:           cfg.DryRun,
+		GracePeriodHours: cfg.GracePeriod.Hours(),
+		Orphaned:         []OrphanedUpload{},
+		Errors:           []string{},
+	}
+
+	referenced, err := referencedUploadKeys()
+	if err != nil {
+		return report, err
+	}
+
+	store := storage.Default()
+	objects, err := store.List(ctx, "packages/")
+	if err != nil {
+		return report, err
+	}
+	districtObjects, err := store.List(ctx, "districts/")
+	if err != nil {
+		return report, err
+	}
+	for _, obj := range districtObjects {
+		name := obj.Key[strings.LastIndex(obj.Key, "/")+1:]
+		if generatedDistrictUpload.MatchString(name) {
+			objects = append(objects, obj)
+		}
+	}
+
+	cutoff := time.Now().Add(-cfg.GracePeriod)
+	for _, obj := range objects {
+		report.Scanned++
+		if referenced[obj.Key] {
+			report.Referenced++
+			continue
+		}
+
+		orphan := OrphanedUpload{Key: obj.Key, Size: obj.Size, ModTime: obj.ModTime, InGracePeriod: obj.ModTime.After(cutoff)}
+		if !cfg.DryRun && !orphan.InGracePeriod {
+			if err := store.Delete(ctx, obj.Key); err != nil {
+				report.Errors = append(report.Errors, obj.Key+": "+err.Error())
+			} else {
+				orphan.Deleted = true
+				report.Deleted++
+				report.DeletedBytes += obj.Size
+			}
+		}
+		report.Orphaned = append(report.Orphaned, orphan)
+	}
+
+	return report, nil
+}
+
+// referencedUploadKeys collects the storage keys of every file a package or
+// district photo still points at, including all generated variants
+func referencedUploadKeys() (map[string]bool, error) {
+	referenced := make(map[string]bool)
+	add := func(prefix, stored string) {
+		for _, name := range utils.PhotoFileNames(stored) {
+			referenced[storage.Key(prefix, name)] = true
+		}
+	}
+
+	// travel_packages.photos covers packages created before package_photos
+	rows, err := config.DB.Query(`
+		SELECT filename FROM package_photos
+		UNION
+		SELECT unnest(photos) FROM travel_packages WHERE photos IS NOT NULL
+	`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var filename string
+		if err := rows.Scan(&filename); err == nil && filename != "" {
+			add("packages", filename)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = config.DB.Query(`SELECT folder, filename FROM district_photos`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var folder, filename string
+		if err := rows.Scan(&folder, &filename); err == nil {
+			add(storage.Key("districts", folder), filename)
+		}
+	}
+	return referenced, rows.Err()
+}
@@ -0,0 +1,216 @@
+package jobs
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"trip-planner-backend/config"
+	"trip-planner-backend/utils"
+)
+
+// WeatherAlertConfig controls the severe-weather scan for upcoming trips
+type WeatherAlertConfig struct {
+	DaysAhead  int
+	Interval   time.Duration
+	Thresholds utils.SevereWeatherThresholds
+}
+
+// WeatherAlertConfigFromEnv reads WEATHER_ALERT_* overrides on top of defaults
+func WeatherAlertConfigFromEnv() WeatherAlertConfig {
+	cfg := WeatherAlertConfig{
+		DaysAhead:  5,
+		Interval:   6 * time.Hour,
+		Thresholds: utils.DefaultSevereWeatherThresholds,
+	}
+
+	if days, err := strconv.Atoi(os.Getenv("WEATHER_ALERT_DAYS_AHEAD")); err == nil && days > 0 {
+		cfg.DaysAhead = days
+	}
+	if hours, err := strconv.Atoi(os.Getenv("WEATHER_ALERT_INTERVAL_HOURS")); err == nil && hours > 0 {
+		cfg.Interval = time.Duration(hours) * time.Hour
+	}
+	if mm, err := strconv.ParseFloat(os.Getenv("WEATHER_ALERT_HEAVY_RAIN_MM"), 64); err == nil && mm > 0 {
+		cfg.Thresholds.HeavyRainMM = mm
+	}
+	if celsius, err := strconv.ParseFloat(os.Getenv("WEATHER_ALERT_HEAT_C"), 64); err == nil && celsius > 0 {
+		cfg.Thresholds.ExtremeHeatC = celsius
+	}
+
+	return cfg
+}
+
+// StartWeatherAlerts runs the scan immediately and then on every interval
+func StartWeatherAlerts(cfg WeatherAlertConfig) {
+	if os.Getenv("WEATHER_ALERTS_ENABLED") == "false" {
+		log.Println("Weather alerts disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(cfg.Interval)
+		defer ticker.Stop()
+		for {
+			sent, err := RunWeatherAlertScan(cfg)
+			if err != nil {
+				log.Printf("Weather alert scan failed: %v", err)
+			} else if sent > 0 {
+				log.Printf("Weather alert scan sent %d email(s)", sent)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+type upcomingTrip struct {
+	tripID      int
+	destination string
+	startDate   time.Time
+	endDate     time.Time
+	recipients  []alertRecipient
+}
+
+type alertRecipient struct {
+	email string
+	name  string
+}
+
+// RunWeatherAlertScan emails users whose saved trips are under way or start
+// within the next DaysAhead days and cross a severe-weather threshold. Each
+// trip is alerted at most once per forecast day. Returns the number of emails sent.
+func RunWeatherAlertScan(cfg WeatherAlertConfig) (int, error) {
+	rows, err := config.DB.Query(`
+		SELECT t.tripid, t.final_destination, t.start_date, COALESCE(t.end_date, t.start_date), u.email, u.firstname
+		FROM trips t
+		INNER JOIN saved s ON t.tripid = s.tripid
+		INNER JOIN users u ON s.userid = u.userid
+		WHERE t.final_destination IS NOT NULL
+		  AND t.start_date <= CURRENT_DATE + $1::int
+		  AND COALESCE(t.end_date, t.start_date) >= CURRENT_DATE
+		ORDER BY t.tripid
+	`, cfg.DaysAhead)
+	if err != nil {
+		return 0, err
+	}
+
+	trips := []*upcomingTrip{}
+	byID := make(map[int]*upcomingTrip)
+	for rows.Next() {
+		var trip upcomingTrip
+		var recipient alertRecipient
+		if err := rows.Scan(&trip.tripID, &trip.destination, &trip.startDate, &trip.endDate, &recipient.email, &recipient.name); err != nil {
+			continue
+		}
+		existing, ok := byID[trip.tripID]
+		if !ok {
+			existing = &trip
+			byID[trip.tripID] = existing
+			trips = append(trips, existing)
+		}
+		existing.recipients = append(existing.recipients, recipient)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, trip := range trips {
+		alerts := alertsForTrip(trip, cfg.Thresholds)
+		if len(alerts) == 0 {
+			continue
+		}
+
+		claimed, err := claimAlertDays(trip.tripID, alerts)
+		if err != nil {
+			log.Printf("Error recording weather alerts for trip %d: %v", trip.tripID, err)
+			continue
+		}
+		if len(claimed) == 0 {
+			continue
+		}
+
+		delivered := false
+		for _, recipient := range trip.recipients {
+			err := utils.SendWeatherAlertEmail(recipient.email, recipient.name, trip.destination, trip.startDate.Format("January 2, 2006"), claimed)
+			if err != nil {
+				log.Printf("Error sending weather alert to %s: %v", recipient.email, err)
+				continue
+			}
+			delivered = true
+			sent++
+		}
+
+		// Release the claim so the next scan retries if nobody got the email
+		if !delivered {
+			releaseAlertDays(trip.tripID, claimed)
+		}
+	}
+
+	return sent, nil
+}
+
+func alertsForTrip(trip *upcomingTrip, thresholds utils.SevereWeatherThresholds) []utils.SevereWeatherAlert {
+	weather, err := utils.GetWeatherForecast(trip.destination)
+	if err != nil {
+		log.Printf("Weather alert: forecast unavailable for %s: %v", trip.destination, err)
+		return nil
+	}
+
+	start := trip.startDate.Format("2006-01-02")
+	end := trip.endDate.Format("2006-01-02")
+
+	alerts := []utils.SevereWeatherAlert{}
+	for _, forecast := range weather.Forecasts {
+		if forecast.Date < start || forecast.Date > end {
+			continue
+		}
+		alerts = append(alerts, utils.DetectSevereWeather(forecast, thresholds)...)
+	}
+	return alerts
+}
+
+// claimAlertDays records the alert days for a trip and returns only the
+// alerts for days that had not been alerted before
+func claimAlertDays(tripID int, alerts []utils.SevereWeatherAlert) ([]utils.SevereWeatherAlert, error) {
+	kindsByDate := make(map[string][]string)
+	dates := []string{}
+	for _, alert := range alerts {
+		if _, ok := kindsByDate[alert.Date]; !ok {
+			dates = append(dates, alert.Date)
+		}
+		kindsByDate[alert.Date] = append(kindsByDate[alert.Date], alert.Kind)
+	}
+
+	claimedDates := make(map[string]bool)
+	for _, date := range dates {
+		result, err := config.DB.Exec(`
+			INSERT INTO weather_alerts (tripid, alert_date, alert_kinds)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (tripid, alert_date) DO NOTHING
+		`, tripID, date, strings.Join(kindsByDate[date], ","))
+		if err != nil {
+			return nil, err
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			claimedDates[date] = true
+		}
+	}
+
+	claimed := []utils.SevereWeatherAlert{}
+	for _, alert := range alerts {
+		if claimedDates[alert.Date] {
+			claimed = append(claimed, alert)
+		}
+	}
+	return claimed, nil
+}
+
+func releaseAlertDays(tripID int, alerts []utils.SevereWeatherAlert) {
+	for _, alert := range alerts {
+		if _, err := config.DB.Exec(`DELETE FROM weather_alerts WHERE tripid = $1 AND alert_date = $2`, tripID, alert.Date); err != nil {
+			log.Printf("Error releasing weather alert for trip %d: %v", tripID, err)
+		}
+	}
+}
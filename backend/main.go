@@ -4,10 +4,14 @@ import (
 	"log"
 	"net/http"
 	"os"
-	"path/filepath"
+	"time"
 	"trip-planner-backend/config"
 	"trip-planner-backend/handlers"
+	"trip-planner-backend/jobs"
 	"trip-planner-backend/middleware"
+	"trip-planner-backend/payments"
+	"trip-planner-backend/storage"
+	"trip-planner-backend/utils"
 
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
@@ -22,8 +26,26 @@ func main() {
 	config.InitDB()
 	defer config.CloseDB()
 
+	storage.Init()
+	payments.Init()
+
+	// District lookups and the itinerary place catalog read the database,
+	// refreshed every few minutes so edits made through another instance
+	// are picked up
+	utils.SetDistrictLoader(handlers.LoadDistricts, 5*time.Minute)
+	utils.SetPlaceCatalogLoader(handlers.LoadPlaceCatalog, 10*time.Minute)
+
+	jobs.StartWeatherAlerts(jobs.WeatherAlertConfigFromEnv())
+	jobs.StartUploadGC(jobs.UploadGCConfigFromEnv())
+
 	router := mux.NewRouter()
 
+	// Uploaded files are only served from here with the local storage
+	// driver; S3 links point straight at the bucket
+	if local, ok := storage.Default().(*storage.Local); ok {
+		router.PathPrefix(local.BaseURL + "/").Handler(local.Handler()).Methods("GET")
+	}
+
 	// Public routes
 	router.HandleFunc("/api/send-registration-otp", handlers.SendRegistrationOTP).Methods("POST")
 	router.HandleFunc("/api/verify-registration-otp", handlers.VerifyRegistrationOTP).Methods("POST")
@@ -39,20 +61,18 @@ func main() {
 	router.HandleFunc("/api/reset-password", handlers.ResetPassword).Methods("POST")
 	router.HandleFunc("/api/weather/{destination}", handlers.GetWeather).Methods("GET")
 	router.HandleFunc("/api/contact", handlers.SaveContactMessage).Methods("POST")
-	router.HandleFunc("/uploads/packages/{filename}", func(w http.ResponseWriter, r *http.Request) {
-		filename := mux.Vars(r)["filename"]
-		filePath := filepath.Join("uploads", "packages", filename)
-		http.ServeFile(w, r, filePath)
-	}).Methods("GET")
-	router.HandleFunc("/uploads/districts/{folder}/{filename}", func(w http.ResponseWriter, r *http.Request) {
-		vars := mux.Vars(r)
-		folder := vars["folder"]
-		filename := vars["filename"]
-		filePath := filepath.Join("uploads", "districts", folder, filename)
-		http.ServeFile(w, r, filePath)
-	}).Methods("GET")
+	router.HandleFunc("/api/districts", handlers.GetDistricts).Methods("GET")
+	router.HandleFunc("/api/districts/{district}", handlers.GetDistrict).Methods("GET")
+	router.HandleFunc("/api/pois", handlers.SearchPOIs).Methods("GET")
+	router.HandleFunc("/api/pois/{poiid:[0-9]+}", handlers.GetPOI).Methods("GET")
 	router.HandleFunc("/api/district-photos/{district}", handlers.GetDistrictPhotos).Methods("GET")
 	router.HandleFunc("/api/packages", handlers.GetPublicTravelPackages).Methods("GET")
+	router.HandleFunc("/api/packages/search", handlers.SearchTravelPackages).Methods("GET")
+	router.HandleFunc("/api/packages/{packageid:[0-9]+}", handlers.GetPublicTravelPackage).Methods("GET")
+	router.HandleFunc("/api/packages/{packageid:[0-9]+}/departures", handlers.GetPackageDepartures).Methods("GET")
+	router.HandleFunc("/api/packages/{packageid:[0-9]+}/refund-quote", handlers.GetPackageRefundQuote).Methods("GET")
+	router.HandleFunc("/api/packages/{packageid:[0-9]+}/quote", handlers.GetPackageQuote).Methods("GET")
+	router.HandleFunc("/api/payments/webhook", handlers.PaymentWebhook).Methods("POST")
 	router.HandleFunc("/api/agencies", handlers.GetAllAgencies).Methods("GET")
 	router.HandleFunc("/api/feedbacks/district", handlers.GetFeedbacksByDistrict).Methods("GET")
 	router.HandleFunc("/api/feedbacks/agency", handlers.GetPublicAgencyFeedbacks).Methods("GET")
@@ -66,6 +86,24 @@ func main() {
 	protected.HandleFunc("/saved-trips", handlers.GetSavedTrips).Methods("GET")
 	protected.HandleFunc("/save-trip", handlers.SaveTrip).Methods("POST")
 	protected.HandleFunc("/saved-trips/{tripid}", handlers.DeleteSavedTrip).Methods("DELETE")
+	protected.HandleFunc("/saved-trips/{tripid}/packing-list", handlers.GetTripPackingList).Methods("GET")
+	protected.HandleFunc("/saved-trips/{tripid}/packing-list", handlers.RegenerateTripPackingList).Methods("POST")
+	protected.HandleFunc("/saved-trips/{tripid}/packing-list/items/{itemid}", handlers.UpdatePackingItem).Methods("PUT")
+	protected.HandleFunc("/saved-trips/{tripid}/routes", handlers.GetTripRoutes).Methods("GET")
+	protected.HandleFunc("/packing-list", handlers.GeneratePackingList).Methods("POST")
+	protected.HandleFunc("/routes/optimize", handlers.OptimizeRoutes).Methods("POST")
+	protected.HandleFunc("/bookings", handlers.CreateBooking).Methods("POST")
+	protected.HandleFunc("/bookings", handlers.GetUserBookings).Methods("GET")
+	protected.HandleFunc("/bookings/{bookingid:[0-9]+}", handlers.GetUserBooking).Methods("GET")
+	protected.HandleFunc("/bookings/{bookingid:[0-9]+}/cancel", handlers.CancelBooking).Methods("POST")
+	protected.HandleFunc("/payments/orders", handlers.CreatePaymentOrder).Methods("POST")
+	protected.HandleFunc("/payments/verify", handlers.VerifyPayment).Methods("POST")
+	protected.HandleFunc("/payments", handlers.GetUserPayments).Methods("GET")
+	protected.HandleFunc("/payments/{paymentid:[0-9]+}", handlers.GetUserPayment).Methods("GET")
+	if payments.MockEnabled() {
+		// Development only: stands in for the gateway's hosted checkout
+		protected.HandleFunc("/payments/{paymentid:[0-9]+}/mock-checkout", handlers.MockCheckout).Methods("POST")
+	}
 	protected.HandleFunc("/feedback", handlers.SubmitFeedback).Methods("POST")
 	protected.HandleFunc("/feedbacks", handlers.GetUserFeedbacks).Methods("GET")
 	protected.HandleFunc("/feedback", handlers.DeleteFeedback).Methods("DELETE")
@@ -86,6 +124,35 @@ func main() {
 	admin.HandleFunc("/agencies/{agencyid}", handlers.AdminUpdateAgency).Methods("PUT")
 	admin.HandleFunc("/agencies/{agencyid}", handlers.AdminDeleteAgency).Methods("DELETE")
 	admin.HandleFunc("/agencies/{agencyid}/packages", handlers.GetAgencyPackagesAdmin).Methods("GET")
+	admin.HandleFunc("/agencies/{agencyid:[0-9]+}/verification", handlers.AdminGetAgencyVerification).Methods("GET")
+	admin.HandleFunc("/agencies/{agencyid:[0-9]+}/status", handlers.AdminSetAgencyStatus).Methods("PUT")
+	admin.HandleFunc("/agencies/{agencyid:[0-9]+}/documents/{documentid:[0-9]+}", handlers.AdminDownloadAgencyDocument).Methods("GET")
+	admin.HandleFunc("/districts", handlers.AdminCreateDistrict).Methods("POST")
+	admin.HandleFunc("/districts/{districtid:[0-9]+}", handlers.AdminUpdateDistrict).Methods("PUT")
+	admin.HandleFunc("/districts/{districtid:[0-9]+}", handlers.AdminDeleteDistrict).Methods("DELETE")
+	admin.HandleFunc("/districts/{district}/photos", handlers.AdminGetDistrictPhotos).Methods("GET")
+	admin.HandleFunc("/districts/{district}/photos", handlers.AdminUploadDistrictPhotos).Methods("POST")
+	admin.HandleFunc("/districts/{district}/photos/import", handlers.AdminImportDistrictPhotos).Methods("POST")
+	admin.HandleFunc("/districts/{district}/photos/order", handlers.AdminReorderDistrictPhotos).Methods("PUT")
+	admin.HandleFunc("/district-photos/{photoid}", handlers.AdminUpdateDistrictPhoto).Methods("PUT")
+	admin.HandleFunc("/district-photos/{photoid}/cover", handlers.AdminSetDistrictCoverPhoto).Methods("PUT")
+	admin.HandleFunc("/district-photos/{photoid}", handlers.AdminDeleteDistrictPhoto).Methods("DELETE")
+	admin.HandleFunc("/pois", handlers.AdminCreatePOI).Methods("POST")
+	admin.HandleFunc("/pois/import", handlers.AdminImportPOIs).Methods("POST")
+	admin.HandleFunc("/pois/{poiid:[0-9]+}", handlers.AdminUpdatePOI).Methods("PUT")
+	admin.HandleFunc("/pois/{poiid:[0-9]+}", handlers.AdminDeletePOI).Methods("DELETE")
+	admin.HandleFunc("/uploads/gc", handlers.AdminUploadGC).Methods("GET", "POST")
+	admin.HandleFunc("/payments/{paymentid:[0-9]+}/refund", handlers.AdminRefundPayment).Methods("POST")
+	admin.HandleFunc("/promo-codes", handlers.GetAdminPromoCodes).Methods("GET")
+	admin.HandleFunc("/promo-codes", handlers.CreateAdminPromoCode).Methods("POST")
+	admin.HandleFunc("/promo-codes/{promoid:[0-9]+}", handlers.UpdateAdminPromoCode).Methods("PUT")
+	admin.HandleFunc("/moderation/packages", handlers.AdminGetModerationQueue).Methods("GET")
+	admin.HandleFunc("/moderation/packages/{packageid:[0-9]+}", handlers.AdminGetModerationPackage).Methods("GET")
+	admin.HandleFunc("/moderation/packages/{packageid:[0-9]+}", handlers.AdminModeratePackage).Methods("PUT")
+	admin.HandleFunc("/moderation/packages/{packageid:[0-9]+}/recheck", handlers.AdminRecheckPackage).Methods("POST")
+	admin.HandleFunc("/moderation/banned-words", handlers.AdminGetBannedWords).Methods("GET")
+	admin.HandleFunc("/moderation/banned-words", handlers.AdminAddBannedWords).Methods("POST")
+	admin.HandleFunc("/moderation/banned-words/{word}", handlers.AdminDeleteBannedWord).Methods("DELETE")
 
 	// Agency routes (protected)
 	agency := router.PathPrefix("/api/agency").Subrouter()
@@ -95,6 +162,36 @@ func main() {
 	agency.HandleFunc("/packages", handlers.CreateTravelPackage).Methods("POST")
 	agency.HandleFunc("/packages/{packageid}", handlers.UpdateTravelPackage).Methods("PUT")
 	agency.HandleFunc("/packages/{packageid}", handlers.DeleteTravelPackage).Methods("DELETE")
+	agency.HandleFunc("/packages/{packageid}/photos", handlers.GetPackagePhotos).Methods("GET")
+	agency.HandleFunc("/packages/{packageid}/photos", handlers.AddPackagePhotos).Methods("POST")
+	agency.HandleFunc("/packages/{packageid}/photos/order", handlers.ReorderPackagePhotos).Methods("PUT")
+	agency.HandleFunc("/packages/{packageid}/photos/{photoid}", handlers.DeletePackagePhoto).Methods("DELETE")
+	agency.HandleFunc("/packages/{packageid}/photos/{photoid}/cover", handlers.SetPackageCoverPhoto).Methods("PUT")
+	agency.HandleFunc("/packages/{packageid}/departures", handlers.GetAgencyDepartures).Methods("GET")
+	agency.HandleFunc("/packages/{packageid}/departures", handlers.CreateDeparture).Methods("POST")
+	agency.HandleFunc("/packages/{packageid}/departures/{departureid:[0-9]+}", handlers.UpdateDeparture).Methods("PUT")
+	agency.HandleFunc("/packages/{packageid}/departures/{departureid:[0-9]+}", handlers.DeleteDeparture).Methods("DELETE")
+	agency.HandleFunc("/bookings", handlers.GetAgencyBookings).Methods("GET")
+	agency.HandleFunc("/bookings/{bookingid:[0-9]+}/accept", handlers.AcceptBooking).Methods("PUT")
+	agency.HandleFunc("/bookings/{bookingid:[0-9]+}/reject", handlers.RejectBooking).Methods("PUT")
+	agency.HandleFunc("/bookings/{bookingid:[0-9]+}/complete", handlers.CompleteBooking).Methods("PUT")
+	agency.HandleFunc("/payments", handlers.GetAgencyPayments).Methods("GET")
+	agency.HandleFunc("/payments/{paymentid:[0-9]+}/refund", handlers.AgencyRefundPayment).Methods("POST")
+	agency.HandleFunc("/promo-codes", handlers.GetAgencyPromoCodes).Methods("GET")
+	agency.HandleFunc("/promo-codes", handlers.CreateAgencyPromoCode).Methods("POST")
+	agency.HandleFunc("/promo-codes/{promoid:[0-9]+}", handlers.UpdateAgencyPromoCode).Methods("PUT")
+	agency.HandleFunc("/billing-profile", handlers.GetAgencyBillingProfile).Methods("GET")
+	agency.HandleFunc("/billing-profile", handlers.UpdateAgencyBillingProfile).Methods("PUT")
+	agency.HandleFunc("/billing-documents", handlers.GetBillingDocuments).Methods("GET")
+	agency.HandleFunc("/billing-documents", handlers.CreateBillingDocument).Methods("POST")
+	agency.HandleFunc("/billing-documents/{documentid:[0-9]+}", handlers.GetBillingDocument).Methods("GET")
+	agency.HandleFunc("/billing-documents/{documentid:[0-9]+}/pdf", handlers.DownloadBillingDocument).Methods("GET")
+	agency.HandleFunc("/billing-documents/{documentid:[0-9]+}/email", handlers.EmailBillingDocument).Methods("POST")
+	agency.HandleFunc("/verification", handlers.GetAgencyVerification).Methods("GET")
+	agency.HandleFunc("/verification/documents", handlers.UploadAgencyDocument).Methods("POST")
+	agency.HandleFunc("/verification/documents/{documentid:[0-9]+}", handlers.DownloadAgencyDocument).Methods("GET")
+	agency.HandleFunc("/verification/documents/{documentid:[0-9]+}", handlers.DeleteAgencyDocument).Methods("DELETE")
+	agency.HandleFunc("/verification/submit", handlers.SubmitAgencyVerification).Methods("POST")
 	agency.HandleFunc("/feedbacks", handlers.GetAgencyFeedbacks).Methods("GET")
 
 	c := cors.New(cors.Options{
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Agency statuses. A new agency is pending until an admin approves or
+// rejects it; approved agencies can be suspended and later reinstated.
+// Only approved agencies' packages are shown to travelers.
+const (
+	AgencyPending   = "pending"
+	AgencyApproved  = "approved"
+	AgencySuspended = "suspended"
+	AgencyRejected  = "rejected"
+)
+
+// KYC document types
+const (
+	AgencyDocRegistration = "registration_certificate"
+	AgencyDocGST          = "gst_certificate"
+	AgencyDocOther        = "other"
+)
+
+// AgencyDocument is an uploaded KYC file. The file itself is only served
+// through the API.
+type AgencyDocument struct {
+	DocumentID       int       `json:"document_id"`
+	AgencyID         int       `json:"agency_id"`
+	DocumentType     string    `json:"document_type"`
+	OriginalFilename string    `json:"original_filename"`
+	ContentType      string    `json:"content_type"`
+	SizeBytes        int64     `json:"size_bytes"`
+	UploadedAt       time.Time `json:"uploaded_at"`
+}
+
+// AgencyReview is one status change. ReviewedBy is "admin", or "agency"
+// when the agency resubmitted.
+type AgencyReview struct {
+	ReviewID   int       `json:"review_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Notes      string    `json:"notes,omitempty"`
+	ReviewedBy string    `json:"reviewed_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// AgencyVerification is an agency's review state with its documents and
+// history
+type AgencyVerification struct {
+	AgencyID      int              `json:"agency_id"`
+	Name          string           `json:"name"`
+	Email         string           `json:"email"`
+	Phone         string           `json:"phone,omitempty"`
+	Website       string           `json:"website,omitempty"`
+	GSTIN         string           `json:"gstin,omitempty"`
+	Status        string           `json:"status"`
+	ReviewerNotes string           `json:"reviewer_notes,omitempty"`
+	SubmittedAt   *time.Time       `json:"submitted_at,omitempty"`
+	ReviewedAt    *time.Time       `json:"reviewed_at,omitempty"`
+	CreatedAt     time.Time        `json:"created_at"`
+	Documents     []AgencyDocument `json:"documents"`
+	Reviews       []AgencyReview   `json:"reviews"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// Billing document types
+const (
+	DocumentQuotation = "quotation"
+	DocumentInvoice   = "invoice"
+)
+
+// BillingParty is the supplier or customer printed on a document. StateCode
+// is the two-digit GST state code.
+type BillingParty struct {
+	Name      string `json:"name"`
+	Email     string `json:"email,omitempty"`
+	Phone     string `json:"phone,omitempty"`
+	Address   string `json:"address,omitempty"`
+	GSTIN     string `json:"gstin,omitempty"`
+	StateCode string `json:"state_code,omitempty"`
+	State     string `json:"state,omitempty"`
+}
+
+// TaxSplit is GST on an amount. Intra-state supplies split the tax into
+// CGST and SGST; inter-state supplies pay IGST.
+type TaxSplit struct {
+	Rate         float64 `json:"rate"`
+	IntraState   bool    `json:"intra_state"`
+	TaxableValue float64 `json:"taxable_value"`
+	CGST         float64 `json:"cgst"`
+	SGST         float64 `json:"sgst"`
+	IGST         float64 `json:"igst"`
+	TotalTax     float64 `json:"total_tax"`
+	Total        float64 `json:"total"`
+}
+
+// BillingDocument is a numbered quotation or invoice. Everything printed
+// on it is a snapshot taken when it was issued.
+type BillingDocument struct {
+	DocumentID       int          `json:"document_id"`
+	AgencyID         int          `json:"agency_id"`
+	PackageID        *int         `json:"package_id"`
+	PackageTitle     string       `json:"package_title"`
+	DocumentType     string       `json:"document_type"`
+	DocumentNumber   string       `json:"document_number"`
+	IssueDate        string       `json:"issue_date"`
+	ValidUntil       string       `json:"valid_until,omitempty"`
+	Supplier         BillingParty `json:"supplier"`
+	Customer         BillingParty `json:"customer"`
+	PlaceOfSupply    string       `json:"place_of_supply"`
+	SACCode          string       `json:"sac_code"`
+	PricesIncludeTax bool         `json:"prices_include_tax"`
+	Quote            PriceQuote   `json:"quote"`
+	Tax              TaxSplit     `json:"tax"`
+	Notes            string       `json:"notes,omitempty"`
+	EmailedAt        *time.Time   `json:"emailed_at,omitempty"`
+	CreatedAt        time.Time    `json:"created_at"`
+}
+
+// AgencyBillingProfile is what an agency prints on its documents
+type AgencyBillingProfile struct {
+	GSTIN            string `json:"gstin"`
+	BillingAddress   string `json:"billing_address"`
+	BillingStateCode string `json:"billing_state_code"`
+	BillingState     string `json:"billing_state,omitempty"`
+}
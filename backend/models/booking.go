@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Booking statuses. A booking starts as requested; the agency confirms or
+// rejects it (rejection cancels it), and a confirmed trip is completed
+// after departure. Users may cancel until completion.
+const (
+	BookingRequested = "requested"
+	BookingConfirmed = "confirmed"
+	BookingCompleted = "completed"
+	BookingCancelled = "cancelled"
+)
+
+type BookingTraveler struct {
+	FullName string `json:"full_name"`
+	Age      int    `json:"age"`
+	Gender   string `json:"gender,omitempty"`
+}
+
+// Booking is a user's request for a package departure. Prices are a
+// snapshot taken when the booking was made.
+type Booking struct {
+	BookingID          int               `json:"booking_id"`
+	UserID             int               `json:"user_id"`
+	AgencyID           int               `json:"agency_id"`
+	AgencyName         string            `json:"agency_name,omitempty"`
+	PackageID          *int              `json:"package_id"`
+	PackageTitle       string            `json:"package_title"`
+	DepartureID        *int              `json:"departure_id,omitempty"`
+	DepartureDate      string            `json:"departure_date"`
+	NumTravelers       int               `json:"num_travelers"`
+	UnitPrice          float64           `json:"unit_price"`
+	TotalPrice         float64           `json:"total_price"`
+	DiscountTotal      float64           `json:"discount_total"`
+	PriceBreakdown     *PriceQuote       `json:"price_breakdown,omitempty"`
+	Currency           string            `json:"currency"`
+	ContactName        string            `json:"contact_name"`
+	ContactEmail       string            `json:"contact_email"`
+	ContactPhone       string            `json:"contact_phone"`
+	SpecialRequests    string            `json:"special_requests,omitempty"`
+	Status             string            `json:"status"`
+	CancelledBy        string            `json:"cancelled_by,omitempty"`
+	CancellationReason string            `json:"cancellation_reason,omitempty"`
+	Travelers          []BookingTraveler `json:"travelers"`
+	ConfirmedAt        *time.Time        `json:"confirmed_at,omitempty"`
+	CompletedAt        *time.Time        `json:"completed_at,omitempty"`
+	CancelledAt        *time.Time        `json:"cancelled_at,omitempty"`
+	CreatedAt          time.Time         `json:"created_at"`
+	UpdatedAt          time.Time         `json:"updated_at"`
+}
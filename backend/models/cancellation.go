@@ -0,0 +1,19 @@
+package models
+
+// CancellationTier refunds RefundPercent of the price when a booking is
+// cancelled at least MinDaysBefore days before departure
+type CancellationTier struct {
+	MinDaysBefore int     `json:"min_days_before"`
+	RefundPercent float64 `json:"refund_percent"`
+}
+
+// RefundQuote is the refund a policy gives for one cancellation
+type RefundQuote struct {
+	Price               float64           `json:"price"`
+	DepartureDate       string            `json:"departure_date"`
+	CancellationDate    string            `json:"cancellation_date"`
+	DaysBeforeDeparture int               `json:"days_before_departure"`
+	RefundPercent       float64           `json:"refund_percent"`
+	RefundAmount        float64           `json:"refund_amount"`
+	Tier                *CancellationTier `json:"tier"`
+}
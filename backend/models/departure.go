@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Departure availability as shown on the public calendar
+const (
+	DepartureAvailable = "available"
+	DepartureLimited   = "limited"
+	DepartureSoldOut   = "sold_out"
+	DepartureClosed    = "closed"
+)
+
+// PackageDeparture is a scheduled start date for a package with its own
+// seat inventory. Price is the effective per-person price: the override
+// when set, otherwise the package price.
+type PackageDeparture struct {
+	DepartureID    int       `json:"departure_id"`
+	PackageID      int       `json:"package_id"`
+	StartDate      string    `json:"start_date"`
+	EndDate        string    `json:"end_date"`
+	TotalSeats     int       `json:"total_seats"`
+	SeatsAvailable int       `json:"seats_available"`
+	BookedSeats    int       `json:"booked_seats"`
+	PriceOverride  *float64  `json:"price_override"`
+	Price          float64   `json:"price"`
+	IsActive       bool      `json:"is_active"`
+	Status         string    `json:"status"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
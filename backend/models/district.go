@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+type DistrictPhoto struct {
+	PhotoID      int           `json:"photo_id,omitempty"`
+	DistrictName string        `json:"district_name"`
+	Folder       string        `json:"folder"`
+	Filename     string        `json:"filename"`
+	URL          string        `json:"url"`
+	Variants     PhotoVariants `json:"variants"`
+	Caption      string        `json:"caption,omitempty"`
+	AltText      string        `json:"alt_text"`
+	Credit       string        `json:"credit,omitempty"`
+	SortOrder    int           `json:"sort_order"`
+	IsCover      bool          `json:"is_cover"`
+	CreatedAt    time.Time     `json:"created_at,omitempty"`
+}
+
+type District struct {
+	DistrictID  int       `json:"district_id,omitempty"`
+	Name        string    `json:"name"`
+	Slug        string    `json:"slug"`
+	Aliases     []string  `json:"aliases"`
+	Latitude    float64   `json:"latitude"`
+	Longitude   float64   `json:"longitude"`
+	Description string    `json:"description,omitempty"`
+	BestSeason  string    `json:"best_season,omitempty"`
+	Highlights  []string  `json:"highlights"`
+	PhotoFolder string    `json:"photo_folder"`
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty"`
+}
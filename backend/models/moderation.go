@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// Package moderation statuses. New and edited packages wait in review;
+// only approved ones are shown to travelers. Agencies answer a change
+// request by editing the package, which sends it back for review.
+const (
+	PackagePendingReview    = "pending_review"
+	PackageApproved         = "approved"
+	PackageChangesRequested = "changes_requested"
+	PackageRejected         = "rejected"
+)
+
+// Automated moderation flag codes
+const (
+	FlagMissingPhotos = "missing_photos"
+	FlagPriceTooLow   = "price_below_travel_cost"
+	FlagPriceTooHigh  = "price_above_expected"
+	FlagBannedWords   = "banned_words"
+)
+
+// ModerationFlag is a problem the automated checks found for a reviewer to
+// look at. Flags don't block approval.
+type ModerationFlag struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PackageReview is one moderation status change. ReviewedBy is "admin", or
+// "agency" when an edit sent the package back for review.
+type PackageReview struct {
+	ReviewID   int       `json:"review_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Notes      string    `json:"notes,omitempty"`
+	ReviewedBy string    `json:"reviewed_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ModerationItem is a package in the moderation queue
+type ModerationItem struct {
+	PackageID          int              `json:"package_id"`
+	AgencyID           int              `json:"agency_id"`
+	AgencyName         string           `json:"agency_name"`
+	AgencyStatus       string           `json:"agency_status"`
+	Title              string           `json:"title"`
+	Description        string           `json:"description"`
+	Location           string           `json:"location"`
+	InitialDestination string           `json:"initial_destination"`
+	Locations          []string         `json:"locations"`
+	DurationDays       int              `json:"duration_days"`
+	NumTravelers       int              `json:"num_travelers"`
+	TransportMode      string           `json:"transport_mode"`
+	Price              float64          `json:"price"`
+	PricingMode        string           `json:"pricing_mode"`
+	IsActive           bool             `json:"is_active"`
+	PhotoCount         int              `json:"photo_count"`
+	ModerationStatus   string           `json:"moderation_status"`
+	ModerationNotes    string           `json:"moderation_notes,omitempty"`
+	Flags              []ModerationFlag `json:"flags"`
+	ChecksPending      bool             `json:"checks_pending"`
+	SubmittedAt        *time.Time       `json:"submitted_at,omitempty"`
+	ModeratedAt        *time.Time       `json:"moderated_at,omitempty"`
+	Reviews            []PackageReview  `json:"reviews,omitempty"`
+}
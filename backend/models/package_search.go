@@ -0,0 +1,10 @@
+package models
+
+// PackageSearchResult is a package matched by full-text search. The
+// highlights are HTML-escaped with matches wrapped in <mark>.
+type PackageSearchResult struct {
+	TravelPackage
+	Rank           float64 `json:"rank"`
+	TitleHighlight string  `json:"title_highlight"`
+	Snippet        string  `json:"snippet"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+type PackingItem struct {
+	ItemID    int    `json:"item_id,omitempty"`
+	Category  string `json:"category"`
+	Name      string `json:"name"`
+	Quantity  int    `json:"quantity"`
+	Reason    string `json:"reason,omitempty"`
+	Checked   bool   `json:"checked"`
+	SortOrder int    `json:"sort_order"`
+}
+
+type PackingList struct {
+	TripID       int           `json:"tripid,omitempty"`
+	Destination  string        `json:"destination"`
+	StartDate    string        `json:"start_date"`
+	EndDate      string        `json:"end_date"`
+	Items        []PackingItem `json:"items"`
+	CheckedCount int           `json:"checked_count"`
+	GeneratedAt  time.Time     `json:"generated_at"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// Payment statuses. An order starts as created and becomes paid once the
+// checkout signature or a capture webhook is verified. A capture for a
+// booking another payment already paid becomes duplicate instead, and is
+// owed back to the user in full.
+const (
+	PaymentCreated           = "created"
+	PaymentPaid              = "paid"
+	PaymentFailed            = "failed"
+	PaymentPartiallyRefunded = "partially_refunded"
+	PaymentRefunded          = "refunded"
+	PaymentDuplicate         = "duplicate"
+)
+
+// PaymentAttempt is one checkout callback or webhook received for a payment
+type PaymentAttempt struct {
+	AttemptID        int       `json:"attempt_id"`
+	Source           string    `json:"source"`
+	Event            string    `json:"event,omitempty"`
+	GatewayPaymentID string    `json:"gateway_payment_id,omitempty"`
+	Outcome          string    `json:"outcome"`
+	ErrorMessage     string    `json:"error_message,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type PaymentRefund struct {
+	RefundID        int       `json:"refund_id"`
+	GatewayRefundID string    `json:"gateway_refund_id"`
+	Amount          float64   `json:"amount"`
+	Reason          string    `json:"reason,omitempty"`
+	Status          string    `json:"status"`
+	RequestedBy     string    `json:"requested_by"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// Payment is a gateway order for a package and party size, optionally tied
+// to a booking. RefundDue flags money still held for a duplicate capture
+// or a cancelled booking.
+type Payment struct {
+	PaymentID        int              `json:"payment_id"`
+	UserID           int              `json:"user_id"`
+	AgencyID         int              `json:"agency_id"`
+	PackageID        *int             `json:"package_id"`
+	PackageTitle     string           `json:"package_title,omitempty"`
+	BookingID        *int             `json:"booking_id,omitempty"`
+	NumTravelers     int              `json:"num_travelers"`
+	Amount           float64          `json:"amount"`
+	AmountRefunded   float64          `json:"amount_refunded"`
+	Currency         string           `json:"currency"`
+	Gateway          string           `json:"gateway"`
+	GatewayOrderID   string           `json:"gateway_order_id"`
+	GatewayPaymentID string           `json:"gateway_payment_id,omitempty"`
+	Status           string           `json:"status"`
+	RefundDue        bool             `json:"refund_due"`
+	PaidAt           *time.Time       `json:"paid_at,omitempty"`
+	Attempts         []PaymentAttempt `json:"attempts,omitempty"`
+	Refunds          []PaymentRefund  `json:"refunds,omitempty"`
+	CreatedAt        time.Time        `json:"created_at"`
+	UpdatedAt        time.Time        `json:"updated_at"`
+}
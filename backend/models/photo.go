@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// ImageURLs points at one size of a photo. Variants are only encoded as
+// JPEG.
+type ImageURLs struct {
+	JPEG string `json:"jpeg"`
+}
+
+// PhotoVariants lists the generated sizes of an uploaded photo. Photos
+// uploaded before resizing existed point every size at the original file.
+type PhotoVariants struct {
+	Thumb  ImageURLs `json:"thumb"`
+	Medium ImageURLs `json:"medium"`
+	Large  ImageURLs `json:"large"`
+}
+
+type PackagePhoto struct {
+	PhotoID   int           `json:"photo_id"`
+	PackageID int           `json:"package_id"`
+	Filename  string        `json:"filename"`
+	URL       string        `json:"url"`
+	Variants  PhotoVariants `json:"variants"`
+	SortOrder int           `json:"sort_order"`
+	IsCover   bool          `json:"is_cover"`
+	CreatedAt time.Time     `json:"created_at"`
+}
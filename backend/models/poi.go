@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// OpeningPeriod is one opening window on a weekday (0 = Sunday). Times are
+// "HH:MM" local time; Close "24:00" means open until midnight.
+type OpeningPeriod struct {
+	Day   int    `json:"day"`
+	Open  string `json:"open"`
+	Close string `json:"close"`
+}
+
+// POI is a point of interest in the curated catalog. Empty OpeningHours
+// means the hours are unknown; nil fees mean the fee is unknown.
+type POI struct {
+	POIID                int             `json:"poi_id,omitempty"`
+	DistrictName         string          `json:"district_name"`
+	Name                 string          `json:"name"`
+	Category             string          `json:"category"`
+	Description          string          `json:"description,omitempty"`
+	Latitude             float64         `json:"latitude"`
+	Longitude            float64         `json:"longitude"`
+	OpeningHours         []OpeningPeriod `json:"opening_hours"`
+	VisitDurationMinutes int             `json:"visit_duration_minutes"`
+	EntryFeeMin          *float64        `json:"entry_fee_min"`
+	EntryFeeMax          *float64        `json:"entry_fee_max"`
+	Accessibility        []string        `json:"accessibility"`
+	Tags                 []string        `json:"tags"`
+	DistanceKm           *float64        `json:"distance_km,omitempty"`
+	CreatedAt            time.Time       `json:"created_at,omitempty"`
+	UpdatedAt            time.Time       `json:"updated_at,omitempty"`
+}
@@ -0,0 +1,112 @@
+package models
+
+import "time"
+
+// Pricing modes. Per-person prices are charged for each traveler; a
+// per-group price covers the whole party up to the package size.
+const (
+	PricingPerPerson = "per_person"
+	PricingPerGroup  = "per_group"
+)
+
+// Promo discount types
+const (
+	PromoPercent = "percent"
+	PromoFlat    = "flat"
+)
+
+// ChildPricing charges PricePercent of the adult price for travelers aged
+// MaxAge or younger
+type ChildPricing struct {
+	MaxAge       int     `json:"max_age"`
+	PricePercent float64 `json:"price_percent"`
+}
+
+// SeniorPricing charges PricePercent of the adult price for travelers aged
+// MinAge or older
+type SeniorPricing struct {
+	MinAge       int     `json:"min_age"`
+	PricePercent float64 `json:"price_percent"`
+}
+
+// Season multiplies the price for departures between StartDate and
+// EndDate (inclusive, YYYY-MM-DD)
+type Season struct {
+	Name       string  `json:"name"`
+	StartDate  string  `json:"start_date"`
+	EndDate    string  `json:"end_date"`
+	Multiplier float64 `json:"multiplier"`
+}
+
+// GroupDiscount takes Percent off per-person parties of at least
+// MinTravelers
+type GroupDiscount struct {
+	MinTravelers int     `json:"min_travelers"`
+	Percent      float64 `json:"percent"`
+}
+
+// PricingRules adjust a package's base price. A package without rules is
+// priced per person with no adjustments.
+type PricingRules struct {
+	Mode           string          `json:"mode"`
+	Child          *ChildPricing   `json:"child,omitempty"`
+	Senior         *SeniorPricing  `json:"senior,omitempty"`
+	Seasons        []Season        `json:"seasons,omitempty"`
+	GroupDiscounts []GroupDiscount `json:"group_discounts,omitempty"`
+}
+
+// PartySize counts travelers by price band
+type PartySize struct {
+	Adults   int `json:"adults"`
+	Children int `json:"children"`
+	Seniors  int `json:"seniors"`
+}
+
+func (p PartySize) Total() int {
+	return p.Adults + p.Children + p.Seniors
+}
+
+type PromoCode struct {
+	PromoID       int        `json:"promo_id"`
+	Code          string     `json:"code"`
+	AgencyID      *int       `json:"agency_id"`
+	PackageID     *int       `json:"package_id"`
+	DiscountType  string     `json:"discount_type"`
+	DiscountValue float64    `json:"discount_value"`
+	MaxDiscount   *float64   `json:"max_discount"`
+	UsageLimit    *int       `json:"usage_limit"`
+	UsedCount     int        `json:"used_count"`
+	StartsAt      *time.Time `json:"starts_at"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	IsActive      bool       `json:"is_active"`
+	CreatedBy     string     `json:"created_by"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// QuoteLine is one priced line; discounts have negative amounts
+type QuoteLine struct {
+	Label     string  `json:"label"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+	Amount    float64 `json:"amount"`
+}
+
+// PriceQuote is an itemized price for a departure date and party.
+// UnitPrice is the adult price per person after the seasonal multiplier,
+// or the group price for per-group packages.
+type PriceQuote struct {
+	Currency         string      `json:"currency"`
+	Mode             string      `json:"mode"`
+	DepartureDate    string      `json:"departure_date,omitempty"`
+	Party            PartySize   `json:"party"`
+	BasePrice        float64     `json:"base_price"`
+	Season           string      `json:"season,omitempty"`
+	SeasonMultiplier float64     `json:"season_multiplier"`
+	UnitPrice        float64     `json:"unit_price"`
+	Lines            []QuoteLine `json:"lines"`
+	Subtotal         float64     `json:"subtotal"`
+	Discounts        []QuoteLine `json:"discounts"`
+	DiscountTotal    float64     `json:"discount_total"`
+	PromoCode        string      `json:"promo_code,omitempty"`
+	Total            float64     `json:"total"`
+}
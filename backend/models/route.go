@@ -0,0 +1,41 @@
+package models
+
+// RouteStop is one visit in an optimised day route. Leg values describe the
+// drive from the previous stop (or the day's start point).
+type RouteStop struct {
+	Order         int     `json:"order"`
+	Name          string  `json:"name"`
+	POIID         int     `json:"poi_id,omitempty"`
+	Category      string  `json:"category"`
+	Latitude      float64 `json:"latitude"`
+	Longitude     float64 `json:"longitude"`
+	Slot          string  `json:"slot"`
+	Arrive        string  `json:"arrive"`
+	Start         string  `json:"start"`
+	Depart        string  `json:"depart"`
+	WaitMinutes   int     `json:"wait_minutes"`
+	VisitMinutes  int     `json:"visit_minutes"`
+	LegDistanceKm float64 `json:"leg_distance_km"`
+	LegMinutes    int     `json:"leg_minutes"`
+	Warning       string  `json:"warning,omitempty"`
+}
+
+// RoutePoint is a named coordinate, such as a day's starting point
+type RoutePoint struct {
+	Name      string  `json:"name"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// DayRoute is the ordered stops for one itinerary day. Source is
+// "openrouteservice" for road distances or "haversine" for estimates.
+type DayRoute struct {
+	Day                int         `json:"day"`
+	Date               string      `json:"date,omitempty"`
+	Start              RoutePoint  `json:"start"`
+	Stops              []RouteStop `json:"stops"`
+	Unresolved         []string    `json:"unresolved"`
+	TotalDistanceKm    float64     `json:"total_distance_km"`
+	TotalTravelMinutes int         `json:"total_travel_minutes"`
+	Source             string      `json:"source"`
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type User struct {
 	UserID    int       `json:"userid"`
@@ -35,9 +38,10 @@ type TripRequest struct {
 }
 
 type SavedTripResponse struct {
-	TripID      int       `json:"tripid"`
-	TripDetails string    `json:"tripdetails"`
-	SavedAt     time.Time `json:"saved_at"`
+	TripID      int             `json:"tripid"`
+	TripDetails string          `json:"tripdetails"`
+	Grounding   json.RawMessage `json:"grounding,omitempty"`
+	SavedAt     time.Time       `json:"saved_at"`
 }
 
 type TravelAgency struct {
@@ -47,28 +51,40 @@ type TravelAgency struct {
 	Phone     string    `json:"phone,omitempty"`
 	Website   string    `json:"website,omitempty"`
 	Password  string    `json:"password,omitempty"`
+	Status    string    `json:"status,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
 type TravelPackage struct {
-	PackageID          int       `json:"package_id"`
-	AgencyID           int       `json:"agency_id"`
-	AgencyName         string    `json:"agency_name"`
-	AgencyEmail        string    `json:"agency_email"`
-	AgencyPhone        string    `json:"agency_phone"`
-	Title              string    `json:"title"`
-	Description        string    `json:"description"`
-	Location           string    `json:"location"`
-	InitialDestination string    `json:"initial_destination"`
-	DurationDays       int       `json:"duration_days"`
-	NumTravelers       int       `json:"num_travelers"`
-	TransportMode      string    `json:"transport_mode"`
-	Price              float64   `json:"price"`
-	IsActive           bool      `json:"is_active"`
-	Locations          []string  `json:"locations"`
-	Photos             []string  `json:"photos"`
-	CreatedAt          time.Time `json:"created_at"`
-	UpdatedAt          time.Time `json:"updated_at"`
+	PackageID          int             `json:"package_id"`
+	AgencyID           int             `json:"agency_id"`
+	AgencyName         string          `json:"agency_name"`
+	AgencyEmail        string          `json:"agency_email"`
+	AgencyPhone        string          `json:"agency_phone"`
+	Title              string          `json:"title"`
+	Description        string          `json:"description"`
+	Location           string          `json:"location"`
+	InitialDestination string          `json:"initial_destination"`
+	DurationDays       int             `json:"duration_days"`
+	NumTravelers       int             `json:"num_travelers"`
+	TransportMode      string          `json:"transport_mode"`
+	Price              float64         `json:"price"`
+	IsActive           bool            `json:"is_active"`
+	Locations          []string        `json:"locations"`
+	Photos             []string        `json:"photos"`
+	PhotoVariants      []PhotoVariants `json:"photo_variants"`
+	// Tiers sorted from the earliest cancellation; null when the agency
+	// hasn't published a policy
+	CancellationPolicy []CancellationTier `json:"cancellation_policy"`
+	// Adjustments to Price; null prices per person with none
+	PricingRules      *PricingRules `json:"pricing_rules"`
+	AgencyRating      *float64      `json:"agency_rating,omitempty"`
+	AgencyReviewCount int           `json:"agency_review_count,omitempty"`
+	CreatedAt         time.Time     `json:"created_at"`
+	UpdatedAt         time.Time     `json:"updated_at"`
+	// Packages are shown to travelers only once moderation approves them
+	ModerationStatus string `json:"moderation_status,omitempty"`
+	ModerationNotes  string `json:"moderation_notes,omitempty"`
 }
 
 type Feedback struct {
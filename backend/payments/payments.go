@@ -0,0 +1,165 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid payment signature")
+	ErrUnknownEvent     = errors.New("unknown webhook event")
+	ErrDisabled         = errors.New("online payments are not configured")
+)
+
+// Webhook event types, named after the gateway events they normalise
+const (
+	EventPaymentCaptured = "payment.captured"
+	EventPaymentFailed   = "payment.failed"
+	EventRefundProcessed = "refund.processed"
+)
+
+// OrderRequest asks the gateway for an order. Amounts are in the smallest
+// currency unit (paise for INR).
+type OrderRequest struct {
+	Amount   int64
+	Currency string
+	Receipt  string
+	Notes    map[string]string
+}
+
+// Order is a gateway order the client pays against at checkout
+type Order struct {
+	ID       string
+	Amount   int64
+	Currency string
+	Receipt  string
+}
+
+// Refund is a refund issued against a captured payment
+type Refund struct {
+	ID        string
+	PaymentID string
+	Amount    int64
+	Status    string
+}
+
+// WebhookEvent is a verified webhook, reduced to what we store
+type WebhookEvent struct {
+	Type      string
+	OrderID   string
+	PaymentID string
+	RefundID  string
+	Amount    int64
+	Currency  string
+	Error     string
+}
+
+// Gateway is a payment provider. The flow follows Razorpay's: the server
+// creates an order, the client checks out against it and returns a
+// payment ID with a signature the server verifies, and the gateway later
+// confirms the outcome through a signed webhook.
+type Gateway interface {
+	Name() string
+	// KeyID is the public key the client checkout is opened with
+	KeyID() string
+	CreateOrder(ctx context.Context, req OrderRequest) (Order, error)
+	// VerifyPayment checks the signature returned by the client checkout
+	VerifyPayment(orderID, paymentID, signature string) error
+	// ParseWebhook verifies a webhook body against its signature header
+	ParseWebhook(body []byte, header http.Header) (WebhookEvent, error)
+	Refund(ctx context.Context, paymentID string, amount int64, notes map[string]string) (Refund, error)
+}
+
+var (
+	defaultGateway Gateway
+	defaultMu      sync.Mutex
+)
+
+// Init builds the gateway from the environment. Call it once at startup;
+// a misconfigured gateway stops the server. Without PAYMENT_GATEWAY the
+// server runs with online payments disabled.
+func Init() {
+	g, err := FromEnv()
+	if err != nil {
+		log.Fatal("Error configuring payment gateway:", err)
+	}
+	Set(g)
+	if g == nil {
+		log.Printf("Payment gateway: none, online payments are disabled")
+		return
+	}
+	log.Printf("Payment gateway: %s", g.Name())
+}
+
+// Default returns the gateway configured by Init, or ErrDisabled when
+// online payments are off
+func Default() (Gateway, error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultGateway == nil {
+		return nil, ErrDisabled
+	}
+	return defaultGateway, nil
+}
+
+// Set replaces the gateway used by Default
+func Set(g Gateway) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultGateway = g
+}
+
+// MockEnabled reports whether the configured gateway is the mock, which
+// lets any caller produce valid payment signatures. Development routes
+// such as mock checkout are only registered when it is.
+func MockEnabled() bool {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	_, ok := defaultGateway.(*Mock)
+	return ok
+}
+
+// FromEnv builds a Gateway from PAYMENT_* environment variables, or
+// returns nil when PAYMENT_GATEWAY is unset. Only the mock gateway ships
+// today; a real adapter plugs in as another case here. Every gateway needs
+// its own key and webhook secrets, and the mock is only chosen when
+// PAYMENT_MOCK_ENABLED=true is set as well.
+func FromEnv() (Gateway, error) {
+	keySecret := os.Getenv("PAYMENT_KEY_SECRET")
+	webhookSecret := os.Getenv("PAYMENT_WEBHOOK_SECRET")
+	switch name := os.Getenv("PAYMENT_GATEWAY"); name {
+	case "":
+		return nil, nil
+	case "mock":
+		if os.Getenv("PAYMENT_MOCK_ENABLED") != "true" {
+			return nil, errors.New("the mock gateway is for development only; set PAYMENT_MOCK_ENABLED=true to use it")
+		}
+		if keySecret == "" || webhookSecret == "" {
+			return nil, errors.New("PAYMENT_KEY_SECRET and PAYMENT_WEBHOOK_SECRET are required")
+		}
+		return NewMock("mock_key", keySecret, webhookSecret), nil
+	default:
+		return nil, fmt.Errorf("unknown PAYMENT_GATEWAY %q", name)
+	}
+}
+
+// Sign returns the hex HMAC-SHA256 of message under secret, the scheme
+// Razorpay uses for both checkout and webhook signatures
+func Sign(secret, message string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// validSignature compares signatures in constant time
+func validSignature(secret, message, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, message)), []byte(signature))
+}
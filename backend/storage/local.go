@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Local stores objects as files under Root and serves them under BaseURL
+type Local struct {
+	Root    string
+	BaseURL string
+	secret  []byte
+	ttl     time.Duration
+}
+
+// NewLocal creates a local disk store. With a secret and a positive ttl,
+// URL returns signed links that Handler verifies.
+func NewLocal(root, baseURL string, secret []byte, ttl time.Duration) *Local {
+	return &Local{Root: root, BaseURL: strings.TrimSuffix(baseURL, "/"), secret: secret, ttl: ttl}
+}
+
+// path resolves a key to a file under Root. Keys that would land outside
+// Root are rejected even if cleanKey let them through.
+func (l *Local) path(key string) (string, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	root, err := filepath.Abs(l.Root)
+	if err != nil {
+		return "", err
+	}
+	p := filepath.Join(root, filepath.FromSlash(cleaned))
+	if rel, err := filepath.Rel(root, p); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", ErrInvalidKey
+	}
+	return p, nil
+}
+
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	dest, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	// Write to a temp file first so readers never see a partial upload
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
+
+func (l *Local) Open(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	p, err := l.path(key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	file, err := os.Open(p)
+	if os.IsNotExist(err) {
+		return nil, ObjectInfo{}, ErrNotFound
+	}
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	stat, err := file.Stat()
+	if err != nil || stat.IsDir() {
+		file.Close()
+		return nil, ObjectInfo{}, ErrNotFound
+	}
+	return file, ObjectInfo{
+		Key:         key,
+		Size:        stat.Size(),
+		ContentType: mime.TypeByExtension(path.Ext(key)),
+		ModTime:     stat.ModTime(),
+	}, nil
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (l *Local) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	objects := []ObjectInfo{}
+	err := filepath.WalkDir(l.Root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		rel, err := filepath.Rel(l.Root, p)
+		if err != nil {
+			return nil
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		objects = append(objects, ObjectInfo{
+			Key:         key,
+			Size:        info.Size(),
+			ContentType: mime.TypeByExtension(path.Ext(key)),
+			ModTime:     info.ModTime(),
+		})
+		return nil
+	})
+	return objects, err
+}
+
+func (l *Local) URL(ctx context.Context, key string) (string, error) {
+	cleaned, err := cleanKey(key)
+	if err != nil || strings.HasPrefix(cleaned, PrivatePrefix) {
+		return "", ErrInvalidKey
+	}
+	segments := strings.Split(cleaned, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	link := l.BaseURL + "/" + strings.Join(segments, "/")
+
+	if l.ttl > 0 {
+		expires := strconv.FormatInt(time.Now().Add(l.ttl).Unix(), 10)
+		link += "?expires=" + expires + "&signature=" + l.sign(cleaned, expires)
+	}
+	return link, nil
+}
+
+func (l *Local) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (l *Local) verify(key string, query url.Values) bool {
+	expires := query.Get("expires")
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return false
+	}
+	return hmac.Equal([]byte(query.Get("signature")), []byte(l.sign(key, expires)))
+}
+
+// Handler serves stored files under BaseURL, checking signatures when
+// signed URLs are enabled
+func (l *Local) Handler() http.Handler {
+	return http.StripPrefix(l.BaseURL+"/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, err := cleanKey(r.URL.Path)
+		if err != nil || hasHiddenSegment(key) || strings.HasPrefix(key, PrivatePrefix) {
+			http.NotFound(w, r)
+			return
+		}
+		if l.ttl > 0 && !l.verify(key, r.URL.Query()) {
+			http.Error(w, "Link expired or invalid", http.StatusForbidden)
+			return
+		}
+
+		body, info, err := l.Open(r.Context(), key)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer body.Close()
+
+		if info.ContentType != "" {
+			w.Header().Set("Content-Type", info.ContentType)
+		}
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		http.ServeContent(w, r, path.Base(key), info.ModTime, body.(io.ReadSeeker))
+	}))
+}
+
+// hasHiddenSegment reports whether any part of the key starts with a dot,
+// which covers in-progress temp files and dotfiles in the uploads root
+func hasHiddenSegment(key string) bool {
+	for _, segment := range strings.Split(key, "/") {
+		if strings.HasPrefix(segment, ".") {
+			return true
+		}
+	}
+	return false
+}
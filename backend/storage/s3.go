@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Config configures an S3-compatible store (AWS S3, MinIO, R2, ...)
+type S3Config struct {
+	Endpoint string
+	Region   string
+	Bucket   string
+	// PrivateBucket holds keys under PrivatePrefix. It must not be
+	// publicly readable, since Bucket usually is.
+	PrivateBucket string
+	AccessKey     string
+	SecretKey     string
+	UseSSL        bool
+	// PublicBaseURL is used for unsigned links, e.g. a CDN in front of the
+	// bucket. Defaults to the endpoint with path-style bucket addressing.
+	PublicBaseURL string
+	SignedURLTTL  time.Duration
+}
+
+// S3 stores objects in an S3-compatible bucket
+type S3 struct {
+	client        *minio.Client
+	bucket        string
+	privateBucket string
+	baseURL       string
+	ttl           time.Duration
+}
+
+func NewS3(cfg S3Config) (*S3, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, errors.New("S3_ENDPOINT and S3_BUCKET are required")
+	}
+	if cfg.PrivateBucket == "" || cfg.PrivateBucket == cfg.Bucket {
+		return nil, errors.New("S3_PRIVATE_BUCKET is required and must differ from S3_BUCKET")
+	}
+
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL := strings.TrimSuffix(cfg.PublicBaseURL, "/")
+	if baseURL == "" {
+		baseURL = client.EndpointURL().String() + "/" + cfg.Bucket
+	}
+	s := &S3{client: client, bucket: cfg.Bucket, privateBucket: cfg.PrivateBucket, baseURL: baseURL, ttl: cfg.SignedURLTTL}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	policy, err := client.GetBucketPolicy(ctx, cfg.PrivateBucket)
+	if err != nil {
+		log.Printf("Could not read the policy of private bucket %s: %v", cfg.PrivateBucket, err)
+	} else if publicPolicy(policy) {
+		return nil, fmt.Errorf("private bucket %s has a policy allowing anonymous access", cfg.PrivateBucket)
+	}
+	return s, nil
+}
+
+// publicPolicy reports whether a bucket policy allows anything to every
+// principal
+func publicPolicy(policy string) bool {
+	if policy == "" {
+		return false
+	}
+	var doc struct {
+		Statement []struct {
+			Effect    string
+			Principal json.RawMessage
+		}
+	}
+	if err := json.Unmarshal([]byte(policy), &doc); err != nil {
+		// Can't tell, so assume the worst
+		return true
+	}
+	for _, st := range doc.Statement {
+		if st.Effect != "Allow" {
+			continue
+		}
+		var principal interface{}
+		json.Unmarshal(st.Principal, &principal)
+		switch p := principal.(type) {
+		case string:
+			if p == "*" {
+				return true
+			}
+		case map[string]interface{}:
+			switch aws := p["AWS"].(type) {
+			case string:
+				if aws == "*" {
+					return true
+				}
+			case []interface{}:
+				for _, v := range aws {
+					if v == "*" {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// bucketFor picks the bucket a key lives in
+func (s *S3) bucketFor(key string) string {
+	if strings.HasPrefix(key, PrivatePrefix) {
+		return s.privateBucket
+	}
+	return s.bucket
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutObject(ctx, s.bucketFor(key), key, r, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+func (s *S3) Open(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	obj, err := s.client.GetObject(ctx, s.bucketFor(key), key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, ObjectInfo{}, s.mapError(err)
+	}
+	stat, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, ObjectInfo{}, s.mapError(err)
+	}
+	return obj, ObjectInfo{Key: key, Size: stat.Size, ContentType: stat.ContentType, ModTime: stat.LastModified}, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	return s.client.RemoveObject(ctx, s.bucketFor(key), key, minio.RemoveObjectOptions{})
+}
+
+func (s *S3) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	objects := []ObjectInfo{}
+	for obj := range s.client.ListObjects(ctx, s.bucketFor(prefix), minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		objects = append(objects, ObjectInfo{Key: obj.Key, Size: obj.Size, ContentType: obj.ContentType, ModTime: obj.LastModified})
+	}
+	return objects, nil
+}
+
+func (s *S3) URL(ctx context.Context, key string) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil || strings.HasPrefix(key, PrivatePrefix) {
+		return "", ErrInvalidKey
+	}
+	if s.ttl > 0 {
+		signed, err := s.client.PresignedGetObject(ctx, s.bucket, key, s.ttl, url.Values{})
+		if err != nil {
+			return "", err
+		}
+		return signed.String(), nil
+	}
+
+	segments := strings.Split(key, "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	return s.baseURL + "/" + strings.Join(segments, "/"), nil
+}
+
+func (s *S3) mapError(err error) error {
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return ErrNotFound
+	}
+	return err
+}
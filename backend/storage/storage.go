@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("object not found")
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// PrivatePrefix starts keys that are never served by URL, such as agency
+// KYC documents. Read them with Open and check access first. URL refuses
+// them, the local handler never serves them, and the S3 driver keeps them
+// in a separate bucket that must not be publicly readable.
+const PrivatePrefix = "private/"
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Storage is a blob store for uploaded files. Keys are slash-separated
+// paths such as "packages/12_1700000000_large.jpg".
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, ObjectInfo, error)
+	Delete(ctx context.Context, key string) error
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	// URL returns a link clients can fetch the object from. When signed
+	// URLs are enabled the link expires after the configured TTL. Keys
+	// under PrivatePrefix have no URL.
+	URL(ctx context.Context, key string) (string, error)
+}
+
+var (
+	defaultStorage Storage
+	defaultMu      sync.Mutex
+)
+
+// Init builds the storage backend from the environment. Call it once at
+// startup; a misconfigured backend stops the server.
+func Init() {
+	s, err := FromEnv()
+	if err != nil {
+		log.Fatal("Error configuring upload storage:", err)
+	}
+	Set(s)
+
+	driver := os.Getenv("STORAGE_DRIVER")
+	if driver == "" {
+		driver = "local"
+	}
+	log.Printf("Upload storage: %s", driver)
+}
+
+// Default returns the configured storage, or local disk storage under
+// uploads/ if Init was not called
+func Default() Storage {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultStorage == nil {
+		defaultStorage = NewLocal("uploads", "/uploads", nil, 0)
+	}
+	return defaultStorage
+}
+
+// Set replaces the storage used by Default
+func Set(s Storage) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultStorage = s
+}
+
+// FromEnv builds a Storage from STORAGE_* and S3_* environment variables
+func FromEnv() (Storage, error) {
+	var ttl time.Duration
+	if os.Getenv("STORAGE_SIGNED_URLS") == "true" {
+		ttl = time.Hour
+		if minutes, err := strconv.Atoi(os.Getenv("STORAGE_URL_TTL_MINUTES")); err == nil && minutes > 0 {
+			ttl = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	switch driver := os.Getenv("STORAGE_DRIVER"); driver {
+	case "", "local":
+		root := os.Getenv("STORAGE_LOCAL_ROOT")
+		if root == "" {
+			root = "uploads"
+		}
+		var secret []byte
+		if ttl > 0 {
+			secret = []byte(os.Getenv("STORAGE_SIGNING_SECRET"))
+			if len(secret) == 0 {
+				return nil, errors.New("STORAGE_SIGNING_SECRET is required for signed local URLs")
+			}
+		}
+		return NewLocal(root, "/uploads", secret, ttl), nil
+	case "s3":
+		return NewS3(S3Config{
+			Endpoint:      os.Getenv("S3_ENDPOINT"),
+			Region:        os.Getenv("S3_REGION"),
+			Bucket:        os.Getenv("S3_BUCKET"),
+			PrivateBucket: os.Getenv("S3_PRIVATE_BUCKET"),
+			AccessKey:     os.Getenv("S3_ACCESS_KEY"),
+			SecretKey:     os.Getenv("S3_SECRET_KEY"),
+			UseSSL:        os.Getenv("S3_USE_SSL") != "false",
+			PublicBaseURL: os.Getenv("STORAGE_PUBLIC_BASE_URL"),
+			SignedURLTTL:  ttl,
+		})
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_DRIVER %q", driver)
+	}
+}
+
+// Key joins path segments into an object key
+func Key(parts ...string) string {
+	return path.Join(parts...)
+}
+
+// cleanKey rejects keys that are empty or try to escape their prefix
+func cleanKey(key string) (string, error) {
+	cleaned := path.Clean("/" + key)[1:]
+	if cleaned == "" || cleaned != strings.TrimPrefix(key, "/") {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
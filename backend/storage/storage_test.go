@@ -0,0 +1,355 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+func TestCleanKey(t *testing.T) {
+	tests := []struct {
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{key: "packages/12_large.jpg", want: "packages/12_large.jpg"},
+		{key: "/packages/12_large.jpg", want: "packages/12_large.jpg"},
+		{key: "private/kyc/3/pan.pdf", want: "private/kyc/3/pan.pdf"},
+		{key: "", wantErr: true},
+		{key: ".", wantErr: true},
+		{key: "/", wantErr: true},
+		{key: "../secret", wantErr: true},
+		{key: "packages/../../secret", wantErr: true},
+		{key: "packages/../private/kyc/3/pan.pdf", wantErr: true},
+		{key: "packages//12.jpg", wantErr: true},
+		{key: "packages/./12.jpg", wantErr: true},
+		{key: "packages/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := cleanKey(tt.key)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidKey) {
+					t.Fatalf("cleanKey(%q) = %q, %v; want ErrInvalidKey", tt.key, got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("cleanKey(%q) = %q, %v; want %q", tt.key, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocalConfinesKeysToRoot(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "uploads")
+	local := NewLocal(root, "/uploads", nil, 0)
+	ctx := context.Background()
+
+	if err := os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("outside"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"../secret.txt", "../escaped.txt", "a/../../escaped.txt", "", "/"} {
+		t.Run(key, func(t *testing.T) {
+			if err := local.Put(ctx, key, strings.NewReader("x"), 1, "text/plain"); !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("Put: got %v, want ErrInvalidKey", err)
+			}
+			if _, _, err := local.Open(ctx, key); !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("Open: got %v, want ErrInvalidKey", err)
+			}
+			if err := local.Delete(ctx, key); !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("Delete: got %v, want ErrInvalidKey", err)
+			}
+			if _, err := local.URL(ctx, key); !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("URL: got %v, want ErrInvalidKey", err)
+			}
+		})
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "escaped.txt")); !os.IsNotExist(err) {
+		t.Error("a file was written outside the root")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "secret.txt")); err != nil {
+		t.Error("a file outside the root was deleted")
+	}
+}
+
+func TestLocalRoundTrip(t *testing.T) {
+	local := NewLocal(t.TempDir(), "/uploads", nil, 0)
+	ctx := context.Background()
+
+	if err := local.Put(ctx, "packages/7_large.jpg", strings.NewReader("jpeg"), 4, "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	body, info, err := local.Open(ctx, "packages/7_large.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if string(data) != "jpeg" || info.Size != 4 || info.ContentType != "image/jpeg" {
+		t.Errorf("got %q %+v", data, info)
+	}
+
+	objects, err := local.List(ctx, "packages/")
+	if err != nil || len(objects) != 1 || objects[0].Key != "packages/7_large.jpg" {
+		t.Errorf("List = %+v, %v", objects, err)
+	}
+
+	if err := local.Delete(ctx, "packages/7_large.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := local.Open(ctx, "packages/7_large.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open after Delete: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestLocalHandler(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	for _, key := range []string{"packages/7_large.jpg", "private/kyc/3/pan.pdf"} {
+		if err := NewLocal(dir, "/uploads", nil, 0).Put(ctx, key, strings.NewReader("data"), 4, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "packages", ".upload-123"), []byte("partial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	unsigned := NewLocal(dir, "/uploads", nil, 0)
+	signed := NewLocal(dir, "/uploads", []byte("test-secret"), time.Hour)
+	validLink, err := signed.URL(ctx, "packages/7_large.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	link, _ := url.Parse(validLink)
+	tampered := *link
+	query := tampered.Query()
+	query.Set("expires", "9999999999")
+	tampered.RawQuery = query.Encode()
+
+	tests := []struct {
+		name   string
+		store  *Local
+		target string
+		want   int
+	}{
+		{name: "public file", store: unsigned, target: "/uploads/packages/7_large.jpg", want: http.StatusOK},
+		{name: "missing file", store: unsigned, target: "/uploads/packages/8_large.jpg", want: http.StatusNotFound},
+		{name: "private file", store: unsigned, target: "/uploads/private/kyc/3/pan.pdf", want: http.StatusNotFound},
+		{name: "path traversal into private", store: unsigned, target: "/uploads/packages/../private/kyc/3/pan.pdf", want: http.StatusNotFound},
+		{name: "temp file", store: unsigned, target: "/uploads/packages/.upload-123", want: http.StatusNotFound},
+		{name: "signed link", store: signed, target: link.RequestURI(), want: http.StatusOK},
+		{name: "unsigned request", store: signed, target: "/uploads/packages/7_large.jpg", want: http.StatusForbidden},
+		{name: "tampered expiry", store: signed, target: tampered.RequestURI(), want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.store.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != tt.want {
+				t.Errorf("GET %s = %d, want %d", tt.target, rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestURLRefusesPrivateKeys(t *testing.T) {
+	ctx := context.Background()
+	stores := map[string]Storage{
+		"local": NewLocal(t.TempDir(), "/uploads", nil, 0),
+		"s3":    &S3{bucket: "trip-planner", privateBucket: "trip-planner-private", baseURL: "https://cdn.example.com"},
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			if _, err := store.URL(ctx, "private/kyc/3/pan.pdf"); !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("private key: got %v, want ErrInvalidKey", err)
+			}
+			link, err := store.URL(ctx, "packages/7 large.jpg")
+			if err != nil || !strings.HasSuffix(link, "/packages/7%20large.jpg") {
+				t.Errorf("public key: got %q, %v", link, err)
+			}
+		})
+	}
+}
+
+func TestS3BucketFor(t *testing.T) {
+	s := &S3{bucket: "trip-planner", privateBucket: "trip-planner-private"}
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"packages/7_large.jpg", "trip-planner"},
+		{"private/kyc/3/pan.pdf", "trip-planner-private"},
+		{"private/", "trip-planner-private"},
+		{"privateer/photo.jpg", "trip-planner"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := s.bucketFor(tt.key); got != tt.want {
+				t.Errorf("bucketFor(%q) = %s, want %s", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewS3RequiresSeparatePrivateBucket(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  S3Config
+	}{
+		{name: "no private bucket", cfg: S3Config{Endpoint: "localhost:9000", Bucket: "trip-planner"}},
+		{name: "same bucket", cfg: S3Config{Endpoint: "localhost:9000", Bucket: "trip-planner", PrivateBucket: "trip-planner"}},
+		{name: "no bucket", cfg: S3Config{Endpoint: "localhost:9000", PrivateBucket: "trip-planner-private"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewS3(tt.cfg); err == nil {
+				t.Error("expected a configuration error")
+			}
+		})
+	}
+}
+
+func TestPublicPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		want   bool
+	}{
+		{name: "no policy", policy: "", want: false},
+		{
+			name:   "anonymous read",
+			policy: `{"Statement":[{"Effect":"Allow","Principal":{"AWS":["*"]},"Action":["s3:GetObject"]}]}`,
+			want:   true,
+		},
+		{
+			name:   "wildcard principal string",
+			policy: `{"Statement":[{"Effect":"Allow","Principal":"*","Action":["s3:GetObject"]}]}`,
+			want:   true,
+		},
+		{
+			name:   "single AWS wildcard",
+			policy: `{"Statement":[{"Effect":"Allow","Principal":{"AWS":"*"},"Action":["s3:ListBucket"]}]}`,
+			want:   true,
+		},
+		{
+			name:   "deny everyone",
+			policy: `{"Statement":[{"Effect":"Deny","Principal":"*","Action":["s3:GetObject"]}]}`,
+			want:   false,
+		},
+		{
+			name:   "named account only",
+			policy: `{"Statement":[{"Effect":"Allow","Principal":{"AWS":["arn:aws:iam::123456789012:root"]},"Action":["s3:GetObject"]}]}`,
+			want:   false,
+		},
+		{name: "unreadable policy", policy: `{not json`, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := publicPolicy(tt.policy); got != tt.want {
+				t.Errorf("publicPolicy = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestS3Integration runs the S3 driver against a real server, e.g. MinIO.
+// It is skipped unless S3_TEST_ENDPOINT is set, and writes under a prefix
+// unique to the run in S3_TEST_BUCKET and S3_TEST_PRIVATE_BUCKET.
+func TestS3Integration(t *testing.T) {
+	endpoint := os.Getenv("S3_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("S3_TEST_ENDPOINT is not set")
+	}
+	s, err := NewS3(S3Config{
+		Endpoint:      endpoint,
+		Region:        os.Getenv("S3_TEST_REGION"),
+		Bucket:        os.Getenv("S3_TEST_BUCKET"),
+		PrivateBucket: os.Getenv("S3_TEST_PRIVATE_BUCKET"),
+		AccessKey:     os.Getenv("S3_TEST_ACCESS_KEY"),
+		SecretKey:     os.Getenv("S3_TEST_SECRET_KEY"),
+		UseSSL:        os.Getenv("S3_TEST_USE_SSL") == "true",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	run := strconv.FormatInt(time.Now().UnixNano(), 10)
+	public := "packages/" + run + "/7_large.jpg"
+	private := PrivatePrefix + "kyc/" + run + "/pan.pdf"
+	t.Cleanup(func() {
+		s.Delete(ctx, public)
+		s.Delete(ctx, private)
+	})
+
+	for key, body := range map[string]string{public: "jpeg", private: "pdf"} {
+		if err := s.Put(ctx, key, strings.NewReader(body), int64(len(body)), "application/octet-stream"); err != nil {
+			t.Fatalf("Put %s: %v", key, err)
+		}
+		r, info, err := s.Open(ctx, key)
+		if err != nil {
+			t.Fatalf("Open %s: %v", key, err)
+		}
+		data, _ := io.ReadAll(r)
+		r.Close()
+		if string(data) != body || info.Size != int64(len(body)) {
+			t.Errorf("Open %s = %q %+v", key, data, info)
+		}
+	}
+
+	// Each object must exist in its own bucket and only there
+	placement := []struct {
+		bucket string
+		key    string
+		want   bool
+	}{
+		{s.bucket, public, true},
+		{s.privateBucket, public, false},
+		{s.privateBucket, private, true},
+		{s.bucket, private, false},
+	}
+	for _, p := range placement {
+		_, err := s.client.StatObject(ctx, p.bucket, p.key, minio.StatObjectOptions{})
+		if exists := err == nil; exists != p.want {
+			t.Errorf("%s in %s: exists = %v, want %v (%v)", p.key, p.bucket, exists, p.want, err)
+		}
+	}
+
+	for prefix, want := range map[string]string{"packages/" + run + "/": public, PrivatePrefix + "kyc/" + run + "/": private} {
+		objects, err := s.List(ctx, prefix)
+		if err != nil || len(objects) != 1 || objects[0].Key != want {
+			t.Errorf("List(%s) = %+v, %v", prefix, objects, err)
+		}
+	}
+
+	for _, key := range []string{public, private} {
+		if err := s.Delete(ctx, key); err != nil {
+			t.Fatalf("Delete %s: %v", key, err)
+		}
+		if _, _, err := s.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Open %s after Delete: got %v, want ErrNotFound", key, err)
+		}
+	}
+}
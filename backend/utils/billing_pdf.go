@@ -0,0 +1,275 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"trip-planner-backend/models"
+	"trip-planner-backend/pdf"
+)
+
+const (
+	pdfMargin = 50.0
+	pdfBottom = pdf.A4Height - 60
+)
+
+// pdfCursor writes top to bottom, starting a new page when the current
+// one is full
+type pdfCursor struct {
+	doc  *pdf.Document
+	page *pdf.Page
+	y    float64
+}
+
+func (c *pdfCursor) ensure(height float64) {
+	if c.page == nil || c.y+height > pdfBottom {
+		c.page = c.doc.AddPage()
+		c.y = pdfMargin
+	}
+}
+
+// RenderBillingDocument lays out a quotation or tax invoice as an A4 PDF
+func RenderBillingDocument(doc models.BillingDocument) ([]byte, error) {
+	title := "TAX INVOICE"
+	if doc.DocumentType == models.DocumentQuotation {
+		title = "QUOTATION"
+	}
+	c := &pdfCursor{doc: pdf.New(title + " " + doc.DocumentNumber)}
+	c.ensure(0)
+	right := pdf.A4Width - pdfMargin
+	contentWidth := right - pdfMargin
+
+	// Heading with the document number and dates on the right
+	c.page.Text(pdfMargin, c.y+18, pdf.HelveticaBold, 20, title)
+	c.page.TextRight(right, c.y+6, pdf.HelveticaBold, 10, doc.DocumentNumber)
+	c.page.TextRight(right, c.y+20, pdf.Helvetica, 9, "Date: "+doc.IssueDate)
+	if doc.ValidUntil != "" {
+		c.page.TextRight(right, c.y+32, pdf.Helvetica, 9, "Valid until: "+doc.ValidUntil)
+	}
+	c.y += 48
+	c.page.Line(pdfMargin, c.y, right, c.y, 1)
+	c.y += 18
+
+	// Supplier and customer side by side
+	half := contentWidth / 2
+	leftEnd := writeParty(c.page, pdfMargin, c.y, half-10, "From", doc.Supplier)
+	rightEnd := writeParty(c.page, pdfMargin+half, c.y, half-10, "Bill to", doc.Customer)
+	c.y = math.Max(leftEnd, rightEnd) + 10
+
+	placeName, _ := GSTStateName(doc.PlaceOfSupply)
+	c.page.Text(pdfMargin, c.y, pdf.Helvetica, 9, fmt.Sprintf("Place of supply: %s - %s", doc.PlaceOfSupply, placeName))
+	c.page.TextRight(right, c.y, pdf.Helvetica, 9, "SAC: "+doc.SACCode)
+	c.y += 14
+	trip := doc.PackageTitle
+	if doc.Quote.DepartureDate != "" {
+		trip += ", departing " + doc.Quote.DepartureDate
+	}
+	for _, line := range pdf.Wrap(pdf.Helvetica, 9, "Tour package: "+trip, contentWidth) {
+		c.page.Text(pdfMargin, c.y, pdf.Helvetica, 9, line)
+		c.y += 12
+	}
+	c.y += 8
+
+	// Line items
+	qtyX, rateX := right-190, right-95
+	c.ensure(22)
+	c.page.FillRect(pdfMargin, c.y, contentWidth, 18, 0.9)
+	c.page.Text(pdfMargin+6, c.y+12.5, pdf.HelveticaBold, 9, "Description")
+	c.page.TextRight(qtyX, c.y+12.5, pdf.HelveticaBold, 9, "Qty")
+	c.page.TextRight(rateX, c.y+12.5, pdf.HelveticaBold, 9, "Rate")
+	c.page.TextRight(right-6, c.y+12.5, pdf.HelveticaBold, 9, "Amount")
+	c.y += 18
+	items := append(append([]models.QuoteLine{}, doc.Quote.Lines...), doc.Quote.Discounts...)
+	for _, item := range items {
+		labels := pdf.Wrap(pdf.Helvetica, 9, item.Label, qtyX-pdfMargin-40)
+		c.ensure(float64(len(labels))*12 + 8)
+		c.y += 14
+		for i, label := range labels {
+			c.page.Text(pdfMargin+6, c.y+float64(i)*12, pdf.Helvetica, 9, label)
+		}
+		c.page.TextRight(qtyX, c.y, pdf.Helvetica, 9, fmt.Sprint(item.Quantity))
+		c.page.TextRight(rateX, c.y, pdf.Helvetica, 9, FormatINR(item.UnitPrice))
+		c.page.TextRight(right-6, c.y, pdf.Helvetica, 9, FormatINR(item.Amount))
+		c.y += float64(len(labels)-1)*12 + 6
+		c.page.Line(pdfMargin, c.y, right, c.y, 0.3)
+	}
+	c.y += 8
+
+	// Totals
+	tax := doc.Tax
+	type row struct {
+		label  string
+		amount float64
+		bold   bool
+	}
+	rows := []row{}
+	if len(doc.Quote.Discounts) > 0 {
+		rows = append(rows, row{"Subtotal", doc.Quote.Subtotal, false}, row{"Discounts", -doc.Quote.DiscountTotal, false})
+	}
+	rate := fmt.Sprintf("%g%%", tax.Rate)
+	halfRate := fmt.Sprintf("%g%%", tax.Rate/2)
+	rows = append(rows, row{"Taxable value", tax.TaxableValue, false})
+	if tax.IntraState {
+		rows = append(rows, row{"CGST @ " + halfRate, tax.CGST, false}, row{"SGST @ " + halfRate, tax.SGST, false})
+	} else {
+		rows = append(rows, row{"IGST @ " + rate, tax.IGST, false})
+	}
+	rows = append(rows, row{"Total (INR)", tax.Total, true})
+	for _, r := range rows {
+		c.ensure(16)
+		font := pdf.Helvetica
+		if r.bold {
+			font = pdf.HelveticaBold
+			c.page.Line(right-220, c.y, right, c.y, 0.6)
+			c.y += 4
+		}
+		c.y += 12
+		c.page.TextRight(right-110, c.y, font, 10, r.label)
+		c.page.TextRight(right-6, c.y, font, 10, FormatINR(r.amount))
+	}
+	c.y += 18
+	for _, line := range pdf.Wrap(pdf.Helvetica, 9, "Amount in words: "+AmountInWords(tax.Total), contentWidth) {
+		c.ensure(12)
+		c.page.Text(pdfMargin, c.y, pdf.Helvetica, 9, line)
+		c.y += 12
+	}
+	if doc.PricesIncludeTax {
+		c.ensure(12)
+		c.page.Text(pdfMargin, c.y, pdf.Helvetica, 8, "Package prices include GST.")
+		c.y += 12
+	}
+
+	if doc.Notes != "" {
+		c.y += 10
+		c.ensure(26)
+		c.page.Text(pdfMargin, c.y, pdf.HelveticaBold, 9, "Notes")
+		c.y += 13
+		for _, line := range pdf.Wrap(pdf.Helvetica, 9, doc.Notes, contentWidth) {
+			c.ensure(12)
+			c.page.Text(pdfMargin, c.y, pdf.Helvetica, 9, line)
+			c.y += 12
+		}
+	}
+
+	footer := "This is a computer-generated invoice and does not need a signature."
+	if doc.DocumentType == models.DocumentQuotation {
+		footer = "This quotation is not a tax invoice. Prices and availability are confirmed on booking."
+	}
+	c.ensure(30)
+	c.page.Text(pdfMargin, pdfBottom+20, pdf.Helvetica, 8, footer)
+	return c.doc.Bytes()
+}
+
+// writeParty prints a labelled party block and returns where it ends
+func writeParty(page *pdf.Page, x, y, width float64, label string, p models.BillingParty) float64 {
+	page.Text(x, y, pdf.HelveticaBold, 8, strings.ToUpper(label))
+	y += 14
+	for _, line := range pdf.Wrap(pdf.HelveticaBold, 10, p.Name, width) {
+		page.Text(x, y, pdf.HelveticaBold, 10, line)
+		y += 13
+	}
+	details := pdf.Wrap(pdf.Helvetica, 9, p.Address, width)
+	if p.GSTIN != "" {
+		details = append(details, "GSTIN: "+p.GSTIN)
+	}
+	if p.StateCode != "" {
+		details = append(details, fmt.Sprintf("State: %s (%s)", p.State, p.StateCode))
+	}
+	for _, contact := range []string{p.Email, p.Phone} {
+		if contact != "" {
+			details = append(details, contact)
+		}
+	}
+	for _, line := range details {
+		if line == "" {
+			continue
+		}
+		page.Text(x, y, pdf.Helvetica, 9, line)
+		y += 12
+	}
+	return y
+}
+
+// FormatINR formats an amount with Indian digit grouping, e.g. 12,34,567.50
+func FormatINR(amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	s := fmt.Sprintf("%.2f", amount)
+	whole, frac := s[:len(s)-3], s[len(s)-3:]
+	if len(whole) > 3 {
+		head, tail := whole[:len(whole)-3], whole[len(whole)-3:]
+		var groups []string
+		for len(head) > 2 {
+			groups = append([]string{head[len(head)-2:]}, groups...)
+			head = head[:len(head)-2]
+		}
+		if head != "" {
+			groups = append([]string{head}, groups...)
+		}
+		whole = strings.Join(groups, ",") + "," + tail
+	}
+	return sign + whole + frac
+}
+
+var (
+	wordsOnes = []string{"", "One", "Two", "Three", "Four", "Five", "Six", "Seven", "Eight", "Nine", "Ten",
+		"Eleven", "Twelve", "Thirteen", "Fourteen", "Fifteen", "Sixteen", "Seventeen", "Eighteen", "Nineteen"}
+	wordsTens = []string{"", "", "Twenty", "Thirty", "Forty", "Fifty", "Sixty", "Seventy", "Eighty", "Ninety"}
+)
+
+// belowThousandWords spells 0-999
+func belowThousandWords(n int) string {
+	var parts []string
+	if n >= 100 {
+		parts = append(parts, wordsOnes[n/100]+" Hundred")
+		n %= 100
+	}
+	if n >= 20 {
+		word := wordsTens[n/10]
+		if n%10 != 0 {
+			word += "-" + wordsOnes[n%10]
+		}
+		parts = append(parts, word)
+	} else if n > 0 {
+		parts = append(parts, wordsOnes[n])
+	}
+	return strings.Join(parts, " ")
+}
+
+// AmountInWords spells a rupee amount in the Indian numbering system, e.g.
+// "Rupees One Lakh Twenty Thousand and Fifty Paise Only"
+func AmountInWords(amount float64) string {
+	paise := int64(math.Round(amount * 100))
+	rupees, rest := paise/100, int(paise%100)
+
+	var parts []string
+	for _, unit := range []struct {
+		size int64
+		name string
+	}{{10000000, "Crore"}, {100000, "Lakh"}, {1000, "Thousand"}} {
+		if rupees >= unit.size {
+			count := rupees / unit.size
+			rupees %= unit.size
+			if count >= 1000 {
+				// Amounts of a thousand crore or more keep the figure
+				parts = append(parts, fmt.Sprint(count)+" "+unit.name)
+			} else {
+				parts = append(parts, belowThousandWords(int(count))+" "+unit.name)
+			}
+		}
+	}
+	if rupees > 0 {
+		parts = append(parts, belowThousandWords(int(rupees)))
+	}
+	words := "Rupees " + strings.Join(parts, " ")
+	if len(parts) == 0 {
+		words = "Rupees Zero"
+	}
+	if rest > 0 {
+		words += " and " + belowThousandWords(rest) + " Paise"
+	}
+	return words + " Only"
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// BM25 parameters: k1 controls term-frequency saturation, b how strongly
+// long documents are penalised
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+var searchStopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "in": true, "is": true, "it": true, "its": true, "of": true, "on": true,
+	"or": true, "the": true, "to": true, "with": true, "was": true, "were": true, "this": true, "that": true,
+	"very": true, "no": true, "not": true, "but": true, "we": true, "our": true, "you": true,
+}
+
+// Tokenize lowercases text, splits it into words and drops stopwords. A
+// trailing plural "s" is removed so "temples" matches "temple". Combining
+// marks such as Kannada vowel signs belong to the word they are in.
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r)
+	})
+	tokens := make([]string, 0, len(words))
+	for _, w := range words {
+		if len(w) < 2 || searchStopwords[w] {
+			continue
+		}
+		if len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") {
+			w = strings.TrimSuffix(w, "s")
+		}
+		tokens = append(tokens, w)
+	}
+	return tokens
+}
+
+// BM25Index ranks a fixed set of documents against keyword queries
+type BM25Index struct {
+	docs      []map[string]int
+	lengths   []int
+	avgLength float64
+	docFreq   map[string]int
+}
+
+// NewBM25Index indexes already tokenized documents
+func NewBM25Index(docs [][]string) *BM25Index {
+	idx := &BM25Index{
+		docs:    make([]map[string]int, len(docs)),
+		lengths: make([]int, len(docs)),
+		docFreq: make(map[string]int),
+	}
+	total := 0
+	for i, tokens := range docs {
+		freq := make(map[string]int)
+		for _, t := range tokens {
+			freq[t]++
+		}
+		for t := range freq {
+			idx.docFreq[t]++
+		}
+		idx.docs[i] = freq
+		idx.lengths[i] = len(tokens)
+		total += len(tokens)
+	}
+	if len(docs) > 0 {
+		idx.avgLength = float64(total) / float64(len(docs))
+	}
+	return idx
+}
+
+// Scores returns the BM25 score of every document for the query, in
+// document order
+func (idx *BM25Index) Scores(query []string) []float64 {
+	scores := make([]float64, len(idx.docs))
+	if idx.avgLength == 0 {
+		return scores
+	}
+
+	n := float64(len(idx.docs))
+	seen := make(map[string]bool)
+	for _, term := range query {
+		if seen[term] || idx.docFreq[term] == 0 {
+			continue
+		}
+		seen[term] = true
+		df := float64(idx.docFreq[term])
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for i, freq := range idx.docs {
+			tf := float64(freq[term])
+			if tf == 0 {
+				continue
+			}
+			norm := bm25K1 * (1 - bm25B + bm25B*float64(idx.lengths[i])/idx.avgLength)
+			scores[i] += idf * tf * (bm25K1 + 1) / (tf + norm)
+		}
+	}
+	return scores
+}
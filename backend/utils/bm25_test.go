@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", []string{}},
+		{"Temples of the Hoysala era", []string{"temple", "hoysala", "era"}},
+		{"Hills, waterfalls & coffee-estates!", []string{"hill", "waterfall", "coffee", "estate"}},
+		{"Bus pass glass", []string{"bus", "pass", "glass"}},
+		{"A 2-day trip to Hampi", []string{"day", "trip", "hampi"}},
+		{"ಮೈಸೂರು palace", []string{"ಮೈಸೂರು", "palace"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBM25Scores(t *testing.T) {
+	docs := [][]string{
+		{"temple", "hill"},
+		{"beach", "sunset"},
+		{"temple", "temple", "temple", "fort"},
+	}
+	idx := NewBM25Index(docs)
+
+	// Three documents averaging 8/3 tokens; "temple" is in two of them
+	templeIDF := math.Log(1 + (3-2+0.5)/(2+0.5))
+	beachIDF := math.Log(1 + (3-1+0.5)/(1+0.5))
+	norm := func(length float64) float64 { return bm25K1 * (1 - bm25B + bm25B*length/(8.0/3)) }
+	temple := []float64{
+		templeIDF * 1 * (bm25K1 + 1) / (1 + norm(2)),
+		0,
+		templeIDF * 3 * (bm25K1 + 1) / (3 + norm(4)),
+	}
+
+	tests := []struct {
+		name  string
+		index *BM25Index
+		query []string
+		want  []float64
+	}{
+		{name: "single term", index: idx, query: []string{"temple"}, want: temple},
+		{name: "repeated query terms count once", index: idx, query: []string{"temple", "temple"}, want: temple},
+		{name: "unknown term", index: idx, query: []string{"waterfall"}, want: []float64{0, 0, 0}},
+		{
+			name:  "terms add up",
+			index: idx,
+			query: []string{"temple", "beach"},
+			want:  []float64{temple[0], beachIDF * (bm25K1 + 1) / (1 + norm(2)), temple[2]},
+		},
+		{name: "empty index", index: NewBM25Index(nil), query: []string{"temple"}, want: []float64{}},
+		{name: "empty documents", index: NewBM25Index([][]string{{}, {}}), query: []string{"temple"}, want: []float64{0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.index.Scores(tt.query)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d scores, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+					t.Errorf("document %d scored %.6f, want %.6f", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	// A rarer term outweighs a common one at the same frequency and length
+	scores := idx.Scores([]string{"beach"})
+	if scores[1] <= temple[0] {
+		t.Errorf("rare term scored %.6f, common term %.6f", scores[1], temple[0])
+	}
+}
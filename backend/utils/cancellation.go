@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+	"trip-planner-backend/models"
+)
+
+const (
+	maxCancellationTiers   = 10
+	maxCancellationDays    = 365
+	cancellationDateLayout = "2006-01-02"
+)
+
+// NormalizeCancellationPolicy validates tiers and sorts them from the
+// earliest cancellation to the latest. Refunds may only shrink as
+// departure gets closer, and each day threshold appears once.
+func NormalizeCancellationPolicy(tiers []models.CancellationTier) ([]models.CancellationTier, error) {
+	if len(tiers) > maxCancellationTiers {
+		return nil, errors.New("a cancellation policy can have at most 10 tiers")
+	}
+	sorted := append([]models.CancellationTier(nil), tiers...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].MinDaysBefore > sorted[j].MinDaysBefore })
+
+	for i, t := range sorted {
+		if t.MinDaysBefore < 0 || t.MinDaysBefore > maxCancellationDays {
+			return nil, errors.New("min_days_before must be between 0 and 365")
+		}
+		if t.RefundPercent < 0 || t.RefundPercent > 100 {
+			return nil, errors.New("refund_percent must be between 0 and 100")
+		}
+		if i > 0 {
+			prev := sorted[i-1]
+			if prev.MinDaysBefore == t.MinDaysBefore {
+				return nil, errors.New("each min_days_before can appear only once")
+			}
+			if t.RefundPercent > prev.RefundPercent {
+				return nil, errors.New("refunds can't grow closer to departure")
+			}
+		}
+	}
+	return sorted, nil
+}
+
+// QuoteRefund applies a normalized policy to a cancellation. The first
+// tier whose threshold the cancellation meets wins; cancelling later than
+// every tier, or after departure, refunds nothing.
+func QuoteRefund(tiers []models.CancellationTier, price float64, departure, cancelledOn time.Time) models.RefundQuote {
+	departure = DateOf(departure)
+	cancelledOn = DateOf(cancelledOn)
+	days := int(math.Round(departure.Sub(cancelledOn).Hours() / 24))
+
+	quote := models.RefundQuote{
+		Price:               price,
+		DepartureDate:       departure.Format(cancellationDateLayout),
+		CancellationDate:    cancelledOn.Format(cancellationDateLayout),
+		DaysBeforeDeparture: days,
+	}
+	if days < 0 {
+		return quote
+	}
+	for i := range tiers {
+		if days >= tiers[i].MinDaysBefore {
+			tier := tiers[i]
+			quote.Tier = &tier
+			quote.RefundPercent = tier.RefundPercent
+			quote.RefundAmount = math.Round(price*tier.RefundPercent) / 100
+			return quote
+		}
+	}
+	return quote
+}
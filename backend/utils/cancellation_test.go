@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"testing"
+	"time"
+	"trip-planner-backend/models"
+)
+
+func TestNormalizeCancellationPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		tiers   []models.CancellationTier
+		want    []int
+		wantErr bool
+	}{
+		{name: "empty policy", want: []int{}},
+		{
+			name:  "sorted from earliest cancellation",
+			tiers: []models.CancellationTier{{MinDaysBefore: 7, RefundPercent: 50}, {MinDaysBefore: 30, RefundPercent: 100}, {MinDaysBefore: 0, RefundPercent: 0}},
+			want:  []int{30, 7, 0},
+		},
+		{
+			name:    "duplicate threshold",
+			tiers:   []models.CancellationTier{{MinDaysBefore: 7, RefundPercent: 50}, {MinDaysBefore: 7, RefundPercent: 25}},
+			wantErr: true,
+		},
+		{
+			name:    "refund grows closer to departure",
+			tiers:   []models.CancellationTier{{MinDaysBefore: 30, RefundPercent: 50}, {MinDaysBefore: 7, RefundPercent: 75}},
+			wantErr: true,
+		},
+		{name: "negative days", tiers: []models.CancellationTier{{MinDaysBefore: -1, RefundPercent: 50}}, wantErr: true},
+		{name: "percent over 100", tiers: []models.CancellationTier{{MinDaysBefore: 1, RefundPercent: 101}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeCancellationPolicy(tt.tiers)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d tiers, want %d", len(got), len(tt.want))
+			}
+			for i, days := range tt.want {
+				if got[i].MinDaysBefore != days {
+					t.Errorf("tier %d has min_days_before %d, want %d", i, got[i].MinDaysBefore, days)
+				}
+			}
+		})
+	}
+}
+
+func TestQuoteRefund(t *testing.T) {
+	tiers := []models.CancellationTier{
+		{MinDaysBefore: 30, RefundPercent: 100},
+		{MinDaysBefore: 7, RefundPercent: 50},
+		{MinDaysBefore: 2, RefundPercent: 25},
+	}
+	departure := time.Date(2026, 12, 20, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		cancelledOn time.Time
+		price       float64
+		wantDays    int
+		wantPercent float64
+		wantAmount  float64
+		wantTier    bool
+	}{
+		{name: "well ahead", cancelledOn: time.Date(2026, 11, 1, 15, 0, 0, 0, time.UTC), price: 12000, wantDays: 49, wantPercent: 100, wantAmount: 12000, wantTier: true},
+		{name: "exactly on a threshold", cancelledOn: time.Date(2026, 12, 13, 9, 0, 0, 0, time.UTC), price: 12000, wantDays: 7, wantPercent: 50, wantAmount: 6000, wantTier: true},
+		{name: "between thresholds", cancelledOn: time.Date(2026, 12, 17, 0, 0, 0, 0, time.UTC), price: 999.99, wantDays: 3, wantPercent: 25, wantAmount: 250, wantTier: true},
+		{name: "later than every tier", cancelledOn: time.Date(2026, 12, 19, 23, 0, 0, 0, time.UTC), price: 12000, wantDays: 1},
+		{name: "after departure", cancelledOn: time.Date(2026, 12, 21, 0, 0, 0, 0, time.UTC), price: 12000, wantDays: -1},
+		// 01:00 IST on the 18th is still the 17th in UTC; the local date counts
+		{name: "local date, not UTC date", cancelledOn: time.Date(2026, 12, 18, 1, 0, 0, 0, ist), price: 12000, wantDays: 2, wantPercent: 25, wantAmount: 3000, wantTier: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote := QuoteRefund(tiers, tt.price, departure, tt.cancelledOn)
+			if quote.DaysBeforeDeparture != tt.wantDays {
+				t.Errorf("days before departure %d, want %d", quote.DaysBeforeDeparture, tt.wantDays)
+			}
+			if quote.RefundPercent != tt.wantPercent || quote.RefundAmount != tt.wantAmount {
+				t.Errorf("refund %.2f%% = %.2f, want %.2f%% = %.2f", quote.RefundPercent, quote.RefundAmount, tt.wantPercent, tt.wantAmount)
+			}
+			if (quote.Tier != nil) != tt.wantTier {
+				t.Errorf("tier %+v, want tier %v", quote.Tier, tt.wantTier)
+			}
+			if quote.DepartureDate != "2026-12-20" {
+				t.Errorf("departure date %s", quote.DepartureDate)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+//go:embed data/climate_normals.json
+var climateNormalsJSON []byte
+
+// ClimateNormal describes typical conditions for a district in a given month
+type ClimateNormal struct {
+	Month      int     `json:"month"`
+	AvgHighC   float64 `json:"avg_high_c"`
+	AvgLowC    float64 `json:"avg_low_c"`
+	RainfallMM float64 `json:"rainfall_mm"`
+	RainyDays  float64 `json:"rainy_days"`
+	Monsoon    bool    `json:"monsoon"`
+}
+
+// ErrNoClimateNormals is returned for destinations that aren't districts
+// and for districts the bundled dataset doesn't cover, such as ones added
+// by an admin after it was built
+var ErrNoClimateNormals = errors.New("no climate normals for this destination")
+
+var (
+	climateNormalsOnce sync.Once
+	// climateNormals is keyed by district slug, which survives renames and
+	// matches districts added later under the same slug
+	climateNormals map[string][]ClimateNormal
+)
+
+func loadClimateNormals() map[string][]ClimateNormal {
+	climateNormalsOnce.Do(func() {
+		var dataset struct {
+			Districts map[string][]ClimateNormal `json:"districts"`
+		}
+		if err := json.Unmarshal(climateNormalsJSON, &dataset); err != nil {
+			log.Printf("Error loading climate normals: %v", err)
+			climateNormals = map[string][]ClimateNormal{}
+			return
+		}
+		climateNormals = dataset.Districts
+	})
+	return climateNormals
+}
+
+// GetClimateNormal returns the bundled normal for a destination and month,
+// or ErrNoClimateNormals
+func GetClimateNormal(destination string, month time.Month) (ClimateNormal, error) {
+	district, ok := FindDistrict(destination)
+	if !ok {
+		return ClimateNormal{}, ErrNoClimateNormals
+	}
+
+	for _, normal := range loadClimateNormals()[district.Slug] {
+		if normal.Month == int(month) {
+			return normal, nil
+		}
+	}
+	return ClimateNormal{}, ErrNoClimateNormals
+}
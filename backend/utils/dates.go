@@ -0,0 +1,18 @@
+package utils
+
+import "time"
+
+// DateOf drops the time of day from t, keeping the calendar date in t's own
+// location. The result is midnight UTC so it compares directly with dates
+// parsed from "2006-01-02" strings and DATE columns.
+func DateOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Today is the server's local calendar date. Truncating time.Now() to 24
+// hours would give the UTC date instead, which in India is still yesterday
+// until 05:30.
+func Today() time.Time {
+	return DateOf(time.Now())
+}
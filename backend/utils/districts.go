@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"trip-planner-backend/models"
+)
+
+// The district registry holds the districts table in memory. Until the
+// table has been loaded (or if it hasn't been migrated yet) it serves the
+// built-in list derived from districtCoordinates.
+type districtRegistry struct {
+	mu        sync.RWMutex
+	districts []models.District
+	loadedAt  time.Time
+	loader    func() ([]models.District, error)
+	ttl       time.Duration
+}
+
+var districts = &districtRegistry{}
+
+var (
+	builtinOnce sync.Once
+	builtinList []models.District
+)
+
+// SetDistrictLoader registers the function that reads the districts table.
+// The registry reloads when its copy is older than ttl, so edits made on
+// another server instance show up within ttl.
+func SetDistrictLoader(loader func() ([]models.District, error), ttl time.Duration) {
+	districts.mu.Lock()
+	defer districts.mu.Unlock()
+	districts.loader = loader
+	districts.ttl = ttl
+	districts.loadedAt = time.Time{}
+}
+
+// InvalidateDistricts forces a reload on the next lookup, e.g. after an
+// admin edit
+func InvalidateDistricts() {
+	districts.mu.Lock()
+	defer districts.mu.Unlock()
+	districts.loadedAt = time.Time{}
+}
+
+func (r *districtRegistry) current() []models.District {
+	r.mu.RLock()
+	list, loader, fresh := r.districts, r.loader, time.Since(r.loadedAt) < r.ttl
+	r.mu.RUnlock()
+	if loader == nil {
+		builtinOnce.Do(func() { builtinList = builtinDistricts() })
+		return builtinList
+	}
+	if fresh && list != nil {
+		return list
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.districts != nil && time.Since(r.loadedAt) < r.ttl {
+		return r.districts
+	}
+
+	loaded, err := r.loader()
+	if err != nil || len(loaded) == 0 {
+		if err != nil {
+			log.Printf("Error loading districts, using built-in list: %v", err)
+		}
+		// Retry a failed or empty load after a minute rather than on every call
+		r.loadedAt = time.Now().Add(time.Minute - r.ttl)
+		if r.districts == nil {
+			r.districts = builtinDistricts()
+		}
+		return r.districts
+	}
+	r.districts = loaded
+	r.loadedAt = time.Now()
+	return r.districts
+}
+
+// AllDistricts returns every district sorted by name
+func AllDistricts() []models.District {
+	list := append([]models.District(nil), districts.current()...)
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// FindDistrict resolves a district as typed by a user ("Mysore", "mysuru",
+// "Mysuru (Mysore)" or the slug "mysuru") to its record
+func FindDistrict(name string) (models.District, bool) {
+	needle := strings.ToLower(strings.TrimSpace(name))
+	if needle == "" {
+		return models.District{}, false
+	}
+
+	list := districts.current()
+	for _, d := range list {
+		if strings.ToLower(d.Name) == needle || d.Slug == needle {
+			return d, true
+		}
+	}
+	for _, d := range list {
+		for _, alias := range d.Aliases {
+			if strings.ToLower(alias) == needle {
+				return d, true
+			}
+		}
+	}
+	return models.District{}, false
+}
+
+// DistrictNames lists every name a district is known by, for matching rows
+// that were stored under an alias
+func DistrictNames(d models.District) []string {
+	names := []string{d.Name}
+	for _, alias := range d.Aliases {
+		if !strings.EqualFold(alias, d.Name) {
+			names = append(names, alias)
+		}
+	}
+	return names
+}
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// DistrictSlug derives a URL slug from the primary part of a district name,
+// e.g. "Mysuru (Mysore)" becomes "mysuru"
+func DistrictSlug(name string) string {
+	primary, _, _ := strings.Cut(name, " (")
+	return strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(primary), "-"), "-")
+}
+
+// builtinDistricts is the fallback list used before the districts table
+// exists. Aliases come from the parenthesised old names.
+func builtinDistricts() []models.District {
+	list := make([]models.District, 0, len(districtCoordinates))
+	for name, coords := range districtCoordinates {
+		primary, alias, _ := strings.Cut(name, " (")
+		aliases := []string{}
+		if alias != "" {
+			aliases = append(aliases, primary, strings.TrimSuffix(alias, ")"))
+		}
+		list = append(list, models.District{
+			Name:        name,
+			Slug:        DistrictSlug(name),
+			Aliases:     aliases,
+			Longitude:   coords[0],
+			Latitude:    coords[1],
+			PhotoFolder: primary,
+			Highlights:  []string{},
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
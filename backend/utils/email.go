@@ -1,9 +1,13 @@
 package utils
 
 import (
+	"encoding/base64"
 	"fmt"
 	"net/smtp"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func SendPasswordResetEmail(toEmail, username, resetCode string) error {
@@ -126,3 +130,262 @@ Support Email: %s`, userName, replyMessage, from)
 
 	return nil
 }
+
+func SendWeatherAlertEmail(toEmail, name, destination, startDate string, alerts []SevereWeatherAlert) error {
+	from := os.Getenv("SMTP_USER")
+	password := os.Getenv("SMTP_PASSWORD")
+	smtpHost := os.Getenv("SMTP_HOST")
+	smtpPort := os.Getenv("SMTP_PORT")
+
+	details := ""
+	for _, alert := range alerts {
+		details += fmt.Sprintf("  - %s: %s\n", alert.Date, alert.Message)
+	}
+
+	subject := fmt.Sprintf("Weather Alert for your trip to %s - AI Trip Planner", destination)
+	body := fmt.Sprintf(`Hello %s,
+
+Your trip to %s starting on %s has severe weather in the forecast:
+
+%s
+Please check local advisories before heading out and keep indoor alternatives ready for these days. You can regenerate your packing list from Saved Trips to get updated gear suggestions.
+
+Stay safe,
+AI Trip Planner Team`, name, destination, startDate, details)
+
+	msg := fmt.Sprintf("From: %s\r\n"+
+		"To: %s\r\n"+
+		"Subject: %s\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=UTF-8\r\n"+
+		"\r\n"+
+		"%s\r\n", from, toEmail, subject, body)
+
+	auth := smtp.PlainAuth("", from, password, smtpHost)
+
+	err := smtp.SendMail(smtpHost+":"+smtpPort, auth, from, []string{toEmail}, []byte(msg))
+	if err != nil {
+		return fmt.Errorf("failed to send email: %v", err)
+	}
+
+	return nil
+}
+
+// sendPlainTextEmail sends a UTF-8 plain text email from SMTP_USER
+func sendPlainTextEmail(toEmail, subject, body string) error {
+	from := os.Getenv("SMTP_USER")
+	password := os.Getenv("SMTP_PASSWORD")
+	smtpHost := os.Getenv("SMTP_HOST")
+	smtpPort := os.Getenv("SMTP_PORT")
+
+	msg := fmt.Sprintf("From: %s\r\n"+
+		"To: %s\r\n"+
+		"Subject: %s\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=UTF-8\r\n"+
+		"\r\n"+
+		"%s\r\n", from, toEmail, subject, body)
+
+	auth := smtp.PlainAuth("", from, password, smtpHost)
+
+	err := smtp.SendMail(smtpHost+":"+smtpPort, auth, from, []string{toEmail}, []byte(msg))
+	if err != nil {
+		return fmt.Errorf("failed to send email: %v", err)
+	}
+
+	return nil
+}
+
+// SendBookingRequestEmail tells an agency about a new booking request
+func SendBookingRequestEmail(toEmail, agencyName, packageTitle, departureDate string, numTravelers int, total float64, contactName string) error {
+	subject := fmt.Sprintf("New booking request: %s - AI Trip Planner", packageTitle)
+	body := fmt.Sprintf(`Hello %s,
+
+%s has requested to book "%s" for %d traveler(s), departing on %s.
+
+Quoted total: INR %.2f
+
+Please accept or reject the request from your agency dashboard.
+
+Best regards,
+AI Trip Planner Team`, agencyName, contactName, packageTitle, numTravelers, departureDate, total)
+
+	return sendPlainTextEmail(toEmail, subject, body)
+}
+
+// SendBookingStatusEmail tells a traveler that their booking changed status
+func SendBookingStatusEmail(toEmail, name, packageTitle, departureDate, status, reason string) error {
+	var summary string
+	switch status {
+	case "confirmed":
+		summary = "has been confirmed by the agency. Have a great trip!"
+	case "completed":
+		summary = "has been marked as completed. We hope you enjoyed it - feedback for the agency is always welcome."
+	case "cancelled":
+		summary = "has been cancelled."
+	default:
+		summary = "is now " + status + "."
+	}
+	if reason != "" {
+		summary += "\n\nReason: " + reason
+	}
+
+	subject := fmt.Sprintf("Your booking for %s is %s - AI Trip Planner", packageTitle, status)
+	body := fmt.Sprintf(`Hello %s,
+
+Your booking for "%s" departing on %s %s
+
+You can see all your bookings under My Bookings.
+
+Best regards,
+AI Trip Planner Team`, name, packageTitle, departureDate, summary)
+
+	return sendPlainTextEmail(toEmail, subject, body)
+}
+
+// sendEmailWithAttachment sends a plain-text email with one attached file
+func sendEmailWithAttachment(toEmail, subject, body, filename, contentType string, attachment []byte) error {
+	from := os.Getenv("SMTP_USER")
+	password := os.Getenv("SMTP_PASSWORD")
+	smtpHost := os.Getenv("SMTP_HOST")
+	smtpPort := os.Getenv("SMTP_PORT")
+
+	boundary := "trip-planner-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n"+
+		"To: %s\r\n"+
+		"Subject: %s\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: multipart/mixed; boundary=%q\r\n"+
+		"\r\n", from, toEmail, subject, boundary)
+	fmt.Fprintf(&msg, "--%s\r\n"+
+		"Content-Type: text/plain; charset=UTF-8\r\n"+
+		"\r\n"+
+		"%s\r\n", boundary, body)
+	fmt.Fprintf(&msg, "--%s\r\n"+
+		"Content-Type: %s; name=%q\r\n"+
+		"Content-Disposition: attachment; filename=%q\r\n"+
+		"Content-Transfer-Encoding: base64\r\n"+
+		"\r\n", boundary, contentType, filename, filename)
+	// Base64 bodies are wrapped at 76 characters per RFC 2045
+	encoded := base64.StdEncoding.EncodeToString(attachment)
+	for len(encoded) > 76 {
+		msg.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	msg.WriteString(encoded + "\r\n")
+	fmt.Fprintf(&msg, "--%s--\r\n", boundary)
+
+	auth := smtp.PlainAuth("", from, password, smtpHost)
+
+	err := smtp.SendMail(smtpHost+":"+smtpPort, auth, from, []string{toEmail}, []byte(msg.String()))
+	if err != nil {
+		return fmt.Errorf("failed to send email: %v", err)
+	}
+
+	return nil
+}
+
+// SendBillingDocumentEmail sends a customer their quotation or invoice as
+// a PDF attachment
+func SendBillingDocumentEmail(toEmail, customerName, agencyName, documentType, documentNumber, packageTitle string, total float64, document []byte) error {
+	kind := "invoice"
+	if documentType == "quotation" {
+		kind = "quotation"
+	}
+	subject := fmt.Sprintf("Your %s %s from %s", kind, documentNumber, agencyName)
+	body := fmt.Sprintf(`Hello %s,
+
+Please find attached %s %s for "%s".
+
+Total: INR %s
+
+For any questions, reply to %s directly.
+
+Best regards,
+AI Trip Planner Team`, customerName, kind, documentNumber, packageTitle, FormatINR(total), agencyName)
+
+	filename := strings.NewReplacer("/", "-").Replace(documentNumber) + ".pdf"
+	return sendEmailWithAttachment(toEmail, subject, body, filename, "application/pdf", document)
+}
+
+// SendAgencyReviewRequestEmail tells the admin that an agency is waiting
+// for verification
+func SendAgencyReviewRequestEmail(toEmail, agencyName, agencyEmail string, documents int) error {
+	subject := fmt.Sprintf("Agency awaiting verification: %s - AI Trip Planner", agencyName)
+	body := fmt.Sprintf(`Hello,
+
+%s (%s) has submitted %d document(s) for verification.
+
+Please review the agency from the admin dashboard.
+
+Best regards,
+AI Trip Planner Team`, agencyName, agencyEmail, documents)
+
+	return sendPlainTextEmail(toEmail, subject, body)
+}
+
+// SendAgencyStatusEmail tells an agency the outcome of a verification
+// review
+func SendAgencyStatusEmail(toEmail, agencyName, status, notes string) error {
+	var summary string
+	switch status {
+	case "approved":
+		summary = "has been approved. Your active packages are now visible to travelers."
+	case "rejected":
+		summary = "could not be approved. You can upload corrected documents and submit them for review again."
+	case "suspended":
+		summary = "has been suspended. Your packages are hidden from travelers until the suspension is lifted."
+	case "pending":
+		summary = "is back under review."
+	default:
+		summary = "is now " + status + "."
+	}
+	if notes != "" {
+		summary += "\n\nReviewer notes: " + notes
+	}
+
+	subject := fmt.Sprintf("Your agency account is %s - AI Trip Planner", status)
+	body := fmt.Sprintf(`Hello %s,
+
+Your agency account %s
+
+You can see your verification status and documents in your agency dashboard.
+
+Best regards,
+AI Trip Planner Team`, agencyName, summary)
+
+	return sendPlainTextEmail(toEmail, subject, body)
+}
+
+// SendPackageModerationEmail tells an agency the outcome of a package review
+func SendPackageModerationEmail(toEmail, agencyName, packageTitle, status, notes string) error {
+	var summary, label string
+	switch status {
+	case "approved":
+		label = "approved"
+		summary = "has been approved. It is visible to travelers while it is active."
+	case "changes_requested":
+		label = "needs changes"
+		summary = "needs changes before it can be published. Edit the package to send it back for review."
+	case "rejected":
+		label = "rejected"
+		summary = "has been rejected and will not be published."
+	default:
+		label = status
+		summary = "is now " + status + "."
+	}
+	if notes != "" {
+		summary += "\n\nReviewer notes: " + notes
+	}
+
+	subject := fmt.Sprintf("Your package %s %s - AI Trip Planner", packageTitle, label)
+	body := fmt.Sprintf(`Hello %s,
+
+Your package "%s" %s
+
+Best regards,
+AI Trip Planner Team`, agencyName, packageTitle, summary)
+
+	return sendPlainTextEmail(toEmail, subject, body)
+}
@@ -29,15 +29,24 @@ type GroqResponse struct {
 	} `json:"choices"`
 }
 
-func GenerateTripWithGroq(tripReq models.TripRequest) (string, error) {
+// GenerateTripWithGroq writes the itinerary and reports which of the places
+// and restaurants it names are in the local catalog
+func GenerateTripWithGroq(tripReq models.TripRequest) (string, ItineraryGrounding, error) {
+	var grounding ItineraryGrounding
 	apiKey := os.Getenv("GROQ_API_KEY")
 	if apiKey == "" {
-		return "", fmt.Errorf("GROQ_API_KEY not set")
+		return "", grounding, fmt.Errorf("GROQ_API_KEY not set")
 	}
 
 	// Calculate trip duration
-	startDate, _ := time.Parse("2006-01-02", tripReq.StartDate)
-	endDate, _ := time.Parse("2006-01-02", tripReq.EndDate)
+	startDate, err := time.Parse("2006-01-02", tripReq.StartDate)
+	if err != nil {
+		return "", grounding, fmt.Errorf("invalid start date: %w", err)
+	}
+	endDate, err := time.Parse("2006-01-02", tripReq.EndDate)
+	if err != nil || endDate.Before(startDate) {
+		return "", grounding, fmt.Errorf("invalid end date %q", tripReq.EndDate)
+	}
 	duration := int(endDate.Sub(startDate).Hours()/24) + 1
 
 	// Format dates
@@ -76,8 +85,21 @@ func GenerateTripWithGroq(tripReq models.TripRequest) (string, error) {
 	// Get distance info (no cost details passed to AI)
 	travelInfo := FormatTravelCostsForTripINR(tripReq.InitialDestination, tripReq.FinalDestination, tripReq.NumTravelers)
 
-	// Get weather info
-	weatherInfo := FormatWeatherForTrip(tripReq.FinalDestination)
+	// Get weather info for the trip dates
+	tripWeather := GetTripWeather(tripReq.FinalDestination, startDate, endDate)
+	weatherInfo := FormatTripWeather(tripWeather)
+	weatherSummary := SummarizeTripWeatherForPrompt(tripWeather, startDate)
+
+	// Offer curated places as preferred picks so the model invents fewer names
+	candidates := RetrieveCandidates(tripReq.FinalDestination, tripReq.Mood)
+	catalogSection := ""
+	if catalogInfo := FormatCandidatesForPrompt(candidates); catalogInfo != "" {
+		catalogSection = fmt.Sprintf(`
+VERIFIED LOCAL CATALOG:
+%s
+Prefer the places and restaurants above and write their names exactly as listed. Schedule visits within their opening hours and allow the listed visit time. Only add places or restaurants outside this list if you are certain they exist.
+`, catalogInfo)
+	}
 
 	prompt := fmt.Sprintf(`Create a personalized %d-day trip itinerary for %s from %s to %s.
 This is for a %s trip focusing on these activities: %s.
@@ -85,6 +107,11 @@ This is for a %s trip focusing on these activities: %s.
 TRAVEL INFORMATION:
 %s
 
+WEATHER BY DAY:
+%s
+
+Use the weather above when scheduling: on days marked RAINY or LIKELY WET, plan indoor activities (museums, palaces, temples, markets, cafes) and keep treks, waterfalls and viewpoints for the drier days.
+%s
 IMPORTANT: DO NOT include any cost estimates, budget breakdowns, or accommodation prices in your response. Only provide the itinerary, attractions, and restaurant recommendations.
 
 Please include:
@@ -161,6 +188,8 @@ This is a %d-day itinerary focused on %s for %d travelers.`,
 		companions,
 		activities,
 		travelInfo,
+		weatherSummary,
+		catalogSection,
 		tripReq.FinalDestination,
 		tripReq.FinalDestination,
 		tripReq.InitialDestination,
@@ -206,12 +235,12 @@ This is a %d-day itinerary focused on %s for %d travelers.`,
 
 	jsonData, err := json.Marshal(groqReq)
 	if err != nil {
-		return "", err
+		return "", grounding, err
 	}
 
 	req, err := http.NewRequest("POST", "https://api.groq.com/openai/v1/chat/completions", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", err
+		return "", grounding, err
 	}
 
 	req.Header.Set("Authorization", "Bearer "+apiKey)
@@ -220,31 +249,35 @@ This is a %d-day itinerary focused on %s for %d travelers.`,
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", err
+		return "", grounding, err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return "", grounding, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("groq API error: %s", string(body))
+		return "", grounding, fmt.Errorf("groq API error: %s", string(body))
 	}
 
 	var groqResp GroqResponse
 	if err := json.Unmarshal(body, &groqResp); err != nil {
-		return "", err
+		return "", grounding, err
 	}
 
 	if len(groqResp.Choices) == 0 {
-		return "", fmt.Errorf("no response from Groq API")
+		return "", grounding, fmt.Errorf("no response from Groq API")
 	}
 
 	// Get the trip content WITHOUT expenses
 	tripContent := groqResp.Choices[0].Message.Content
 
+	// Check the model's picks before the generated sections are appended
+	grounding = VerifyItinerary(tripContent, tripReq.FinalDestination)
+	grounding.Candidates = len(candidates.Places) + len(candidates.Restaurants)
+
 	// Now ADD the manual travel expense calculation
 	costs, _ := CalculateTravelCosts(tripReq.InitialDestination, tripReq.FinalDestination, tripReq.NumTravelers)
 
@@ -270,9 +303,8 @@ This is a %d-day itinerary focused on %s for %d travelers.`,
 
 %s
 
-**Packing Recommendations:**
-Based on the weather forecast above, make sure to pack appropriate clothing and gear. Check the weather closer to your travel dates for the most accurate information.
-`,
+## Packing Checklist
+%s`,
 		tripReq.InitialDestination,
 		tripReq.FinalDestination,
 		costs.Distance,
@@ -287,10 +319,11 @@ Based on the weather forecast above, make sure to pack appropriate clothing and
 		costs.TrainCost/float64(tripReq.NumTravelers),
 		costs.TrainCost,
 		weatherInfo,
+		FormatPackingListMarkdown(GeneratePackingList(tripWeather, PackingOptionsForTrip(tripReq))),
 	)
 
 	// Append manual calculations to the AI-generated content
 	tripContent += travelExpenseSection
 
-	return tripContent, nil
+	return tripContent, grounding, nil
 }
@@ -0,0 +1,485 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"trip-planner-backend/models"
+)
+
+// CatalogPlace is a curated POI or restaurant along with traveler feedback
+// that mentions it, which is indexed for retrieval too
+type CatalogPlace struct {
+	POI      models.POI
+	Feedback []string
+}
+
+// The place catalog is cached like the district registry. Without a loader
+// (or before the pois table is migrated) the bundled CSV is used.
+type placeCatalog struct {
+	mu       sync.RWMutex
+	places   []CatalogPlace
+	loadedAt time.Time
+	loader   func() ([]CatalogPlace, error)
+	ttl      time.Duration
+}
+
+var catalog = &placeCatalog{}
+
+// SetPlaceCatalogLoader registers the function that reads the POI catalog
+// and matching feedback from the database
+func SetPlaceCatalogLoader(loader func() ([]CatalogPlace, error), ttl time.Duration) {
+	catalog.mu.Lock()
+	defer catalog.mu.Unlock()
+	catalog.loader = loader
+	catalog.ttl = ttl
+	catalog.loadedAt = time.Time{}
+}
+
+// InvalidatePlaceCatalog forces a reload on the next lookup, e.g. after an
+// admin edit
+func InvalidatePlaceCatalog() {
+	catalog.mu.Lock()
+	defer catalog.mu.Unlock()
+	catalog.loadedAt = time.Time{}
+}
+
+func (c *placeCatalog) current() []CatalogPlace {
+	c.mu.RLock()
+	places, fresh := c.places, time.Since(c.loadedAt) < c.ttl
+	c.mu.RUnlock()
+	if places != nil && fresh {
+		return places
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.places != nil && time.Since(c.loadedAt) < c.ttl {
+		return c.places
+	}
+
+	var loaded []CatalogPlace
+	var err error
+	if c.loader != nil {
+		loaded, err = c.loader()
+		if err != nil {
+			log.Printf("Error loading place catalog, using bundled list: %v", err)
+		}
+	}
+	if len(loaded) == 0 {
+		loaded = bundledCatalog()
+		if c.loader != nil {
+			// Retry a failed or empty load after a minute rather than on every call
+			c.loadedAt = time.Now().Add(time.Minute - c.ttl)
+			c.places = loaded
+			return loaded
+		}
+	}
+	c.places = loaded
+	c.loadedAt = time.Now()
+	return loaded
+}
+
+func bundledCatalog() []CatalogPlace {
+	pois, _, err := BundledPOIs()
+	if err != nil {
+		log.Printf("Error reading bundled places: %v", err)
+	}
+	places := make([]CatalogPlace, len(pois))
+	for i, p := range pois {
+		places[i] = CatalogPlace{POI: p}
+	}
+	return places
+}
+
+// Retrieval limits for the prompt
+const (
+	groundingRadiusKm      = 100
+	groundingMaxPlaces     = 8
+	groundingMaxRestaurant = 5
+)
+
+// moodKeywords expands a trip mood into words that appear in catalog
+// names, categories, tags and descriptions
+var moodKeywords = map[string]string{
+	"cultural":       "culture temple museum art gallery tradition heritage palace festival music craft worship",
+	"natural_beauty": "nature waterfall viewpoint park garden lake hill forest scenic sunset beach wildlife island",
+	"historical":     "history historic heritage fort palace monument ruins architecture dynasty tomb ancient century",
+	"adventure":      "trek hike climb rafting wildlife safari waterfall peak forest adventure rock",
+	"relaxation":     "relax beach lake garden park coffee peaceful sunset calm island",
+}
+
+// restaurantKeywords are added to every restaurant query so local
+// specialities and meal-time places rank first
+const restaurantKeywords = "restaurant breakfast lunch dinner famous local speciality meals"
+
+// GroundingCandidate is a catalog entry retrieved for a trip
+type GroundingCandidate struct {
+	POI        models.POI
+	DistanceKm float64
+	Score      float64
+}
+
+// GroundingCandidates are the catalog entries offered to the model
+type GroundingCandidates struct {
+	Places      []GroundingCandidate
+	Restaurants []GroundingCandidate
+}
+
+// nearbyCatalog returns catalog entries within the grounding radius of a
+// destination, with their distance from its centre
+func nearbyCatalog(destination string) ([]CatalogPlace, []float64) {
+	district, ok := FindDistrict(destination)
+	if !ok {
+		return nil, nil
+	}
+
+	places := []CatalogPlace{}
+	distances := []float64{}
+	for _, place := range catalog.current() {
+		km := calculateHaversineDistance(district.Latitude, district.Longitude, place.POI.Latitude, place.POI.Longitude)
+		if km <= groundingRadiusKm {
+			places = append(places, place)
+			distances = append(distances, km)
+		}
+	}
+	return places, distances
+}
+
+// RetrieveCandidates ranks catalog places and restaurants near the
+// destination with BM25 over names, categories, tags, descriptions and
+// feedback. The query is the mood's keywords plus the destination, so
+// places in the destination district itself rank above neighbours.
+func RetrieveCandidates(destination, mood string) GroundingCandidates {
+	places, distances := nearbyCatalog(destination)
+	if len(places) == 0 {
+		return GroundingCandidates{}
+	}
+
+	docs := make([][]string, len(places))
+	for i, place := range places {
+		p := place.POI
+		// The name counts twice so a name match outweighs a passing mention
+		text := strings.Join([]string{p.Name, p.Name, strings.ReplaceAll(p.Category, "_", " "), strings.Join(p.Tags, " "),
+			p.Description, p.DistrictName, strings.Join(place.Feedback, " ")}, " ")
+		docs[i] = Tokenize(text)
+	}
+	idx := NewBM25Index(docs)
+
+	destinationTerms := Tokenize(destination)
+	placeScores := idx.Scores(append(Tokenize(moodKeywords[mood]), destinationTerms...))
+	restaurantScores := idx.Scores(append(Tokenize(restaurantKeywords+" "+moodKeywords[mood]), destinationTerms...))
+
+	var result GroundingCandidates
+	for i, place := range places {
+		if place.POI.Category == "restaurant" {
+			result.Restaurants = append(result.Restaurants, GroundingCandidate{POI: place.POI, DistanceKm: distances[i], Score: restaurantScores[i]})
+		} else {
+			result.Places = append(result.Places, GroundingCandidate{POI: place.POI, DistanceKm: distances[i], Score: placeScores[i]})
+		}
+	}
+	result.Places = topCandidates(result.Places, groundingMaxPlaces)
+	result.Restaurants = topCandidates(result.Restaurants, groundingMaxRestaurant)
+	return result
+}
+
+// topCandidates orders by score, then by distance for equal scores
+func topCandidates(candidates []GroundingCandidate, limit int) []GroundingCandidate {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		return candidates[i].DistanceKm < candidates[j].DistanceKm
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	return candidates
+}
+
+// FormatCandidatesForPrompt lists the retrieved places for the model.
+// Entry fees are left out because the itinerary must not mention prices.
+func FormatCandidatesForPrompt(c GroundingCandidates) string {
+	if len(c.Places) == 0 && len(c.Restaurants) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	if len(c.Places) > 0 {
+		b.WriteString("Places:\n")
+		for _, cand := range c.Places {
+			p := cand.POI
+			fmt.Fprintf(&b, "- %s (%s, %s, %.0f km from centre) - hours: %s", p.Name, strings.ReplaceAll(p.Category, "_", " "),
+				p.DistrictName, cand.DistanceKm, FormatOpeningHours(p.OpeningHours))
+			if p.VisitDurationMinutes > 0 {
+				fmt.Fprintf(&b, "; allow about %s", formatMinutes(p.VisitDurationMinutes))
+			}
+			if p.Description != "" {
+				b.WriteString(". " + p.Description)
+			}
+			b.WriteString("\n")
+		}
+	}
+	if len(c.Restaurants) > 0 {
+		b.WriteString("Restaurants:\n")
+		for _, cand := range c.Restaurants {
+			p := cand.POI
+			fmt.Fprintf(&b, "- %s (%s)", p.Name, p.DistrictName)
+			if len(p.Tags) > 0 {
+				b.WriteString(" - " + strings.Join(p.Tags, ", "))
+			}
+			if p.Description != "" {
+				b.WriteString(". " + p.Description)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func formatMinutes(minutes int) string {
+	if minutes < 60 {
+		return fmt.Sprintf("%d min", minutes)
+	}
+	if minutes%60 == 0 {
+		return fmt.Sprintf("%d h", minutes/60)
+	}
+	return fmt.Sprintf("%d h %d min", minutes/60, minutes%60)
+}
+
+var weekdayLabels = []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+
+// FormatOpeningHours renders periods in the compact seed-file style, e.g.
+// "Daily 09:00-17:30" or "Mon-Sat 09:00-17:00; Sun closed"
+func FormatOpeningHours(periods []models.OpeningPeriod) string {
+	if len(periods) == 0 {
+		return "not listed"
+	}
+
+	windows := make([]string, 7)
+	for _, p := range periods {
+		if windows[p.Day] != "" {
+			windows[p.Day] += ","
+		}
+		windows[p.Day] += p.Open + "-" + p.Close
+	}
+	for day := range windows {
+		if windows[day] == "00:00-24:00" {
+			windows[day] = "open 24 hours"
+		} else if windows[day] == "" {
+			windows[day] = "closed"
+		}
+	}
+
+	// Runs of identical days, Monday first
+	order := []int{1, 2, 3, 4, 5, 6, 0}
+	rules := []string{}
+	for i := 0; i < len(order); {
+		j := i
+		for j+1 < len(order) && windows[order[j+1]] == windows[order[i]] {
+			j++
+		}
+		days := weekdayLabels[order[i]]
+		if j > i {
+			days += "-" + weekdayLabels[order[j]]
+		}
+		rules = append(rules, days+" "+windows[order[i]])
+		i = j + 1
+	}
+	if len(rules) == 1 {
+		if windows[0] == "open 24 hours" || windows[0] == "closed" {
+			return windows[0]
+		}
+		return "Daily " + windows[0]
+	}
+	return strings.Join(rules, "; ")
+}
+
+// ItineraryItem is a place or restaurant named in a generated itinerary
+type ItineraryItem struct {
+	Name        string `json:"name"`
+	Kind        string `json:"kind"`
+	Day         int    `json:"day,omitempty"`
+	Verified    bool   `json:"verified"`
+	POIID       int    `json:"poi_id,omitempty"`
+	CatalogName string `json:"catalog_name,omitempty"`
+}
+
+// ItineraryGrounding records which itinerary items exist in the catalog
+type ItineraryGrounding struct {
+	Candidates int             `json:"candidates"`
+	Verified   int             `json:"verified"`
+	Unverified int             `json:"unverified"`
+	Items      []ItineraryItem `json:"items"`
+}
+
+var (
+	dayHeaderPattern = regexp.MustCompile(`(?i)^#{1,3}\s*day\s+(\d+)`)
+	boldPattern      = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	mealLabelPattern = regexp.MustCompile(`(?i)\b(breakfast|lunch|dinner|restaurants?|caf[eé]s?|snacks?)\b`)
+)
+
+// VerifyItinerary finds the places and restaurants named in bold (or after a
+// meal label) in the generated markdown and checks each against the
+// catalog near the destination
+func VerifyItinerary(markdown, destination string) ItineraryGrounding {
+	places, _ := nearbyCatalog(destination)
+	grounding := ItineraryGrounding{Items: []ItineraryItem{}}
+	seen := make(map[string]bool)
+
+	day := 0
+	for _, line := range strings.Split(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if m := dayHeaderPattern.FindStringSubmatch(trimmed); m != nil {
+			day, _ = strconv.Atoi(m[1])
+			continue
+		}
+		if strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		for _, name := range itineraryNames(trimmed) {
+			key := normalizePlaceName(name)
+			if key == "" || seen[key+"|"+strconv.Itoa(day)] {
+				continue
+			}
+			seen[key+"|"+strconv.Itoa(day)] = true
+
+			item := ItineraryItem{Name: name, Kind: "place", Day: day}
+			if mealLabelPattern.MatchString(trimmed) {
+				item.Kind = "restaurant"
+			}
+			if match, ok := matchCatalogPlace(name, places); ok {
+				item.Verified = true
+				item.POIID = match.POIID
+				item.CatalogName = match.Name
+				if match.Category == "restaurant" {
+					item.Kind = "restaurant"
+				} else {
+					item.Kind = "place"
+				}
+				grounding.Verified++
+			} else {
+				grounding.Unverified++
+			}
+			grounding.Items = append(grounding.Items, item)
+		}
+	}
+	return grounding
+}
+
+// itineraryNames pulls candidate names out of one markdown line. Bold text
+// ending in a colon is a label ("**Morning Activities:**"); after a meal
+// label the restaurant name may be plain text up to a dash or bracket.
+func itineraryNames(line string) []string {
+	names := []string{}
+	labelled := false
+	for _, m := range boldPattern.FindAllStringSubmatch(line, -1) {
+		text := strings.TrimSpace(m[1])
+		if strings.HasSuffix(text, ":") {
+			labelled = mealLabelPattern.MatchString(text)
+			continue
+		}
+		if isPlausiblePlaceName(text) {
+			names = append(names, strings.TrimRight(text, ".,;"))
+		}
+	}
+
+	if labelled && len(names) == 0 {
+		_, rest, _ := strings.Cut(line, ":**")
+		rest = strings.TrimSpace(rest)
+		if i := strings.IndexAny(rest, "-–—(,"); i > 0 {
+			rest = rest[:i]
+		}
+		if rest = strings.TrimSpace(rest); isPlausiblePlaceName(rest) {
+			names = append(names, rest)
+		}
+	}
+	return names
+}
+
+// isPlausiblePlaceName rejects labels, sentences and bare district names
+func isPlausiblePlaceName(text string) bool {
+	if len(text) < 3 || len(text) > 80 || strings.Contains(text, ":") || len(strings.Fields(text)) > 8 {
+		return false
+	}
+	_, isDistrict := FindDistrict(text)
+	return !isDistrict
+}
+
+var placeNameFiller = map[string]bool{"the": true, "sri": true, "shri": true, "of": true, "and": true, "at": true}
+
+// normalizePlaceName lowercases a name and drops punctuation and filler
+// words, so "The Mysore Palace" and "Mysore Palace." compare equal
+func normalizePlaceName(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9')
+	})
+	kept := words[:0]
+	for _, w := range words {
+		if !placeNameFiller[w] {
+			kept = append(kept, w)
+		}
+	}
+	return strings.Join(kept, " ")
+}
+
+// matchCatalogPlace accepts a catalog entry whose normalised name contains,
+// or is contained in, the itinerary name, or that shares most of its words
+func matchCatalogPlace(name string, places []CatalogPlace) (models.POI, bool) {
+	target := normalizePlaceName(name)
+	if len(target) < 4 {
+		return models.POI{}, false
+	}
+
+	best, bestScore := models.POI{}, 0.0
+	for _, place := range places {
+		candidate := normalizePlaceName(place.POI.Name)
+		if candidate == "" {
+			continue
+		}
+		score := 0.0
+		if candidate == target {
+			score = 1
+		} else if strings.Contains(" "+target+" ", " "+candidate+" ") {
+			score = 0.9
+		} else if strings.Contains(" "+candidate+" ", " "+target+" ") && strings.Contains(target, " ") {
+			// A one-word name like "Palace" is too vague to verify
+			score = 0.8
+		} else {
+			score = wordOverlap(target, candidate)
+		}
+		if score > bestScore {
+			best, bestScore = place.POI, score
+		}
+	}
+	return best, bestScore >= 0.6
+}
+
+// wordOverlap is the Jaccard similarity of the two names' word sets
+func wordOverlap(a, b string) float64 {
+	wordsA := strings.Fields(a)
+	setB := make(map[string]bool)
+	for _, w := range strings.Fields(b) {
+		setB[w] = true
+	}
+	shared := 0
+	union := len(setB)
+	for _, w := range wordsA {
+		if setB[w] {
+			shared++
+		} else {
+			union++
+		}
+	}
+	if union == 0 {
+		return 0
+	}
+	return math.Round(float64(shared)/float64(union)*100) / 100
+}
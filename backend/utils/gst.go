@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"trip-planner-backend/models"
+)
+
+// TourOperatorSAC is the services accounting code for tour operator
+// services
+const TourOperatorSAC = "998552"
+
+// gstStates maps GST state codes to state and union territory names
+var gstStates = map[string]string{
+	"01": "Jammu and Kashmir", "02": "Himachal Pradesh", "03": "Punjab", "04": "Chandigarh",
+	"05": "Uttarakhand", "06": "Haryana", "07": "Delhi", "08": "Rajasthan", "09": "Uttar Pradesh",
+	"10": "Bihar", "11": "Sikkim", "12": "Arunachal Pradesh", "13": "Nagaland", "14": "Manipur",
+	"15": "Mizoram", "16": "Tripura", "17": "Meghalaya", "18": "Assam", "19": "West Bengal",
+	"20": "Jharkhand", "21": "Odisha", "22": "Chhattisgarh", "23": "Madhya Pradesh", "24": "Gujarat",
+	"26": "Dadra and Nagar Haveli and Daman and Diu", "27": "Maharashtra", "29": "Karnataka", "30": "Goa",
+	"31": "Lakshadweep", "32": "Kerala", "33": "Tamil Nadu", "34": "Puducherry",
+	"35": "Andaman and Nicobar Islands", "36": "Telangana", "37": "Andhra Pradesh", "38": "Ladakh",
+	"97": "Other Territory",
+}
+
+var gstinPattern = regexp.MustCompile(`^[0-9]{2}[A-Z]{5}[0-9]{4}[A-Z][1-9A-Z]Z[0-9A-Z]$`)
+
+const gstinAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// GSTStateName names a GST state code
+func GSTStateName(code string) (string, bool) {
+	name, ok := gstStates[code]
+	return name, ok
+}
+
+// ValidGSTIN checks a GSTIN's format, state code and check digit
+func ValidGSTIN(gstin string) bool {
+	if !gstinPattern.MatchString(gstin) {
+		return false
+	}
+	if _, ok := gstStates[gstin[:2]]; !ok {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 14; i++ {
+		product := strings.IndexByte(gstinAlphabet, gstin[i]) * (1 + i%2)
+		sum += product/36 + product%36
+	}
+	return gstin[14] == gstinAlphabet[(36-sum%36)%36]
+}
+
+// SplitGST computes GST at rate percent on amount. When inclusive is set
+// amount already contains the tax and is split into taxable value and
+// tax; otherwise tax is added on top.
+func SplitGST(amount, rate float64, inclusive, intraState bool) models.TaxSplit {
+	split := models.TaxSplit{Rate: rate, IntraState: intraState}
+	if inclusive {
+		split.Total = roundMoney(amount)
+		split.TaxableValue = roundMoney(amount * 100 / (100 + rate))
+		split.TotalTax = roundMoney(split.Total - split.TaxableValue)
+	} else {
+		split.TaxableValue = roundMoney(amount)
+		split.TotalTax = roundMoney(amount * rate / 100)
+		split.Total = roundMoney(split.TaxableValue + split.TotalTax)
+	}
+	if intraState {
+		split.CGST = roundMoney(split.TotalTax / 2)
+		split.SGST = roundMoney(split.TotalTax - split.CGST)
+	} else {
+		split.IGST = split.TotalTax
+	}
+	return split
+}
+
+// FinancialYear is the Indian financial year (April to March) containing
+// t, e.g. "2026-27"
+func FinancialYear(t time.Time) string {
+	start := t.Year()
+	if t.Month() < time.April {
+		start--
+	}
+	return fmt.Sprintf("%d-%02d", start, (start+1)%100)
+}
+
+// MaxBillingDocumentSequence is the last document number a financial year
+// can use, so that numbers stay within 16 characters
+const MaxBillingDocumentSequence = 99999
+
+// BillingDocumentNumber formats the sequence'th document of a financial
+// year, e.g. INV/26-27/00042. GST caps invoice numbers at 16 characters,
+// so the year is shortened and sequence may not exceed
+// MaxBillingDocumentSequence.
+func BillingDocumentNumber(docType, financialYear string, sequence int) string {
+	prefix := "INV"
+	if docType == models.DocumentQuotation {
+		prefix = "QT"
+	}
+	// "2026-27" becomes "26-27"
+	year := financialYear
+	if len(year) == len("2026-27") {
+		year = year[2:]
+	}
+	return fmt.Sprintf("%s/%s/%05d", prefix, year, sequence)
+}
@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"testing"
+	"time"
+	"trip-planner-backend/models"
+)
+
+func TestValidGSTIN(t *testing.T) {
+	tests := []struct {
+		gstin string
+		want  bool
+	}{
+		{"27AAPFU0939F1ZV", true},
+		{"29AAGCB1286Q1Z0", true},
+		{"27AAPFU0939F1ZW", false}, // wrong check digit
+		{"99AAPFU0939F1ZV", false}, // unknown state code
+		{"27aapfu0939f1zv", false}, // lower case
+		{"27AAPFU0939F1V", false},  // too short
+		{"27AAPFU0939F0ZV", false}, // entity number can't be 0
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.gstin, func(t *testing.T) {
+			if got := ValidGSTIN(tt.gstin); got != tt.want {
+				t.Errorf("ValidGSTIN(%q) = %v, want %v", tt.gstin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitGST(t *testing.T) {
+	tests := []struct {
+		name       string
+		amount     float64
+		rate       float64
+		inclusive  bool
+		intraState bool
+		want       models.TaxSplit
+	}{
+		{
+			name: "exclusive intra-state", amount: 10000, rate: 5, intraState: true,
+			want: models.TaxSplit{Rate: 5, IntraState: true, TaxableValue: 10000, CGST: 250, SGST: 250, TotalTax: 500, Total: 10500},
+		},
+		{
+			name: "exclusive inter-state", amount: 10000, rate: 18,
+			want: models.TaxSplit{Rate: 18, TaxableValue: 10000, IGST: 1800, TotalTax: 1800, Total: 11800},
+		},
+		{
+			name: "inclusive inter-state", amount: 10500, rate: 5, inclusive: true,
+			want: models.TaxSplit{Rate: 5, TaxableValue: 10000, IGST: 500, TotalTax: 500, Total: 10500},
+		},
+		{
+			name: "inclusive rounds the taxable value", amount: 1000, rate: 5, inclusive: true, intraState: true,
+			want: models.TaxSplit{Rate: 5, IntraState: true, TaxableValue: 952.38, CGST: 23.81, SGST: 23.81, TotalTax: 47.62, Total: 1000},
+		},
+		{
+			name: "zero rate", amount: 2500, rate: 0, intraState: true,
+			want: models.TaxSplit{Rate: 0, IntraState: true, TaxableValue: 2500, Total: 2500},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitGST(tt.amount, tt.rate, tt.inclusive, tt.intraState)
+			if got != tt.want {
+				t.Errorf("got  %+v\nwant %+v", got, tt.want)
+			}
+			if got.CGST+got.SGST+got.IGST != got.TotalTax {
+				t.Errorf("components %.2f + %.2f + %.2f don't add up to %.2f", got.CGST, got.SGST, got.IGST, got.TotalTax)
+			}
+		})
+	}
+}
+
+func TestFinancialYear(t *testing.T) {
+	tests := []struct {
+		date string
+		want string
+	}{
+		{"2026-03-31", "2025-26"},
+		{"2026-04-01", "2026-27"},
+		{"2026-12-31", "2026-27"},
+		{"2099-06-15", "2099-00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			date, _ := time.Parse("2006-01-02", tt.date)
+			if got := FinancialYear(date); got != tt.want {
+				t.Errorf("FinancialYear(%s) = %s, want %s", tt.date, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBillingDocumentNumber(t *testing.T) {
+	tests := []struct {
+		docType  string
+		sequence int
+		want     string
+	}{
+		{models.DocumentInvoice, 42, "INV/26-27/00042"},
+		{models.DocumentQuotation, 1, "QT/26-27/00001"},
+		{models.DocumentInvoice, MaxBillingDocumentSequence, "INV/26-27/99999"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			got := BillingDocumentNumber(tt.docType, "2026-27", tt.sequence)
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+			// document_number is VARCHAR(16)
+			if len(got) > 16 {
+				t.Errorf("%s is longer than 16 characters", got)
+			}
+		})
+	}
+}
+
+func TestFormatINR(t *testing.T) {
+	tests := []struct {
+		amount float64
+		want   string
+	}{
+		{0, "0.00"},
+		{999.5, "999.50"},
+		{1000, "1,000.00"},
+		{123456.78, "1,23,456.78"},
+		{12345678.9, "1,23,45,678.90"},
+		{-1500, "-1,500.00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := FormatINR(tt.amount); got != tt.want {
+				t.Errorf("FormatINR(%v) = %s, want %s", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAmountInWords(t *testing.T) {
+	tests := []struct {
+		amount float64
+		want   string
+	}{
+		{0, "Rupees Zero Only"},
+		{1, "Rupees One Only"},
+		{21.5, "Rupees Twenty-One and Fifty Paise Only"},
+		{0.07, "Rupees Zero and Seven Paise Only"},
+		{115000, "Rupees One Lakh Fifteen Thousand Only"},
+		{12345678, "Rupees One Crore Twenty-Three Lakh Forty-Five Thousand Six Hundred Seventy-Eight Only"},
+		{10000000000, "Rupees 1000 Crore Only"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := AmountInWords(tt.amount); got != tt.want {
+				t.Errorf("AmountInWords(%v) = %q, want %q", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
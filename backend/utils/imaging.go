@@ -0,0 +1,368 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	stddraw "image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"strings"
+	"trip-planner-backend/models"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupportedImage = errors.New("unsupported image type")
+	ErrImageTooLarge    = errors.New("image dimensions too large")
+)
+
+// Decoding a huge image allocates width*height*4 bytes, so refuse anything
+// above ~40 megapixels before decoding
+const maxImagePixels = 40_000_000
+
+// AllowedImageTypes maps sniffed content types to their canonical extension
+var AllowedImageTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// AllowedImageExtensions maps accepted upload extensions to the content
+// type the file's bytes must sniff as
+var AllowedImageExtensions = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+}
+
+// ImageVariantSpec is one generated size; images are scaled to fit within
+// MaxDim on their longest side and never upscaled
+type ImageVariantSpec struct {
+	Name        string
+	MaxDim      int
+	JPEGQuality int
+}
+
+var ImageVariantSpecs = []ImageVariantSpec{
+	{Name: "thumb", MaxDim: 320, JPEGQuality: 75},
+	{Name: "medium", MaxDim: 960, JPEGQuality: 80},
+	{Name: "large", MaxDim: 1920, JPEGQuality: 85},
+}
+
+// EncodedImage is one variant in one format, ready to be written out
+type EncodedImage struct {
+	Variant     string
+	Ext         string
+	ContentType string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+// ProcessedImage is the result of running an upload through the pipeline
+type ProcessedImage struct {
+	SourceType string
+	Width      int
+	Height     int
+	Outputs    []EncodedImage
+}
+
+// SniffImageType detects the real content type from the file's first bytes
+// and rejects anything that is not an allowed image type
+func SniffImageType(head []byte) (string, error) {
+	contentType := http.DetectContentType(head)
+	if _, ok := AllowedImageTypes[contentType]; !ok {
+		return contentType, fmt.Errorf("%w: %s", ErrUnsupportedImage, contentType)
+	}
+	return contentType, nil
+}
+
+// ProcessImage validates an uploaded image, applies its EXIF orientation and
+// re-encodes it into every variant as JPEG. Re-encoding drops all metadata,
+// including EXIF GPS coordinates.
+//
+// WebP uploads are accepted but not produced: the only pure-Go WebP
+// encoder is lossless, which made photos several times larger than the
+// JPEG, so the variants were almost never kept.
+func ProcessImage(r io.Reader) (*ProcessedImage, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType, err := SniffImageType(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxImagePixels {
+		return nil, ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+
+	if contentType == "image/jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+
+	bounds := img.Bounds()
+	processed := &ProcessedImage{
+		SourceType: contentType,
+		Width:      bounds.Dx(),
+		Height:     bounds.Dy(),
+	}
+
+	for _, spec := range ImageVariantSpecs {
+		resized := resizeToFit(img, spec.MaxDim)
+		size := resized.Bounds().Size()
+
+		// JPEG has no alpha channel, so flatten transparent PNGs onto white
+		flat := image.NewRGBA(resized.Bounds())
+		stddraw.Draw(flat, flat.Bounds(), &image.Uniform{C: color.White}, image.Point{}, stddraw.Src)
+		stddraw.Draw(flat, flat.Bounds(), resized, resized.Bounds().Min, stddraw.Over)
+
+		var jpegBuf bytes.Buffer
+		if err := jpeg.Encode(&jpegBuf, flat, &jpeg.Options{Quality: spec.JPEGQuality}); err != nil {
+			return nil, err
+		}
+		processed.Outputs = append(processed.Outputs, EncodedImage{
+			Variant: spec.Name, Ext: ".jpg", ContentType: "image/jpeg",
+			Width: size.X, Height: size.Y, Data: jpegBuf.Bytes(),
+		})
+	}
+	return processed, nil
+}
+
+// VariantFilename names a variant file from the upload's base name
+func VariantFilename(base, variant, ext string) string {
+	return fmt.Sprintf("%s_%s%s", base, variant, ext)
+}
+
+// Photos processed while WebP variants were still produced have a .webp
+// next to every JPEG, unless their base name ends in jpegOnlyTag. Those
+// files are never served any more but are deleted along with the photo.
+const jpegOnlyTag = "_jpeg"
+
+// StoredPhotoName is what gets recorded for a processed upload: the large
+// JPEG, which doubles as a full-size fallback for older clients
+func StoredPhotoName(base string) string {
+	return VariantFilename(base, "large", ".jpg")
+}
+
+// PhotoVariantFiles derives the variant filenames from a stored photo name.
+// Photos uploaded before processing existed only have their original file.
+func PhotoVariantFiles(stored string) models.PhotoVariants {
+	base, processed := strings.CutSuffix(stored, "_large.jpg")
+	if !processed {
+		original := models.ImageURLs{JPEG: stored}
+		return models.PhotoVariants{Thumb: original, Medium: original, Large: original}
+	}
+
+	files := func(variant string) models.ImageURLs {
+		return models.ImageURLs{JPEG: VariantFilename(base, variant, ".jpg")}
+	}
+	return models.PhotoVariants{Thumb: files("thumb"), Medium: files("medium"), Large: files("large")}
+}
+
+// PhotoFileNames lists every file that belongs to a stored photo,
+// including the WebP variants of older uploads
+func PhotoFileNames(stored string) []string {
+	variants := PhotoVariantFiles(stored)
+	names := []string{}
+	seen := make(map[string]bool)
+	for _, files := range []models.ImageURLs{variants.Thumb, variants.Medium, variants.Large} {
+		if files.JPEG != "" && !seen[files.JPEG] {
+			seen[files.JPEG] = true
+			names = append(names, files.JPEG)
+		}
+	}
+	if base, processed := strings.CutSuffix(stored, "_large.jpg"); processed && !strings.HasSuffix(base, jpegOnlyTag) {
+		for _, spec := range ImageVariantSpecs {
+			names = append(names, VariantFilename(base, spec.Name, ".webp"))
+		}
+	}
+	return names
+}
+
+// IsSecondaryVariant reports whether a file is one of the generated variants
+// other than the stored large JPEG (or an older upload's WebP variant), so
+// folder listings can skip it
+func IsSecondaryVariant(filename string) bool {
+	for _, spec := range ImageVariantSpecs {
+		if strings.HasSuffix(filename, "_"+spec.Name+".webp") {
+			return true
+		}
+		if spec.Name != "large" && strings.HasSuffix(filename, "_"+spec.Name+".jpg") {
+			return true
+		}
+	}
+	return false
+}
+
+func resizeToFit(img image.Image, maxDim int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w <= maxDim && h <= maxDim {
+		return img
+	}
+
+	if w >= h {
+		h = h * maxDim / w
+		w = maxDim
+	} else {
+		w = w * maxDim / h
+		h = maxDim
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+// jpegOrientation reads the EXIF orientation tag (0x0112) from a JPEG.
+// Returns 1 (normal) when there is no EXIF block or it can't be parsed.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return 1
+		}
+		marker := data[pos+1]
+		if marker == 0xDA || marker == 0xD9 {
+			// Start of scan / end of image: no EXIF before the pixel data
+			return 1
+		}
+		segLen := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if segLen < 2 || pos+2+segLen > len(data) {
+			return 1
+		}
+		segment := data[pos+4 : pos+2+segLen]
+		if marker == 0xE1 && len(segment) > 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(segment[6:])
+		}
+		pos += 2 + segLen
+	}
+	return 1
+}
+
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			orientation := int(order.Uint16(tiff[entry+8 : entry+10]))
+			if orientation < 1 || orientation > 8 {
+				return 1
+			}
+			return orientation
+		}
+	}
+	return 1
+}
+
+// applyOrientation rotates/flips the image so it displays upright once the
+// EXIF orientation tag is gone. Pixels are copied between RGBA buffers
+// rather than through At/Set, which is far too slow for 40 MP photos.
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	src, ok := img.(*image.RGBA)
+	if !ok {
+		// draw has fast paths for what the decoders return (YCbCr for JPEG)
+		src = image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+		stddraw.Draw(src, src.Bounds(), img, img.Bounds().Min, stddraw.Src)
+	}
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	// Orientations 5-8 swap width and height
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	// Where source pixel (0, 0) lands in dst.Pix, and how far the
+	// destination moves for each step right (stepX) and down (stepY) in
+	// the source
+	stride := dst.Stride
+	var start, stepX, stepY int
+	switch orientation {
+	case 2: // mirrored horizontally
+		start, stepX, stepY = (w-1)*4, -4, stride
+	case 3: // rotated 180
+		start, stepX, stepY = (h-1)*stride+(w-1)*4, -4, -stride
+	case 4: // mirrored vertically
+		start, stepX, stepY = (h-1)*stride, 4, -stride
+	case 5: // transposed
+		start, stepX, stepY = 0, stride, 4
+	case 6: // rotated 90 clockwise
+		start, stepX, stepY = (h-1)*4, stride, -4
+	case 7: // transversed
+		start, stepX, stepY = (w-1)*stride+(h-1)*4, -stride, -4
+	case 8: // rotated 90 counter-clockwise
+		start, stepX, stepY = (w-1)*stride, -stride, 4
+	}
+
+	for y := 0; y < h; y++ {
+		row := src.Pix[src.PixOffset(bounds.Min.X, bounds.Min.Y+y):]
+		d := start + y*stepY
+		for x := 0; x < w; x++ {
+			copy(dst.Pix[d:d+4], row[x*4:x*4+4])
+			d += stepX
+		}
+	}
+	return dst
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestApplyOrientation(t *testing.T) {
+	// A 3x2 image whose pixels are numbered row by row
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	for i := 0; i < 6; i++ {
+		src.Set(i%3, i/3, color.RGBA{R: uint8(i + 1), A: 255})
+	}
+
+	tests := []struct {
+		orientation int
+		want        [][]uint8
+	}{
+		{1, [][]uint8{{1, 2, 3}, {4, 5, 6}}},
+		{2, [][]uint8{{3, 2, 1}, {6, 5, 4}}},
+		{3, [][]uint8{{6, 5, 4}, {3, 2, 1}}},
+		{4, [][]uint8{{4, 5, 6}, {1, 2, 3}}},
+		{5, [][]uint8{{1, 4}, {2, 5}, {3, 6}}},
+		{6, [][]uint8{{4, 1}, {5, 2}, {6, 3}}},
+		{7, [][]uint8{{6, 3}, {5, 2}, {4, 1}}},
+		{8, [][]uint8{{3, 6}, {2, 5}, {1, 4}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.orientation), func(t *testing.T) {
+			got := applyOrientation(src, tt.orientation)
+			if got := redChannel(got); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyOrientationYCbCr(t *testing.T) {
+	// JPEG decodes to YCbCr, which is converted before rotating
+	src := image.NewYCbCr(image.Rect(0, 0, 4, 2), image.YCbCrSubsampleRatio444)
+	for i := range src.Y {
+		src.Y[i] = uint8(i * 30)
+		src.Cb[i], src.Cr[i] = 128, 128
+	}
+
+	got := applyOrientation(src, 6)
+	if size := got.Bounds().Size(); size != (image.Point{X: 2, Y: 4}) {
+		t.Fatalf("rotated size is %v, want 2x4", size)
+	}
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 2; y++ {
+			want := color.RGBAModel.Convert(src.At(x, y))
+			if c := got.At(1-y, x); c != want {
+				t.Errorf("pixel (%d, %d) is %v, want %v", x, y, c, want)
+			}
+		}
+	}
+}
+
+func redChannel(img image.Image) [][]uint8 {
+	b := img.Bounds()
+	rows := [][]uint8{}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		row := []uint8{}
+		for x := b.Min.X; x < b.Max.X; x++ {
+			row = append(row, img.At(x, y).(color.RGBA).R)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
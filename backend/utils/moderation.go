@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"trip-planner-backend/models"
+)
+
+// PackageCheckInput is what the automated moderation checks look at
+type PackageCheckInput struct {
+	Title              string
+	Description        string
+	Location           string
+	InitialDestination string
+	Locations          []string
+	DurationDays       int
+	NumTravelers       int
+	TransportMode      string
+	Price              float64
+	PerGroup           bool
+	PhotoCount         int
+}
+
+// maxDailyPackagePrice is the most per person per day, on top of travel, a
+// package is expected to cost. MODERATION_MAX_DAILY_PRICE overrides it.
+func maxDailyPackagePrice() float64 {
+	if v, err := strconv.ParseFloat(os.Getenv("MODERATION_MAX_DAILY_PRICE"), 64); err == nil && v > 0 {
+		return v
+	}
+	return 25000
+}
+
+// CheckPackage runs the automated moderation checks: photos, price against
+// the computed travel cost, and banned words
+func CheckPackage(in PackageCheckInput, bannedWords []string) []models.ModerationFlag {
+	flags := []models.ModerationFlag{}
+	if in.PhotoCount == 0 {
+		flags = append(flags, models.ModerationFlag{Code: models.FlagMissingPhotos, Message: "The package has no photos"})
+	}
+	if flag := checkPackagePrice(in); flag != nil {
+		flags = append(flags, *flag)
+	}
+	if found := FindBannedWords(bannedWords, append([]string{in.Title, in.Description}, in.Locations...)...); len(found) > 0 {
+		flags = append(flags, models.ModerationFlag{
+			Code:    models.FlagBannedWords,
+			Message: "Contains banned words: " + strings.Join(found, ", "),
+		})
+	}
+	return flags
+}
+
+// roundTripCostPerPerson is the computed cost of travelling out and back by
+// mode. Modes without a formula of their own (flight, cruise, mixed) use
+// the cheapest ground option as a floor.
+func roundTripCostPerPerson(costs TravelCostCalculation, mode string, travelers int) float64 {
+	n := float64(travelers)
+	bus, train, car := costs.BusCost/n, costs.TrainCost/n, costs.CarCost/n
+	var oneWay float64
+	switch mode {
+	case "bus":
+		oneWay = bus
+	case "train":
+		oneWay = train
+	case "car":
+		oneWay = car
+	default:
+		oneWay = math.Min(bus, math.Min(train, car))
+	}
+	return 2 * oneWay
+}
+
+// checkPackagePrice flags a per-person price below the cost of the journey
+// itself, or far above it for the number of days. Routes between unknown
+// places aren't checked.
+func checkPackagePrice(in PackageCheckInput) *models.ModerationFlag {
+	if in.NumTravelers <= 0 || in.DurationDays <= 0 {
+		return nil
+	}
+	if _, _, ok := LookupDistrict(in.InitialDestination); !ok {
+		return nil
+	}
+	if _, _, ok := LookupDistrict(in.Location); !ok {
+		return nil
+	}
+	costs, err := CalculateTravelCosts(in.InitialDestination, in.Location, in.NumTravelers)
+	if err != nil {
+		return nil
+	}
+
+	travel := roundTripCostPerPerson(costs, in.TransportMode, in.NumTravelers)
+	perPerson := in.Price
+	if in.PerGroup {
+		perPerson = in.Price / float64(in.NumTravelers)
+	}
+	ceiling := travel + float64(in.DurationDays)*maxDailyPackagePrice()
+
+	switch {
+	case perPerson < travel:
+		return &models.ModerationFlag{
+			Code: models.FlagPriceTooLow,
+			Message: fmt.Sprintf("Rs. %s per person is below the Rs. %s round trip from %s to %s (%.0f km each way)",
+				FormatINR(perPerson), FormatINR(travel), in.InitialDestination, in.Location, costs.Distance),
+		}
+	case perPerson > ceiling:
+		return &models.ModerationFlag{
+			Code: models.FlagPriceTooHigh,
+			Message: fmt.Sprintf("Rs. %s per person is above the Rs. %s expected for %d day(s) including travel",
+				FormatINR(perPerson), FormatINR(ceiling), in.DurationDays),
+		}
+	}
+	return nil
+}
+
+// FindBannedWords returns the banned words or phrases that appear in any
+// of the texts as whole words, ignoring case. Combining marks count as part
+// of a word, so a banned Kannada syllable doesn't match inside a longer word.
+func FindBannedWords(banned []string, texts ...string) []string {
+	if len(banned) == 0 {
+		return nil
+	}
+	text := strings.ToLower(strings.Join(texts, "\n"))
+	found := []string{}
+	for _, word := range banned {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word == "" {
+			continue
+		}
+		pattern := `(^|[^\pL\pM\pN])` + regexp.QuoteMeta(word) + `($|[^\pL\pM\pN])`
+		if regexp.MustCompile(pattern).MatchString(text) {
+			found = append(found, word)
+		}
+	}
+	sort.Strings(found)
+	return found
+}
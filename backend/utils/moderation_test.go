@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+	"trip-planner-backend/models"
+)
+
+func TestFindBannedWords(t *testing.T) {
+	banned := []string{"guaranteed", "100% refund", "Casino", "ಮ", "ಜೂಜು", "  "}
+
+	tests := []struct {
+		name  string
+		texts []string
+		want  []string
+	}{
+		{name: "no matches", texts: []string{"A quiet weekend in Coorg"}, want: []string{}},
+		{name: "ignores case", texts: []string{"GUARANTEED sunsets"}, want: []string{"guaranteed"}},
+		{name: "whole words only", texts: []string{"Casinos and unguaranteed weather"}, want: []string{}},
+		{name: "phrases with punctuation", texts: []string{"Book now, 100% refund!"}, want: []string{"100% refund"}},
+		{name: "any text, sorted", texts: []string{"Casino night", "Guaranteed fun"}, want: []string{"casino", "guaranteed"}},
+		{name: "non-ASCII letters are word characters", texts: []string{"guaranteedé"}, want: []string{}},
+		{name: "combining marks are word characters", texts: []string{"ಮೈಸೂರು"}, want: []string{}},
+		{name: "non-Latin whole word", texts: []string{"ಜೂಜು ಇಲ್ಲ"}, want: []string{"ಜೂಜು"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FindBannedWords(banned, tt.texts...)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := FindBannedWords(nil, "guaranteed"); got != nil {
+		t.Errorf("empty list found %q", got)
+	}
+}
+
+func TestCheckPackage(t *testing.T) {
+	// Without a routing key travel costs come from the straight-line estimate
+	t.Setenv("OPENROUTE_API_KEY", "")
+	t.Setenv("MODERATION_MAX_DAILY_PRICE", "")
+
+	base := PackageCheckInput{
+		Title:              "Mysuru Palace Weekend",
+		Description:        "Two days of palaces and gardens",
+		InitialDestination: "Bengaluru Urban",
+		Location:           "Mysore",
+		DurationDays:       2,
+		NumTravelers:       2,
+		TransportMode:      "bus",
+		Price:              12000,
+		PhotoCount:         3,
+	}
+	with := func(change func(*PackageCheckInput)) PackageCheckInput {
+		in := base
+		change(&in)
+		return in
+	}
+
+	tests := []struct {
+		name string
+		in   PackageCheckInput
+		want []string
+	}{
+		{name: "clean package", in: base, want: []string{}},
+		{name: "no photos", in: with(func(in *PackageCheckInput) { in.PhotoCount = 0 }), want: []string{models.FlagMissingPhotos}},
+		{name: "below the round trip", in: with(func(in *PackageCheckInput) { in.Price = 100 }), want: []string{models.FlagPriceTooLow}},
+		{name: "far above the daily ceiling", in: with(func(in *PackageCheckInput) { in.Price = 200000 }), want: []string{models.FlagPriceTooHigh}},
+		{
+			name: "group price is split per person",
+			in:   with(func(in *PackageCheckInput) { in.Price, in.PerGroup, in.NumTravelers = 200000, true, 8 }),
+			want: []string{},
+		},
+		{
+			name: "unknown places aren't price checked",
+			in:   with(func(in *PackageCheckInput) { in.Location, in.Price = "Goa", 1 }),
+			want: []string{},
+		},
+		{
+			name: "banned word in a stop",
+			in:   with(func(in *PackageCheckInput) { in.Locations = []string{"Casino Royale"} }),
+			want: []string{models.FlagBannedWords},
+		},
+		{
+			name: "several problems",
+			in:   with(func(in *PackageCheckInput) { in.PhotoCount, in.Price, in.Title = 0, 100, "Guaranteed fun" }),
+			want: []string{models.FlagMissingPhotos, models.FlagPriceTooLow, models.FlagBannedWords},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codes := []string{}
+			for _, flag := range CheckPackage(tt.in, []string{"casino", "guaranteed"}) {
+				codes = append(codes, flag.Code)
+			}
+			if !reflect.DeepEqual(codes, tt.want) {
+				t.Errorf("got flags %q, want %q", codes, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"trip-planner-backend/models"
+)
+
+// PackingOptions describes the trip and travellers a checklist is built for
+type PackingOptions struct {
+	Mood         string
+	DurationDays int
+	NumTravelers int
+	WithChildren bool
+	WithSeniors  bool
+}
+
+type packingListBuilder struct {
+	items []models.PackingItem
+	seen  map[string]int
+}
+
+func (b *packingListBuilder) add(category, name string, quantity int, reason string) {
+	if quantity < 1 {
+		quantity = 1
+	}
+	// Rules overlap (e.g. rain and adventure both want quick-dry clothes);
+	// keep the first reason and the larger quantity
+	key := strings.ToLower(name)
+	if idx, ok := b.seen[key]; ok {
+		if quantity > b.items[idx].Quantity {
+			b.items[idx].Quantity = quantity
+		}
+		return
+	}
+	b.seen[key] = len(b.items)
+	b.items = append(b.items, models.PackingItem{
+		Category: category,
+		Name:     name,
+		Quantity: quantity,
+		Reason:   reason,
+	})
+}
+
+// GeneratePackingList builds a checklist from the trip weather (forecast or
+// climate normals), mood, duration and traveller profile.
+func GeneratePackingList(weather TripWeather, opts PackingOptions) []models.PackingItem {
+	b := &packingListBuilder{seen: make(map[string]int)}
+
+	travelers := opts.NumTravelers
+	if travelers < 1 {
+		travelers = 1
+	}
+	days := opts.DurationDays
+	if days < 1 {
+		days = len(weather.Days)
+	}
+	if days < 1 {
+		days = 1
+	}
+	// Pack for at most a week and plan to do laundry on longer trips
+	clothingDays := days
+	if clothingDays > 7 {
+		clothingDays = 7
+	}
+
+	b.add("Essentials", "Government photo ID (Aadhaar, driving licence or passport)", travelers, "Needed for hotel check-in and some monument entries")
+	b.add("Essentials", "Phone charger and power bank", 1, "")
+	b.add("Essentials", "Cash and UPI-enabled phone", 1, "Small shops and rural eateries may not take cards")
+	b.add("Essentials", "Reusable water bottle", travelers, "")
+	b.add("Health", "Personal medicines and basic first-aid kit", 1, "")
+	b.add("Toiletries", "Toothbrush, toothpaste and toiletries", travelers, "")
+	b.add("Clothing", "T-shirts or tops", clothingDays*travelers, fmt.Sprintf("One per day for %d day(s)", clothingDays))
+	b.add("Clothing", "Underwear and socks", (clothingDays+1)*travelers, "")
+	b.add("Clothing", "Sleepwear", travelers, "")
+	b.add("Footwear", "Comfortable walking shoes", travelers, "")
+
+	// Weather-driven items
+	var maxHigh, minLow float64
+	hasTemps := false
+	rainyDays := 0
+	monsoon := false
+	for _, day := range weather.Days {
+		var high, low float64
+		switch day.Source {
+		case WeatherSourceForecast:
+			high, low = day.Forecast.TempMax, day.Forecast.TempMin
+			if day.Forecast.IsRainy() {
+				rainyDays++
+			}
+		case WeatherSourceClimateNormal:
+			high, low = day.Normal.AvgHighC, day.Normal.AvgLowC
+			if day.Normal.IsLikelyWet() {
+				rainyDays++
+			}
+			monsoon = monsoon || day.Normal.Monsoon
+		default:
+			continue
+		}
+		if !hasTemps || high > maxHigh {
+			maxHigh = high
+		}
+		if !hasTemps || low < minLow {
+			minLow = low
+		}
+		hasTemps = true
+	}
+
+	if rainyDays > 0 || monsoon {
+		reason := fmt.Sprintf("Rain expected on %d day(s)", rainyDays)
+		if monsoon {
+			reason = "Monsoon season at the destination"
+		}
+		b.add("Rain Gear", "Umbrella or raincoat", travelers, reason)
+		b.add("Rain Gear", "Waterproof phone pouch", travelers, reason)
+		b.add("Rain Gear", "Dry bag or plastic covers for electronics", 1, reason)
+		b.add("Clothing", "Quick-dry clothes", 2*travelers, "Cotton stays damp for days in humid weather")
+		b.add("Footwear", "Sandals or floaters with grip", travelers, "Shoes take long to dry after rain")
+	}
+	if hasTemps && maxHigh >= 32 {
+		reason := fmt.Sprintf("Highs up to %.0f°C", maxHigh)
+		b.add("Sun Protection", "Sunscreen (SPF 30+)", 1, reason)
+		b.add("Sun Protection", "Sunglasses", travelers, reason)
+		b.add("Sun Protection", "Cap or wide-brimmed hat", travelers, reason)
+		b.add("Clothing", "Light, breathable cotton clothing", clothingDays*travelers, reason)
+		b.add("Health", "ORS sachets", days, "Helps prevent dehydration in the heat")
+	}
+	if hasTemps && minLow <= 16 {
+		reason := fmt.Sprintf("Lows down to %.0f°C", minLow)
+		b.add("Clothing", "Warm jacket or fleece", travelers, reason)
+		b.add("Clothing", "Full-sleeve layer for evenings", travelers, reason)
+	}
+	if rainyDays > 0 || monsoon || (hasTemps && maxHigh >= 28) {
+		b.add("Health", "Mosquito repellent", 1, "Warm, humid weather brings mosquitoes")
+	}
+
+	// Mood-driven items
+	switch opts.Mood {
+	case "adventure":
+		b.add("Adventure Gear", "Trekking shoes with ankle support", travelers, "For trails and uneven terrain")
+		b.add("Adventure Gear", "Daypack (20-30 L)", travelers, "")
+		b.add("Adventure Gear", "Headlamp or torch", 1, "")
+		b.add("Clothing", "Quick-dry clothes", 2*travelers, "Treks mean sweat and stream crossings")
+		b.add("Health", "Blister plasters and pain-relief spray", 1, "")
+		if rainyDays > 0 || monsoon {
+			b.add("Adventure Gear", "Leech socks and salt", travelers, "Western Ghats trails are full of leeches when wet")
+		}
+	case "cultural", "historical":
+		b.add("Clothing", "Modest clothing covering shoulders and knees", 2*travelers, "Required at many temples and mosques")
+		b.add("Clothing", "Scarf, stole or dupatta", travelers, "To cover head or shoulders at places of worship")
+		b.add("Footwear", "Slip-on footwear", travelers, "Shoes are removed at temple entrances")
+		b.add("Essentials", "Small bag for carrying footwear", 1, "")
+		if opts.Mood == "historical" {
+			b.add("Essentials", "Guidebook or offline map of heritage sites", 1, "")
+		}
+	case "natural_beauty":
+		b.add("Essentials", "Camera and spare memory card", 1, "")
+		b.add("Essentials", "Binoculars", 1, "For wildlife and bird watching")
+	case "relaxation":
+		b.add("Clothing", "Swimwear", travelers, "For pools, beaches and spa stays")
+		b.add("Footwear", "Comfortable sandals", travelers, "")
+		b.add("Essentials", "Book or e-reader", 1, "")
+	}
+
+	// Duration and traveller profile
+	if days >= 5 {
+		b.add("Essentials", "Laundry bag and detergent sachets", 1, fmt.Sprintf("%d-day trip", days))
+	}
+	if opts.WithChildren {
+		b.add("Family", "Snacks for children", days, "")
+		b.add("Family", "Wet wipes and hand sanitiser", 2, "")
+		b.add("Family", "Children's medicines (fever, motion sickness)", 1, "")
+	}
+	if opts.WithSeniors {
+		b.add("Family", "Regular prescription medicines with extra supply", 1, "Carry a few days more than the trip length")
+		b.add("Family", "Walking stick or knee support", 1, "Many sites involve steps and uneven ground")
+	}
+	if travelers >= 3 {
+		b.add("Essentials", "Multi-plug extension board", 1, fmt.Sprintf("%d travellers sharing rooms", travelers))
+	}
+
+	// Group by category so the checklist reads section by section
+	sort.SliceStable(b.items, func(i, j int) bool {
+		return packingCategoryRank(b.items[i].Category) < packingCategoryRank(b.items[j].Category)
+	})
+	for i := range b.items {
+		b.items[i].SortOrder = i
+	}
+	return b.items
+}
+
+var packingCategoryOrder = []string{
+	"Essentials", "Clothing", "Footwear", "Rain Gear", "Sun Protection",
+	"Adventure Gear", "Health", "Toiletries", "Family",
+}
+
+func packingCategoryRank(category string) int {
+	for i, c := range packingCategoryOrder {
+		if c == category {
+			return i
+		}
+	}
+	return len(packingCategoryOrder)
+}
+
+// PackingOptionsForTrip derives packing options from a trip request
+func PackingOptionsForTrip(tripReq models.TripRequest) PackingOptions {
+	opts := PackingOptions{Mood: tripReq.Mood, NumTravelers: tripReq.NumTravelers}
+	start, errStart := time.Parse("2006-01-02", tripReq.StartDate)
+	end, errEnd := time.Parse("2006-01-02", tripReq.EndDate)
+	if errStart == nil && errEnd == nil && !end.Before(start) {
+		opts.DurationDays = int(end.Sub(start).Hours()/24) + 1
+	}
+	return opts
+}
+
+// FormatPackingListMarkdown renders a checklist for the trip plan
+func FormatPackingListMarkdown(items []models.PackingItem) string {
+	var sb strings.Builder
+	category := ""
+	for _, item := range items {
+		if item.Category != category {
+			category = item.Category
+			sb.WriteString(fmt.Sprintf("\n**%s:**\n", category))
+		}
+		line := fmt.Sprintf("- [ ] %s", item.Name)
+		if item.Quantity > 1 {
+			line += fmt.Sprintf(" (x%d)", item.Quantity)
+		}
+		if item.Reason != "" {
+			line += fmt.Sprintf(" - %s", item.Reason)
+		}
+		sb.WriteString(line + "\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,405 @@
+package utils
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"trip-planner-backend/models"
+)
+
+//go:embed data/pois.csv
+var bundledPOIsCSV []byte
+
+// POICategories are the catalog categories accepted on create and import
+var POICategories = map[string]bool{
+	"attraction":       true,
+	"temple":           true,
+	"place_of_worship": true,
+	"fort":             true,
+	"palace":           true,
+	"heritage":         true,
+	"museum":           true,
+	"waterfall":        true,
+	"viewpoint":        true,
+	"lake":             true,
+	"beach":            true,
+	"park":             true,
+	"wildlife":         true,
+	"trek":             true,
+	"restaurant":       true,
+}
+
+// POIAccessibilityFlags are the accessibility features a POI can list
+var POIAccessibilityFlags = map[string]bool{
+	"wheelchair":         true,
+	"accessible_toilets": true,
+	"parking":            true,
+	"child_friendly":     true,
+	"senior_friendly":    true,
+}
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ParseOpeningHours reads the compact hours format used in the seed files:
+// "Daily 09:00-17:30", "Wed-Mon 08:30-17:30", "Mon-Sat 07:00-13:00,16:00-20:00; Sun 07:00-20:00"
+// or "24h". An empty string means the hours are unknown.
+func ParseOpeningHours(text string) ([]models.OpeningPeriod, error) {
+	text = strings.TrimSpace(text)
+	periods := []models.OpeningPeriod{}
+	if text == "" {
+		return periods, nil
+	}
+	if strings.EqualFold(text, "24h") {
+		for day := 0; day < 7; day++ {
+			periods = append(periods, models.OpeningPeriod{Day: day, Open: "00:00", Close: "24:00"})
+		}
+		return periods, nil
+	}
+
+	for _, rule := range strings.Split(text, ";") {
+		dayPart, timePart, ok := strings.Cut(strings.TrimSpace(rule), " ")
+		if !ok {
+			return nil, fmt.Errorf("invalid opening hours %q", rule)
+		}
+		days, err := parseDayRange(dayPart)
+		if err != nil {
+			return nil, err
+		}
+		for _, window := range strings.Split(timePart, ",") {
+			open, close, ok := strings.Cut(strings.TrimSpace(window), "-")
+			if !ok {
+				return nil, fmt.Errorf("invalid opening hours %q", window)
+			}
+			for _, day := range days {
+				periods = append(periods, models.OpeningPeriod{Day: day, Open: open, Close: close})
+			}
+		}
+	}
+	return periods, ValidateOpeningHours(periods)
+}
+
+// parseDayRange turns "Daily", "Mon" or a wrapping range like "Wed-Mon"
+// into weekday numbers
+func parseDayRange(text string) ([]int, error) {
+	if strings.EqualFold(text, "daily") {
+		return []int{0, 1, 2, 3, 4, 5, 6}, nil
+	}
+	from, to, isRange := strings.Cut(text, "-")
+	start, end := weekdayIndex(from), weekdayIndex(to)
+	if !isRange {
+		end = start
+	}
+	if start < 0 || end < 0 {
+		return nil, fmt.Errorf("invalid days %q", text)
+	}
+
+	days := []int{}
+	for day := start; ; day = (day + 1) % 7 {
+		days = append(days, day)
+		if day == end {
+			return days, nil
+		}
+	}
+}
+
+func weekdayIndex(name string) int {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for i, day := range weekdayNames {
+		if len(name) >= 3 && strings.HasPrefix(name, day) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ParseClock converts "HH:MM" to minutes after midnight, allowing "24:00"
+func ParseClock(value string) (int, bool) {
+	hours, minutes, ok := strings.Cut(value, ":")
+	h, err1 := strconv.Atoi(hours)
+	m, err2 := strconv.Atoi(minutes)
+	if !ok || err1 != nil || err2 != nil || len(minutes) != 2 || h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, false
+	}
+	return h*60 + m, true
+}
+
+// ValidateOpeningHours checks weekday numbers and that every window closes
+// after it opens
+func ValidateOpeningHours(periods []models.OpeningPeriod) error {
+	for _, p := range periods {
+		if p.Day < 0 || p.Day > 6 {
+			return fmt.Errorf("invalid opening day %d", p.Day)
+		}
+		open, ok1 := ParseClock(p.Open)
+		close, ok2 := ParseClock(p.Close)
+		if !ok1 || !ok2 || close <= open {
+			return fmt.Errorf("invalid opening time %s-%s", p.Open, p.Close)
+		}
+	}
+	return nil
+}
+
+// NormalizePOI validates a POI and rewrites its district to the canonical
+// name. The returned error is meant to be shown to the admin.
+func NormalizePOI(p *models.POI) error {
+	p.Name = strings.TrimSpace(p.Name)
+	p.Description = strings.TrimSpace(p.Description)
+	p.Category = strings.ToLower(strings.TrimSpace(p.Category))
+
+	if p.Name == "" {
+		return errors.New("name is required")
+	}
+	district, ok := FindDistrict(p.DistrictName)
+	if !ok {
+		return fmt.Errorf("unknown district %q", p.DistrictName)
+	}
+	p.DistrictName = district.Name
+	if !POICategories[p.Category] {
+		return fmt.Errorf("unknown category %q", p.Category)
+	}
+	if p.Latitude < -90 || p.Latitude > 90 || p.Longitude < -180 || p.Longitude > 180 || (p.Latitude == 0 && p.Longitude == 0) {
+		return errors.New("valid latitude and longitude are required")
+	}
+	if p.VisitDurationMinutes < 0 || p.VisitDurationMinutes > 24*60 {
+		return errors.New("visit duration must be between 0 and 1440 minutes")
+	}
+	if (p.EntryFeeMin != nil && *p.EntryFeeMin < 0) || (p.EntryFeeMax != nil && *p.EntryFeeMax < 0) {
+		return errors.New("entry fees cannot be negative")
+	}
+	if p.EntryFeeMin != nil && p.EntryFeeMax != nil && *p.EntryFeeMax < *p.EntryFeeMin {
+		return errors.New("maximum entry fee is below the minimum")
+	}
+	if p.EntryFeeMin != nil && p.EntryFeeMax == nil {
+		p.EntryFeeMax = p.EntryFeeMin
+	}
+	if p.OpeningHours == nil {
+		p.OpeningHours = []models.OpeningPeriod{}
+	}
+	if err := ValidateOpeningHours(p.OpeningHours); err != nil {
+		return err
+	}
+
+	flags := []string{}
+	for _, flag := range p.Accessibility {
+		flag = strings.ToLower(strings.TrimSpace(flag))
+		if flag == "" {
+			continue
+		}
+		if !POIAccessibilityFlags[flag] {
+			return fmt.Errorf("unknown accessibility flag %q", flag)
+		}
+		flags = append(flags, flag)
+	}
+	p.Accessibility = flags
+
+	tags := []string{}
+	for _, tag := range p.Tags {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	p.Tags = tags
+	return nil
+}
+
+// POIImportError reports a row of an import file that could not be used
+type POIImportError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// BundledPOIs returns the seed catalog shipped with the backend
+func BundledPOIs() ([]models.POI, []POIImportError, error) {
+	return ParsePOICSV(bytes.NewReader(bundledPOIsCSV))
+}
+
+// ParsePOIFile picks the parser from the file extension (.csv, .geojson or .json)
+func ParsePOIFile(filename string, r io.Reader) ([]models.POI, []POIImportError, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return ParsePOICSV(r)
+	case ".geojson", ".json":
+		return ParsePOIGeoJSON(r)
+	}
+	return nil, nil, errors.New("POI files must be .csv or .geojson")
+}
+
+// ParsePOICSV reads a CSV with a header row naming the columns district,
+// name, category, latitude, longitude, opening_hours, visit_duration_minutes,
+// entry_fee_min, entry_fee_max, accessibility and tags ("|"-separated) and
+// description.
+// Invalid rows are reported and skipped.
+func ParsePOICSV(r io.Reader) ([]models.POI, []POIImportError, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"district", "name", "category", "latitude", "longitude"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("CSV is missing the %q column", required)
+		}
+	}
+
+	pois := []models.POI{}
+	rowErrors := []POIImportError{}
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, POIImportError{Row: row, Error: err.Error()})
+			continue
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		props := poiProperties{
+			District:      field("district"),
+			Name:          field("name"),
+			Category:      field("category"),
+			OpeningHours:  field("opening_hours"),
+			VisitDuration: json.Number(field("visit_duration_minutes")),
+			EntryFeeMin:   json.Number(field("entry_fee_min")),
+			EntryFeeMax:   json.Number(field("entry_fee_max")),
+			Description:   field("description"),
+		}
+		if flags := field("accessibility"); flags != "" {
+			props.Accessibility = strings.Split(flags, "|")
+		}
+		if tags := field("tags"); tags != "" {
+			props.Tags = strings.Split(tags, "|")
+		}
+		lat, err1 := strconv.ParseFloat(field("latitude"), 64)
+		lon, err2 := strconv.ParseFloat(field("longitude"), 64)
+		if err1 != nil || err2 != nil {
+			rowErrors = append(rowErrors, POIImportError{Row: row, Error: "invalid latitude or longitude"})
+			continue
+		}
+
+		poi, err := props.toPOI(lat, lon)
+		if err != nil {
+			rowErrors = append(rowErrors, POIImportError{Row: row, Error: err.Error()})
+			continue
+		}
+		pois = append(pois, poi)
+	}
+	return pois, rowErrors, nil
+}
+
+// ParsePOIGeoJSON reads a FeatureCollection of Point features whose
+// properties use the same names as the CSV columns. Row numbers in errors
+// are 1-based feature indexes.
+func ParsePOIGeoJSON(r io.Reader) ([]models.POI, []POIImportError, error) {
+	var collection struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Geometry struct {
+				Type        string    `json:"type"`
+				Coordinates []float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Properties poiProperties `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.NewDecoder(r).Decode(&collection); err != nil {
+		return nil, nil, fmt.Errorf("reading GeoJSON: %w", err)
+	}
+	if collection.Type != "FeatureCollection" {
+		return nil, nil, errors.New("GeoJSON must be a FeatureCollection")
+	}
+
+	pois := []models.POI{}
+	rowErrors := []POIImportError{}
+	for i, feature := range collection.Features {
+		coords := feature.Geometry.Coordinates
+		if feature.Geometry.Type != "Point" || len(coords) < 2 {
+			rowErrors = append(rowErrors, POIImportError{Row: i + 1, Error: "geometry must be a Point"})
+			continue
+		}
+		// GeoJSON positions are (longitude, latitude)
+		poi, err := feature.Properties.toPOI(coords[1], coords[0])
+		if err != nil {
+			rowErrors = append(rowErrors, POIImportError{Row: i + 1, Error: err.Error()})
+			continue
+		}
+		pois = append(pois, poi)
+	}
+	return pois, rowErrors, nil
+}
+
+// poiProperties holds the text form shared by CSV rows and GeoJSON properties
+type poiProperties struct {
+	District      string      `json:"district"`
+	Name          string      `json:"name"`
+	Category      string      `json:"category"`
+	OpeningHours  string      `json:"opening_hours"`
+	VisitDuration json.Number `json:"visit_duration_minutes"`
+	EntryFeeMin   json.Number `json:"entry_fee_min"`
+	EntryFeeMax   json.Number `json:"entry_fee_max"`
+	Accessibility []string    `json:"accessibility"`
+	Tags          []string    `json:"tags"`
+	Description   string      `json:"description"`
+}
+
+func (p poiProperties) toPOI(lat, lon float64) (models.POI, error) {
+	poi := models.POI{
+		DistrictName:  p.District,
+		Name:          p.Name,
+		Category:      p.Category,
+		Description:   p.Description,
+		Latitude:      lat,
+		Longitude:     lon,
+		Accessibility: p.Accessibility,
+		Tags:          p.Tags,
+	}
+
+	hours, err := ParseOpeningHours(p.OpeningHours)
+	if err != nil {
+		return poi, err
+	}
+	poi.OpeningHours = hours
+
+	if p.VisitDuration != "" {
+		minutes, err := strconv.Atoi(string(p.VisitDuration))
+		if err != nil {
+			return poi, errors.New("invalid visit duration")
+		}
+		poi.VisitDurationMinutes = minutes
+	}
+	if poi.EntryFeeMin, err = parseOptionalFee(string(p.EntryFeeMin)); err != nil {
+		return poi, err
+	}
+	if poi.EntryFeeMax, err = parseOptionalFee(string(p.EntryFeeMax)); err != nil {
+		return poi, err
+	}
+
+	return poi, NormalizePOI(&poi)
+}
+
+func parseOptionalFee(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	fee, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, errors.New("invalid entry fee")
+	}
+	return &fee, nil
+}
@@ -0,0 +1,233 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"trip-planner-backend/models"
+)
+
+const (
+	maxSeasons        = 20
+	maxGroupDiscounts = 10
+	pricingDateLayout = "2006-01-02"
+)
+
+// NormalizePricingRules validates rules and sorts seasons by start date
+// and group discounts by party size. Seasons may not overlap, and larger
+// groups may not get a smaller discount.
+func NormalizePricingRules(rules models.PricingRules) (models.PricingRules, error) {
+	switch rules.Mode {
+	case "":
+		rules.Mode = models.PricingPerPerson
+	case models.PricingPerPerson, models.PricingPerGroup:
+	default:
+		return rules, errors.New("mode must be per_person or per_group")
+	}
+
+	if rules.Child != nil {
+		if rules.Child.MaxAge < 0 || rules.Child.MaxAge > 17 {
+			return rules, errors.New("child max_age must be between 0 and 17")
+		}
+		if rules.Child.PricePercent < 0 || rules.Child.PricePercent > 100 {
+			return rules, errors.New("child price_percent must be between 0 and 100")
+		}
+	}
+	if rules.Senior != nil {
+		if rules.Senior.MinAge < 50 || rules.Senior.MinAge > 100 {
+			return rules, errors.New("senior min_age must be between 50 and 100")
+		}
+		if rules.Senior.PricePercent < 0 || rules.Senior.PricePercent > 100 {
+			return rules, errors.New("senior price_percent must be between 0 and 100")
+		}
+	}
+	if rules.Mode == models.PricingPerGroup && (rules.Child != nil || rules.Senior != nil || len(rules.GroupDiscounts) > 0) {
+		return rules, errors.New("child, senior and group discount pricing apply only to per_person packages")
+	}
+
+	if len(rules.Seasons) > maxSeasons {
+		return rules, fmt.Errorf("at most %d seasons", maxSeasons)
+	}
+	seasons := append([]models.Season(nil), rules.Seasons...)
+	for i := range seasons {
+		s := &seasons[i]
+		s.Name = strings.TrimSpace(s.Name)
+		if s.Name == "" || len(s.Name) > 50 {
+			return rules, errors.New("every season needs a name of at most 50 characters")
+		}
+		start, err1 := time.Parse(pricingDateLayout, s.StartDate)
+		end, err2 := time.Parse(pricingDateLayout, s.EndDate)
+		if err1 != nil || err2 != nil {
+			return rules, errors.New("season dates must be YYYY-MM-DD")
+		}
+		if end.Before(start) {
+			return rules, fmt.Errorf("season %q ends before it starts", s.Name)
+		}
+		if s.Multiplier < 0.25 || s.Multiplier > 5 {
+			return rules, errors.New("season multiplier must be between 0.25 and 5")
+		}
+	}
+	// Dates are YYYY-MM-DD, so string order is date order
+	sort.Slice(seasons, func(i, j int) bool { return seasons[i].StartDate < seasons[j].StartDate })
+	for i := 1; i < len(seasons); i++ {
+		if seasons[i].StartDate <= seasons[i-1].EndDate {
+			return rules, fmt.Errorf("seasons %q and %q overlap", seasons[i-1].Name, seasons[i].Name)
+		}
+	}
+	rules.Seasons = seasons
+
+	if len(rules.GroupDiscounts) > maxGroupDiscounts {
+		return rules, fmt.Errorf("at most %d group discount tiers", maxGroupDiscounts)
+	}
+	tiers := append([]models.GroupDiscount(nil), rules.GroupDiscounts...)
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].MinTravelers < tiers[j].MinTravelers })
+	for i, t := range tiers {
+		if t.MinTravelers < 2 {
+			return rules, errors.New("group discounts start at 2 travelers")
+		}
+		if t.Percent <= 0 || t.Percent > 90 {
+			return rules, errors.New("group discount percent must be above 0 and at most 90")
+		}
+		if i > 0 {
+			if tiers[i-1].MinTravelers == t.MinTravelers {
+				return rules, errors.New("each group size can have only one discount")
+			}
+			if t.Percent < tiers[i-1].Percent {
+				return rules, errors.New("larger groups can't get a smaller discount")
+			}
+		}
+	}
+	rules.GroupDiscounts = tiers
+	return rules, nil
+}
+
+// ClassifyAges sorts travelers into price bands. Without child or senior
+// pricing everyone is an adult.
+func ClassifyAges(rules *models.PricingRules, ages []int) models.PartySize {
+	var party models.PartySize
+	for _, age := range ages {
+		switch {
+		case rules != nil && rules.Child != nil && age <= rules.Child.MaxAge:
+			party.Children++
+		case rules != nil && rules.Senior != nil && age >= rules.Senior.MinAge:
+			party.Seniors++
+		default:
+			party.Adults++
+		}
+	}
+	return party
+}
+
+// PromoDiscount is how much a promo code takes off subtotal
+func PromoDiscount(promo *models.PromoCode, subtotal float64) float64 {
+	var discount float64
+	switch promo.DiscountType {
+	case models.PromoPercent:
+		discount = subtotal * promo.DiscountValue / 100
+		if promo.MaxDiscount != nil && discount > *promo.MaxDiscount {
+			discount = *promo.MaxDiscount
+		}
+	case models.PromoFlat:
+		discount = promo.DiscountValue
+	}
+	return roundMoney(math.Min(discount, subtotal))
+}
+
+// QuotePrice itemizes the price of a party. basePrice is the package
+// price, or the departure's override. The departure date picks the
+// season; a zero date applies no season. The group discount comes before
+// the promo code, which applies to what is left.
+func QuotePrice(basePrice float64, rules *models.PricingRules, departure time.Time, party models.PartySize, promo *models.PromoCode) models.PriceQuote {
+	if rules == nil {
+		rules = &models.PricingRules{Mode: models.PricingPerPerson}
+	}
+	quote := models.PriceQuote{
+		Currency:         "INR",
+		Mode:             rules.Mode,
+		Party:            party,
+		BasePrice:        basePrice,
+		SeasonMultiplier: 1,
+		Lines:            []models.QuoteLine{},
+		Discounts:        []models.QuoteLine{},
+	}
+	if !departure.IsZero() {
+		quote.DepartureDate = departure.Format(pricingDateLayout)
+		for _, s := range rules.Seasons {
+			if quote.DepartureDate >= s.StartDate && quote.DepartureDate <= s.EndDate {
+				quote.Season = s.Name
+				quote.SeasonMultiplier = s.Multiplier
+				break
+			}
+		}
+	}
+	quote.UnitPrice = roundMoney(basePrice * quote.SeasonMultiplier)
+
+	if rules.Mode == models.PricingPerGroup {
+		quote.Lines = append(quote.Lines, models.QuoteLine{
+			Label: fmt.Sprintf("Group price (%d travelers)", party.Total()), Quantity: 1,
+			UnitPrice: quote.UnitPrice, Amount: quote.UnitPrice,
+		})
+	} else {
+		addLine := func(label string, quantity int, unit float64) {
+			if quantity > 0 {
+				quote.Lines = append(quote.Lines, models.QuoteLine{
+					Label: label, Quantity: quantity, UnitPrice: unit, Amount: roundMoney(unit * float64(quantity)),
+				})
+			}
+		}
+		addLine("Adult", party.Adults, quote.UnitPrice)
+		if rules.Child != nil {
+			addLine(fmt.Sprintf("Child (up to %d years)", rules.Child.MaxAge), party.Children, roundMoney(quote.UnitPrice*rules.Child.PricePercent/100))
+		} else {
+			addLine("Child", party.Children, quote.UnitPrice)
+		}
+		if rules.Senior != nil {
+			addLine(fmt.Sprintf("Senior (%d+ years)", rules.Senior.MinAge), party.Seniors, roundMoney(quote.UnitPrice*rules.Senior.PricePercent/100))
+		} else {
+			addLine("Senior", party.Seniors, quote.UnitPrice)
+		}
+	}
+	for _, line := range quote.Lines {
+		quote.Subtotal += line.Amount
+	}
+	quote.Subtotal = roundMoney(quote.Subtotal)
+
+	remaining := quote.Subtotal
+	if rules.Mode == models.PricingPerPerson {
+		var tier *models.GroupDiscount
+		for i := range rules.GroupDiscounts {
+			if party.Total() >= rules.GroupDiscounts[i].MinTravelers {
+				tier = &rules.GroupDiscounts[i]
+			}
+		}
+		if tier != nil {
+			amount := roundMoney(remaining * tier.Percent / 100)
+			quote.Discounts = append(quote.Discounts, models.QuoteLine{
+				Label: fmt.Sprintf("Group discount (%g%% for %d+ travelers)", tier.Percent, tier.MinTravelers), Quantity: 1,
+				UnitPrice: -amount, Amount: -amount,
+			})
+			remaining -= amount
+		}
+	}
+	if promo != nil {
+		amount := PromoDiscount(promo, remaining)
+		quote.PromoCode = promo.Code
+		quote.Discounts = append(quote.Discounts, models.QuoteLine{
+			Label: "Promo code " + promo.Code, Quantity: 1, UnitPrice: -amount, Amount: -amount,
+		})
+		remaining -= amount
+	}
+	for _, d := range quote.Discounts {
+		quote.DiscountTotal -= d.Amount
+	}
+	quote.DiscountTotal = roundMoney(quote.DiscountTotal)
+	quote.Total = roundMoney(math.Max(remaining, 0))
+	return quote
+}
+
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,247 @@
+package utils
+
+import (
+	"testing"
+	"time"
+	"trip-planner-backend/models"
+)
+
+func TestNormalizePricingRules(t *testing.T) {
+	peak := models.Season{Name: "Peak", StartDate: "2026-12-20", EndDate: "2027-01-05", Multiplier: 1.5}
+	monsoon := models.Season{Name: "Monsoon", StartDate: "2026-06-01", EndDate: "2026-09-30", Multiplier: 0.8}
+
+	tests := []struct {
+		name        string
+		rules       models.PricingRules
+		wantMode    string
+		wantSeasons []string
+		wantGroups  []int
+		wantErr     bool
+	}{
+		{name: "mode defaults to per person", wantMode: models.PricingPerPerson},
+		{name: "unknown mode", rules: models.PricingRules{Mode: "per_night"}, wantErr: true},
+		{
+			name:        "seasons sorted by start date",
+			rules:       models.PricingRules{Seasons: []models.Season{peak, monsoon}},
+			wantMode:    models.PricingPerPerson,
+			wantSeasons: []string{"Monsoon", "Peak"},
+		},
+		{
+			name:    "overlapping seasons",
+			rules:   models.PricingRules{Seasons: []models.Season{peak, {Name: "Christmas", StartDate: "2026-12-24", EndDate: "2026-12-26", Multiplier: 2}}},
+			wantErr: true,
+		},
+		{
+			name:    "season ends before it starts",
+			rules:   models.PricingRules{Seasons: []models.Season{{Name: "Odd", StartDate: "2026-05-10", EndDate: "2026-05-01", Multiplier: 1.2}}},
+			wantErr: true,
+		},
+		{
+			name:    "season multiplier out of range",
+			rules:   models.PricingRules{Seasons: []models.Season{{Name: "Free", StartDate: "2026-05-01", EndDate: "2026-05-10", Multiplier: 0}}},
+			wantErr: true,
+		},
+		{
+			name:    "season without a name",
+			rules:   models.PricingRules{Seasons: []models.Season{{Name: "  ", StartDate: "2026-05-01", EndDate: "2026-05-10", Multiplier: 1.2}}},
+			wantErr: true,
+		},
+		{
+			name:       "group discounts sorted by size",
+			rules:      models.PricingRules{GroupDiscounts: []models.GroupDiscount{{MinTravelers: 8, Percent: 10}, {MinTravelers: 4, Percent: 5}}},
+			wantMode:   models.PricingPerPerson,
+			wantGroups: []int{4, 8},
+		},
+		{
+			name:    "larger group gets a smaller discount",
+			rules:   models.PricingRules{GroupDiscounts: []models.GroupDiscount{{MinTravelers: 4, Percent: 10}, {MinTravelers: 8, Percent: 5}}},
+			wantErr: true,
+		},
+		{
+			name:    "group discount for one traveler",
+			rules:   models.PricingRules{GroupDiscounts: []models.GroupDiscount{{MinTravelers: 1, Percent: 5}}},
+			wantErr: true,
+		},
+		{
+			name:    "child age out of range",
+			rules:   models.PricingRules{Child: &models.ChildPricing{MaxAge: 18, PricePercent: 50}},
+			wantErr: true,
+		},
+		{
+			name:    "senior age out of range",
+			rules:   models.PricingRules{Senior: &models.SeniorPricing{MinAge: 40, PricePercent: 80}},
+			wantErr: true,
+		},
+		{
+			name:    "per group with child pricing",
+			rules:   models.PricingRules{Mode: models.PricingPerGroup, Child: &models.ChildPricing{MaxAge: 11, PricePercent: 50}},
+			wantErr: true,
+		},
+		{
+			name:        "per group with seasons",
+			rules:       models.PricingRules{Mode: models.PricingPerGroup, Seasons: []models.Season{peak}},
+			wantMode:    models.PricingPerGroup,
+			wantSeasons: []string{"Peak"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizePricingRules(tt.rules)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Mode != tt.wantMode {
+				t.Errorf("mode %q, want %q", got.Mode, tt.wantMode)
+			}
+			if len(got.Seasons) != len(tt.wantSeasons) {
+				t.Fatalf("got %d seasons, want %d", len(got.Seasons), len(tt.wantSeasons))
+			}
+			for i, name := range tt.wantSeasons {
+				if got.Seasons[i].Name != name {
+					t.Errorf("season %d is %q, want %q", i, got.Seasons[i].Name, name)
+				}
+			}
+			if len(got.GroupDiscounts) != len(tt.wantGroups) {
+				t.Fatalf("got %d group discounts, want %d", len(got.GroupDiscounts), len(tt.wantGroups))
+			}
+			for i, size := range tt.wantGroups {
+				if got.GroupDiscounts[i].MinTravelers != size {
+					t.Errorf("group discount %d starts at %d, want %d", i, got.GroupDiscounts[i].MinTravelers, size)
+				}
+			}
+		})
+	}
+}
+
+func TestClassifyAges(t *testing.T) {
+	rules := &models.PricingRules{
+		Child:  &models.ChildPricing{MaxAge: 11, PricePercent: 50},
+		Senior: &models.SeniorPricing{MinAge: 60, PricePercent: 80},
+	}
+
+	tests := []struct {
+		name  string
+		rules *models.PricingRules
+		ages  []int
+		want  models.PartySize
+	}{
+		{name: "bands at their boundaries", rules: rules, ages: []int{11, 12, 59, 60}, want: models.PartySize{Adults: 2, Children: 1, Seniors: 1}},
+		{name: "no rules means all adults", ages: []int{5, 35, 70}, want: models.PartySize{Adults: 3}},
+		{name: "nobody", rules: rules, want: models.PartySize{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyAges(tt.rules, tt.ages); got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuotePrice(t *testing.T) {
+	perPerson := &models.PricingRules{
+		Mode:   models.PricingPerPerson,
+		Child:  &models.ChildPricing{MaxAge: 11, PricePercent: 50},
+		Senior: &models.SeniorPricing{MinAge: 60, PricePercent: 80},
+		Seasons: []models.Season{
+			{Name: "Peak", StartDate: "2026-12-20", EndDate: "2027-01-05", Multiplier: 1.5},
+		},
+		GroupDiscounts: []models.GroupDiscount{{MinTravelers: 4, Percent: 5}, {MinTravelers: 8, Percent: 10}},
+	}
+	perGroup := &models.PricingRules{Mode: models.PricingPerGroup}
+	maxDiscount := 1000.0
+	tenPercent := &models.PromoCode{Code: "WINTER10", DiscountType: models.PromoPercent, DiscountValue: 10, MaxDiscount: &maxDiscount}
+	bigFlat := &models.PromoCode{Code: "FREE", DiscountType: models.PromoFlat, DiscountValue: 50000}
+
+	date := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	tests := []struct {
+		name          string
+		basePrice     float64
+		rules         *models.PricingRules
+		departure     time.Time
+		party         models.PartySize
+		promo         *models.PromoCode
+		wantSeason    string
+		wantUnit      float64
+		wantSubtotal  float64
+		wantDiscounts float64
+		wantTotal     float64
+		wantLines     int
+	}{
+		{
+			name: "no rules", basePrice: 10000, party: models.PartySize{Adults: 2},
+			wantUnit: 10000, wantSubtotal: 20000, wantTotal: 20000, wantLines: 1,
+		},
+		{
+			name: "off season with a child", basePrice: 10000, rules: perPerson, departure: date("2026-11-10"),
+			party:    models.PartySize{Adults: 2, Children: 1},
+			wantUnit: 10000, wantSubtotal: 25000, wantTotal: 25000, wantLines: 2,
+		},
+		{
+			name: "peak season with a group discount", basePrice: 10000, rules: perPerson, departure: date("2026-12-25"),
+			party:      models.PartySize{Adults: 2, Children: 1, Seniors: 1},
+			wantSeason: "Peak", wantUnit: 15000, wantSubtotal: 49500, wantDiscounts: 2475, wantTotal: 47025, wantLines: 3,
+		},
+		{
+			name: "last day of a season counts", basePrice: 10000, rules: perPerson, departure: date("2027-01-05"),
+			party:      models.PartySize{Adults: 1},
+			wantSeason: "Peak", wantUnit: 15000, wantSubtotal: 15000, wantTotal: 15000, wantLines: 1,
+		},
+		{
+			name: "largest group tier that applies", basePrice: 1000, rules: perPerson, departure: date("2026-11-10"),
+			party:    models.PartySize{Adults: 9},
+			wantUnit: 1000, wantSubtotal: 9000, wantDiscounts: 900, wantTotal: 8100, wantLines: 1,
+		},
+		{
+			name: "promo applies after the group discount and is capped", basePrice: 10000, rules: perPerson, departure: date("2026-12-25"),
+			party: models.PartySize{Adults: 2, Children: 1, Seniors: 1}, promo: tenPercent,
+			wantSeason: "Peak", wantUnit: 15000, wantSubtotal: 49500, wantDiscounts: 3475, wantTotal: 46025, wantLines: 3,
+		},
+		{
+			name: "per group price ignores party size", basePrice: 30000, rules: perGroup, departure: date("2026-11-10"),
+			party:    models.PartySize{Adults: 6},
+			wantUnit: 30000, wantSubtotal: 30000, wantTotal: 30000, wantLines: 1,
+		},
+		{
+			name: "flat promo never takes the total below zero", basePrice: 30000, rules: perGroup,
+			party: models.PartySize{Adults: 6}, promo: bigFlat,
+			wantUnit: 30000, wantSubtotal: 30000, wantDiscounts: 30000, wantTotal: 0, wantLines: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := QuotePrice(tt.basePrice, tt.rules, tt.departure, tt.party, tt.promo)
+			if q.Season != tt.wantSeason {
+				t.Errorf("season %q, want %q", q.Season, tt.wantSeason)
+			}
+			if q.UnitPrice != tt.wantUnit {
+				t.Errorf("unit price %.2f, want %.2f", q.UnitPrice, tt.wantUnit)
+			}
+			if q.Subtotal != tt.wantSubtotal {
+				t.Errorf("subtotal %.2f, want %.2f", q.Subtotal, tt.wantSubtotal)
+			}
+			if q.DiscountTotal != tt.wantDiscounts {
+				t.Errorf("discounts %.2f, want %.2f", q.DiscountTotal, tt.wantDiscounts)
+			}
+			if q.Total != tt.wantTotal {
+				t.Errorf("total %.2f, want %.2f", q.Total, tt.wantTotal)
+			}
+			if len(q.Lines) != tt.wantLines {
+				t.Errorf("got %d lines, want %d: %+v", len(q.Lines), tt.wantLines, q.Lines)
+			}
+		})
+	}
+}
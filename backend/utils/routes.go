@@ -0,0 +1,475 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"trip-planner-backend/models"
+)
+
+const (
+	routeDayStartMinutes  = 9 * 60
+	routeDayEndMinutes    = 19 * 60
+	routeDefaultVisit     = 60
+	routeRoadFactor       = 1.3  // straight-line to road distance
+	routeFallbackSpeedKmh = 35.0 // average speed on district roads
+	routeExhaustiveLimit  = 7    // 7! = 5040 orders is still instant
+	routeViolationPenalty = 100000
+)
+
+// RouteOptions tunes a day route. A zero Date means the weekday is unknown,
+// so only opening hours that are the same every day are enforced.
+type RouteOptions struct {
+	Date         time.Time
+	StartMinutes int
+	Start        *models.RoutePoint
+}
+
+type travelMatrix struct {
+	km      [][]float64
+	minutes [][]float64
+	source  string
+}
+
+type scheduledStop struct {
+	arrive, start, depart, wait int
+	warning                     string
+}
+
+type routeSchedule struct {
+	stops      []scheduledStop
+	violations int
+	travel     int
+	wait       int
+	late       int
+}
+
+func (s routeSchedule) score() int {
+	return s.violations*routeViolationPenalty + s.travel + s.wait + s.late
+}
+
+// GeocodePlaces resolves itinerary names against the catalog near the
+// destination. Names that match nothing, or repeat an earlier stop, are
+// returned separately.
+func GeocodePlaces(names []string, destination string) ([]models.POI, []string) {
+	places, _ := nearbyCatalog(destination)
+	found := []models.POI{}
+	unresolved := []string{}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		poi, ok := matchCatalogPlace(name, places)
+		if !ok {
+			unresolved = append(unresolved, name)
+			continue
+		}
+		key := poi.DistrictName + "|" + poi.Name
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		found = append(found, poi)
+	}
+	return found, unresolved
+}
+
+// OptimizeDayRoute orders one day's stops to minimise driving plus waiting
+// time, visiting each place while it is open. Days with few stops try every
+// order; longer days use nearest-neighbour followed by 2-opt.
+func OptimizeDayRoute(day int, destination string, names []string, opts RouteOptions) (models.DayRoute, error) {
+	start := opts.Start
+	if start == nil {
+		district, ok := FindDistrict(destination)
+		if !ok {
+			return models.DayRoute{}, fmt.Errorf("unknown destination %q", destination)
+		}
+		start = &models.RoutePoint{Name: district.Name, Latitude: district.Latitude, Longitude: district.Longitude}
+	}
+	startMinutes := opts.StartMinutes
+	if startMinutes <= 0 {
+		startMinutes = routeDayStartMinutes
+	}
+	weekday := -1
+	route := models.DayRoute{Day: day, Start: *start, Stops: []models.RouteStop{}, Source: "haversine"}
+	if !opts.Date.IsZero() {
+		weekday = int(opts.Date.Weekday())
+		route.Date = opts.Date.Format("2006-01-02")
+	}
+
+	pois, unresolved := GeocodePlaces(names, destination)
+	route.Unresolved = unresolved
+	if len(pois) == 0 {
+		return route, nil
+	}
+
+	points := []models.RoutePoint{*start}
+	for _, poi := range pois {
+		points = append(points, models.RoutePoint{Name: poi.Name, Latitude: poi.Latitude, Longitude: poi.Longitude})
+	}
+	matrix := buildTravelMatrix(points)
+	route.Source = matrix.source
+
+	evaluate := func(order []int) routeSchedule {
+		return scheduleRoute(order, pois, matrix, weekday, startMinutes)
+	}
+	order := bestRouteOrder(len(pois), evaluate)
+	schedule := evaluate(order)
+
+	prev := 0
+	for i, idx := range order {
+		poi, stop := pois[idx], schedule.stops[i]
+		legKm := matrix.km[prev][idx+1]
+		legMinutes := int(math.Round(matrix.minutes[prev][idx+1]))
+		route.Stops = append(route.Stops, models.RouteStop{
+			Order:         i + 1,
+			Name:          poi.Name,
+			POIID:         poi.POIID,
+			Category:      poi.Category,
+			Latitude:      poi.Latitude,
+			Longitude:     poi.Longitude,
+			Slot:          daySlot(stop.start),
+			Arrive:        formatClock(stop.arrive),
+			Start:         formatClock(stop.start),
+			Depart:        formatClock(stop.depart),
+			WaitMinutes:   stop.wait,
+			VisitMinutes:  stop.depart - stop.start,
+			LegDistanceKm: math.Round(legKm*10) / 10,
+			LegMinutes:    legMinutes,
+			Warning:       stop.warning,
+		})
+		route.TotalDistanceKm += legKm
+		route.TotalTravelMinutes += legMinutes
+		prev = idx + 1
+	}
+	route.TotalDistanceKm = math.Round(route.TotalDistanceKm*10) / 10
+	return route, nil
+}
+
+// PlanTripRoutes builds a route for every day of a generated itinerary from
+// its verified places. Restaurants are left out since meals fit around
+// the sightseeing rather than fixing the order.
+func PlanTripRoutes(destination string, startDate time.Time, items []ItineraryItem) []models.DayRoute {
+	byDay := make(map[int][]string)
+	for _, item := range items {
+		if item.Day <= 0 || item.Kind != "place" || !item.Verified {
+			continue
+		}
+		name := item.CatalogName
+		if name == "" {
+			name = item.Name
+		}
+		byDay[item.Day] = append(byDay[item.Day], name)
+	}
+
+	days := make([]int, 0, len(byDay))
+	for day := range byDay {
+		days = append(days, day)
+	}
+	sort.Ints(days)
+
+	routes := []models.DayRoute{}
+	for _, day := range days {
+		opts := RouteOptions{}
+		if !startDate.IsZero() {
+			opts.Date = startDate.AddDate(0, 0, day-1)
+		}
+		route, err := OptimizeDayRoute(day, destination, byDay[day], opts)
+		if err != nil {
+			log.Printf("Error planning day %d route: %v", day, err)
+			continue
+		}
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// bestRouteOrder returns the visiting order (indexes into the stops) with
+// the lowest schedule score
+func bestRouteOrder(n int, evaluate func([]int) routeSchedule) []int {
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	if n <= 1 {
+		return order
+	}
+
+	if n <= routeExhaustiveLimit {
+		best := append([]int(nil), order...)
+		bestScore := evaluate(order).score()
+		permute(order, 0, func(candidate []int) {
+			if score := evaluate(candidate).score(); score < bestScore {
+				bestScore = score
+				copy(best, candidate)
+			}
+		})
+		return best
+	}
+
+	// Nearest neighbour: repeatedly take the stop that extends the schedule
+	// most cheaply
+	route := []int{}
+	remaining := append([]int(nil), order...)
+	for len(remaining) > 0 {
+		bestIdx, bestScore := 0, math.MaxInt
+		for i, candidate := range remaining {
+			score := evaluate(append(append([]int(nil), route...), candidate)).score()
+			if score < bestScore {
+				bestIdx, bestScore = i, score
+			}
+		}
+		route = append(route, remaining[bestIdx])
+		remaining = append(remaining[:bestIdx], remaining[bestIdx+1:]...)
+	}
+
+	// 2-opt: reverse segments while that lowers the score
+	bestScore := evaluate(route).score()
+	for improved := true; improved; {
+		improved = false
+		for i := 0; i < n-1; i++ {
+			for j := i + 1; j < n; j++ {
+				candidate := append([]int(nil), route...)
+				for a, b := i, j; a < b; a, b = a+1, b-1 {
+					candidate[a], candidate[b] = candidate[b], candidate[a]
+				}
+				if score := evaluate(candidate).score(); score < bestScore {
+					route, bestScore, improved = candidate, score, true
+				}
+			}
+		}
+	}
+	return route
+}
+
+func permute(order []int, k int, visit func([]int)) {
+	if k == len(order) {
+		visit(order)
+		return
+	}
+	for i := k; i < len(order); i++ {
+		order[k], order[i] = order[i], order[k]
+		permute(order, k+1, visit)
+		order[k], order[i] = order[i], order[k]
+	}
+}
+
+// scheduleRoute walks an order from the start point, waiting for places to
+// open and counting visits that cannot fit inside opening hours
+func scheduleRoute(order []int, pois []models.POI, matrix travelMatrix, weekday, startMinutes int) routeSchedule {
+	schedule := routeSchedule{stops: make([]scheduledStop, 0, len(order))}
+	clock := startMinutes
+	prev := 0
+	for _, idx := range order {
+		travel := int(math.Round(matrix.minutes[prev][idx+1]))
+		arrive := clock + travel
+		visit := pois[idx].VisitDurationMinutes
+		if visit <= 0 {
+			visit = routeDefaultVisit
+		}
+
+		start, warning := fitVisit(pois[idx], weekday, arrive, visit)
+		if warning != "" {
+			schedule.violations++
+		}
+		stop := scheduledStop{arrive: arrive, start: start, depart: start + visit, wait: start - arrive, warning: warning}
+		schedule.stops = append(schedule.stops, stop)
+		schedule.travel += travel
+		schedule.wait += stop.wait
+		clock = stop.depart
+		prev = idx + 1
+	}
+	if clock > routeDayEndMinutes {
+		schedule.late = clock - routeDayEndMinutes
+	}
+	return schedule
+}
+
+// fitVisit returns when a visit arriving at arrive can start, or a warning
+// when no opening window leaves room for the whole visit
+func fitVisit(poi models.POI, weekday, arrive, visit int) (int, string) {
+	windows, constrained := openWindows(poi.OpeningHours, weekday)
+	if !constrained {
+		return arrive, ""
+	}
+	if len(windows) == 0 {
+		return arrive, "Closed on " + time.Weekday(weekday).String()
+	}
+	for _, w := range windows {
+		start := arrive
+		if w[0] > start {
+			start = w[0]
+		}
+		if start+visit <= w[1] {
+			return start, ""
+		}
+	}
+	last := windows[len(windows)-1]
+	if arrive >= last[1] {
+		return arrive, "Arrives after closing time (" + formatClock(last[1]) + ")"
+	}
+	return arrive, "Closes at " + formatClock(last[1]) + ", before the visit ends"
+}
+
+// openWindows lists a place's opening windows (minutes after midnight) on a
+// weekday. constrained is false when hours are unknown, or when the weekday
+// is unknown (-1) and the hours differ between days.
+func openWindows(periods []models.OpeningPeriod, weekday int) ([][2]int, bool) {
+	if len(periods) == 0 {
+		return nil, false
+	}
+	byDay := make([][][2]int, 7)
+	for _, p := range periods {
+		open, ok1 := ParseClock(p.Open)
+		closes, ok2 := ParseClock(p.Close)
+		if !ok1 || !ok2 || p.Day < 0 || p.Day > 6 {
+			continue
+		}
+		byDay[p.Day] = append(byDay[p.Day], [2]int{open, closes})
+	}
+	for _, windows := range byDay {
+		sort.Slice(windows, func(i, j int) bool { return windows[i][0] < windows[j][0] })
+	}
+
+	if weekday >= 0 {
+		return byDay[weekday], true
+	}
+	for _, windows := range byDay[1:] {
+		if fmt.Sprint(windows) != fmt.Sprint(byDay[0]) {
+			return nil, false
+		}
+	}
+	return byDay[0], true
+}
+
+// buildTravelMatrix gets driving distances (km) and times (minutes) between
+// every pair of points from OpenRouteService, falling back to haversine
+// estimates for the whole matrix or for pairs it cannot route
+func buildTravelMatrix(points []models.RoutePoint) travelMatrix {
+	fallback := haversineMatrix(points)
+	km, minutes, err := getOpenRouteMatrix(points)
+	if err != nil {
+		if os.Getenv("OPENROUTE_API_KEY") != "" {
+			log.Printf("Falling back to haversine route estimates: %v", err)
+		}
+		return fallback
+	}
+
+	matrix := travelMatrix{km: fallback.km, minutes: fallback.minutes, source: "openrouteservice"}
+	for i := range points {
+		for j := range points {
+			if km[i][j] != nil && minutes[i][j] != nil {
+				matrix.km[i][j] = *km[i][j]
+				matrix.minutes[i][j] = *minutes[i][j] / 60
+			}
+		}
+	}
+	return matrix
+}
+
+func haversineMatrix(points []models.RoutePoint) travelMatrix {
+	n := len(points)
+	matrix := travelMatrix{km: make([][]float64, n), minutes: make([][]float64, n), source: "haversine"}
+	for i, from := range points {
+		matrix.km[i] = make([]float64, n)
+		matrix.minutes[i] = make([]float64, n)
+		for j, to := range points {
+			if i == j {
+				continue
+			}
+			km := calculateHaversineDistance(from.Latitude, from.Longitude, to.Latitude, to.Longitude) * routeRoadFactor
+			matrix.km[i][j] = km
+			matrix.minutes[i][j] = km / routeFallbackSpeedKmh * 60
+		}
+	}
+	return matrix
+}
+
+// OpenRouteMatrixResponse is the OpenRouteService matrix response. Entries
+// are null for pairs with no route.
+type OpenRouteMatrixResponse struct {
+	Distances [][]*float64 `json:"distances"`
+	Durations [][]*float64 `json:"durations"`
+}
+
+// getOpenRouteMatrix returns distances in km and durations in seconds
+func getOpenRouteMatrix(points []models.RoutePoint) ([][]*float64, [][]*float64, error) {
+	apiKey := os.Getenv("OPENROUTE_API_KEY")
+	if apiKey == "" {
+		return nil, nil, fmt.Errorf("OPENROUTE_API_KEY not set")
+	}
+
+	locations := make([][]float64, len(points))
+	for i, p := range points {
+		locations[i] = []float64{p.Longitude, p.Latitude}
+	}
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"locations": locations,
+		"metrics":   []string{"distance", "duration"},
+		"units":     "km",
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req, err := http.NewRequest("POST", "https://api.openrouteservice.org/v2/matrix/driving-car", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Authorization", apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("OpenRouteService matrix error: %d", resp.StatusCode)
+	}
+
+	var matrix OpenRouteMatrixResponse
+	if err := json.Unmarshal(body, &matrix); err != nil {
+		return nil, nil, err
+	}
+	if len(matrix.Distances) != len(points) || len(matrix.Durations) != len(points) {
+		return nil, nil, fmt.Errorf("unexpected matrix size")
+	}
+	for i := range points {
+		if len(matrix.Distances[i]) != len(points) || len(matrix.Durations[i]) != len(points) {
+			return nil, nil, fmt.Errorf("unexpected matrix size")
+		}
+	}
+	return matrix.Distances, matrix.Durations, nil
+}
+
+func daySlot(minutes int) string {
+	switch {
+	case minutes < 12*60:
+		return "morning"
+	case minutes < 17*60:
+		return "afternoon"
+	default:
+		return "evening"
+	}
+}
+
+func formatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60%24, minutes%60)
+}
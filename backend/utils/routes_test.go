@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"trip-planner-backend/models"
+)
+
+// lineMatrix places the start at 0 and each stop at its position on a
+// straight road, ten minutes apart per unit
+func lineMatrix(positions []float64) travelMatrix {
+	points := append([]float64{0}, positions...)
+	matrix := travelMatrix{km: make([][]float64, len(points)), minutes: make([][]float64, len(points))}
+	for i := range points {
+		matrix.km[i] = make([]float64, len(points))
+		matrix.minutes[i] = make([]float64, len(points))
+		for j := range points {
+			matrix.km[i][j] = math.Abs(points[i] - points[j])
+			matrix.minutes[i][j] = 10 * matrix.km[i][j]
+		}
+	}
+	return matrix
+}
+
+// everyDay is an opening window repeated on all weekdays
+func everyDay(open, close string) []models.OpeningPeriod {
+	periods := []models.OpeningPeriod{}
+	for day := 0; day < 7; day++ {
+		periods = append(periods, models.OpeningPeriod{Day: day, Open: open, Close: close})
+	}
+	return periods
+}
+
+func TestBestRouteOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		positions []float64
+		hours     map[int][]models.OpeningPeriod
+		want      []int
+	}{
+		{name: "no stops", want: []int{}},
+		{name: "single stop", positions: []float64{4}, want: []int{0}},
+		{name: "shortest drive along the road", positions: []float64{3, 1, 2}, want: []int{1, 2, 0}},
+		{
+			// Visiting the far stop while the near one is still shut waits less
+			name:      "waits less for a late opening",
+			positions: []float64{1, 2},
+			hours:     map[int][]models.OpeningPeriod{0: everyDay("14:00", "18:00")},
+			want:      []int{1, 0},
+		},
+		{
+			// The near stop first would reach the far one after it closes
+			name:      "avoids arriving after closing",
+			positions: []float64{1, 5},
+			hours:     map[int][]models.OpeningPeriod{1: everyDay("09:00", "11:00")},
+			want:      []int{1, 0},
+		},
+		{
+			name:      "heuristic above the exhaustive limit",
+			positions: []float64{5, 2, 9, 1, 7, 3, 8, 4, 6},
+			want:      []int{3, 1, 5, 7, 0, 8, 4, 6, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pois := make([]models.POI, len(tt.positions))
+			for i := range pois {
+				pois[i].OpeningHours = tt.hours[i]
+			}
+			matrix := lineMatrix(tt.positions)
+			got := bestRouteOrder(len(pois), func(order []int) routeSchedule {
+				return scheduleRoute(order, pois, matrix, -1, routeDayStartMinutes)
+			})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got order %v, want %v", got, tt.want)
+			}
+			if s := scheduleRoute(got, pois, matrix, -1, routeDayStartMinutes); s.violations != 0 {
+				t.Errorf("best order still has %d violations", s.violations)
+			}
+		})
+	}
+}
+
+func TestFitVisit(t *testing.T) {
+	museum := models.POI{OpeningHours: []models.OpeningPeriod{
+		{Day: 1, Open: "10:00", Close: "13:00"},
+		{Day: 1, Open: "14:00", Close: "17:00"},
+	}}
+
+	tests := []struct {
+		name      string
+		poi       models.POI
+		weekday   int
+		arrive    int
+		wantStart int
+		wantWarn  bool
+	}{
+		{name: "unknown hours", poi: models.POI{}, weekday: 1, arrive: 8 * 60, wantStart: 8 * 60},
+		{name: "waits for opening", poi: museum, weekday: 1, arrive: 9 * 60, wantStart: 10 * 60},
+		{name: "moves to the afternoon window", poi: museum, weekday: 1, arrive: 12*60 + 30, wantStart: 14 * 60},
+		{name: "too late", poi: museum, weekday: 1, arrive: 16*60 + 30, wantStart: 16*60 + 30, wantWarn: true},
+		{name: "closed that day", poi: museum, weekday: 2, arrive: 10 * 60, wantStart: 10 * 60, wantWarn: true},
+		{name: "unknown weekday with varying hours", poi: museum, weekday: -1, arrive: 7 * 60, wantStart: 7 * 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, warning := fitVisit(tt.poi, tt.weekday, tt.arrive, 60)
+			if start != tt.wantStart {
+				t.Errorf("starts at %s, want %s", formatClock(start), formatClock(tt.wantStart))
+			}
+			if (warning != "") != tt.wantWarn {
+				t.Errorf("warning %q, want warning %v", warning, tt.wantWarn)
+			}
+		})
+	}
+}
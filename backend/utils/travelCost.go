@@ -26,7 +26,9 @@ type TravelCostCalculation struct {
 	CarDuration   string  `json:"car_duration"`
 }
 
-// District coordinates (longitude, latitude) for Karnataka districts
+// District coordinates (longitude, latitude) for Karnataka districts. The
+// districts table is the source of truth; this list is the fallback until
+// it has been migrated.
 var districtCoordinates = map[string][]float64{
 	"Bagalkot":                {75.6615, 16.1691},
 	"Ballari (Bellary)":       {76.9214, 15.1394},
@@ -61,6 +63,17 @@ var districtCoordinates = map[string][]float64{
 	"Vijayanagara":            {76.4700, 15.3350},
 }
 
+// LookupDistrict resolves a district name as typed by a user ("Mysore",
+// "mysuru", "Mysuru (Mysore)") to its canonical name and coordinates
+// (longitude, latitude).
+func LookupDistrict(name string) (string, []float64, bool) {
+	d, ok := FindDistrict(name)
+	if !ok {
+		return "", nil, false
+	}
+	return d.Name, []float64{d.Longitude, d.Latitude}, true
+}
+
 // OpenRouteService response structure
 type OpenRouteResponse struct {
 	Routes []struct {
@@ -132,9 +145,8 @@ func getOpenRouteDistance(fromCoords, toCoords []float64) (float64, float64, err
 
 // Calculate travel costs using OpenRouteService for distance
 func CalculateTravelCosts(from, to string, numTravelers int) (TravelCostCalculation, error) {
-	// Get coordinates from our predefined map
-	fromCoords, fromExists := districtCoordinates[from]
-	toCoords, toExists := districtCoordinates[to]
+	_, fromCoords, fromExists := LookupDistrict(from)
+	_, toCoords, toExists := LookupDistrict(to)
 
 	if !fromExists || !toExists {
 		log.Printf("Coordinates not found for: %s or %s", from, to)
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+	"sync"
+)
+
+// UploadLimits caps what a single upload request may contain
+type UploadLimits struct {
+	MaxFileBytes        int64
+	MaxRequestBytes     int64
+	MaxPhotosPerPackage int
+}
+
+// UploadLimitsFromEnv reads UPLOAD_MAX_FILE_MB, UPLOAD_MAX_REQUEST_MB and
+// PACKAGE_MAX_PHOTOS, falling back to 10 MB, 50 MB and 10 photos
+func UploadLimitsFromEnv() UploadLimits {
+	limits := UploadLimits{
+		MaxFileBytes:        10 << 20,
+		MaxRequestBytes:     50 << 20,
+		MaxPhotosPerPackage: 10,
+	}
+	if mb, err := strconv.Atoi(os.Getenv("UPLOAD_MAX_FILE_MB")); err == nil && mb > 0 {
+		limits.MaxFileBytes = int64(mb) << 20
+	}
+	if mb, err := strconv.Atoi(os.Getenv("UPLOAD_MAX_REQUEST_MB")); err == nil && mb > 0 {
+		limits.MaxRequestBytes = int64(mb) << 20
+	}
+	if n, err := strconv.Atoi(os.Getenv("PACKAGE_MAX_PHOTOS")); err == nil && n > 0 {
+		limits.MaxPhotosPerPackage = n
+	}
+	return limits
+}
+
+var (
+	uploadLimitsOnce sync.Once
+	uploadLimits     UploadLimits
+)
+
+// DefaultUploadLimits returns the limits from the environment. They are
+// read on first use rather than at package init, so values from the .env
+// file loaded in main are seen.
+func DefaultUploadLimits() UploadLimits {
+	uploadLimitsOnce.Do(func() { uploadLimits = UploadLimitsFromEnv() })
+	return uploadLimits
+}
@@ -1,38 +1,44 @@
 package utils
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
+	"log"
+	"math"
+	"strings"
 	"time"
 )
 
 type WeatherResponse struct {
 	City struct {
-		Name    string `json:"name"`
-		Country string `json:"country"`
+		Name     string `json:"name"`
+		Country  string `json:"country"`
+		Timezone int    `json:"timezone"` // Shift in seconds from UTC
 	} `json:"city"`
-	List []struct {
-		Dt   int64 `json:"dt"`
-		Main struct {
-			Temp      float64 `json:"temp"`
-			FeelsLike float64 `json:"feels_like"`
-			TempMin   float64 `json:"temp_min"`
-			TempMax   float64 `json:"temp_max"`
-			Humidity  int     `json:"humidity"`
-		} `json:"main"`
-		Weather []struct {
-			Main        string `json:"main"`
-			Description string `json:"description"`
-			Icon        string `json:"icon"`
-		} `json:"weather"`
-		Wind struct {
-			Speed float64 `json:"speed"`
-		} `json:"wind"`
-		Pop float64 `json:"pop"` // Probability of precipitation
-	} `json:"list"`
+	List []WeatherSample `json:"list"`
+}
+
+// WeatherSample is a single 3-hour bucket from the forecast API
+type WeatherSample struct {
+	Dt   int64 `json:"dt"`
+	Main struct {
+		Temp      float64 `json:"temp"`
+		FeelsLike float64 `json:"feels_like"`
+		TempMin   float64 `json:"temp_min"`
+		TempMax   float64 `json:"temp_max"`
+		Humidity  int     `json:"humidity"`
+	} `json:"main"`
+	Weather []struct {
+		Main        string `json:"main"`
+		Description string `json:"description"`
+		Icon        string `json:"icon"`
+	} `json:"weather"`
+	Wind struct {
+		Speed float64 `json:"speed"`
+	} `json:"wind"`
+	Pop  float64 `json:"pop"` // Probability of precipitation
+	Rain struct {
+		ThreeHour float64 `json:"3h"`
+	} `json:"rain"`
 }
 
 type WeatherData struct {
@@ -42,109 +48,334 @@ type WeatherData struct {
 	ErrorMsg  string     `json:"error_msg,omitempty"`
 }
 
+// Forecast is the daily aggregate of all 3-hour buckets for one local date
 type Forecast struct {
 	Date        string  `json:"date"`
-	Temp        float64 `json:"temp"`
+	Temp        float64 `json:"temp"` // Mean over the day's buckets
 	FeelsLike   float64 `json:"feels_like"`
 	TempMin     float64 `json:"temp_min"`
 	TempMax     float64 `json:"temp_max"`
 	Humidity    int     `json:"humidity"`
 	Description string  `json:"description"`
+	Condition   string  `json:"condition"`
 	Icon        string  `json:"icon"`
-	WindSpeed   float64 `json:"wind_speed"`
-	RainChance  float64 `json:"rain_chance"`
+	WindSpeed   float64 `json:"wind_speed"`  // Strongest bucket of the day
+	RainChance  float64 `json:"rain_chance"` // Chance of rain at any point in the day
+	RainMM      float64 `json:"rain_mm"`
+	Samples     int     `json:"samples"`
+}
+
+// IsRainy reports whether the day is wet enough to favour indoor plans
+func (f Forecast) IsRainy() bool {
+	return f.RainChance >= 60 || f.RainMM >= 5 || f.Condition == "Thunderstorm"
 }
 
 func GetWeatherForecast(destination string) (WeatherData, error) {
-	apiKey := os.Getenv("OPENWEATHER_API_KEY")
-	if apiKey == "" {
-		return WeatherData{ErrorMsg: "Weather API key not configured"}, fmt.Errorf("OPENWEATHER_API_KEY not set")
+	weatherResp, err := DefaultWeatherProvider().Forecast(ResolveWeatherLocation(destination))
+	if err != nil {
+		return WeatherData{ErrorMsg: weatherErrorMessage(err)}, err
 	}
 
-	// API endpoint for 5-day forecast
-	url := fmt.Sprintf("https://api.openweathermap.org/data/2.5/forecast?q=%s&appid=%s&units=metric", destination, apiKey)
+	return WeatherData{
+		City:      weatherResp.City.Name,
+		Country:   weatherResp.City.Country,
+		Forecasts: AggregateDailyForecasts(weatherResp),
+	}, nil
+}
 
-	resp, err := http.Get(url)
-	if err != nil {
-		return WeatherData{ErrorMsg: "Failed to fetch weather data"}, err
-	}
-	defer resp.Body.Close()
+// Higher means more relevant to a traveller when summarising a day
+var conditionSeverity = map[string]int{
+	"Clear":        0,
+	"Clouds":       1,
+	"Haze":         2,
+	"Mist":         2,
+	"Fog":          3,
+	"Drizzle":      4,
+	"Rain":         5,
+	"Snow":         5,
+	"Squall":       6,
+	"Thunderstorm": 7,
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return WeatherData{ErrorMsg: "Failed to read weather data"}, err
-	}
+// AggregateDailyForecasts groups the 3-hour buckets by local date and
+// combines them: min/max temperature, summed rainfall and the probability of
+// rain at any point in the day. The headline condition is the most severe one
+// seen during daytime hours.
+func AggregateDailyForecasts(weatherResp WeatherResponse) []Forecast {
+	zone := time.FixedZone("local", weatherResp.City.Timezone)
 
-	if resp.StatusCode != http.StatusOK {
-		return WeatherData{ErrorMsg: "Weather data not available for this location"}, fmt.Errorf("weather API error: %s", string(body))
-	}
+	forecasts := []Forecast{}
+	byDate := make(map[string]int)
+	dryChance := make(map[string]float64)
+	sums := make(map[string]struct {
+		temp, feels float64
+		humidity    int
+	})
+	severity := make(map[string]int)
+
+	for _, item := range weatherResp.List {
+		local := time.Unix(item.Dt, 0).In(zone)
+		date := local.Format("2006-01-02")
+
+		idx, ok := byDate[date]
+		if !ok {
+			forecasts = append(forecasts, Forecast{
+				Date:    date,
+				TempMin: item.Main.TempMin,
+				TempMax: item.Main.TempMax,
+			})
+			idx = len(forecasts) - 1
+			byDate[date] = idx
+			dryChance[date] = 1
+			severity[date] = -1
+		}
+		f := &forecasts[idx]
+
+		f.Samples++
+		f.TempMin = math.Min(f.TempMin, item.Main.TempMin)
+		f.TempMax = math.Max(f.TempMax, item.Main.TempMax)
+		f.WindSpeed = math.Max(f.WindSpeed, item.Wind.Speed)
+		f.RainMM += item.Rain.ThreeHour
+		dryChance[date] *= 1 - item.Pop
 
-	var weatherResp WeatherResponse
-	if err := json.Unmarshal(body, &weatherResp); err != nil {
-		return WeatherData{ErrorMsg: "Failed to parse weather data"}, err
+		sum := sums[date]
+		sum.temp += item.Main.Temp
+		sum.feels += item.Main.FeelsLike
+		sum.humidity += item.Main.Humidity
+		sums[date] = sum
+
+		if len(item.Weather) == 0 {
+			continue
+		}
+		// Night-time buckets only set the condition if nothing else has
+		rank := conditionSeverity[item.Weather[0].Main]
+		if local.Hour() < 6 || local.Hour() > 21 {
+			rank -= 10
+		}
+		if rank > severity[date] || f.Description == "" {
+			severity[date] = rank
+			f.Condition = item.Weather[0].Main
+			f.Description = item.Weather[0].Description
+			f.Icon = item.Weather[0].Icon
+		}
 	}
 
-	// Process forecasts - group by day and take midday forecast
-	weatherData := WeatherData{
-		City:      weatherResp.City.Name,
-		Country:   weatherResp.City.Country,
-		Forecasts: []Forecast{},
+	for i := range forecasts {
+		f := &forecasts[i]
+		sum := sums[f.Date]
+		f.Temp = math.Round(sum.temp/float64(f.Samples)*10) / 10
+		f.FeelsLike = math.Round(sum.feels/float64(f.Samples)*10) / 10
+		f.Humidity = sum.humidity / f.Samples
+		f.RainChance = math.Round((1-dryChance[f.Date])*100*10) / 10
+		f.RainMM = math.Round(f.RainMM*10) / 10
 	}
 
-	processedDates := make(map[string]bool)
+	return forecasts
+}
 
-	for _, item := range weatherResp.List {
-		date := time.Unix(item.Dt, 0).Format("2006-01-02")
-		hour := time.Unix(item.Dt, 0).Hour()
-
-		// Take forecast around noon (12:00) for each day
-		if hour >= 11 && hour <= 14 && !processedDates[date] {
-			forecast := Forecast{
-				Date:        date,
-				Temp:        item.Main.Temp,
-				FeelsLike:   item.Main.FeelsLike,
-				TempMin:     item.Main.TempMin,
-				TempMax:     item.Main.TempMax,
-				Humidity:    item.Main.Humidity,
-				Description: item.Weather[0].Description,
-				Icon:        item.Weather[0].Icon,
-				WindSpeed:   item.Wind.Speed,
-				RainChance:  item.Pop * 100, // Convert to percentage
-			}
-			weatherData.Forecasts = append(weatherData.Forecasts, forecast)
-			processedDates[date] = true
+// TripWeatherDay is the weather for one day of a trip. Days within the
+// forecast horizon carry a real forecast; later days fall back to the
+// district's climate normal for that month.
+type TripWeatherDay struct {
+	Date     string         `json:"date"`
+	Source   string         `json:"source"` // "forecast" or "climate_normal"
+	Forecast *Forecast      `json:"forecast,omitempty"`
+	Normal   *ClimateNormal `json:"normal,omitempty"`
+}
+
+type TripWeather struct {
+	Destination string           `json:"destination"`
+	City        string           `json:"city,omitempty"`
+	Country     string           `json:"country,omitempty"`
+	Days        []TripWeatherDay `json:"days"`
+	ErrorMsg    string           `json:"error_msg,omitempty"`
+	// NormalsMissing is set when days beyond the forecast horizon were left
+	// out because the destination has no climate normals
+	NormalsMissing bool `json:"normals_missing,omitempty"`
+}
+
+const (
+	WeatherSourceForecast      = "forecast"
+	WeatherSourceClimateNormal = "climate_normal"
+)
+
+// GetTripWeather builds a day-by-day view of the trip window, mixing live
+// forecasts and climate normals. A forecast failure is not fatal as long as
+// normals exist for the destination.
+func GetTripWeather(destination string, startDate, endDate time.Time) TripWeather {
+	tripWeather := TripWeather{Destination: destination, Days: []TripWeatherDay{}}
+
+	forecastsByDate := make(map[string]Forecast)
+	weather, err := GetWeatherForecast(destination)
+	if err != nil {
+		log.Printf("Weather forecast unavailable for %s: %v", destination, err)
+		tripWeather.ErrorMsg = weather.ErrorMsg
+	} else {
+		tripWeather.City = weather.City
+		tripWeather.Country = weather.Country
+		for _, forecast := range weather.Forecasts {
+			forecastsByDate[forecast.Date] = forecast
 		}
 	}
 
-	return weatherData, nil
+	for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		if forecast, ok := forecastsByDate[date]; ok {
+			forecast := forecast
+			tripWeather.Days = append(tripWeather.Days, TripWeatherDay{Date: date, Source: WeatherSourceForecast, Forecast: &forecast})
+			continue
+		}
+		normal, err := GetClimateNormal(destination, day.Month())
+		if err != nil {
+			tripWeather.NormalsMissing = true
+			continue
+		}
+		tripWeather.Days = append(tripWeather.Days, TripWeatherDay{Date: date, Source: WeatherSourceClimateNormal, Normal: &normal})
+	}
+
+	// Normals cover for a failed forecast, so only surface the error when
+	// there is nothing at all to show
+	if len(tripWeather.Days) > 0 {
+		tripWeather.ErrorMsg = ""
+	} else if tripWeather.ErrorMsg == "" {
+		tripWeather.ErrorMsg = "Weather data not available for these dates"
+	}
+
+	return tripWeather
 }
 
-func FormatWeatherForTrip(destination string) string {
-	weather, err := GetWeatherForecast(destination)
+func FormatWeatherForTrip(destination, startDate, endDate string) string {
+	start, err := time.Parse("2006-01-02", startDate)
 	if err != nil {
 		return fmt.Sprintf("\n⚠️ Weather information not available for %s\n", destination)
 	}
+	end, err := time.Parse("2006-01-02", endDate)
+	if err != nil || end.Before(start) {
+		end = start
+	}
+
+	return FormatTripWeather(GetTripWeather(destination, start, end))
+}
 
+// FormatTripWeather renders the weather section appended to a trip plan
+func FormatTripWeather(weather TripWeather) string {
+	destination := weather.Destination
 	if weather.ErrorMsg != "" {
 		return fmt.Sprintf("\n⚠️ %s\n", weather.ErrorMsg)
 	}
 
-	weatherInfo := fmt.Sprintf("\n🌤️ **Weather Forecast for %s, %s**\n\n", weather.City, weather.Country)
+	weatherInfo := ""
+	hasNormals := false
+	currentMonth := time.Month(0)
 
-	for _, forecast := range weather.Forecasts {
-		weatherInfo += fmt.Sprintf("**%s:**\n", forecast.Date)
-		weatherInfo += fmt.Sprintf("- Temperature: %.1f°C (Feels like: %.1f°C)\n", forecast.Temp, forecast.FeelsLike)
-		weatherInfo += fmt.Sprintf("- High/Low: %.1f°C / %.1f°C\n", forecast.TempMax, forecast.TempMin)
-		weatherInfo += fmt.Sprintf("- Condition: %s\n", forecast.Description)
-		weatherInfo += fmt.Sprintf("- Humidity: %d%%\n", forecast.Humidity)
-		weatherInfo += fmt.Sprintf("- Wind Speed: %.1f m/s\n", forecast.WindSpeed)
-		weatherInfo += fmt.Sprintf("- Rain Chance: %.0f%%\n\n", forecast.RainChance)
+	for _, day := range weather.Days {
+		date, _ := time.Parse("2006-01-02", day.Date)
+
+		if day.Source == WeatherSourceForecast {
+			forecast := day.Forecast
+			if currentMonth != 0 || weatherInfo == "" {
+				location := destination
+				if weather.City != "" {
+					location = fmt.Sprintf("%s, %s", weather.City, weather.Country)
+				}
+				weatherInfo += fmt.Sprintf("\n🌤️ **Weather Forecast for %s**\n\n", location)
+				currentMonth = 0
+			}
+			weatherInfo += fmt.Sprintf("**%s:**\n", forecast.Date)
+			weatherInfo += fmt.Sprintf("- Average Temperature: %.1f°C (Feels like: %.1f°C)\n", forecast.Temp, forecast.FeelsLike)
+			weatherInfo += fmt.Sprintf("- High/Low: %.1f°C / %.1f°C\n", forecast.TempMax, forecast.TempMin)
+			weatherInfo += fmt.Sprintf("- Condition: %s\n", forecast.Description)
+			weatherInfo += fmt.Sprintf("- Humidity: %d%%\n", forecast.Humidity)
+			weatherInfo += fmt.Sprintf("- Wind Speed: %.1f m/s\n", forecast.WindSpeed)
+			weatherInfo += fmt.Sprintf("- Rain Chance: %.0f%%", forecast.RainChance)
+			if forecast.RainMM > 0 {
+				weatherInfo += fmt.Sprintf(" (%.1f mm expected)", forecast.RainMM)
+			}
+			weatherInfo += "\n\n"
+			continue
+		}
+
+		// Consecutive days in the same month share one climate-normal block
+		if date.Month() == currentMonth {
+			continue
+		}
+		currentMonth = date.Month()
+		hasNormals = true
+
+		normal := day.Normal
+		lastDay := date
+		for _, other := range weather.Days {
+			otherDate, _ := time.Parse("2006-01-02", other.Date)
+			if other.Source == WeatherSourceClimateNormal && otherDate.After(lastDay) && otherDate.Month() == currentMonth {
+				lastDay = otherDate
+			}
+		}
+
+		weatherInfo += fmt.Sprintf("\n📊 **Typical %s Conditions (climate normals, not a forecast)**\n\n", currentMonth)
+		weatherInfo += fmt.Sprintf("**%s – %s:**\n", date.Format("Jan 2"), lastDay.Format("Jan 2"))
+		weatherInfo += fmt.Sprintf("- Average High/Low: %.1f°C / %.1f°C\n", normal.AvgHighC, normal.AvgLowC)
+		weatherInfo += fmt.Sprintf("- Monthly Rainfall: %.0f mm over about %.0f rainy days\n", normal.RainfallMM, normal.RainyDays)
+		if normal.Monsoon {
+			weatherInfo += "- Monsoon season: expect heavy showers, slippery trails and occasional road closures\n"
+		}
+		weatherInfo += "\n"
 	}
 
 	weatherInfo += "💡 **Weather Tips:**\n"
+	if hasNormals {
+		weatherInfo += "- Dates beyond the 5-day forecast window show typical conditions only\n"
+	}
+	if weather.NormalsMissing {
+		weatherInfo += "- Typical conditions aren't available for this destination, so later dates have no weather\n"
+	}
 	weatherInfo += "- Check weather updates closer to your travel dates\n"
 	weatherInfo += "- Pack accordingly based on the forecast\n"
 	weatherInfo += "- Consider weather when planning outdoor activities\n\n"
 
 	return weatherInfo
 }
+
+// IsLikelyWet reports whether a typical day in this month sees rain
+func (n ClimateNormal) IsLikelyWet() bool {
+	return n.RainyDays >= 12
+}
+
+// SummarizeTripWeatherForPrompt produces one compact line per trip day for
+// the LLM, flagging wet days so it can favour indoor activities on them.
+func SummarizeTripWeatherForPrompt(weather TripWeather, startDate time.Time) string {
+	if len(weather.Days) == 0 {
+		return "Weather data not available for these dates."
+	}
+
+	lines := []string{}
+	for _, day := range weather.Days {
+		date, _ := time.Parse("2006-01-02", day.Date)
+		dayNumber := int(date.Sub(startDate).Hours()/24) + 1
+		label := fmt.Sprintf("Day %d (%s)", dayNumber, date.Format("Jan 2"))
+
+		switch day.Source {
+		case WeatherSourceForecast:
+			f := day.Forecast
+			line := fmt.Sprintf("%s: forecast %.0f-%.0f°C, %s, %.0f%% rain chance", label, f.TempMin, f.TempMax, f.Description, f.RainChance)
+			if f.RainMM > 0 {
+				line += fmt.Sprintf(", %.0f mm rain", f.RainMM)
+			}
+			if f.IsRainy() {
+				line += " - RAINY: prefer indoor activities"
+			}
+			lines = append(lines, line)
+		case WeatherSourceClimateNormal:
+			n := day.Normal
+			line := fmt.Sprintf("%s: typical %.0f-%.0f°C, about %.0f rainy days this month", label, n.AvgLowC, n.AvgHighC, n.RainyDays)
+			if n.Monsoon {
+				line += ", monsoon season"
+			}
+			if n.IsLikelyWet() {
+				line += " - LIKELY WET: keep indoor backups"
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,54 @@
+package utils
+
+import "fmt"
+
+// SevereWeatherThresholds decide when a daily forecast is worth alerting on
+type SevereWeatherThresholds struct {
+	HeavyRainMM  float64
+	StormWindMS  float64
+	ExtremeHeatC float64
+}
+
+// DefaultSevereWeatherThresholds follow IMD's heavy-rain band and heatwave
+// guidance for the plains; gale-force wind starts around 17 m/s.
+var DefaultSevereWeatherThresholds = SevereWeatherThresholds{
+	HeavyRainMM:  64.5,
+	StormWindMS:  17,
+	ExtremeHeatC: 40,
+}
+
+// SevereWeatherAlert is one crossed threshold on one day
+type SevereWeatherAlert struct {
+	Date    string `json:"date"`
+	Kind    string `json:"kind"` // "heavy_rain", "storm" or "extreme_heat"
+	Message string `json:"message"`
+}
+
+// DetectSevereWeather checks a daily forecast against the thresholds
+func DetectSevereWeather(f Forecast, t SevereWeatherThresholds) []SevereWeatherAlert {
+	alerts := []SevereWeatherAlert{}
+
+	if f.RainMM >= t.HeavyRainMM {
+		alerts = append(alerts, SevereWeatherAlert{
+			Date:    f.Date,
+			Kind:    "heavy_rain",
+			Message: fmt.Sprintf("Heavy rain: about %.0f mm expected (%.0f%% chance). Waterfalls, ghat roads and treks may be unsafe.", f.RainMM, f.RainChance),
+		})
+	}
+	if f.Condition == "Thunderstorm" || f.Condition == "Squall" || f.WindSpeed >= t.StormWindMS {
+		alerts = append(alerts, SevereWeatherAlert{
+			Date:    f.Date,
+			Kind:    "storm",
+			Message: fmt.Sprintf("Storm risk: %s with winds up to %.0f km/h. Avoid open viewpoints and beaches.", f.Description, f.WindSpeed*3.6),
+		})
+	}
+	if f.TempMax >= t.ExtremeHeatC {
+		alerts = append(alerts, SevereWeatherAlert{
+			Date:    f.Date,
+			Kind:    "extreme_heat",
+			Message: fmt.Sprintf("Extreme heat: highs of %.0f°C. Plan sightseeing for early morning and evening, and stay hydrated.", f.TempMax),
+		})
+	}
+
+	return alerts
+}
@@ -0,0 +1,255 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrWeatherAPIKeyMissing = errors.New("OPENWEATHER_API_KEY not set")
+	ErrWeatherUnavailable   = errors.New("weather data not available for this location")
+)
+
+// WeatherLocation identifies the place a forecast is requested for. Known
+// districts carry coordinates; anything else falls back to a name query.
+type WeatherLocation struct {
+	Name      string
+	Lat       float64
+	Lon       float64
+	HasCoords bool
+}
+
+// Key is the cache key for the location
+func (l WeatherLocation) Key() string {
+	if l.HasCoords {
+		return fmt.Sprintf("%.4f,%.4f", l.Lat, l.Lon)
+	}
+	return "q:" + strings.ToLower(strings.TrimSpace(l.Name))
+}
+
+// ResolveWeatherLocation looks the destination up in the district map
+func ResolveWeatherLocation(destination string) WeatherLocation {
+	district, coords, ok := LookupDistrict(destination)
+	if !ok {
+		return WeatherLocation{Name: strings.TrimSpace(destination)}
+	}
+	// districtCoordinates stores (longitude, latitude)
+	return WeatherLocation{Name: district, Lat: coords[1], Lon: coords[0], HasCoords: true}
+}
+
+// WeatherProvider fetches the raw 5-day/3-hour forecast for a location
+type WeatherProvider interface {
+	Forecast(loc WeatherLocation) (WeatherResponse, error)
+}
+
+// OpenWeatherProvider talks to the OpenWeather forecast API
+type OpenWeatherProvider struct {
+	APIKey  string
+	BaseURL string
+	Client  *http.Client
+}
+
+func NewOpenWeatherProvider() *OpenWeatherProvider {
+	return &OpenWeatherProvider{
+		BaseURL: "https://api.openweathermap.org/data/2.5/forecast",
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *OpenWeatherProvider) Forecast(loc WeatherLocation) (WeatherResponse, error) {
+	apiKey := p.APIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("OPENWEATHER_API_KEY")
+	}
+	if apiKey == "" {
+		return WeatherResponse{}, ErrWeatherAPIKeyMissing
+	}
+
+	params := url.Values{}
+	if loc.HasCoords {
+		params.Set("lat", strconv.FormatFloat(loc.Lat, 'f', 4, 64))
+		params.Set("lon", strconv.FormatFloat(loc.Lon, 'f', 4, 64))
+	} else {
+		if loc.Name == "" {
+			return WeatherResponse{}, ErrWeatherUnavailable
+		}
+		params.Set("q", loc.Name)
+	}
+	params.Set("appid", apiKey)
+	params.Set("units", "metric")
+
+	resp, err := p.Client.Get(p.BaseURL + "?" + params.Encode())
+	if err != nil {
+		return WeatherResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return WeatherResponse{}, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return WeatherResponse{}, fmt.Errorf("%w: %s", ErrWeatherUnavailable, string(body))
+	}
+
+	var weatherResp WeatherResponse
+	if err := json.Unmarshal(body, &weatherResp); err != nil {
+		return WeatherResponse{}, err
+	}
+
+	return weatherResp, nil
+}
+
+type cachedForecast struct {
+	response  WeatherResponse
+	expiresAt time.Time
+}
+
+// CachedWeatherProvider keeps successful forecasts in memory for a TTL so
+// repeated widget loads and trip generations share one upstream call.
+// Expired entries are purged on insert, at most once per TTL, so the map
+// only holds locations asked for recently.
+type CachedWeatherProvider struct {
+	provider WeatherProvider
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu         sync.Mutex
+	entries    map[string]cachedForecast
+	lastPurged time.Time
+}
+
+func NewCachedWeatherProvider(provider WeatherProvider, ttl time.Duration) *CachedWeatherProvider {
+	return &CachedWeatherProvider{
+		provider: provider,
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  make(map[string]cachedForecast),
+	}
+}
+
+func (c *CachedWeatherProvider) Forecast(loc WeatherLocation) (WeatherResponse, error) {
+	key := loc.Key()
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok && c.now().Before(entry.expiresAt) {
+		c.mu.Unlock()
+		return entry.response, nil
+	}
+	c.mu.Unlock()
+
+	// Errors are not cached so a transient failure doesn't stick for a whole TTL
+	resp, err := c.provider.Forecast(loc)
+	if err != nil {
+		return resp, err
+	}
+
+	c.mu.Lock()
+	now := c.now()
+	if now.Sub(c.lastPurged) >= c.ttl {
+		c.purgeLocked(now)
+	}
+	c.entries[key] = cachedForecast{response: resp, expiresAt: now.Add(c.ttl)}
+	c.mu.Unlock()
+
+	return resp, nil
+}
+
+// Purge drops expired entries
+func (c *CachedWeatherProvider) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.purgeLocked(c.now())
+}
+
+func (c *CachedWeatherProvider) purgeLocked(now time.Time) {
+	for key, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+	c.lastPurged = now
+}
+
+// FakeWeatherProvider returns canned forecasts keyed by location name and
+// records every call. Meant for tests and local development.
+type FakeWeatherProvider struct {
+	Responses map[string]WeatherResponse
+	Err       error
+
+	mu    sync.Mutex
+	Calls []WeatherLocation
+}
+
+func (f *FakeWeatherProvider) Forecast(loc WeatherLocation) (WeatherResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Calls = append(f.Calls, loc)
+
+	if f.Err != nil {
+		return WeatherResponse{}, f.Err
+	}
+	resp, ok := f.Responses[loc.Name]
+	if !ok {
+		return WeatherResponse{}, ErrWeatherUnavailable
+	}
+	return resp, nil
+}
+
+// CallCount returns how many times Forecast was called
+func (f *FakeWeatherProvider) CallCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.Calls)
+}
+
+var (
+	weatherProviderMu sync.Mutex
+	weatherProvider   WeatherProvider
+)
+
+// DefaultWeatherProvider returns the shared, cached OpenWeather provider.
+// It is built lazily so the .env file has been loaded by the time the TTL
+// is read.
+func DefaultWeatherProvider() WeatherProvider {
+	weatherProviderMu.Lock()
+	defer weatherProviderMu.Unlock()
+
+	if weatherProvider == nil {
+		ttl := 30 * time.Minute
+		if minutes, err := strconv.Atoi(os.Getenv("WEATHER_CACHE_TTL_MINUTES")); err == nil && minutes > 0 {
+			ttl = time.Duration(minutes) * time.Minute
+		}
+		weatherProvider = NewCachedWeatherProvider(NewOpenWeatherProvider(), ttl)
+	}
+	return weatherProvider
+}
+
+// SetWeatherProvider swaps the shared provider, e.g. for a fake in tests
+func SetWeatherProvider(provider WeatherProvider) {
+	weatherProviderMu.Lock()
+	defer weatherProviderMu.Unlock()
+	weatherProvider = provider
+}
+
+func weatherErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrWeatherAPIKeyMissing):
+		return "Weather API key not configured"
+	case errors.Is(err, ErrWeatherUnavailable):
+		return "Weather data not available for this location"
+	default:
+		return "Failed to fetch weather data"
+	}
+}
@@ -0,0 +1,209 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+const istOffset = 19800
+
+var ist = time.FixedZone("IST", istOffset)
+
+// sample builds a 3-hour bucket at a local IST time
+func sample(local string, temp, pop, rainMM float64, condition string) WeatherSample {
+	at, err := time.ParseInLocation("2006-01-02 15:04", local, ist)
+	if err != nil {
+		panic(err)
+	}
+	var s WeatherSample
+	s.Dt = at.Unix()
+	s.Main.Temp = temp
+	s.Main.FeelsLike = temp + 1
+	s.Main.TempMin = temp - 1
+	s.Main.TempMax = temp + 1
+	s.Main.Humidity = 60
+	s.Wind.Speed = temp / 10
+	s.Pop = pop
+	s.Rain.ThreeHour = rainMM
+	if condition != "" {
+		s.Weather = append(s.Weather, struct {
+			Main        string `json:"main"`
+			Description string `json:"description"`
+			Icon        string `json:"icon"`
+		}{Main: condition, Description: condition + " desc", Icon: "01d"})
+	}
+	return s
+}
+
+func forecastResponse(samples ...WeatherSample) WeatherResponse {
+	var resp WeatherResponse
+	resp.City.Name = "Mysuru"
+	resp.City.Country = "IN"
+	resp.City.Timezone = istOffset
+	resp.List = samples
+	return resp
+}
+
+func TestAggregateDailyForecasts(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []WeatherSample
+		want    []Forecast
+	}{
+		{
+			name: "no samples",
+			want: []Forecast{},
+		},
+		{
+			name: "buckets grouped by local date",
+			samples: []WeatherSample{
+				// 23:30 UTC on the 9th is already the 10th in IST
+				sample("2026-01-10 05:00", 20, 0, 0, "Clear"),
+				sample("2026-01-10 11:00", 30, 0, 0, "Clear"),
+				sample("2026-01-11 11:00", 28, 0, 0, "Clouds"),
+			},
+			want: []Forecast{
+				{Date: "2026-01-10", Temp: 25, FeelsLike: 26, TempMin: 19, TempMax: 31, Humidity: 60, Condition: "Clear", Description: "Clear desc", Icon: "01d", WindSpeed: 3, Samples: 2},
+				{Date: "2026-01-11", Temp: 28, FeelsLike: 29, TempMin: 27, TempMax: 29, Humidity: 60, Condition: "Clouds", Description: "Clouds desc", Icon: "01d", WindSpeed: 2.8, Samples: 1},
+			},
+		},
+		{
+			name: "rain chance is the chance of rain at any point",
+			samples: []WeatherSample{
+				sample("2026-07-01 09:00", 24, 0.5, 2, "Rain"),
+				sample("2026-07-01 12:00", 24, 0.5, 3.25, "Rain"),
+			},
+			want: []Forecast{
+				{Date: "2026-07-01", Temp: 24, FeelsLike: 25, TempMin: 23, TempMax: 25, Humidity: 60, Condition: "Rain", Description: "Rain desc", Icon: "01d", WindSpeed: 2.4, RainChance: 75, RainMM: 5.3, Samples: 2},
+			},
+		},
+		{
+			name: "most severe daytime condition wins",
+			samples: []WeatherSample{
+				sample("2026-07-01 09:00", 24, 0, 0, "Clouds"),
+				sample("2026-07-01 15:00", 24, 0, 0, "Thunderstorm"),
+				sample("2026-07-01 18:00", 24, 0, 0, "Rain"),
+			},
+			want: []Forecast{
+				{Date: "2026-07-01", Temp: 24, FeelsLike: 25, TempMin: 23, TempMax: 25, Humidity: 60, Condition: "Thunderstorm", Description: "Thunderstorm desc", Icon: "01d", WindSpeed: 2.4, Samples: 3},
+			},
+		},
+		{
+			name: "night-time storm does not override a daytime condition",
+			samples: []WeatherSample{
+				sample("2026-07-01 02:00", 24, 0, 0, "Thunderstorm"),
+				sample("2026-07-01 12:00", 24, 0, 0, "Clouds"),
+			},
+			want: []Forecast{
+				{Date: "2026-07-01", Temp: 24, FeelsLike: 25, TempMin: 23, TempMax: 25, Humidity: 60, Condition: "Clouds", Description: "Clouds desc", Icon: "01d", WindSpeed: 2.4, Samples: 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AggregateDailyForecasts(forecastResponse(tt.samples...))
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d days, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("day %d:\n got  %+v\n want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCachedWeatherProvider(t *testing.T) {
+	mysuru := WeatherLocation{Name: "Mysuru", Lat: 12.2958, Lon: 76.6394, HasCoords: true}
+	hassan := WeatherLocation{Name: "Hassan", Lat: 13.0068, Lon: 76.0996, HasCoords: true}
+	fake := &FakeWeatherProvider{Responses: map[string]WeatherResponse{
+		"Mysuru": forecastResponse(sample("2026-01-10 12:00", 28, 0, 0, "Clear")),
+		"Hassan": forecastResponse(sample("2026-01-10 12:00", 26, 0, 0, "Clouds")),
+	}}
+
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	cache := NewCachedWeatherProvider(fake, 30*time.Minute)
+	cache.now = func() time.Time { return now }
+
+	steps := []struct {
+		name      string
+		advance   time.Duration
+		loc       WeatherLocation
+		wantCalls int
+		wantCache int
+	}{
+		{name: "first lookup goes upstream", loc: mysuru, wantCalls: 1, wantCache: 1},
+		{name: "repeat within the TTL is cached", advance: 10 * time.Minute, loc: mysuru, wantCalls: 1, wantCache: 1},
+		{name: "another location goes upstream", advance: 10 * time.Minute, loc: hassan, wantCalls: 2, wantCache: 2},
+		{name: "expired entry is refetched", advance: 15 * time.Minute, loc: mysuru, wantCalls: 3, wantCache: 2},
+		// Hassan expired and the last purge was a TTL ago
+		{name: "insert purges expired entries", advance: 30 * time.Minute, loc: mysuru, wantCalls: 4, wantCache: 1},
+	}
+
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		if _, err := cache.Forecast(step.loc); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got := fake.CallCount(); got != step.wantCalls {
+			t.Errorf("%s: %d upstream calls, want %d", step.name, got, step.wantCalls)
+		}
+		if got := len(cache.entries); got != step.wantCache {
+			t.Errorf("%s: %d cached entries, want %d", step.name, got, step.wantCache)
+		}
+	}
+}
+
+func TestCachedWeatherProviderDoesNotCacheErrors(t *testing.T) {
+	fake := &FakeWeatherProvider{Err: ErrWeatherAPIKeyMissing}
+	cache := NewCachedWeatherProvider(fake, time.Hour)
+	loc := WeatherLocation{Name: "Udupi"}
+
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Forecast(loc); !errors.Is(err, ErrWeatherAPIKeyMissing) {
+			t.Fatalf("got error %v, want %v", err, ErrWeatherAPIKeyMissing)
+		}
+	}
+	if got := fake.CallCount(); got != 2 {
+		t.Errorf("%d upstream calls, want 2", got)
+	}
+}
+
+func TestGetWeatherForecastUsesProvider(t *testing.T) {
+	fake := &FakeWeatherProvider{Responses: map[string]WeatherResponse{
+		"Mysuru (Mysore)": forecastResponse(sample("2026-01-10 12:00", 28, 0, 0, "Clear")),
+	}}
+	SetWeatherProvider(fake)
+	defer SetWeatherProvider(nil)
+
+	tests := []struct {
+		destination string
+		wantErr     error
+		wantDays    int
+	}{
+		{destination: "mysore", wantDays: 1},
+		{destination: "Atlantis", wantErr: ErrWeatherUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.destination, func(t *testing.T) {
+			data, err := GetWeatherForecast(tt.destination)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if len(data.Forecasts) != tt.wantDays {
+				t.Errorf("got %d days, want %d", len(data.Forecasts), tt.wantDays)
+			}
+			if tt.wantErr != nil && data.ErrorMsg == "" {
+				t.Error("missing error message")
+			}
+		})
+	}
+
+	if got := fake.Calls[0]; !got.HasCoords {
+		t.Errorf("district lookup sent %+v, want coordinates", got)
+	}
+}
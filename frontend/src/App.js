@@ -20,6 +20,13 @@ import AgencyPackages from './components/AgencyPackages';
 import TravelPackages from './components/TravelPackages';
 import PackageDetails from './components/PackageDetails';
 import Feedback from './components/Feedback';
+import MyBookings from './components/MyBookings';
+import AgencyBookings from './components/AgencyBookings';
+import AgencyDepartures from './components/AgencyDepartures';
+import AgencyPromoCodes from './components/AgencyPromoCodes';
+import AgencyBilling from './components/AgencyBilling';
+import AgencyVerification from './components/AgencyVerification';
+import PackageModeration from './components/PackageModeration';
 
 function App() {
   return (
@@ -42,10 +49,17 @@ function App() {
             <Route path="/admin/users" element={<UserManagement />} />
             <Route path="/admin/messages" element={<MessageManagement />} />
             <Route path="/admin/agencies" element={<AgencyManagement />} />
+            <Route path="/admin/moderation" element={<PackageModeration />} />
             <Route path="/agency/packages" element={<AgencyPackages />} />
+            <Route path="/agency/packages/:id/departures" element={<AgencyDepartures />} />
+            <Route path="/agency/bookings" element={<AgencyBookings />} />
+            <Route path="/agency/promo-codes" element={<AgencyPromoCodes />} />
+            <Route path="/agency/billing" element={<AgencyBilling />} />
+            <Route path="/agency/verification" element={<AgencyVerification />} />
             <Route path="/packages" element={<TravelPackages />} />
             <Route path="/packages/:id" element={<PackageDetails />} />
             <Route path="/feedback" element={<Feedback />} />
+            <Route path="/bookings" element={<MyBookings />} />
             <Route path="*" element={<Navigate to="/" />} />
           </Routes>
         </div>
@@ -0,0 +1,457 @@
+import React, { useCallback, useEffect, useState } from 'react';
+import axios from 'axios';
+import { useNavigate } from 'react-router-dom';
+import { useAuth } from '../context/AuthContext';
+
+// GST state codes, as printed at the start of a GSTIN
+const gstStates = [
+  ['01', 'Jammu and Kashmir'], ['02', 'Himachal Pradesh'], ['03', 'Punjab'], ['04', 'Chandigarh'],
+  ['05', 'Uttarakhand'], ['06', 'Haryana'], ['07', 'Delhi'], ['08', 'Rajasthan'], ['09', 'Uttar Pradesh'],
+  ['10', 'Bihar'], ['11', 'Sikkim'], ['12', 'Arunachal Pradesh'], ['13', 'Nagaland'], ['14', 'Manipur'],
+  ['15', 'Mizoram'], ['16', 'Tripura'], ['17', 'Meghalaya'], ['18', 'Assam'], ['19', 'West Bengal'],
+  ['20', 'Jharkhand'], ['21', 'Odisha'], ['22', 'Chhattisgarh'], ['23', 'Madhya Pradesh'], ['24', 'Gujarat'],
+  ['26', 'Dadra and Nagar Haveli and Daman and Diu'], ['27', 'Maharashtra'], ['29', 'Karnataka'], ['30', 'Goa'],
+  ['31', 'Lakshadweep'], ['32', 'Kerala'], ['33', 'Tamil Nadu'], ['34', 'Puducherry'],
+  ['35', 'Andaman and Nicobar Islands'], ['36', 'Telangana'], ['37', 'Andhra Pradesh'], ['38', 'Ladakh'],
+  ['97', 'Other Territory'],
+];
+
+const emptyProfile = { gstin: '', billing_address: '', billing_state_code: '' };
+
+const emptyDocument = {
+  document_type: 'quotation',
+  package_id: '',
+  departure_date: '',
+  adults: 1,
+  children: 0,
+  seniors: 0,
+  customer_name: '',
+  customer_email: '',
+  customer_phone: '',
+  customer_address: '',
+  customer_gstin: '',
+  customer_state_code: '',
+  tax_rate: '5',
+  prices_include_tax: true,
+  valid_until: '',
+  notes: '',
+  send_email: false,
+};
+
+const errorText = (err, fallback) => (typeof err.response?.data === 'string' ? err.response.data.trim() : fallback);
+
+const formatMoney = (amount) =>
+  `₹${Number(amount).toLocaleString('en-IN', { minimumFractionDigits: 2, maximumFractionDigits: 2 })}`;
+
+function StateSelect({ name, value, onChange, disabled }) {
+  return (
+    <select name={name} value={value} onChange={onChange} disabled={disabled}>
+      <option value="">Select state</option>
+      {gstStates.map(([code, state]) => (
+        <option key={code} value={code}>
+          {code} - {state}
+        </option>
+      ))}
+    </select>
+  );
+}
+
+function AgencyBilling() {
+  const navigate = useNavigate();
+  const { token, user } = useAuth();
+  const [profile, setProfile] = useState(emptyProfile);
+  const [documents, setDocuments] = useState([]);
+  const [packages, setPackages] = useState([]);
+  const [formData, setFormData] = useState(emptyDocument);
+  const [filters, setFilters] = useState({ type: '', from: '', to: '' });
+  const [loading, setLoading] = useState(true);
+  const [message, setMessage] = useState('');
+  const [error, setError] = useState('');
+
+  const apiUrl = `${process.env.REACT_APP_API_URL}/api/agency`;
+  const headers = { Authorization: `Bearer ${token}` };
+
+  const filterParams = useCallback(() => {
+    const params = {};
+    Object.entries(filters).forEach(([key, value]) => {
+      if (value) params[key] = value;
+    });
+    return params;
+  }, [filters]);
+
+  const fetchDocuments = useCallback(async () => {
+    try {
+      const response = await axios.get(`${apiUrl}/billing-documents`, {
+        headers: { Authorization: `Bearer ${token}` },
+        params: filterParams(),
+      });
+      setDocuments(response.data);
+    } catch (err) {
+      setError(errorText(err, 'Failed to load documents'));
+    } finally {
+      setLoading(false);
+    }
+  }, [apiUrl, token, filterParams]);
+
+  useEffect(() => {
+    if (!token || user?.role !== 'agency') {
+      navigate('/agency/login');
+      return;
+    }
+    const auth = { headers: { Authorization: `Bearer ${token}` } };
+    Promise.all([axios.get(`${apiUrl}/billing-profile`, auth), axios.get(`${apiUrl}/packages`, auth)])
+      .then(([profileResponse, packageResponse]) => {
+        setProfile({ ...emptyProfile, ...profileResponse.data });
+        setPackages(packageResponse.data);
+      })
+      .catch((err) => setError(errorText(err, 'Failed to load billing profile')));
+  }, [token, user, navigate, apiUrl]);
+
+  useEffect(() => {
+    if (token && user?.role === 'agency') fetchDocuments();
+  }, [token, user, fetchDocuments]);
+
+  const handleProfileChange = (e) => {
+    const { name, value } = e.target;
+    setProfile((prev) => {
+      const next = { ...prev, [name]: name === 'gstin' ? value.toUpperCase() : value };
+      if (name === 'gstin' && /^[0-9]{2}/.test(next.gstin)) next.billing_state_code = next.gstin.slice(0, 2);
+      return next;
+    });
+  };
+
+  const saveProfile = async (e) => {
+    e.preventDefault();
+    setError('');
+    setMessage('');
+    try {
+      const response = await axios.put(`${apiUrl}/billing-profile`, profile, { headers });
+      setProfile({ ...emptyProfile, ...response.data });
+      setMessage('Billing profile saved');
+    } catch (err) {
+      setError(errorText(err, 'Failed to save billing profile'));
+    }
+  };
+
+  const handleChange = (e) => {
+    const { name, value, type, checked } = e.target;
+    setFormData((prev) => {
+      const next = { ...prev, [name]: type === 'checkbox' ? checked : value };
+      if (name === 'customer_gstin') {
+        next.customer_gstin = value.toUpperCase();
+        if (/^[0-9]{2}/.test(value)) next.customer_state_code = value.slice(0, 2);
+      }
+      return next;
+    });
+  };
+
+  const issueDocument = async (e) => {
+    e.preventDefault();
+    setError('');
+    setMessage('');
+    try {
+      const response = await axios.post(
+        `${apiUrl}/billing-documents`,
+        {
+          document_type: formData.document_type,
+          package_id: Number(formData.package_id),
+          departure_date: formData.departure_date,
+          adults: Number(formData.adults),
+          children: Number(formData.children),
+          seniors: Number(formData.seniors),
+          customer: {
+            name: formData.customer_name,
+            email: formData.customer_email,
+            phone: formData.customer_phone,
+            address: formData.customer_address,
+            gstin: formData.customer_gstin,
+            state_code: formData.customer_state_code,
+          },
+          tax_rate: Number(formData.tax_rate),
+          prices_include_tax: formData.prices_include_tax,
+          valid_until: formData.document_type === 'quotation' ? formData.valid_until : '',
+          notes: formData.notes,
+          send_email: formData.send_email,
+        },
+        { headers }
+      );
+      setMessage(
+        response.data.email_error || `Issued ${response.data.document_number}${response.data.emailed_at ? ' and emailed it' : ''}`
+      );
+      setFormData(emptyDocument);
+      fetchDocuments();
+    } catch (err) {
+      setError(errorText(err, 'Failed to issue document'));
+    }
+  };
+
+  const downloadBlob = (data, filename) => {
+    const url = window.URL.createObjectURL(data);
+    const link = document.createElement('a');
+    link.href = url;
+    link.download = filename;
+    link.click();
+    window.URL.revokeObjectURL(url);
+  };
+
+  const downloadPdf = async (doc) => {
+    try {
+      const response = await axios.get(`${apiUrl}/billing-documents/${doc.document_id}/pdf`, {
+        headers,
+        responseType: 'blob',
+      });
+      downloadBlob(response.data, `${doc.document_number.replaceAll('/', '-')}.pdf`);
+    } catch (err) {
+      setError('Failed to download PDF');
+    }
+  };
+
+  const exportCsv = async () => {
+    try {
+      const response = await axios.get(`${apiUrl}/billing-documents`, {
+        headers,
+        params: { ...filterParams(), format: 'csv' },
+        responseType: 'blob',
+      });
+      downloadBlob(response.data, 'billing-documents.csv');
+    } catch (err) {
+      setError('Failed to export documents');
+    }
+  };
+
+  const emailDocument = async (doc) => {
+    const email = window.prompt('Send to', doc.customer.email || '');
+    if (!email) return;
+    setError('');
+    setMessage('');
+    try {
+      await axios.post(`${apiUrl}/billing-documents/${doc.document_id}/email`, { email }, { headers });
+      setMessage(`Sent ${doc.document_number} to ${email}`);
+      fetchDocuments();
+    } catch (err) {
+      setError(errorText(err, 'Failed to send email'));
+    }
+  };
+
+  return (
+    <div className="packages-container">
+      <div className="packages-header">
+        <div>
+          <h2 className="page-title">Quotations & Invoices</h2>
+          <p className="page-subtitle">GST documents for your packages, numbered per financial year</p>
+        </div>
+      </div>
+
+      {message && <p className="success-message">{message}</p>}
+      {error && <p className="error-message">{error}</p>}
+
+      <form className="package-form" onSubmit={saveProfile}>
+        <h3>Billing profile</h3>
+        <div className="form-row">
+          <div className="form-group">
+            <label>GSTIN</label>
+            <input type="text" name="gstin" value={profile.gstin} onChange={handleProfileChange} maxLength="15" />
+          </div>
+          <div className="form-group">
+            <label>State</label>
+            <StateSelect
+              name="billing_state_code"
+              value={profile.billing_state_code}
+              onChange={handleProfileChange}
+              disabled={Boolean(profile.gstin)}
+            />
+          </div>
+        </div>
+        <div className="form-group">
+          <label>Billing address</label>
+          <textarea name="billing_address" value={profile.billing_address} onChange={handleProfileChange} rows="3" />
+        </div>
+        <button type="submit" className="secondary-button">
+          Save Profile
+        </button>
+      </form>
+
+      <form className="package-form" onSubmit={issueDocument}>
+        <h3>Issue a document</h3>
+        <div className="form-row">
+          <div className="form-group">
+            <label>Type</label>
+            <select name="document_type" value={formData.document_type} onChange={handleChange}>
+              <option value="quotation">Quotation</option>
+              <option value="invoice">Tax invoice</option>
+            </select>
+          </div>
+          <div className="form-group">
+            <label>Package</label>
+            <select name="package_id" value={formData.package_id} onChange={handleChange} required>
+              <option value="">Select package</option>
+              {packages.map((p) => (
+                <option key={p.package_id} value={p.package_id}>
+                  {p.title}
+                </option>
+              ))}
+            </select>
+          </div>
+          <div className="form-group">
+            <label>Departure (optional)</label>
+            <input type="date" name="departure_date" value={formData.departure_date} onChange={handleChange} />
+          </div>
+        </div>
+        <div className="form-row">
+          {['adults', 'children', 'seniors'].map((field) => (
+            <div className="form-group" key={field}>
+              <label>{field.charAt(0).toUpperCase() + field.slice(1)}</label>
+              <input type="number" name={field} value={formData[field]} onChange={handleChange} min="0" />
+            </div>
+          ))}
+        </div>
+        <div className="form-row">
+          <div className="form-group">
+            <label>Customer name</label>
+            <input type="text" name="customer_name" value={formData.customer_name} onChange={handleChange} required />
+          </div>
+          <div className="form-group">
+            <label>Email</label>
+            <input type="email" name="customer_email" value={formData.customer_email} onChange={handleChange} />
+          </div>
+          <div className="form-group">
+            <label>Phone</label>
+            <input type="text" name="customer_phone" value={formData.customer_phone} onChange={handleChange} />
+          </div>
+        </div>
+        <div className="form-row">
+          <div className="form-group">
+            <label>Customer GSTIN (optional)</label>
+            <input
+              type="text"
+              name="customer_gstin"
+              value={formData.customer_gstin}
+              onChange={handleChange}
+              maxLength="15"
+            />
+          </div>
+          <div className="form-group">
+            <label>Customer state (place of supply)</label>
+            <StateSelect
+              name="customer_state_code"
+              value={formData.customer_state_code}
+              onChange={handleChange}
+              disabled={Boolean(formData.customer_gstin)}
+            />
+          </div>
+        </div>
+        <div className="form-group">
+          <label>Customer address</label>
+          <textarea name="customer_address" value={formData.customer_address} onChange={handleChange} rows="2" />
+        </div>
+        <div className="form-row">
+          <div className="form-group">
+            <label>GST rate</label>
+            <select name="tax_rate" value={formData.tax_rate} onChange={handleChange}>
+              {['0', '5', '12', '18'].map((rate) => (
+                <option key={rate} value={rate}>
+                  {rate}%
+                </option>
+              ))}
+            </select>
+          </div>
+          {formData.document_type === 'quotation' && (
+            <div className="form-group">
+              <label>Valid until (default 15 days)</label>
+              <input type="date" name="valid_until" value={formData.valid_until} onChange={handleChange} />
+            </div>
+          )}
+        </div>
+        <div className="form-group">
+          <label>Notes</label>
+          <textarea name="notes" value={formData.notes} onChange={handleChange} rows="2" />
+        </div>
+        <div className="billing-options">
+          <label>
+            <input
+              type="checkbox"
+              name="prices_include_tax"
+              checked={formData.prices_include_tax}
+              onChange={handleChange}
+            />{' '}
+            Package prices include GST
+          </label>
+          <label>
+            <input type="checkbox" name="send_email" checked={formData.send_email} onChange={handleChange} /> Email the
+            PDF to the customer
+          </label>
+        </div>
+        <button type="submit" className="auth-button">
+          Issue Document
+        </button>
+      </form>
+
+      <div className="billing-filters">
+        <select value={filters.type} onChange={(e) => setFilters({ ...filters, type: e.target.value })}>
+          <option value="">All documents</option>
+          <option value="quotation">Quotations</option>
+          <option value="invoice">Invoices</option>
+        </select>
+        <input type="date" value={filters.from} onChange={(e) => setFilters({ ...filters, from: e.target.value })} />
+        <input type="date" value={filters.to} onChange={(e) => setFilters({ ...filters, to: e.target.value })} />
+        <button className="secondary-button" onClick={exportCsv}>
+          Export CSV
+        </button>
+      </div>
+
+      {loading ? (
+        <div className="loading-container">
+          <div className="loading-spinner"></div>
+          <p>Loading documents...</p>
+        </div>
+      ) : documents.length === 0 ? (
+        <div className="empty-state">
+          <div className="empty-icon">🧾</div>
+          <p>No documents issued yet.</p>
+        </div>
+      ) : (
+        <table className="departures-table">
+          <thead>
+            <tr>
+              <th>Number</th>
+              <th>Date</th>
+              <th>Customer</th>
+              <th>Package</th>
+              <th>GST</th>
+              <th>Total</th>
+              <th></th>
+            </tr>
+          </thead>
+          <tbody>
+            {documents.map((doc) => (
+              <tr key={doc.document_id}>
+                <td>
+                  <strong>{doc.document_number}</strong>
+                </td>
+                <td>{doc.issue_date}</td>
+                <td>{doc.customer.name}</td>
+                <td>{doc.package_title}</td>
+                <td>
+                  {doc.tax.intra_state
+                    ? `CGST ${formatMoney(doc.tax.cgst)} + SGST ${formatMoney(doc.tax.sgst)}`
+                    : `IGST ${formatMoney(doc.tax.igst)}`}
+                </td>
+                <td>{formatMoney(doc.tax.total)}</td>
+                <td className="billing-actions">
+                  <button className="secondary-button" onClick={() => downloadPdf(doc)}>
+                    PDF
+                  </button>
+                  <button className="secondary-button" onClick={() => emailDocument(doc)}>
+                    {doc.emailed_at ? 'Resend' : 'Email'}
+                  </button>
+                </td>
+              </tr>
+            ))}
+          </tbody>
+        </table>
+      )}
+    </div>
+  );
+}
+
+export default AgencyBilling;
@@ -0,0 +1,175 @@
+import React, { useCallback, useEffect, useState } from 'react';
+import axios from 'axios';
+import { useNavigate } from 'react-router-dom';
+import { useAuth } from '../context/AuthContext';
+
+const STATUS_FILTERS = ['', 'requested', 'confirmed', 'completed', 'cancelled'];
+
+function AgencyBookings() {
+  const navigate = useNavigate();
+  const { isAuthenticated, token, user } = useAuth();
+  const [bookings, setBookings] = useState([]);
+  const [status, setStatus] = useState('requested');
+  const [loading, setLoading] = useState(true);
+  const [error, setError] = useState('');
+  const [paymentsByBooking, setPaymentsByBooking] = useState({});
+
+  const fetchBookings = useCallback(async () => {
+    setLoading(true);
+    try {
+      const headers = { Authorization: `Bearer ${token}` };
+      const [response, paymentsResponse] = await Promise.all([
+        axios.get(`${process.env.REACT_APP_API_URL}/api/agency/bookings`, {
+          params: status ? { status } : {},
+          headers,
+        }),
+        axios.get(`${process.env.REACT_APP_API_URL}/api/agency/payments`, { headers }),
+      ]);
+      setBookings(response.data);
+      const byBooking = {};
+      paymentsResponse.data.forEach((payment) => {
+        if (payment.booking_id && !byBooking[payment.booking_id]) {
+          byBooking[payment.booking_id] = payment;
+        }
+      });
+      setPaymentsByBooking(byBooking);
+      setError('');
+    } catch (err) {
+      setError('Failed to load bookings');
+    } finally {
+      setLoading(false);
+    }
+  }, [status, token]);
+
+  useEffect(() => {
+    if (!isAuthenticated || user?.role !== 'agency') {
+      navigate('/agency/login');
+      return;
+    }
+    fetchBookings();
+  }, [isAuthenticated, user, navigate, fetchBookings]);
+
+  const act = async (booking, action) => {
+    let body = {};
+    if (action === 'reject') {
+      const reason = window.prompt('Why are you rejecting this booking? The traveler will see this.');
+      if (!reason) return;
+      body = { reason };
+    }
+
+    try {
+      await axios.put(
+        `${process.env.REACT_APP_API_URL}/api/agency/bookings/${booking.booking_id}/${action}`,
+        body,
+        { headers: { Authorization: `Bearer ${token}` } }
+      );
+      fetchBookings();
+    } catch (err) {
+      setError(typeof err.response?.data === 'string' ? err.response.data.trim() : `Failed to ${action} booking`);
+    }
+  };
+
+  const refund = async (payment) => {
+    const remaining = Number(payment.amount) - Number(payment.amount_refunded);
+    const amount = window.prompt(`Refund amount (up to ₹${remaining.toLocaleString('en-IN')})`, remaining);
+    if (!amount) return;
+    const reason = window.prompt('Reason for the refund (optional)') || '';
+
+    try {
+      await axios.post(
+        `${process.env.REACT_APP_API_URL}/api/agency/payments/${payment.payment_id}/refund`,
+        { amount: Number(amount), reason },
+        { headers: { Authorization: `Bearer ${token}` } }
+      );
+      fetchBookings();
+    } catch (err) {
+      setError(typeof err.response?.data === 'string' ? err.response.data.trim() : 'Failed to refund payment');
+    }
+  };
+
+  return (
+    <div className="packages-container">
+      <div className="packages-header">
+        <div>
+          <h2 className="page-title">Bookings</h2>
+          <p className="page-subtitle">Accept, reject and complete bookings for your packages</p>
+        </div>
+        <select className="search-input" value={status} onChange={(e) => setStatus(e.target.value)}>
+          {STATUS_FILTERS.map((s) => (
+            <option key={s} value={s}>{s ? s.charAt(0).toUpperCase() + s.slice(1) : 'All bookings'}</option>
+          ))}
+        </select>
+      </div>
+
+      {error && <p className="error-message">{error}</p>}
+
+      {loading ? (
+        <div className="loading-container">
+          <div className="loading-spinner"></div>
+          <p>Loading bookings...</p>
+        </div>
+      ) : bookings.length === 0 ? (
+        <div className="empty-state">
+          <div className="empty-icon">📭</div>
+          <h3>No bookings here</h3>
+        </div>
+      ) : (
+        <div className="bookings-list">
+          {bookings.map((booking) => (
+            <div key={booking.booking_id} className={`booking-card status-${booking.status}`}>
+              <div className="booking-card-header">
+                <h4>#{booking.booking_id} · {booking.package_title}</h4>
+                <span className="booking-status">{booking.status}</span>
+              </div>
+              <p>
+                🗓️ Departs {booking.departure_date} · 👥 {booking.num_travelers} · ₹
+                {Number(booking.total_price).toLocaleString('en-IN')}
+              </p>
+              {paymentsByBooking[booking.booking_id] && (
+                <p>
+                  💳 Payment:{' '}
+                  <span className={`payment-badge ${paymentsByBooking[booking.booking_id].status}`}>
+                    {paymentsByBooking[booking.booking_id].status.replace('_', ' ')}
+                  </span>
+                  {Number(paymentsByBooking[booking.booking_id].amount_refunded) > 0 &&
+                    ` · ₹${Number(paymentsByBooking[booking.booking_id].amount_refunded).toLocaleString('en-IN')} refunded`}
+                  {paymentsByBooking[booking.booking_id].refund_due && ' · refund due'}
+                </p>
+              )}
+              <p>
+                📇 {booking.contact_name} · {booking.contact_email} · {booking.contact_phone}
+              </p>
+              <p className="booking-travelers">
+                {booking.travelers.map((t) => `${t.full_name} (${t.age}${t.gender ? `, ${t.gender}` : ''})`).join(', ')}
+              </p>
+              {booking.special_requests && <p className="booking-note">📝 {booking.special_requests}</p>}
+              {booking.cancellation_reason && (
+                <p className="booking-note">
+                  Cancelled by {booking.cancelled_by}: {booking.cancellation_reason}
+                </p>
+              )}
+              <div className="booking-actions">
+                {booking.status === 'requested' && (
+                  <>
+                    <button className="view-details-btn" onClick={() => act(booking, 'accept')}>Accept</button>
+                    <button className="secondary-button" onClick={() => act(booking, 'reject')}>Reject</button>
+                  </>
+                )}
+                {booking.status === 'confirmed' && (
+                  <button className="view-details-btn" onClick={() => act(booking, 'complete')}>Mark completed</button>
+                )}
+                {['paid', 'partially_refunded', 'duplicate'].includes(paymentsByBooking[booking.booking_id]?.status) && (
+                  <button className="secondary-button" onClick={() => refund(paymentsByBooking[booking.booking_id])}>
+                    Refund
+                  </button>
+                )}
+              </div>
+            </div>
+          ))}
+        </div>
+      )}
+    </div>
+  );
+}
+
+export default AgencyBookings;
@@ -0,0 +1,209 @@
+import React, { useCallback, useEffect, useState } from 'react';
+import axios from 'axios';
+import { useNavigate, useParams } from 'react-router-dom';
+import { useAuth } from '../context/AuthContext';
+
+const emptyForm = { start_date: '', total_seats: '', price_override: '', is_active: true };
+
+function AgencyDepartures() {
+  const { id } = useParams();
+  const navigate = useNavigate();
+  const { token, user } = useAuth();
+  const [departures, setDepartures] = useState([]);
+  const [formData, setFormData] = useState(emptyForm);
+  const [editingId, setEditingId] = useState(null);
+  const [loading, setLoading] = useState(true);
+  const [error, setError] = useState('');
+
+  const baseUrl = `${process.env.REACT_APP_API_URL}/api/agency/packages/${id}/departures`;
+  const headers = { Authorization: `Bearer ${token}` };
+
+  const fetchDepartures = useCallback(async () => {
+    try {
+      const response = await axios.get(baseUrl, { headers: { Authorization: `Bearer ${token}` } });
+      setDepartures(response.data);
+    } catch (err) {
+      setError(err.response?.data?.error || 'Failed to load departures');
+    } finally {
+      setLoading(false);
+    }
+  }, [baseUrl, token]);
+
+  useEffect(() => {
+    if (!token || user?.role !== 'agency') {
+      navigate('/agency/login');
+      return;
+    }
+    fetchDepartures();
+  }, [token, user, navigate, fetchDepartures]);
+
+  const resetForm = () => {
+    setFormData(emptyForm);
+    setEditingId(null);
+  };
+
+  const handleChange = (e) => {
+    const { name, value, type, checked } = e.target;
+    setFormData((prev) => ({ ...prev, [name]: type === 'checkbox' ? checked : value }));
+  };
+
+  const handleSubmit = async (e) => {
+    e.preventDefault();
+    setError('');
+    const payload = {
+      start_date: formData.start_date,
+      total_seats: Number(formData.total_seats),
+      price_override: formData.price_override === '' ? null : Number(formData.price_override),
+      is_active: formData.is_active,
+    };
+
+    try {
+      if (editingId) {
+        await axios.put(`${baseUrl}/${editingId}`, payload, { headers });
+      } else {
+        await axios.post(baseUrl, payload, { headers });
+      }
+      resetForm();
+      fetchDepartures();
+    } catch (err) {
+      setError(err.response?.data?.error || 'Failed to save departure');
+    }
+  };
+
+  const handleEdit = (departure) => {
+    setEditingId(departure.departure_id);
+    setFormData({
+      start_date: departure.start_date,
+      total_seats: departure.total_seats,
+      price_override: departure.price_override ?? '',
+      is_active: departure.is_active,
+    });
+  };
+
+  const handleDelete = async (departureId) => {
+    if (!window.confirm('Delete this departure?')) return;
+    try {
+      await axios.delete(`${baseUrl}/${departureId}`, { headers });
+      fetchDepartures();
+    } catch (err) {
+      setError(err.response?.data?.error || 'Failed to delete departure');
+    }
+  };
+
+  return (
+    <div className="packages-container">
+      <div className="packages-header">
+        <div>
+          <h2 className="page-title">Departures</h2>
+          <p className="page-subtitle">Schedule start dates and seats. Travelers book from this calendar.</p>
+        </div>
+        <button className="secondary-button" onClick={() => navigate('/agency/packages')}>
+          ← Back to packages
+        </button>
+      </div>
+
+      <form className="package-form" onSubmit={handleSubmit}>
+        <div className="form-row">
+          <div className="form-group">
+            <label>Start date</label>
+            <input type="date" name="start_date" value={formData.start_date} onChange={handleChange} required />
+          </div>
+          <div className="form-group">
+            <label>Seats</label>
+            <input
+              type="number"
+              name="total_seats"
+              value={formData.total_seats}
+              onChange={handleChange}
+              min="1"
+              required
+            />
+          </div>
+          <div className="form-group">
+            <label>Price per person (optional override)</label>
+            <input
+              type="number"
+              name="price_override"
+              value={formData.price_override}
+              onChange={handleChange}
+              min="0"
+              placeholder="Package price"
+            />
+          </div>
+          <label className="checkbox-group">
+            <input type="checkbox" name="is_active" checked={formData.is_active} onChange={handleChange} />
+            Open for booking
+          </label>
+        </div>
+
+        {error && <p className="error-message">{error}</p>}
+
+        <div className="card-actions">
+          <button type="submit" className="auth-button">
+            {editingId ? 'Update Departure' : 'Add Departure'}
+          </button>
+          {editingId && (
+            <button type="button" className="secondary-button" onClick={resetForm}>
+              Cancel
+            </button>
+          )}
+        </div>
+      </form>
+
+      {loading ? (
+        <div className="loading-container">
+          <div className="loading-spinner"></div>
+          <p>Loading departures...</p>
+        </div>
+      ) : departures.length === 0 ? (
+        <div className="empty-state">
+          <div className="empty-icon">🗓️</div>
+          <p>No departures yet. Without them travelers can request any date.</p>
+        </div>
+      ) : (
+        <table className="departures-table">
+          <thead>
+            <tr>
+              <th>Dates</th>
+              <th>Seats</th>
+              <th>Price</th>
+              <th>Status</th>
+              <th></th>
+            </tr>
+          </thead>
+          <tbody>
+            {departures.map((d) => (
+              <tr key={d.departure_id}>
+                <td>
+                  {d.start_date} → {d.end_date}
+                </td>
+                <td>
+                  {d.booked_seats} booked · {d.seats_available} left of {d.total_seats}
+                </td>
+                <td>
+                  ₹{Number(d.price).toLocaleString('en-IN')}
+                  {d.price_override !== null && ' (override)'}
+                </td>
+                <td>
+                  <span className={`status-pill ${d.status === 'closed' ? 'inactive' : 'active'}`}>
+                    {d.status.replace('_', ' ')}
+                  </span>
+                </td>
+                <td className="card-actions">
+                  <button className="secondary-button" onClick={() => handleEdit(d)}>
+                    Edit
+                  </button>
+                  <button className="delete-button" onClick={() => handleDelete(d.departure_id)}>
+                    Delete
+                  </button>
+                </td>
+              </tr>
+            ))}
+          </tbody>
+        </table>
+      )}
+    </div>
+  );
+}
+
+export default AgencyDepartures;
@@ -2,6 +2,18 @@ import React, { useState, useEffect } from 'react';
 import { useNavigate } from 'react-router-dom';
 import { useAuth } from '../context/AuthContext';
 import axios from 'axios';
+import { documentLabels, downloadDocument } from './AgencyVerification';
+import { moderationLabels } from './PackageModeration';
+
+// Status changes an admin can make from each agency status
+const reviewActions = {
+  pending: ['approved', 'rejected'],
+  approved: ['suspended'],
+  suspended: ['approved', 'rejected'],
+  rejected: ['approved'],
+};
+
+const actionLabels = { approved: 'Approve', rejected: 'Reject', suspended: 'Suspend' };
 
 function AgencyManagement() {
   const [agencies, setAgencies] = useState([]);
@@ -11,6 +23,10 @@ function AgencyManagement() {
   const [editingAgency, setEditingAgency] = useState(null);
   const [viewingPackages, setViewingPackages] = useState(null);
   const [agencyPackages, setAgencyPackages] = useState([]);
+  const [statusFilter, setStatusFilter] = useState('');
+  const [reviewing, setReviewing] = useState(null);
+  const [reviewNotes, setReviewNotes] = useState('');
+  const [reviewError, setReviewError] = useState('');
   const [formData, setFormData] = useState({
     name: '',
     email: '',
@@ -27,14 +43,15 @@ function AgencyManagement() {
       return;
     }
     fetchAgencies();
-  }, [token, user, navigate]);
+  }, [token, user, navigate, statusFilter]);
 
   const fetchAgencies = async () => {
     try {
       const response = await axios.get(
         `${process.env.REACT_APP_API_URL}/api/admin/agencies`,
         {
-          headers: { Authorization: `Bearer ${token}` }
+          headers: { Authorization: `Bearer ${token}` },
+          params: statusFilter ? { status: statusFilter } : {}
         }
       );
       setAgencies(response.data || []);
@@ -60,6 +77,57 @@ function AgencyManagement() {
     }
   };
 
+  const openReview = async (agencyId) => {
+    try {
+      const response = await axios.get(
+        `${process.env.REACT_APP_API_URL}/api/admin/agencies/${agencyId}/verification`,
+        {
+          headers: { Authorization: `Bearer ${token}` }
+        }
+      );
+      setReviewing(response.data);
+      setReviewNotes('');
+      setReviewError('');
+      setViewingPackages(null);
+    } catch (err) {
+      alert('Failed to load verification');
+    }
+  };
+
+  const handleReview = async (status) => {
+    if (status !== 'approved' && !reviewNotes.trim()) {
+      setReviewError('Add notes explaining why');
+      return;
+    }
+    try {
+      const response = await axios.put(
+        `${process.env.REACT_APP_API_URL}/api/admin/agencies/${reviewing.agency_id}/status`,
+        { status, notes: reviewNotes },
+        {
+          headers: { Authorization: `Bearer ${token}` }
+        }
+      );
+      setReviewing(response.data);
+      setReviewNotes('');
+      setReviewError('');
+      fetchAgencies();
+    } catch (err) {
+      setReviewError(err.response?.data?.error || 'Failed to update status');
+    }
+  };
+
+  const handleDownload = async (doc) => {
+    try {
+      await downloadDocument(
+        `${process.env.REACT_APP_API_URL}/api/admin/agencies/${reviewing.agency_id}/documents/${doc.document_id}`,
+        token,
+        doc.original_filename
+      );
+    } catch (err) {
+      alert('Failed to download document');
+    }
+  };
+
   const handleChange = (e) => {
     setFormData({
       ...formData,
@@ -204,6 +272,9 @@ function AgencyManagement() {
                       <span className={`status-badge ${pkg.is_active ? 'active' : 'inactive'}`}>
                         {pkg.is_active ? 'Active' : 'Inactive'}
                       </span>
+                      <span className={`moderation-status ${pkg.moderation_status}`}>
+                        {moderationLabels[pkg.moderation_status]}
+                      </span>
                     </div>
                     <p className="package-route">
                       📍 {pkg.initial_destination} → {pkg.location}
@@ -221,6 +292,70 @@ function AgencyManagement() {
         </div>
       )}
 
+      {/* Verification Review Modal */}
+      {reviewing && (
+        <div className="packages-modal">
+          <div className="packages-modal-content">
+            <div className="packages-modal-header">
+              <h2>🛡️ Review {reviewing.name}</h2>
+              <button className="close-modal-btn" onClick={() => setReviewing(null)}>×</button>
+            </div>
+            <p>
+              <span className={`agency-status ${reviewing.status}`}>{reviewing.status}</span>{' '}
+              {reviewing.email}
+              {reviewing.gstin ? ` · GSTIN ${reviewing.gstin}` : ''}
+              {reviewing.submitted_at ? ` · submitted ${new Date(reviewing.submitted_at).toLocaleDateString()}` : ''}
+            </p>
+            {reviewing.reviewer_notes && <p><strong>Last notes:</strong> {reviewing.reviewer_notes}</p>}
+
+            <h4>Documents</h4>
+            {reviewing.documents.length === 0 ? (
+              <p className="no-packages">No documents uploaded.</p>
+            ) : (
+              <ul className="verification-documents">
+                {reviewing.documents.map(doc => (
+                  <li key={doc.document_id}>
+                    <button className="view-btn" onClick={() => handleDownload(doc)} title="Download">⬇️</button>
+                    {documentLabels[doc.document_type]}: {doc.original_filename}
+                  </li>
+                ))}
+              </ul>
+            )}
+
+            <div className="form-group">
+              <label>Reviewer notes (required to reject or suspend)</label>
+              <textarea value={reviewNotes} onChange={(e) => setReviewNotes(e.target.value)} rows="3" />
+            </div>
+            {reviewError && <p className="error-message">{reviewError}</p>}
+            <div className="form-actions">
+              {(reviewActions[reviewing.status] || []).map(status => (
+                <button
+                  key={status}
+                  className={status === 'approved' ? 'save-button' : 'cancel-button'}
+                  onClick={() => handleReview(status)}
+                >
+                  {actionLabels[status]}
+                </button>
+              ))}
+            </div>
+
+            {reviewing.reviews.length > 0 && (
+              <div className="verification-history">
+                <h4>History</h4>
+                <ul>
+                  {reviewing.reviews.map(review => (
+                    <li key={review.review_id}>
+                      {new Date(review.created_at).toLocaleDateString()}: {review.from_status} → {review.to_status}{' '}
+                      by {review.reviewed_by}{review.notes ? ` (${review.notes})` : ''}
+                    </li>
+                  ))}
+                </ul>
+              </div>
+            )}
+          </div>
+        </div>
+      )}
+
       {/* Create/Edit Agency Form */}
       {(showCreateForm || editingAgency) && (
         <div className="user-form-card">
@@ -291,7 +426,16 @@ function AgencyManagement() {
 
       {/* Agencies Table */}
       <div className="users-table-card">
-        <h2>All Travel Agencies ({agencies.length})</h2>
+        <div className="agency-table-header">
+          <h2>{statusFilter ? `${statusFilter.charAt(0).toUpperCase()}${statusFilter.slice(1)}` : 'All'} Travel Agencies ({agencies.length})</h2>
+          <select value={statusFilter} onChange={(e) => setStatusFilter(e.target.value)}>
+            <option value="">All statuses</option>
+            <option value="pending">Pending review</option>
+            <option value="approved">Approved</option>
+            <option value="suspended">Suspended</option>
+            <option value="rejected">Rejected</option>
+          </select>
+        </div>
         <div className="table-responsive">
           <table className="users-table agencies-table">
             <thead>
@@ -300,6 +444,7 @@ function AgencyManagement() {
                 <th>Agency Name</th>
                 <th>Email</th>
                 <th>Phone</th>
+                <th>Status</th>
                 <th>Joined</th>
                 <th>Actions</th>
               </tr>
@@ -307,7 +452,7 @@ function AgencyManagement() {
             <tbody>
               {agencies.length === 0 ? (
                 <tr>
-                  <td colSpan="6" className="no-data">No agencies found</td>
+                  <td colSpan="7" className="no-data">No agencies found</td>
                 </tr>
               ) : (
                 agencies.map(agency => (
@@ -316,8 +461,16 @@ function AgencyManagement() {
                     <td><strong>{agency.name}</strong></td>
                     <td>{agency.email}</td>
                     <td>{agency.phone || '-'}</td>
+                    <td><span className={`agency-status ${agency.status}`}>{agency.status}</span></td>
                     <td>{new Date(agency.created_at).toLocaleDateString()}</td>
                     <td className="actions-cell">
+                      <button 
+                        onClick={() => openReview(agency.agency_id)} 
+                        className="view-btn"
+                        title="Review verification"
+                      >
+                        🛡️
+                      </button>
                       <button 
                         onClick={() => fetchAgencyPackages(agency.agency_id, agency.name)} 
                         className="view-btn"
@@ -1,7 +1,9 @@
 import React, { useEffect, useState, useCallback } from 'react';
 import axios from 'axios';
-import { useNavigate } from 'react-router-dom';
+import { Link, useNavigate } from 'react-router-dom';
 import { useAuth } from '../context/AuthContext';
+import { mediaUrl, packagePhotoUrl } from '../utils/mediaUrl';
+import { moderationLabels } from './PackageModeration';
 
 const defaultForm = {
   title: '',
@@ -15,6 +17,29 @@ const defaultForm = {
   is_active: true
 };
 
+const defaultPricing = { mode: 'per_person', child: null, senior: null, seasons: [], group_discounts: [] };
+
+// Form strings to the pricing_rules the API expects. Age bands and group
+// discounts only apply per person.
+const pricingRulesPayload = (pricing) => {
+  const perPerson = pricing.mode === 'per_person';
+  return {
+    mode: pricing.mode,
+    child: perPerson && pricing.child ? {
+      max_age: Number(pricing.child.max_age),
+      price_percent: Number(pricing.child.price_percent)
+    } : null,
+    senior: perPerson && pricing.senior ? {
+      min_age: Number(pricing.senior.min_age),
+      price_percent: Number(pricing.senior.price_percent)
+    } : null,
+    seasons: pricing.seasons.map((s) => ({ ...s, multiplier: Number(s.multiplier) })),
+    group_discounts: perPerson
+      ? pricing.group_discounts.map((g) => ({ min_travelers: Number(g.min_travelers), percent: Number(g.percent) }))
+      : []
+  };
+};
+
 function AgencyPackages() {
   const [packages, setPackages] = useState([]);
   const [loading, setLoading] = useState(true);
@@ -26,9 +51,17 @@ function AgencyPackages() {
   const [locations, setLocations] = useState([]);
   const [locationInput, setLocationInput] = useState('');
 
+  // Cancellation policy tiers: refund % when cancelled at least N days ahead
+  const [policyTiers, setPolicyTiers] = useState([]);
+
+  // Pricing rules: per-person or per-group, age bands, seasons and group discounts
+  const [pricing, setPricing] = useState(defaultPricing);
+
   // Photos state
   const [photoFiles, setPhotoFiles] = useState([]);
   const [photoPreviews, setPhotoPreviews] = useState([]);
+  const [existingPhotos, setExistingPhotos] = useState([]);
+  const [agencyStatus, setAgencyStatus] = useState('');
 
   const { token, user } = useAuth();
   const navigate = useNavigate();
@@ -58,6 +91,12 @@ function AgencyPackages() {
       return;
     }
     fetchPackages();
+    axios
+      .get(`${process.env.REACT_APP_API_URL}/api/agency/verification`, {
+        headers: { Authorization: `Bearer ${token}` }
+      })
+      .then((response) => setAgencyStatus(response.data.status))
+      .catch(() => {});
   }, [token, user, navigate, fetchPackages]);
 
   const resetForm = () => {
@@ -65,8 +104,72 @@ function AgencyPackages() {
     setFormData(defaultForm);
     setLocations([]);
     setLocationInput('');
+    setPolicyTiers([]);
+    setPricing(defaultPricing);
     setPhotoFiles([]);
     setPhotoPreviews([]);
+    setExistingPhotos([]);
+  };
+
+  const photosUrl = (packageId) =>
+    `${process.env.REACT_APP_API_URL}/api/agency/packages/${packageId}/photos`;
+
+  const fetchExistingPhotos = async (packageId) => {
+    try {
+      const response = await axios.get(photosUrl(packageId), {
+        headers: { Authorization: `Bearer ${token}` }
+      });
+      setExistingPhotos(response.data.photos || []);
+    } catch (err) {
+      setExistingPhotos([]);
+    }
+  };
+
+  const handleDeleteExistingPhoto = async (photoId) => {
+    if (!window.confirm('Remove this photo from the package?')) {
+      return;
+    }
+    try {
+      const response = await axios.delete(`${photosUrl(editingId)}/${photoId}`, {
+        headers: { Authorization: `Bearer ${token}` }
+      });
+      setExistingPhotos(response.data.photos || []);
+      fetchPackages();
+    } catch (err) {
+      setError('Failed to remove photo');
+    }
+  };
+
+  const handleSetCoverPhoto = async (photoId) => {
+    try {
+      const response = await axios.put(`${photosUrl(editingId)}/${photoId}/cover`, null, {
+        headers: { Authorization: `Bearer ${token}` }
+      });
+      setExistingPhotos(response.data.photos || []);
+      fetchPackages();
+    } catch (err) {
+      setError('Failed to set cover photo');
+    }
+  };
+
+  const handleMovePhoto = async (idx, direction) => {
+    const target = idx + direction;
+    if (target < 0 || target >= existingPhotos.length) {
+      return;
+    }
+    const ordered = [...existingPhotos];
+    [ordered[idx], ordered[target]] = [ordered[target], ordered[idx]];
+    try {
+      const response = await axios.put(
+        `${photosUrl(editingId)}/order`,
+        { photo_ids: ordered.map((photo) => photo.photo_id) },
+        { headers: { Authorization: `Bearer ${token}` } }
+      );
+      setExistingPhotos(response.data.photos || []);
+      fetchPackages();
+    } catch (err) {
+      setError('Failed to reorder photos');
+    }
   };
 
   const handleChange = (e) => {
@@ -96,6 +199,29 @@ function AgencyPackages() {
     }
   };
 
+  // Cancellation policy handlers
+  const handleAddTier = () => {
+    setPolicyTiers([...policyTiers, { min_days_before: '', refund_percent: '' }]);
+  };
+
+  const handleTierChange = (idx, field, value) => {
+    setPolicyTiers(policyTiers.map((tier, i) => (i === idx ? { ...tier, [field]: value } : tier)));
+  };
+
+  const handleRemoveTier = (idx) => {
+    setPolicyTiers(policyTiers.filter((_, i) => i !== idx));
+  };
+
+  // Pricing rule handlers; list is 'seasons' or 'group_discounts'
+  const updatePricing = (changes) => setPricing({ ...pricing, ...changes });
+
+  const handleAddPricingRow = (list, row) => updatePricing({ [list]: [...pricing[list], row] });
+
+  const handlePricingRowChange = (list, idx, field, value) =>
+    updatePricing({ [list]: pricing[list].map((row, i) => (i === idx ? { ...row, [field]: value } : row)) });
+
+  const handleRemovePricingRow = (list, idx) => updatePricing({ [list]: pricing[list].filter((_, i) => i !== idx) });
+
   // Photo handlers
   const handlePhotoChange = (e) => {
     const files = Array.from(e.target.files);
@@ -128,14 +254,25 @@ function AgencyPackages() {
     data.append('price', String(formData.price));
     data.append('is_active', formData.is_active ? 'true' : 'false');
     data.append('locations', JSON.stringify(locations));
+    data.append(
+      'cancellation_policy',
+      JSON.stringify(
+        policyTiers.map((tier) => ({
+          min_days_before: Number(tier.min_days_before),
+          refund_percent: Number(tier.refund_percent)
+        }))
+      )
+    );
+    data.append('pricing_rules', JSON.stringify(pricingRulesPayload(pricing)));
 
     photoFiles.forEach((file) => {
       data.append('photos', file);
     });
 
     try {
+      let response;
       if (editingId) {
-        await axios.put(
+        response = await axios.put(
           `${process.env.REACT_APP_API_URL}/api/agency/packages/${editingId}`,
           data,
           {
@@ -146,7 +283,7 @@ function AgencyPackages() {
           }
         );
       } else {
-        await axios.post(
+        response = await axios.post(
           `${process.env.REACT_APP_API_URL}/api/agency/packages`,
           data,
           {
@@ -159,8 +296,15 @@ function AgencyPackages() {
       }
       resetForm();
       fetchPackages();
+      const photoErrors = response.data?.photo_errors || [];
+      if (photoErrors.length > 0) {
+        setError(
+          'Package saved, but some photos were rejected: ' +
+            photoErrors.map((e) => `${e.filename} (${e.error})`).join('; ')
+        );
+      }
     } catch (err) {
-      const message = err.response?.data || 'Failed to save package';
+      const message = err.response?.data?.error || err.response?.data || 'Failed to save package';
       setError(typeof message === 'string' ? message : 'Failed to save package');
     }
   };
@@ -179,9 +323,17 @@ function AgencyPackages() {
       is_active: pkg.is_active
     });
     setLocations(pkg.locations || []);
-    // Clear photo files for editing (existing photos are managed server-side)
+    setPolicyTiers(pkg.cancellation_policy || []);
+    setPricing({
+      ...defaultPricing,
+      ...(pkg.pricing_rules || {}),
+      seasons: pkg.pricing_rules?.seasons || [],
+      group_discounts: pkg.pricing_rules?.group_discounts || []
+    });
+    // New uploads are added to the gallery; existing photos are managed below
     setPhotoFiles([]);
     setPhotoPreviews([]);
+    fetchExistingPhotos(pkg.package_id);
     window.scrollTo(0, 0);
   };
 
@@ -204,9 +356,6 @@ function AgencyPackages() {
     }
   };
 
-  const getImageUrl = (filename) =>
-    `${process.env.REACT_APP_API_URL}/uploads/packages/${filename}`;
-
   if (!token || user?.role !== 'agency') {
     return null;
   }
@@ -223,6 +372,15 @@ function AgencyPackages() {
         </button>
       </div>
 
+      {agencyStatus && agencyStatus !== 'approved' && (
+        <div className={`verification-banner ${agencyStatus}`}>
+          <p>
+            Your agency is {agencyStatus}, so travelers can't see your packages yet.{' '}
+            <Link to="/agency/verification">Check your verification</Link>
+          </p>
+        </div>
+      )}
+
       <form className="package-form" onSubmit={handleSubmit}>
         <div className="form-row">
           <div className="form-group">
@@ -344,7 +502,7 @@ function AgencyPackages() {
           <label>📷 Package Gallery</label>
           <input
             type="file"
-            accept="image/*"
+            accept="image/jpeg,image/png,image/gif,image/webp"
             multiple
             onChange={handlePhotoChange}
             className="photo-input"
@@ -365,9 +523,45 @@ function AgencyPackages() {
               ))}
             </div>
           )}
+          {editingId && existingPhotos.length > 0 && (
+            <div className="photo-previews">
+              {existingPhotos.map((photo, idx) => (
+                <div key={photo.photo_id} className="photo-preview-item">
+                  <img src={mediaUrl(photo.variants?.thumb?.jpeg || photo.url)} alt={`Photo ${idx + 1}`} />
+                  <button
+                    type="button"
+                    className="preview-remove"
+                    onClick={() => handleDeleteExistingPhoto(photo.photo_id)}
+                  >
+                    ×
+                  </button>
+                  <div className="photo-preview-actions">
+                    <button type="button" onClick={() => handleMovePhoto(idx, -1)} disabled={idx === 0}>
+                      ‹
+                    </button>
+                    <button
+                      type="button"
+                      onClick={() => handleSetCoverPhoto(photo.photo_id)}
+                      disabled={photo.is_cover}
+                      title={photo.is_cover ? 'Cover photo' : 'Make cover photo'}
+                    >
+                      {photo.is_cover ? '★' : '☆'}
+                    </button>
+                    <button
+                      type="button"
+                      onClick={() => handleMovePhoto(idx, 1)}
+                      disabled={idx === existingPhotos.length - 1}
+                    >
+                      ›
+                    </button>
+                  </div>
+                </div>
+              ))}
+            </div>
+          )}
           {editingId && (
             <p className="photo-note">
-              Note: Uploading new photos will replace the existing gallery for this package.
+              New photos are added to the existing gallery.
             </p>
           )}
         </div>
@@ -406,6 +600,215 @@ function AgencyPackages() {
           </label>
         </div>
 
+        <div className="form-group">
+          <label>Cancellation Policy</label>
+          <p className="form-hint">
+            Refund percentage when a traveler cancels at least the given number of days before departure.
+            Cancelling later than every tier refunds nothing.
+          </p>
+          {policyTiers.map((tier, idx) => (
+            <div key={idx} className="policy-tier-row">
+              <input
+                type="number"
+                min="0"
+                max="365"
+                value={tier.min_days_before}
+                onChange={(e) => handleTierChange(idx, 'min_days_before', e.target.value)}
+                placeholder="Days before"
+                required
+              />
+              <span>days or more before →</span>
+              <input
+                type="number"
+                min="0"
+                max="100"
+                value={tier.refund_percent}
+                onChange={(e) => handleTierChange(idx, 'refund_percent', e.target.value)}
+                placeholder="Refund %"
+                required
+              />
+              <span>% refund</span>
+              <button type="button" className="secondary-button" onClick={() => handleRemoveTier(idx)}>
+                ✕
+              </button>
+            </div>
+          ))}
+          <button type="button" className="secondary-button" onClick={handleAddTier}>
+            + Add tier
+          </button>
+        </div>
+
+        <div className="form-group">
+          <label>Pricing</label>
+          <p className="form-hint">
+            The price above is charged per person or once for the whole group. Seasons multiply it for departures in
+            their date range; discounts for children, seniors and larger groups apply to per-person packages.
+          </p>
+          <select value={pricing.mode} onChange={(e) => updatePricing({ mode: e.target.value })}>
+            <option value="per_person">Per person</option>
+            <option value="per_group">Per group</option>
+          </select>
+
+          {pricing.mode === 'per_person' && (
+            <>
+              <div className="policy-tier-row">
+                <label className="checkbox-group">
+                  <input
+                    type="checkbox"
+                    checked={!!pricing.child}
+                    onChange={(e) =>
+                      updatePricing({ child: e.target.checked ? { max_age: 11, price_percent: 50 } : null })
+                    }
+                  />
+                  Child price
+                </label>
+                {pricing.child && (
+                  <>
+                    <span>up to age</span>
+                    <input
+                      type="number"
+                      min="0"
+                      max="17"
+                      value={pricing.child.max_age}
+                      onChange={(e) => updatePricing({ child: { ...pricing.child, max_age: e.target.value } })}
+                      required
+                    />
+                    <span>pays</span>
+                    <input
+                      type="number"
+                      min="0"
+                      max="100"
+                      value={pricing.child.price_percent}
+                      onChange={(e) => updatePricing({ child: { ...pricing.child, price_percent: e.target.value } })}
+                      required
+                    />
+                    <span>% of the adult price</span>
+                  </>
+                )}
+              </div>
+              <div className="policy-tier-row">
+                <label className="checkbox-group">
+                  <input
+                    type="checkbox"
+                    checked={!!pricing.senior}
+                    onChange={(e) =>
+                      updatePricing({ senior: e.target.checked ? { min_age: 60, price_percent: 80 } : null })
+                    }
+                  />
+                  Senior price
+                </label>
+                {pricing.senior && (
+                  <>
+                    <span>from age</span>
+                    <input
+                      type="number"
+                      min="50"
+                      max="100"
+                      value={pricing.senior.min_age}
+                      onChange={(e) => updatePricing({ senior: { ...pricing.senior, min_age: e.target.value } })}
+                      required
+                    />
+                    <span>pays</span>
+                    <input
+                      type="number"
+                      min="0"
+                      max="100"
+                      value={pricing.senior.price_percent}
+                      onChange={(e) => updatePricing({ senior: { ...pricing.senior, price_percent: e.target.value } })}
+                      required
+                    />
+                    <span>% of the adult price</span>
+                  </>
+                )}
+              </div>
+
+              {pricing.group_discounts.map((tier, idx) => (
+                <div key={idx} className="policy-tier-row">
+                  <input
+                    type="number"
+                    min="2"
+                    value={tier.min_travelers}
+                    onChange={(e) => handlePricingRowChange('group_discounts', idx, 'min_travelers', e.target.value)}
+                    placeholder="Travelers"
+                    required
+                  />
+                  <span>or more travelers →</span>
+                  <input
+                    type="number"
+                    min="1"
+                    max="90"
+                    step="0.5"
+                    value={tier.percent}
+                    onChange={(e) => handlePricingRowChange('group_discounts', idx, 'percent', e.target.value)}
+                    placeholder="Discount %"
+                    required
+                  />
+                  <span>% off</span>
+                  <button
+                    type="button"
+                    className="secondary-button"
+                    onClick={() => handleRemovePricingRow('group_discounts', idx)}
+                  >
+                    ✕
+                  </button>
+                </div>
+              ))}
+              <button
+                type="button"
+                className="secondary-button"
+                onClick={() => handleAddPricingRow('group_discounts', { min_travelers: '', percent: '' })}
+              >
+                + Add group discount
+              </button>
+            </>
+          )}
+
+          {pricing.seasons.map((season, idx) => (
+            <div key={idx} className="policy-tier-row">
+              <input
+                type="text"
+                value={season.name}
+                onChange={(e) => handlePricingRowChange('seasons', idx, 'name', e.target.value)}
+                placeholder="Season name"
+                required
+              />
+              <input
+                type="date"
+                value={season.start_date}
+                onChange={(e) => handlePricingRowChange('seasons', idx, 'start_date', e.target.value)}
+                required
+              />
+              <span>to</span>
+              <input
+                type="date"
+                value={season.end_date}
+                onChange={(e) => handlePricingRowChange('seasons', idx, 'end_date', e.target.value)}
+                required
+              />
+              <span>×</span>
+              <input
+                type="number"
+                min="0.25"
+                max="5"
+                step="0.05"
+                value={season.multiplier}
+                onChange={(e) => handlePricingRowChange('seasons', idx, 'multiplier', e.target.value)}
+                required
+              />
+              <button type="button" className="secondary-button" onClick={() => handleRemovePricingRow('seasons', idx)}>
+                ✕
+              </button>
+            </div>
+          ))}
+          <button
+            type="button"
+            className="secondary-button"
+            onClick={() => handleAddPricingRow('seasons', { name: '', start_date: '', end_date: '', multiplier: 1 })}
+          >
+            + Add season
+          </button>
+        </div>
+
         {error && <p className="error-message">{error}</p>}
 
         <button type="submit" className="auth-button">
@@ -433,7 +836,7 @@ function AgencyPackages() {
                 {pkg.photos && pkg.photos.length > 0 && (
                   <div className="card-image-preview">
                     <img
-                      src={getImageUrl(pkg.photos[0])}
+                      src={packagePhotoUrl(pkg, 0, 'medium')}
                       alt={pkg.title}
                       className="card-preview-img"
                     />
@@ -448,6 +851,18 @@ function AgencyPackages() {
                     {pkg.is_active ? 'Active' : 'Inactive'}
                   </span>
                 </div>
+                {pkg.moderation_status && (
+                  <p>
+                    <span className={`moderation-status ${pkg.moderation_status}`}>
+                      {moderationLabels[pkg.moderation_status]}
+                    </span>
+                  </p>
+                )}
+                {pkg.moderation_notes && pkg.moderation_status !== 'approved' && (
+                  <p className="moderation-notes">
+                    <strong>Reviewer notes:</strong> {pkg.moderation_notes}
+                  </p>
+                )}
                 <p className="package-location">📍 {pkg.initial_destination} → {pkg.location}</p>
                 {/* Itinerary Preview */}
                 {pkg.locations && pkg.locations.length > 0 && (
@@ -475,6 +890,12 @@ function AgencyPackages() {
                   <button className="secondary-button" onClick={() => handleEdit(pkg)}>
                     Edit
                   </button>
+                  <button
+                    className="secondary-button"
+                    onClick={() => navigate(`/agency/packages/${pkg.package_id}/departures`)}
+                  >
+                    Departures
+                  </button>
                   <button className="delete-button" onClick={() => handleDelete(pkg.package_id)}>
                     Delete
                   </button>
@@ -0,0 +1,226 @@
+import React, { useCallback, useEffect, useState } from 'react';
+import axios from 'axios';
+import { useNavigate } from 'react-router-dom';
+import { useAuth } from '../context/AuthContext';
+
+const emptyForm = {
+  code: '',
+  package_id: '',
+  discount_type: 'percent',
+  discount_value: '',
+  max_discount: '',
+  usage_limit: '',
+  starts_at: '',
+  expires_at: '',
+};
+
+const errorText = (err, fallback) => (typeof err.response?.data === 'string' ? err.response.data.trim() : fallback);
+
+function AgencyPromoCodes() {
+  const navigate = useNavigate();
+  const { token, user } = useAuth();
+  const [promos, setPromos] = useState([]);
+  const [packages, setPackages] = useState([]);
+  const [formData, setFormData] = useState(emptyForm);
+  const [loading, setLoading] = useState(true);
+  const [error, setError] = useState('');
+
+  const baseUrl = `${process.env.REACT_APP_API_URL}/api/agency/promo-codes`;
+  const headers = { Authorization: `Bearer ${token}` };
+
+  const fetchPromos = useCallback(async () => {
+    try {
+      const auth = { headers: { Authorization: `Bearer ${token}` } };
+      const [promoResponse, packageResponse] = await Promise.all([
+        axios.get(baseUrl, auth),
+        axios.get(`${process.env.REACT_APP_API_URL}/api/agency/packages`, auth),
+      ]);
+      setPromos(promoResponse.data);
+      setPackages(packageResponse.data);
+    } catch (err) {
+      setError(errorText(err, 'Failed to load promo codes'));
+    } finally {
+      setLoading(false);
+    }
+  }, [baseUrl, token]);
+
+  useEffect(() => {
+    if (!token || user?.role !== 'agency') {
+      navigate('/agency/login');
+      return;
+    }
+    fetchPromos();
+  }, [token, user, navigate, fetchPromos]);
+
+  const handleChange = (e) => {
+    const { name, value } = e.target;
+    setFormData((prev) => ({ ...prev, [name]: name === 'code' ? value.toUpperCase() : value }));
+  };
+
+  const handleSubmit = async (e) => {
+    e.preventDefault();
+    setError('');
+    const optionalNumber = (value) => (value === '' ? null : Number(value));
+    try {
+      await axios.post(
+        baseUrl,
+        {
+          code: formData.code,
+          package_id: optionalNumber(formData.package_id),
+          discount_type: formData.discount_type,
+          discount_value: Number(formData.discount_value),
+          max_discount: formData.discount_type === 'percent' ? optionalNumber(formData.max_discount) : null,
+          usage_limit: optionalNumber(formData.usage_limit),
+          starts_at: formData.starts_at,
+          expires_at: formData.expires_at,
+        },
+        { headers }
+      );
+      setFormData(emptyForm);
+      fetchPromos();
+    } catch (err) {
+      setError(errorText(err, 'Failed to create promo code'));
+    }
+  };
+
+  const toggleActive = async (promo) => {
+    try {
+      await axios.put(`${baseUrl}/${promo.promo_id}`, { is_active: !promo.is_active }, { headers });
+      fetchPromos();
+    } catch (err) {
+      setError(errorText(err, 'Failed to update promo code'));
+    }
+  };
+
+  const packageTitle = (packageId) => packages.find((p) => p.package_id === packageId)?.title || `#${packageId}`;
+
+  return (
+    <div className="packages-container">
+      <div className="packages-header">
+        <div>
+          <h2 className="page-title">Promo Codes</h2>
+          <p className="page-subtitle">Discount codes travelers can enter when booking your packages</p>
+        </div>
+      </div>
+
+      <form className="package-form" onSubmit={handleSubmit}>
+        <div className="form-row">
+          <div className="form-group">
+            <label>Code</label>
+            <input type="text" name="code" value={formData.code} onChange={handleChange} placeholder="SUMMER25" required />
+          </div>
+          <div className="form-group">
+            <label>Package</label>
+            <select name="package_id" value={formData.package_id} onChange={handleChange}>
+              <option value="">All my packages</option>
+              {packages.map((p) => (
+                <option key={p.package_id} value={p.package_id}>
+                  {p.title}
+                </option>
+              ))}
+            </select>
+          </div>
+        </div>
+        <div className="form-row">
+          <div className="form-group">
+            <label>Discount</label>
+            <select name="discount_type" value={formData.discount_type} onChange={handleChange}>
+              <option value="percent">Percent off</option>
+              <option value="flat">Flat amount off (₹)</option>
+            </select>
+          </div>
+          <div className="form-group">
+            <label>Value</label>
+            <input
+              type="number"
+              name="discount_value"
+              value={formData.discount_value}
+              onChange={handleChange}
+              min="1"
+              max={formData.discount_type === 'percent' ? 100 : undefined}
+              required
+            />
+          </div>
+          {formData.discount_type === 'percent' && (
+            <div className="form-group">
+              <label>Max discount (₹, optional)</label>
+              <input type="number" name="max_discount" value={formData.max_discount} onChange={handleChange} min="1" />
+            </div>
+          )}
+        </div>
+        <div className="form-row">
+          <div className="form-group">
+            <label>Usage limit (optional)</label>
+            <input type="number" name="usage_limit" value={formData.usage_limit} onChange={handleChange} min="1" />
+          </div>
+          <div className="form-group">
+            <label>Valid from (optional)</label>
+            <input type="date" name="starts_at" value={formData.starts_at} onChange={handleChange} />
+          </div>
+          <div className="form-group">
+            <label>Expires on</label>
+            <input type="date" name="expires_at" value={formData.expires_at} onChange={handleChange} required />
+          </div>
+        </div>
+
+        {error && <p className="error-message">{error}</p>}
+
+        <button type="submit" className="auth-button">
+          Create Promo Code
+        </button>
+      </form>
+
+      {loading ? (
+        <div className="loading-container">
+          <div className="loading-spinner"></div>
+          <p>Loading promo codes...</p>
+        </div>
+      ) : promos.length === 0 ? (
+        <div className="empty-state">
+          <div className="empty-icon">🏷️</div>
+          <p>No promo codes yet.</p>
+        </div>
+      ) : (
+        <table className="departures-table">
+          <thead>
+            <tr>
+              <th>Code</th>
+              <th>Discount</th>
+              <th>Applies to</th>
+              <th>Used</th>
+              <th>Expires</th>
+              <th></th>
+            </tr>
+          </thead>
+          <tbody>
+            {promos.map((promo) => (
+              <tr key={promo.promo_id}>
+                <td>
+                  <strong>{promo.code}</strong>
+                </td>
+                <td>
+                  {promo.discount_type === 'percent'
+                    ? `${promo.discount_value}%${promo.max_discount ? ` (max ₹${promo.max_discount})` : ''}`
+                    : `₹${Number(promo.discount_value).toLocaleString('en-IN')}`}
+                </td>
+                <td>{promo.package_id ? packageTitle(promo.package_id) : 'All packages'}</td>
+                <td>
+                  {promo.used_count}
+                  {promo.usage_limit ? ` / ${promo.usage_limit}` : ''}
+                </td>
+                <td>{new Date(promo.expires_at).toLocaleDateString()}</td>
+                <td>
+                  <button className="secondary-button" onClick={() => toggleActive(promo)}>
+                    {promo.is_active ? 'Deactivate' : 'Activate'}
+                  </button>
+                </td>
+              </tr>
+            ))}
+          </tbody>
+        </table>
+      )}
+    </div>
+  );
+}
+
+export default AgencyPromoCodes;
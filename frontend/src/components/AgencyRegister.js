@@ -126,7 +126,7 @@ function AgencyRegister() {
         role,
         username: agency.name
       });
-      navigate('/agency/packages');
+      navigate('/agency/verification');
     } catch (err) {
       const message = err.response?.data || 'Registration failed. Please try again.';
       setError(typeof message === 'string' ? message : 'Registration failed. Please try again.');
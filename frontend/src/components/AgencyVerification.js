@@ -0,0 +1,235 @@
+import React, { useCallback, useEffect, useState } from 'react';
+import axios from 'axios';
+import { useNavigate } from 'react-router-dom';
+import { useAuth } from '../context/AuthContext';
+
+export const documentLabels = {
+  registration_certificate: 'Registration certificate',
+  gst_certificate: 'GST certificate',
+  other: 'Other',
+};
+
+const statusText = {
+  pending: 'Your agency is awaiting verification. Packages stay hidden from travelers until it is approved.',
+  approved: 'Your agency is verified. Active packages are visible to travelers.',
+  suspended: 'Your agency is suspended. Packages are hidden from travelers until an admin reinstates it.',
+  rejected: 'Your agency was not approved. Upload corrected documents and submit them again.',
+};
+
+// downloadDocument fetches an authenticated file and saves it
+export const downloadDocument = async (url, token, filename) => {
+  const response = await axios.get(url, { headers: { Authorization: `Bearer ${token}` }, responseType: 'blob' });
+  const link = document.createElement('a');
+  const href = window.URL.createObjectURL(response.data);
+  link.href = href;
+  link.download = filename;
+  link.click();
+  window.URL.revokeObjectURL(href);
+};
+
+function AgencyVerification() {
+  const navigate = useNavigate();
+  const { token, user } = useAuth();
+  const [verification, setVerification] = useState(null);
+  const [documentType, setDocumentType] = useState('registration_certificate');
+  const [file, setFile] = useState(null);
+  const [uploading, setUploading] = useState(false);
+  const [message, setMessage] = useState('');
+  const [error, setError] = useState('');
+
+  const baseUrl = `${process.env.REACT_APP_API_URL}/api/agency/verification`;
+  const headers = { Authorization: `Bearer ${token}` };
+
+  const fetchVerification = useCallback(async () => {
+    try {
+      const response = await axios.get(baseUrl, { headers: { Authorization: `Bearer ${token}` } });
+      setVerification(response.data);
+    } catch (err) {
+      setError(err.response?.data?.error || 'Failed to load verification status');
+    }
+  }, [baseUrl, token]);
+
+  useEffect(() => {
+    if (!token || user?.role !== 'agency') {
+      navigate('/agency/login');
+      return;
+    }
+    fetchVerification();
+  }, [token, user, navigate, fetchVerification]);
+
+  const handleUpload = async (e) => {
+    e.preventDefault();
+    if (!file) return;
+    setError('');
+    setMessage('');
+    setUploading(true);
+    const data = new FormData();
+    data.append('document_type', documentType);
+    data.append('file', file);
+    try {
+      const response = await axios.post(`${baseUrl}/documents`, data, { headers });
+      setVerification(response.data);
+      setFile(null);
+      e.target.reset();
+    } catch (err) {
+      setError(err.response?.data?.error || 'Failed to upload document');
+    } finally {
+      setUploading(false);
+    }
+  };
+
+  const handleDelete = async (doc) => {
+    if (!window.confirm(`Delete ${doc.original_filename}?`)) return;
+    try {
+      const response = await axios.delete(`${baseUrl}/documents/${doc.document_id}`, { headers });
+      setVerification(response.data);
+    } catch (err) {
+      setError(err.response?.data?.error || 'Failed to delete document');
+    }
+  };
+
+  const handleDownload = async (doc) => {
+    try {
+      await downloadDocument(`${baseUrl}/documents/${doc.document_id}`, token, doc.original_filename);
+    } catch (err) {
+      setError('Failed to download document');
+    }
+  };
+
+  const handleSubmit = async () => {
+    setError('');
+    setMessage('');
+    try {
+      const response = await axios.post(`${baseUrl}/submit`, {}, { headers });
+      setVerification(response.data);
+      setMessage('Submitted for review. We will email you once an admin has looked at it.');
+    } catch (err) {
+      setError(err.response?.data?.error || 'Failed to submit for review');
+    }
+  };
+
+  if (!verification) {
+    return (
+      <div className="loading-container">
+        {error ? <p className="error-message">{error}</p> : <div className="loading-spinner"></div>}
+      </div>
+    );
+  }
+
+  const editable = verification.status === 'pending' || verification.status === 'rejected';
+  const canSubmit =
+    verification.status === 'rejected' || (verification.status === 'pending' && !verification.submitted_at);
+
+  return (
+    <div className="packages-container">
+      <div className="packages-header">
+        <div>
+          <h2 className="page-title">Agency Verification</h2>
+          <p className="page-subtitle">Upload your KYC documents so travelers can see your packages</p>
+        </div>
+        <span className={`agency-status ${verification.status}`}>{verification.status}</span>
+      </div>
+
+      <div className={`verification-banner ${verification.status}`}>
+        <p>{statusText[verification.status]}</p>
+        {verification.status === 'pending' && verification.submitted_at && (
+          <p>Submitted on {new Date(verification.submitted_at).toLocaleDateString()}.</p>
+        )}
+        {verification.reviewer_notes && (
+          <p>
+            <strong>Reviewer notes:</strong> {verification.reviewer_notes}
+          </p>
+        )}
+      </div>
+
+      {message && <p className="success-message">{message}</p>}
+      {error && <p className="error-message">{error}</p>}
+
+      <form className="package-form" onSubmit={handleUpload}>
+        <h3>Documents</h3>
+        <div className="form-row">
+          <div className="form-group">
+            <label>Document type</label>
+            <select value={documentType} onChange={(e) => setDocumentType(e.target.value)}>
+              {Object.entries(documentLabels).map(([value, label]) => (
+                <option key={value} value={value}>
+                  {label}
+                </option>
+              ))}
+            </select>
+          </div>
+          <div className="form-group">
+            <label>File (PDF, JPEG or PNG)</label>
+            <input
+              type="file"
+              accept="application/pdf,image/jpeg,image/png"
+              onChange={(e) => setFile(e.target.files[0] || null)}
+            />
+          </div>
+        </div>
+        <button type="submit" className="secondary-button" disabled={!file || uploading}>
+          {uploading ? 'Uploading...' : 'Upload'}
+        </button>
+      </form>
+
+      {verification.documents.length === 0 ? (
+        <div className="empty-state">
+          <div className="empty-icon">📄</div>
+          <p>No documents uploaded yet. A registration certificate is required.</p>
+        </div>
+      ) : (
+        <table className="departures-table">
+          <thead>
+            <tr>
+              <th>Type</th>
+              <th>File</th>
+              <th>Uploaded</th>
+              <th></th>
+            </tr>
+          </thead>
+          <tbody>
+            {verification.documents.map((doc) => (
+              <tr key={doc.document_id}>
+                <td>{documentLabels[doc.document_type]}</td>
+                <td>{doc.original_filename}</td>
+                <td>{new Date(doc.uploaded_at).toLocaleDateString()}</td>
+                <td className="billing-actions">
+                  <button className="secondary-button" onClick={() => handleDownload(doc)}>
+                    Download
+                  </button>
+                  {editable && (
+                    <button className="secondary-button" onClick={() => handleDelete(doc)}>
+                      Delete
+                    </button>
+                  )}
+                </td>
+              </tr>
+            ))}
+          </tbody>
+        </table>
+      )}
+
+      {canSubmit && (
+        <button className="auth-button" onClick={handleSubmit}>
+          Submit for Review
+        </button>
+      )}
+
+      {verification.reviews.length > 0 && (
+        <div className="verification-history">
+          <h3>History</h3>
+          <ul>
+            {verification.reviews.map((review) => (
+              <li key={review.review_id}>
+                {new Date(review.created_at).toLocaleDateString()}: {review.from_status} → {review.to_status}
+                {review.notes ? ` (${review.notes})` : ''}
+              </li>
+            ))}
+          </ul>
+        </div>
+      )}
+    </div>
+  );
+}
+
+export default AgencyVerification;
@@ -0,0 +1,324 @@
+import React, { useEffect, useState } from 'react';
+import axios from 'axios';
+import { useNavigate } from 'react-router-dom';
+import { useAuth } from '../context/AuthContext';
+
+const emptyTraveler = () => ({ full_name: '', age: '', gender: '' });
+
+// Earliest departure the backend accepts is tomorrow
+const tomorrow = () => {
+  const d = new Date();
+  d.setDate(d.getDate() + 1);
+  return d.toISOString().slice(0, 10);
+};
+
+// Counts travelers per price band the way the backend does
+const partyFromAges = (ages, rules) => {
+  const party = { adults: 0, children: 0, seniors: 0 };
+  ages.forEach((age) => {
+    if (rules?.child && age !== '' && Number(age) <= rules.child.max_age) party.children += 1;
+    else if (rules?.senior && age !== '' && Number(age) >= rules.senior.min_age) party.seniors += 1;
+    else party.adults += 1;
+  });
+  return party;
+};
+
+function BookingForm({ pkg }) {
+  const navigate = useNavigate();
+  const { isAuthenticated, token, user } = useAuth();
+  const [departureDate, setDepartureDate] = useState('');
+  const [travelers, setTravelers] = useState([emptyTraveler()]);
+  const [contact, setContact] = useState({
+    contact_name: user?.firstname ? `${user.firstname} ${user.lastname || ''}`.trim() : '',
+    contact_email: user?.email || '',
+    contact_phone: '',
+    special_requests: '',
+  });
+  const [submitting, setSubmitting] = useState(false);
+  const [error, setError] = useState('');
+  const [booking, setBooking] = useState(null);
+  const [departures, setDepartures] = useState([]);
+  const [promoCode, setPromoCode] = useState('');
+  const [quote, setQuote] = useState(null);
+  const [quoteError, setQuoteError] = useState('');
+
+  useEffect(() => {
+    const fetchDepartures = async () => {
+      try {
+        const response = await axios.get(
+          `${process.env.REACT_APP_API_URL}/api/packages/${pkg.package_id}/departures`
+        );
+        setDepartures(response.data.departures || []);
+      } catch (err) {
+        setDepartures([]);
+      }
+    };
+    fetchDepartures();
+  }, [pkg.package_id]);
+
+  const ages = travelers.map((t) => t.age).join(',');
+  useEffect(() => {
+    if (!departureDate) {
+      setQuote(null);
+      return undefined;
+    }
+    // Wait for typing to settle before asking for a new quote
+    const timer = setTimeout(async () => {
+      try {
+        const response = await axios.get(`${process.env.REACT_APP_API_URL}/api/packages/${pkg.package_id}/quote`, {
+          params: {
+            departure_date: departureDate,
+            ...partyFromAges(ages.split(','), pkg.pricing_rules),
+            ...(promoCode.trim() ? { promo_code: promoCode.trim() } : {}),
+          },
+        });
+        setQuote(response.data);
+        setQuoteError('');
+      } catch (err) {
+        setQuote(null);
+        setQuoteError(typeof err.response?.data === 'string' ? err.response.data.trim() : '');
+      }
+    }, 400);
+    return () => clearTimeout(timer);
+  }, [pkg.package_id, pkg.pricing_rules, departureDate, ages, promoCode]);
+
+  if (!isAuthenticated || (user?.role && user.role !== 'user')) {
+    return (
+      <div className="booking-section">
+        <h3 className="section-title">🧾 Book this package</h3>
+        {user?.role === 'agency' || user?.role === 'admin' ? (
+          <p className="booking-note">Bookings are made from a traveler account.</p>
+        ) : (
+          <button className="view-details-btn" onClick={() => navigate('/login')}>
+            Log in to book
+          </button>
+        )}
+      </div>
+    );
+  }
+
+  const selectedDeparture = departures.find((d) => d.start_date === departureDate);
+  const maxParty = selectedDeparture
+    ? Math.min(pkg.num_travelers, selectedDeparture.seats_available)
+    : pkg.num_travelers;
+  const unitPrice = selectedDeparture ? selectedDeparture.price : Number(pkg.price);
+  const estimatedTotal = quote ? quote.total : unitPrice * travelers.length;
+
+  const updateTraveler = (index, field, value) => {
+    setTravelers((prev) => prev.map((t, i) => (i === index ? { ...t, [field]: value } : t)));
+  };
+
+  const addTraveler = () => {
+    if (travelers.length < maxParty) {
+      setTravelers((prev) => [...prev, emptyTraveler()]);
+    }
+  };
+
+  const removeTraveler = (index) => {
+    setTravelers((prev) => prev.filter((_, i) => i !== index));
+  };
+
+  const handleSubmit = async (e) => {
+    e.preventDefault();
+    setSubmitting(true);
+    setError('');
+    try {
+      const response = await axios.post(
+        `${process.env.REACT_APP_API_URL}/api/bookings`,
+        {
+          package_id: pkg.package_id,
+          departure_date: departureDate,
+          travelers: travelers.map((t) => ({ ...t, age: Number(t.age) })),
+          promo_code: promoCode.trim(),
+          ...contact,
+        },
+        { headers: { Authorization: `Bearer ${token}` } }
+      );
+      setBooking(response.data);
+    } catch (err) {
+      setError(typeof err.response?.data === 'string' ? err.response.data.trim() : 'Could not create the booking.');
+    } finally {
+      setSubmitting(false);
+    }
+  };
+
+  if (booking) {
+    return (
+      <div className="booking-section">
+        <h3 className="section-title">✅ Booking requested</h3>
+        <p className="booking-note">
+          Your request for {booking.num_travelers} traveler{booking.num_travelers > 1 ? 's' : ''} departing on{' '}
+          {booking.departure_date} has been sent to {booking.agency_name}. Quoted total: ₹
+          {Number(booking.total_price).toLocaleString('en-IN')}.
+        </p>
+        <button className="view-details-btn" onClick={() => navigate('/bookings')}>
+          View my bookings →
+        </button>
+      </div>
+    );
+  }
+
+  return (
+    <form className="booking-section" onSubmit={handleSubmit}>
+      <h3 className="section-title">🧾 Book this package</h3>
+      {error && <p className="error-message">{error}</p>}
+
+      {departures.length > 0 ? (
+        <div className="form-group">
+          <label>Choose a departure</label>
+          <div className="departure-calendar">
+            {departures.map((d) => (
+              <button
+                type="button"
+                key={d.departure_id}
+                className={`departure-chip ${d.status} ${d.start_date === departureDate ? 'selected' : ''}`}
+                disabled={d.status === 'sold_out'}
+                onClick={() => setDepartureDate(d.start_date)}
+              >
+                <strong>
+                  {new Date(d.start_date).toLocaleDateString('en-IN', { day: 'numeric', month: 'short', year: 'numeric' })}
+                </strong>
+                <span>₹{Number(d.price).toLocaleString('en-IN')}</span>
+                <span>{d.status === 'sold_out' ? 'Sold out' : `${d.seats_available} seats left`}</span>
+              </button>
+            ))}
+          </div>
+        </div>
+      ) : (
+        <div className="form-group">
+          <label>Departure date</label>
+          <input
+            type="date"
+            min={tomorrow()}
+            value={departureDate}
+            onChange={(e) => setDepartureDate(e.target.value)}
+            required
+          />
+        </div>
+      )}
+
+      <h4>Travelers ({travelers.length} of up to {maxParty})</h4>
+      {travelers.map((traveler, index) => (
+        <div className="booking-traveler" key={index}>
+          <input
+            type="text"
+            placeholder="Full name"
+            value={traveler.full_name}
+            onChange={(e) => updateTraveler(index, 'full_name', e.target.value)}
+            required
+          />
+          <input
+            type="number"
+            placeholder="Age"
+            min="0"
+            max="120"
+            value={traveler.age}
+            onChange={(e) => updateTraveler(index, 'age', e.target.value)}
+            required
+          />
+          <select value={traveler.gender} onChange={(e) => updateTraveler(index, 'gender', e.target.value)}>
+            <option value="">Gender (optional)</option>
+            <option value="female">Female</option>
+            <option value="male">Male</option>
+            <option value="other">Other</option>
+          </select>
+          {travelers.length > 1 && (
+            <button type="button" className="secondary-button" onClick={() => removeTraveler(index)}>
+              Remove
+            </button>
+          )}
+        </div>
+      ))}
+      {travelers.length < maxParty && (
+        <button type="button" className="secondary-button" onClick={addTraveler}>
+          + Add traveler
+        </button>
+      )}
+
+      <h4>Contact details</h4>
+      <div className="booking-contact">
+        <input
+          type="text"
+          placeholder="Contact name"
+          value={contact.contact_name}
+          onChange={(e) => setContact({ ...contact, contact_name: e.target.value })}
+          required
+        />
+        <input
+          type="email"
+          placeholder="Email"
+          value={contact.contact_email}
+          onChange={(e) => setContact({ ...contact, contact_email: e.target.value })}
+          required
+        />
+        <input
+          type="tel"
+          placeholder="Phone"
+          value={contact.contact_phone}
+          onChange={(e) => setContact({ ...contact, contact_phone: e.target.value })}
+          required
+        />
+      </div>
+      <textarea
+        placeholder="Special requests (optional)"
+        value={contact.special_requests}
+        onChange={(e) => setContact({ ...contact, special_requests: e.target.value })}
+        rows="3"
+      />
+
+      <div className="booking-promo">
+        <input
+          type="text"
+          placeholder="Promo code (optional)"
+          value={promoCode}
+          onChange={(e) => setPromoCode(e.target.value.toUpperCase())}
+        />
+        {quoteError && <span className="error-message">{quoteError}</span>}
+      </div>
+
+      {quote && (
+        <table className="quote-table">
+          <tbody>
+            {quote.lines.map((line) => (
+              <tr key={line.label}>
+                <td>
+                  {line.label}
+                  {quote.mode === 'per_person' && ` × ${line.quantity}`}
+                </td>
+                <td>₹{Number(line.amount).toLocaleString('en-IN')}</td>
+              </tr>
+            ))}
+            {quote.season && (
+              <tr className="quote-note">
+                <td colSpan="2">
+                  {quote.season} season pricing (×{quote.season_multiplier})
+                </td>
+              </tr>
+            )}
+            {quote.discounts.map((line) => (
+              <tr key={line.label} className="quote-discount">
+                <td>{line.label}</td>
+                <td>−₹{Math.abs(line.amount).toLocaleString('en-IN')}</td>
+              </tr>
+            ))}
+          </tbody>
+        </table>
+      )}
+
+      <div className="booking-summary">
+        <span>
+          Estimated total: <strong>₹{estimatedTotal.toLocaleString('en-IN')}</strong>
+        </span>
+        <button
+          type="submit"
+          className="view-details-btn"
+          disabled={submitting || !departureDate || travelers.length > maxParty}
+        >
+          {submitting ? 'Sending request...' : 'Request booking'}
+        </button>
+      </div>
+    </form>
+  );
+}
+
+export default BookingForm;
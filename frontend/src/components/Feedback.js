@@ -2,21 +2,12 @@ import React, { useState, useEffect } from 'react';
 import { useAuth } from '../context/AuthContext';
 import { useNavigate } from 'react-router-dom';
 import axios from 'axios';
-
-// Karnataka Districts
-const KARNATAKA_DISTRICTS = [
-  "Bagalkot", "Ballari (Bellary)", "Belagavi (Belgaum)", "Bengaluru Rural", "Bengaluru Urban",
-  "Bidar", "Chamarajanagar", "Chikkaballapur", "Chikkamagaluru", "Chitradurga",
-  "Dakshina Kannada", "Davanagere", "Dharwad", "Gadag", "Hassan", "Haveri",
-  "Kalaburagi (Gulbarga)", "Kodagu (Coorg)", "Kolar", "Koppal", "Mandya",
-  "Mysuru (Mysore)", "Raichur", "Ramanagara", "Shivamogga (Shimoga)",
-  "Tumakuru (Tumkur)", "Udupi", "Uttara Kannada (Karwar)", "Vijayapura (Bijapur)",
-  "Yadgir", "Vijayanagara"
-];
+import { useDistricts } from '../utils/districts';
 
 function Feedback() {
   const { user, isAuthenticated } = useAuth();
   const navigate = useNavigate();
+  const districts = useDistricts();
   
   const [step, setStep] = useState('select'); // 'select', 'form', 'history'
   const [feedbackType, setFeedbackType] = useState('');
@@ -289,9 +280,9 @@ function Feedback() {
                   className="form-select"
                 >
                   <option value="">Choose a district...</option>
-                  {KARNATAKA_DISTRICTS.map(district => (
-                    <option key={district} value={district}>
-                      {district}
+                  {districts.map(district => (
+                    <option key={district.name} value={district.name}>
+                      {district.name}
                     </option>
                   ))}
                 </select>
@@ -5,75 +5,8 @@ import axios from 'axios';
 import WeatherWidget from './WeatherWidget';
 import TripMap from './TripMap';
 import { generateTripPDF } from '../utils/pdfGenerator';
-
-const KARNATAKA_DISTRICTS = [
-  "Bagalkot",
-  "Ballari (Bellary)",
-  "Belagavi (Belgaum)",
-  "Bengaluru Rural",
-  "Bengaluru Urban",
-  "Bidar",
-  "Chamarajanagar",
-  "Chikkaballapur",
-  "Chikkamagaluru",
-  "Chitradurga",
-  "Dakshina Kannada",
-  "Davanagere",
-  "Dharwad",
-  "Gadag",
-  "Hassan",
-  "Haveri",
-  "Kalaburagi (Gulbarga)",
-  "Kodagu (Coorg)",
-  "Kolar",
-  "Koppal",
-  "Mandya",
-  "Mysuru (Mysore)",
-  "Raichur",
-  "Ramanagara",
-  "Shivamogga (Shimoga)",
-  "Tumakuru (Tumkur)",
-  "Udupi",
-  "Uttara Kannada (Karwar)",
-  "Vijayapura (Bijapur)",
-  "Yadgir",
-  "Vijayanagara"
-];
-
-// District coordinates (longitude, latitude) for OpenRouteService
-const DISTRICT_COORDINATES = {
-  "Bagalkot": [75.6615, 16.1691],
-  "Ballari (Bellary)": [76.9214, 15.1394],
-  "Belagavi (Belgaum)": [74.4977, 15.8497],
-  "Bengaluru Rural": [77.3910, 13.2257],
-  "Bengaluru Urban": [77.5946, 12.9716],
-  "Bidar": [77.5199, 17.9104],
-  "Chamarajanagar": [76.9398, 11.9261],
-  "Chikkaballapur": [77.7278, 13.4355],
-  "Chikkamagaluru": [75.7747, 13.3161],
-  "Chitradurga": [76.3980, 14.2251],
-  "Dakshina Kannada": [74.8560, 12.9141],
-  "Davanagere": [75.9218, 14.4644],
-  "Dharwad": [75.0078, 15.4589],
-  "Gadag": [75.6290, 15.4166],
-  "Hassan": [76.0996, 13.0068],
-  "Haveri": [75.3990, 14.7951],
-  "Kalaburagi (Gulbarga)": [76.8343, 17.3297],
-  "Kodagu (Coorg)": [75.7382, 12.4244],
-  "Kolar": [78.1290, 13.1360],
-  "Koppal": [76.1548, 15.3550],
-  "Mandya": [76.8958, 12.5218],
-  "Mysuru (Mysore)": [76.6394, 12.2958],
-  "Raichur": [77.3566, 16.2120],
-  "Ramanagara": [77.2826, 12.7159],
-  "Shivamogga (Shimoga)": [75.5681, 13.9299],
-  "Tumakuru (Tumkur)": [77.1010, 13.3379],
-  "Udupi": [74.7421, 13.3409],
-  "Uttara Kannada (Karwar)": [74.1240, 14.8182],
-  "Vijayapura (Bijapur)": [75.7100, 16.8302],
-  "Yadgir": [77.1383, 16.7700],
-  "Vijayanagara": [76.4700, 15.3350]
-};
+import { mediaUrl } from '../utils/mediaUrl';
+import { useDistricts, districtCoordinates } from '../utils/districts';
 
 function GenerateTrip() {
   const [step, setStep] = useState(1);
@@ -87,15 +20,18 @@ function GenerateTrip() {
   });
   const [loading, setLoading] = useState(false);
   const [tripDetails, setTripDetails] = useState('');
+  const [grounding, setGrounding] = useState(null);
+  const [dayRoutes, setDayRoutes] = useState([]);
   const [error, setError] = useState('');
   const [districtPhotos, setDistrictPhotos] = useState([]);
-  const [photoFolder, setPhotoFolder] = useState('');
   const [routeDistance, setRouteDistance] = useState(null);
   const [distanceLoading, setDistanceLoading] = useState(false);
   const [destinationFeedbacks, setDestinationFeedbacks] = useState([]);
   const [feedbackStats, setFeedbackStats] = useState({ average_rating: 0, total_reviews: 0 });
   const [mustVisitPlaces, setMustVisitPlaces] = useState([]);
 
+  const districts = useDistricts();
+
   const { isAuthenticated, token, user } = useAuth();
   const navigate = useNavigate();
 
@@ -111,8 +47,8 @@ function GenerateTrip() {
 
   // Fetch distance using OpenRouteService API
   const fetchRouteDistance = async (startDistrict, endDistrict) => {
-    const startCoords = DISTRICT_COORDINATES[startDistrict];
-    const endCoords = DISTRICT_COORDINATES[endDistrict];
+    const startCoords = districtCoordinates(districts, startDistrict);
+    const endCoords = districtCoordinates(districts, endDistrict);
 
     if (!startCoords || !endCoords) {
       console.log('Coordinates not found for districts:', startDistrict, endDistrict);
@@ -232,6 +168,7 @@ function GenerateTrip() {
       );
 
       setTripDetails(response.data.tripdetails);
+      setGrounding(response.data.grounding || null);
       
       // Parse must-visit places from trip details
       const parsedPlaces = parseMustVisitPlaces(response.data.tripdetails);
@@ -241,13 +178,24 @@ function GenerateTrip() {
       const distance = await fetchRouteDistance(formData.initial_destination, formData.final_destination);
       setRouteDistance(distance);
       
+      // Fetch optimised day routes through the verified places
+      try {
+        const routesResponse = await axios.get(
+          `${process.env.REACT_APP_API_URL}/api/saved-trips/${response.data.tripid}/routes`,
+          { headers: { Authorization: `Bearer ${token}` } }
+        );
+        setDayRoutes(routesResponse.data.days || []);
+      } catch (routeErr) {
+        console.log('Could not fetch day routes:', routeErr);
+        setDayRoutes([]);
+      }
+
       // Fetch district photos for the destination
       try {
         const photosResponse = await axios.get(
           `${process.env.REACT_APP_API_URL}/api/district-photos/${encodeURIComponent(formData.final_destination)}`
         );
         setDistrictPhotos(photosResponse.data.photos || []);
-        setPhotoFolder(photosResponse.data.folder || '');
       } catch (photoErr) {
         console.log('Could not fetch district photos:', photoErr);
         setDistrictPhotos([]);
@@ -318,6 +266,15 @@ function GenerateTrip() {
     return places;
   };
 
+// Shows whether a place or restaurant named in the line is in the local catalog
+const groundingBadge = (text) => {
+  const item = grounding?.items?.find(it => text.toLowerCase().includes(it.name.toLowerCase()));
+  if (!item) return null;
+  return item.verified
+    ? <span className="grounding-badge verified" title={`Listed in our catalog as ${item.catalog_name}`}>✓ Verified</span>
+    : <span className="grounding-badge unverified" title="Not in our local catalog - check before visiting">Unverified</span>;
+};
+
 const formatTripDetails = (details) => {
   const lines = details.split('\n');
   const result = [];
@@ -365,7 +322,7 @@ const formatTripDetails = (details) => {
                 <div key={idx} className="must-visit-card">
                   <div className="must-visit-number">{idx + 1}</div>
                   <div className="must-visit-content">
-                    <h3 className="must-visit-name">{place.name}</h3>
+                    <h3 className="must-visit-name">{place.name} {groundingBadge(place.name)}</h3>
                     <p className="must-visit-desc">{place.description}</p>
                   </div>
                 </div>
@@ -435,11 +392,11 @@ const formatTripDetails = (details) => {
     }
     // Bold text with **
     else if (line.includes('**') && line.trim().startsWith('**')) {
-      result.push(<p key={i} className="trip-bold">{line.replace(/\*\*/g, '')}</p>);
+      result.push(<p key={i} className="trip-bold">{line.replace(/\*\*/g, '')} {groundingBadge(line)}</p>);
     }
     // List items
     else if (line.trim().startsWith('- ') || line.trim().startsWith('* ')) {
-      result.push(<li key={i} className="trip-list-item">{line.replace(/^[\s-\*]+/, '')}</li>);
+      result.push(<li key={i} className="trip-list-item">{line.replace(/^[\s-\*]+/, '')} {groundingBadge(line)}</li>);
     }
     // Numbered lists
     else if (/^\d+\./.test(line.trim())) {
@@ -475,9 +432,10 @@ const formatTripDetails = (details) => {
       mood: 'cultural'
     });
     setTripDetails('');
+    setGrounding(null);
+    setDayRoutes([]);
     setError('');
     setDistrictPhotos([]);
-    setPhotoFolder('');
     setRouteDistance(null);
     window.scrollTo(0, 0);
   };
@@ -527,9 +485,9 @@ const formatTripDetails = (details) => {
                   required
                 >
                   <option value="">Select starting district...</option>
-                  {KARNATAKA_DISTRICTS.map((district) => (
-                    <option key={district} value={district}>
-                      {district}
+                  {districts.map((district) => (
+                    <option key={district.name} value={district.name}>
+                      {district.name}
                     </option>
                   ))}
                 </select>
@@ -544,9 +502,9 @@ const formatTripDetails = (details) => {
                   required
                 >
                   <option value="">Select destination district...</option>
-                  {KARNATAKA_DISTRICTS.map((district) => (
-                    <option key={district} value={district}>
-                      {district}
+                  {districts.map((district) => (
+                    <option key={district.name} value={district.name}>
+                      {district.name}
                     </option>
                   ))}
                 </select>
@@ -738,13 +696,19 @@ const formatTripDetails = (details) => {
                     <h3 className="gallery-title">📸 Glimpses of {formData.final_destination}</h3>
                     <div className="district-photos-grid">
                       {districtPhotos.slice(0, 5).map((photo, index) => (
-                        <div key={index} className="district-photo-item">
+                        <figure key={photo.photo_id || index} className="district-photo-item">
                           <img
-                            src={`${process.env.REACT_APP_API_URL}/uploads/districts/${encodeURIComponent(photoFolder)}/${encodeURIComponent(photo)}`}
-                            alt={`${formData.final_destination} - ${index + 1}`}
+                            src={mediaUrl(photo.variants?.medium?.jpeg || photo.url)}
+                            alt={photo.alt_text || `${formData.final_destination} - ${index + 1}`}
                             onError={(e) => { e.target.style.display = 'none'; }}
                           />
-                        </div>
+                          {(photo.caption || photo.credit) && (
+                            <figcaption className="district-photo-caption">
+                              {photo.caption}
+                              {photo.credit && <span className="district-photo-credit"> © {photo.credit}</span>}
+                            </figcaption>
+                          )}
+                        </figure>
                       ))}
                     </div>
                   </div>
@@ -752,6 +716,12 @@ const formatTripDetails = (details) => {
 
                 {/* Trip Details */}
                 <div className="trip-result">
+                  {grounding?.items?.length > 0 && (
+                    <p className="grounding-summary">
+                      {grounding.verified} of {grounding.items.length} recommended places and restaurants are in our verified local catalog.
+                      Double-check unverified ones before you go.
+                    </p>
+                  )}
                   <div className="trip-details">
                     {formatTripDetails(tripDetails)}
                   </div>
@@ -792,12 +762,17 @@ const formatTripDetails = (details) => {
                     source={formData.initial_destination}
                     destination={formData.final_destination}
                     mustVisitPlaces={mustVisitPlaces}
+                    dayRoutes={dayRoutes}
                   />
                 )}
 
                 {/* Weather Widget */}
                 {formData.final_destination && (
-                  <WeatherWidget destination={formData.final_destination} />
+                  <WeatherWidget
+                    destination={formData.final_destination}
+                    startDate={formData.start_date}
+                    endDate={formData.end_date}
+                  />
                 )}
 
                 {/* Destination Feedbacks Section */}
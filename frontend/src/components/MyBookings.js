@@ -0,0 +1,204 @@
+import React, { useEffect, useState } from 'react';
+import axios from 'axios';
+import { useNavigate } from 'react-router-dom';
+import { useAuth } from '../context/AuthContext';
+
+const STATUS_LABELS = {
+  requested: '⏳ Requested',
+  confirmed: '✅ Confirmed',
+  completed: '🏁 Completed',
+  cancelled: '✖ Cancelled',
+};
+
+const isPaid = (payment) => payment && ['paid', 'partially_refunded', 'refunded', 'duplicate'].includes(payment.status);
+
+// Payments come newest first; keep the latest one for each booking
+const latestPaymentByBooking = (payments) => {
+  const byBooking = {};
+  payments.forEach((payment) => {
+    if (payment.booking_id && !byBooking[payment.booking_id]) {
+      byBooking[payment.booking_id] = payment;
+    }
+  });
+  return byBooking;
+};
+
+function MyBookings() {
+  const navigate = useNavigate();
+  const { isAuthenticated, token, user } = useAuth();
+  const [bookings, setBookings] = useState([]);
+  const [loading, setLoading] = useState(true);
+  const [error, setError] = useState('');
+  const [paymentsByBooking, setPaymentsByBooking] = useState({});
+  const [payingId, setPayingId] = useState(null);
+
+  useEffect(() => {
+    if (!isAuthenticated) {
+      navigate('/login');
+      return;
+    }
+    if (user?.role && user.role !== 'user') {
+      navigate('/');
+      return;
+    }
+
+    const fetchBookings = async () => {
+      try {
+        const headers = { Authorization: `Bearer ${token}` };
+        const [bookingsResponse, paymentsResponse] = await Promise.all([
+          axios.get(`${process.env.REACT_APP_API_URL}/api/bookings`, { headers }),
+          axios.get(`${process.env.REACT_APP_API_URL}/api/payments`, { headers }),
+        ]);
+        setBookings(bookingsResponse.data);
+        setPaymentsByBooking(latestPaymentByBooking(paymentsResponse.data));
+      } catch (err) {
+        setError('Failed to load bookings');
+      } finally {
+        setLoading(false);
+      }
+    };
+    fetchBookings();
+  }, [isAuthenticated, user, navigate, token]);
+
+  const handlePay = async (booking) => {
+    const headers = { Authorization: `Bearer ${token}` };
+    setPayingId(booking.booking_id);
+    setError('');
+    try {
+      const order = await axios.post(
+        `${process.env.REACT_APP_API_URL}/api/payments/orders`,
+        { booking_id: booking.booking_id },
+        { headers }
+      );
+      if (order.data.checkout.gateway !== 'mock') {
+        setError('Online checkout is not available yet for this gateway.');
+        return;
+      }
+      // The mock gateway stands in for the hosted checkout page
+      const checkout = await axios.post(
+        `${process.env.REACT_APP_API_URL}/api/payments/${order.data.payment.payment_id}/mock-checkout`,
+        {},
+        { headers }
+      );
+      const verified = await axios.post(`${process.env.REACT_APP_API_URL}/api/payments/verify`, checkout.data, {
+        headers,
+      });
+      setPaymentsByBooking((prev) => ({ ...prev, [booking.booking_id]: verified.data }));
+    } catch (err) {
+      setError(typeof err.response?.data === 'string' ? err.response.data.trim() : 'Payment failed');
+    } finally {
+      setPayingId(null);
+    }
+  };
+
+  const handleCancel = async (booking) => {
+    let refundNote = '';
+    if (booking.package_id) {
+      try {
+        const quote = await axios.get(
+          `${process.env.REACT_APP_API_URL}/api/packages/${booking.package_id}/refund-quote`,
+          { params: { price: booking.total_price, departure_date: booking.departure_date } }
+        );
+        refundNote = `Under the agency's policy you would get back ₹${Number(quote.data.refund_amount).toLocaleString(
+          'en-IN'
+        )} (${quote.data.refund_percent}%) of what you paid. `;
+      } catch (err) {
+        // No estimate when the package is no longer listed
+      }
+    }
+    const reason = window.prompt(`${refundNote}Cancel this booking? You can add a reason (optional).`);
+    if (reason === null) return;
+
+    try {
+      const response = await axios.post(
+        `${process.env.REACT_APP_API_URL}/api/bookings/${booking.booking_id}/cancel`,
+        { reason },
+        { headers: { Authorization: `Bearer ${token}` } }
+      );
+      setBookings((prev) => prev.map((b) => (b.booking_id === booking.booking_id ? response.data : b)));
+    } catch (err) {
+      setError(typeof err.response?.data === 'string' ? err.response.data.trim() : 'Failed to cancel booking');
+    }
+  };
+
+  return (
+    <div className="packages-container">
+      <div className="packages-header">
+        <div>
+          <h2 className="page-title">My Bookings</h2>
+          <p className="page-subtitle">Track your package bookings and their status</p>
+        </div>
+      </div>
+
+      {error && <p className="error-message">{error}</p>}
+
+      {loading ? (
+        <div className="loading-container">
+          <div className="loading-spinner"></div>
+          <p>Loading bookings...</p>
+        </div>
+      ) : bookings.length === 0 ? (
+        <div className="empty-state">
+          <div className="empty-icon">🧳</div>
+          <h3>No bookings yet</h3>
+          <button className="view-details-btn" onClick={() => navigate('/packages')}>
+            Browse packages →
+          </button>
+        </div>
+      ) : (
+        <div className="bookings-list">
+          {bookings.map((booking) => (
+            <div key={booking.booking_id} className={`booking-card status-${booking.status}`}>
+              <div className="booking-card-header">
+                <h4>{booking.package_title}</h4>
+                <span className="booking-status">{STATUS_LABELS[booking.status] || booking.status}</span>
+              </div>
+              <p>
+                🏢 {booking.agency_name} · 🗓️ Departs {booking.departure_date} · 👥 {booking.num_travelers} traveler
+                {booking.num_travelers > 1 ? 's' : ''}
+              </p>
+              <p className="package-price">
+                ₹{Number(booking.total_price).toLocaleString('en-IN')}
+                {paymentsByBooking[booking.booking_id] && (
+                  <span className={`payment-badge ${paymentsByBooking[booking.booking_id].status}`}>
+                    {paymentsByBooking[booking.booking_id].status.replace('_', ' ')}
+                  </span>
+                )}
+              </p>
+              {paymentsByBooking[booking.booking_id]?.refund_due && (
+                <p className="booking-note">A refund is due for this payment; the agency will send it back.</p>
+              )}
+              <p className="booking-travelers">
+                {booking.travelers.map((t) => `${t.full_name} (${t.age})`).join(', ')}
+              </p>
+              {booking.cancellation_reason && (
+                <p className="booking-note">
+                  Cancelled by {booking.cancelled_by}: {booking.cancellation_reason}
+                </p>
+              )}
+              {(booking.status === 'requested' || booking.status === 'confirmed') && (
+                <div className="card-footer">
+                  <span>Requested {new Date(booking.created_at).toLocaleDateString()}</span>
+                  {!isPaid(paymentsByBooking[booking.booking_id]) && (
+                    <button
+                      className="view-details-btn"
+                      onClick={() => handlePay(booking)}
+                      disabled={payingId === booking.booking_id}
+                    >
+                      {payingId === booking.booking_id ? 'Paying...' : 'Pay now'}
+                    </button>
+                  )}
+                  <button className="secondary-button" onClick={() => handleCancel(booking)}>
+                    Cancel booking
+                  </button>
+                </div>
+              )}
+            </div>
+          ))}
+        </div>
+      )}
+    </div>
+  );
+}
+
+export default MyBookings;
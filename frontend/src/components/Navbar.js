@@ -53,6 +53,11 @@ function Navbar() {
                       🏢 Agencies
                     </Link>
                   </li>
+                  <li className="navbar-item">
+                    <Link to="/admin/moderation" className="navbar-link admin-link">
+                      🔎 Moderation
+                    </Link>
+                  </li>
                   <li className="navbar-item">
                     <Link to="/admin/messages" className="navbar-link admin-link">
                       📬 Messages
@@ -67,6 +72,18 @@ function Navbar() {
                   <li className="navbar-item">
                     <Link to="/agency/packages" className="navbar-link">Manage Packages</Link>
                   </li>
+                  <li className="navbar-item">
+                    <Link to="/agency/bookings" className="navbar-link">Bookings</Link>
+                  </li>
+                  <li className="navbar-item">
+                    <Link to="/agency/promo-codes" className="navbar-link">Promo Codes</Link>
+                  </li>
+                  <li className="navbar-item">
+                    <Link to="/agency/billing" className="navbar-link">Billing</Link>
+                  </li>
+                  <li className="navbar-item">
+                    <Link to="/agency/verification" className="navbar-link">Verification</Link>
+                  </li>
                   <li className="navbar-item">
                     <span className="navbar-user">🏢 {user?.name || user?.username}</span>
                   </li>
@@ -76,6 +93,9 @@ function Navbar() {
                   <li className="navbar-item">
                     <Link to="/saved-trips" className="navbar-link">Saved Trips</Link>
                   </li>
+                  <li className="navbar-item">
+                    <Link to="/bookings" className="navbar-link">My Bookings</Link>
+                  </li>
                   <li className="navbar-item">
                     <Link to="/feedback" className="navbar-link">Feedback</Link>
                   </li>
@@ -2,6 +2,8 @@ import React, { useEffect, useState } from 'react';
 import axios from 'axios';
 import { useParams, useNavigate } from 'react-router-dom';
 import { useAuth } from '../context/AuthContext';
+import { packagePhotoUrl } from '../utils/mediaUrl';
+import BookingForm from './BookingForm';
 
 function PackageDetails() {
   const { id } = useParams();
@@ -20,11 +22,9 @@ function PackageDetails() {
       setLoading(true);
       try {
         const response = await axios.get(
-          `${process.env.REACT_APP_API_URL}/api/packages`
-        );
-        const found = response.data.find(
-          (p) => p.package_id === parseInt(id, 10)
+          `${process.env.REACT_APP_API_URL}/api/packages/${encodeURIComponent(id)}`
         );
+        const found = response.data;
         if (found) {
           setPkg(found);
           // Fetch agency feedbacks
@@ -45,7 +45,7 @@ function PackageDetails() {
           setError('Package not found');
         }
       } catch (err) {
-        setError('Failed to load package details');
+        setError(err.response?.status === 404 ? 'Package not found' : 'Failed to load package details');
       } finally {
         setLoading(false);
       }
@@ -53,8 +53,7 @@ function PackageDetails() {
     fetchPackage();
   }, [id]);
 
-  const getImageUrl = (filename) =>
-    `${process.env.REACT_APP_API_URL}/uploads/packages/${filename}`;
+  const getImageUrl = (index, size = 'large') => packagePhotoUrl(pkg, index, size);
 
   const handlePrevImage = () => {
     if (pkg?.photos?.length) {
@@ -112,7 +111,7 @@ function PackageDetails() {
           <div className="package-gallery">
             <div className="gallery-main">
               <img
-                src={getImageUrl(photos[activeImage])}
+                src={getImageUrl(activeImage)}
                 alt={`${pkg.title} - ${activeImage + 1}`}
                 className="gallery-main-image"
                 onClick={() => setLightboxOpen(true)}
@@ -136,7 +135,7 @@ function PackageDetails() {
                 {photos.map((photo, idx) => (
                   <img
                     key={idx}
-                    src={getImageUrl(photo)}
+                    src={getImageUrl(idx, 'thumb')}
                     alt={`Thumbnail ${idx + 1}`}
                     className={`gallery-thumb ${idx === activeImage ? 'active' : ''}`}
                     onClick={() => setActiveImage(idx)}
@@ -163,7 +162,7 @@ function PackageDetails() {
           </div>
           <div className="package-price-large">
             ₹{Number(pkg.price).toLocaleString('en-IN')}
-            <span className="price-note">per person</span>
+            <span className="price-note">{pkg.pricing_rules?.mode === 'per_group' ? 'per group' : 'per person'}</span>
           </div>
         </div>
 
@@ -236,6 +235,61 @@ function PackageDetails() {
           )}
         </div>
 
+        {/* Pricing Section */}
+        {pkg.pricing_rules &&
+          (pkg.pricing_rules.child ||
+            pkg.pricing_rules.senior ||
+            pkg.pricing_rules.seasons?.length > 0 ||
+            pkg.pricing_rules.group_discounts?.length > 0) && (
+            <div className="cancellation-policy-section">
+              <h3 className="section-title">🏷️ Pricing</h3>
+              <ul className="policy-tiers">
+                {pkg.pricing_rules.child && (
+                  <li>
+                    Children up to {pkg.pricing_rules.child.max_age}: <strong>{pkg.pricing_rules.child.price_percent}%</strong> of
+                    the adult price
+                  </li>
+                )}
+                {pkg.pricing_rules.senior && (
+                  <li>
+                    Seniors {pkg.pricing_rules.senior.min_age}+: <strong>{pkg.pricing_rules.senior.price_percent}%</strong> of the
+                    adult price
+                  </li>
+                )}
+                {(pkg.pricing_rules.group_discounts || []).map((tier) => (
+                  <li key={tier.min_travelers}>
+                    Groups of {tier.min_travelers}+: <strong>{tier.percent}% off</strong>
+                  </li>
+                ))}
+                {(pkg.pricing_rules.seasons || []).map((season) => (
+                  <li key={season.name}>
+                    {season.name} ({season.start_date} to {season.end_date}): <strong>×{season.multiplier}</strong>
+                  </li>
+                ))}
+              </ul>
+            </div>
+          )}
+
+        {/* Cancellation Policy Section */}
+        <div className="cancellation-policy-section">
+          <h3 className="section-title">↩️ Cancellation Policy</h3>
+          {pkg.cancellation_policy && pkg.cancellation_policy.length > 0 ? (
+            <ul className="policy-tiers">
+              {pkg.cancellation_policy.map((tier) => (
+                <li key={tier.min_days_before}>
+                  {tier.min_days_before === 0
+                    ? 'Up to the departure day'
+                    : `${tier.min_days_before}+ days before departure`}
+                  : <strong>{tier.refund_percent}% refund</strong>
+                </li>
+              ))}
+              <li>Later cancellations: no refund</li>
+            </ul>
+          ) : (
+            <p className="booking-note">The agency hasn't published a cancellation policy. Contact them for details.</p>
+          )}
+        </div>
+
         {/* Contact Details Section */}
         <div className="contact-details-section">
           <h3 className="section-title">📞 Contact Details</h3>
@@ -276,6 +330,8 @@ function PackageDetails() {
           </div>
         </div>
 
+        <BookingForm pkg={pkg} />
+
         {/* Agency Feedbacks Section */}
         {agencyFeedbacks.length > 0 && (
           <div className="agency-feedbacks-section">
@@ -343,7 +399,7 @@ function PackageDetails() {
             ‹
           </button>
           <img
-            src={getImageUrl(photos[activeImage])}
+            src={getImageUrl(activeImage)}
             alt={`${pkg.title} - ${activeImage + 1}`}
             className="lightbox-image"
             onClick={(e) => e.stopPropagation()}
@@ -0,0 +1,342 @@
+import React, { useState, useEffect } from 'react';
+import { useNavigate } from 'react-router-dom';
+import { useAuth } from '../context/AuthContext';
+import axios from 'axios';
+
+// Decisions an admin can make from each moderation status
+const moderationActions = {
+  pending_review: ['approve', 'request_changes', 'reject'],
+  changes_requested: ['approve', 'reject'],
+  approved: ['request_changes', 'reject'],
+  rejected: [],
+};
+
+const actionLabels = { approve: 'Approve', request_changes: 'Request Changes', reject: 'Reject' };
+
+export const moderationLabels = {
+  pending_review: 'In review',
+  approved: 'Approved',
+  changes_requested: 'Changes requested',
+  rejected: 'Rejected',
+};
+
+function PackageModeration() {
+  const [packages, setPackages] = useState([]);
+  const [loading, setLoading] = useState(true);
+  const [error, setError] = useState('');
+  const [statusFilter, setStatusFilter] = useState('pending_review');
+  const [flaggedOnly, setFlaggedOnly] = useState(false);
+  const [reviewing, setReviewing] = useState(null);
+  const [reviewNotes, setReviewNotes] = useState('');
+  const [reviewError, setReviewError] = useState('');
+  const [bannedWords, setBannedWords] = useState([]);
+  const [newWords, setNewWords] = useState('');
+
+  const { token, user } = useAuth();
+  const navigate = useNavigate();
+  const baseUrl = `${process.env.REACT_APP_API_URL}/api/admin/moderation`;
+
+  useEffect(() => {
+    if (!token || user?.role !== 'admin') {
+      navigate('/');
+      return;
+    }
+    fetchQueue();
+  }, [token, user, navigate, statusFilter, flaggedOnly]);
+
+  useEffect(() => {
+    if (token && user?.role === 'admin') {
+      fetchBannedWords();
+    }
+  }, [token, user]);
+
+  const fetchQueue = async () => {
+    try {
+      const response = await axios.get(`${baseUrl}/packages`, {
+        headers: { Authorization: `Bearer ${token}` },
+        params: flaggedOnly ? { status: statusFilter, flagged: 'true' } : { status: statusFilter }
+      });
+      setPackages(response.data || []);
+    } catch (err) {
+      setError(err.response?.data?.error || 'Failed to load moderation queue');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const fetchBannedWords = async () => {
+    try {
+      const response = await axios.get(`${baseUrl}/banned-words`, {
+        headers: { Authorization: `Bearer ${token}` }
+      });
+      setBannedWords(response.data || []);
+    } catch (err) {
+      setError('Failed to load banned words');
+    }
+  };
+
+  const openReview = async (packageId) => {
+    try {
+      const response = await axios.get(`${baseUrl}/packages/${packageId}`, {
+        headers: { Authorization: `Bearer ${token}` }
+      });
+      setReviewing(response.data);
+      setReviewNotes('');
+      setReviewError('');
+    } catch (err) {
+      alert('Failed to load package');
+    }
+  };
+
+  const handleRecheck = async () => {
+    try {
+      const response = await axios.post(`${baseUrl}/packages/${reviewing.package_id}/recheck`, {}, {
+        headers: { Authorization: `Bearer ${token}` }
+      });
+      setReviewing(response.data);
+      fetchQueue();
+    } catch (err) {
+      setReviewError(err.response?.data?.error || 'Failed to run checks');
+    }
+  };
+
+  const handleDecision = async (action) => {
+    if (action !== 'approve' && !reviewNotes.trim()) {
+      setReviewError('Add notes telling the agency what to fix');
+      return;
+    }
+    try {
+      const response = await axios.put(
+        `${baseUrl}/packages/${reviewing.package_id}`,
+        { action, notes: reviewNotes },
+        {
+          headers: { Authorization: `Bearer ${token}` }
+        }
+      );
+      setReviewing(response.data);
+      setReviewNotes('');
+      setReviewError('');
+      fetchQueue();
+    } catch (err) {
+      setReviewError(err.response?.data?.error || 'Failed to update package');
+    }
+  };
+
+  const handleAddWords = async (e) => {
+    e.preventDefault();
+    const words = newWords.split(',').map(w => w.trim()).filter(Boolean);
+    if (words.length === 0) return;
+    try {
+      const response = await axios.post(`${baseUrl}/banned-words`, { words }, {
+        headers: { Authorization: `Bearer ${token}` }
+      });
+      setBannedWords(response.data || []);
+      setNewWords('');
+    } catch (err) {
+      setError(err.response?.data?.error || 'Failed to add banned words');
+    }
+  };
+
+  const handleRemoveWord = async (word) => {
+    try {
+      const response = await axios.delete(`${baseUrl}/banned-words/${encodeURIComponent(word)}`, {
+        headers: { Authorization: `Bearer ${token}` }
+      });
+      setBannedWords(response.data || []);
+    } catch (err) {
+      setError(err.response?.data?.error || 'Failed to remove banned word');
+    }
+  };
+
+  if (loading) {
+    return (
+      <div className="loading-container">
+        <div className="loading-spinner"></div>
+        <p>Loading moderation queue...</p>
+      </div>
+    );
+  }
+
+  return (
+    <div className="admin-container">
+      <div className="admin-header">
+        <h1>🔎 Package Moderation</h1>
+      </div>
+
+      {error && <p className="error-message">{error}</p>}
+
+      {/* Review Modal */}
+      {reviewing && (
+        <div className="packages-modal">
+          <div className="packages-modal-content">
+            <div className="packages-modal-header">
+              <h2>🔎 {reviewing.title}</h2>
+              <button className="close-modal-btn" onClick={() => setReviewing(null)}>×</button>
+            </div>
+            <p>
+              <span className={`moderation-status ${reviewing.moderation_status}`}>
+                {moderationLabels[reviewing.moderation_status]}
+              </span>{' '}
+              {reviewing.agency_name}{' '}
+              <span className={`agency-status ${reviewing.agency_status}`}>{reviewing.agency_status}</span>
+              {reviewing.is_active ? '' : ' · hidden by the agency'}
+            </p>
+            <p className="package-route">
+              📍 {reviewing.initial_destination} → {reviewing.location}
+              {reviewing.locations.length > 0 ? ` (${reviewing.locations.join(', ')})` : ''}
+            </p>
+            <div className="package-details">
+              <span>⏱ {reviewing.duration_days} days</span>
+              <span>👥 {reviewing.num_travelers} travelers</span>
+              <span>🚌 {reviewing.transport_mode}</span>
+              <span>
+                💰 ₹{Number(reviewing.price).toLocaleString('en-IN')}
+                {reviewing.pricing_mode === 'per_group' ? ' per group' : ' per person'}
+              </span>
+              <span>🖼 {reviewing.photo_count} photos</span>
+            </div>
+            <p>{reviewing.description}</p>
+            {reviewing.moderation_notes && <p><strong>Last notes:</strong> {reviewing.moderation_notes}</p>}
+
+            <h4>Automated checks</h4>
+            {reviewing.checks_pending ? (
+              <p className="no-packages">Checks are still running.</p>
+            ) : reviewing.flags.length === 0 ? (
+              <p className="no-packages">No problems found.</p>
+            ) : (
+              <ul className="moderation-flags">
+                {reviewing.flags.map(flag => (
+                  <li key={flag.code}>⚠️ {flag.message}</li>
+                ))}
+              </ul>
+            )}
+            <button className="secondary-button" onClick={handleRecheck}>Run checks again</button>
+
+            {moderationActions[reviewing.moderation_status].length > 0 && (
+              <>
+                <div className="form-group">
+                  <label>Notes for the agency (required to reject or request changes)</label>
+                  <textarea value={reviewNotes} onChange={(e) => setReviewNotes(e.target.value)} rows="3" />
+                </div>
+                {reviewError && <p className="error-message">{reviewError}</p>}
+                <div className="form-actions">
+                  {moderationActions[reviewing.moderation_status].map(action => (
+                    <button
+                      key={action}
+                      className={action === 'approve' ? 'save-button' : 'cancel-button'}
+                      onClick={() => handleDecision(action)}
+                    >
+                      {actionLabels[action]}
+                    </button>
+                  ))}
+                </div>
+              </>
+            )}
+
+            {reviewing.reviews.length > 0 && (
+              <div className="verification-history">
+                <h4>History</h4>
+                <ul>
+                  {reviewing.reviews.map(review => (
+                    <li key={review.review_id}>
+                      {new Date(review.created_at).toLocaleDateString()}: {moderationLabels[review.from_status]} →{' '}
+                      {moderationLabels[review.to_status]} by {review.reviewed_by}
+                      {review.notes ? ` (${review.notes})` : ''}
+                    </li>
+                  ))}
+                </ul>
+              </div>
+            )}
+          </div>
+        </div>
+      )}
+
+      {/* Queue */}
+      <div className="users-table-card">
+        <div className="agency-table-header">
+          <h2>{moderationLabels[statusFilter]} ({packages.length})</h2>
+          <div className="moderation-filters">
+            <label>
+              <input type="checkbox" checked={flaggedOnly} onChange={(e) => setFlaggedOnly(e.target.checked)} />
+              Flagged only
+            </label>
+            <select value={statusFilter} onChange={(e) => setStatusFilter(e.target.value)}>
+              {Object.entries(moderationLabels).map(([value, label]) => (
+                <option key={value} value={value}>{label}</option>
+              ))}
+            </select>
+          </div>
+        </div>
+        <div className="table-responsive">
+          <table className="users-table">
+            <thead>
+              <tr>
+                <th>Package</th>
+                <th>Agency</th>
+                <th>Route</th>
+                <th>Price</th>
+                <th>Flags</th>
+                <th>Submitted</th>
+                <th>Actions</th>
+              </tr>
+            </thead>
+            <tbody>
+              {packages.length === 0 ? (
+                <tr>
+                  <td colSpan="7" className="no-data">No packages found</td>
+                </tr>
+              ) : (
+                packages.map(pkg => (
+                  <tr key={pkg.package_id}>
+                    <td><strong>{pkg.title}</strong></td>
+                    <td>{pkg.agency_name}</td>
+                    <td>{pkg.initial_destination} → {pkg.location}</td>
+                    <td>₹{Number(pkg.price).toLocaleString('en-IN')}</td>
+                    <td>
+                      {pkg.checks_pending ? '…' : pkg.flags.length === 0 ? '-' : (
+                        <span className="moderation-flag-count" title={pkg.flags.map(f => f.message).join('\n')}>
+                          ⚠️ {pkg.flags.length}
+                        </span>
+                      )}
+                    </td>
+                    <td>{pkg.submitted_at ? new Date(pkg.submitted_at).toLocaleDateString() : '-'}</td>
+                    <td className="actions-cell">
+                      <button onClick={() => openReview(pkg.package_id)} className="view-btn" title="Review package">
+                        🔎
+                      </button>
+                    </td>
+                  </tr>
+                ))
+              )}
+            </tbody>
+          </table>
+        </div>
+      </div>
+
+      {/* Banned Words */}
+      <div className="users-table-card">
+        <h2>Banned Words ({bannedWords.length})</h2>
+        <p className="page-subtitle">Packages using these words or phrases are flagged for review.</p>
+        <form className="moderation-words-form" onSubmit={handleAddWords}>
+          <input
+            type="text"
+            value={newWords}
+            onChange={(e) => setNewWords(e.target.value)}
+            placeholder="Words or phrases, separated by commas"
+          />
+          <button type="submit" className="save-button">Add</button>
+        </form>
+        <div className="moderation-words">
+          {bannedWords.map(word => (
+            <span key={word} className="moderation-word">
+              {word}
+              <button onClick={() => handleRemoveWord(word)} title="Remove">×</button>
+            </span>
+          ))}
+        </div>
+      </div>
+    </div>
+  );
+}
+
+export default PackageModeration;
@@ -1,22 +1,80 @@
-import React, { useEffect, useMemo, useState } from 'react';
+import React, { useCallback, useEffect, useState } from 'react';
 import axios from 'axios';
 import { useNavigate } from 'react-router-dom';
 import { useAuth } from '../context/AuthContext';
+import { packagePhotoUrl } from '../utils/mediaUrl';
+import { useDistricts } from '../utils/districts';
+
+const PAGE_SIZE = 12;
+
+const EMPTY_FILTERS = {
+  district: '',
+  min_price: '',
+  max_price: '',
+  max_days: '',
+  travelers: '',
+  transport_mode: '',
+  sort: 'newest',
+};
 
 function TravelPackages() {
   const navigate = useNavigate();
   const [packages, setPackages] = useState([]);
+  const [total, setTotal] = useState(0);
+  const [nextCursor, setNextCursor] = useState(null);
+  const [suggestions, setSuggestions] = useState([]);
   const [loading, setLoading] = useState(true);
+  const [loadingMore, setLoadingMore] = useState(false);
   const [search, setSearch] = useState('');
+  const [debouncedSearch, setDebouncedSearch] = useState('');
+  const [filters, setFilters] = useState(EMPTY_FILTERS);
   const [error, setError] = useState('');
   const { user } = useAuth();
+  const districts = useDistricts();
+
+  useEffect(() => {
+    const timer = setTimeout(() => setDebouncedSearch(search.trim()), 300);
+    return () => clearTimeout(timer);
+  }, [search]);
+
+  // A search query switches to the ranked full-text endpoint, which pages
+  // by offset; the plain listing pages by cursor
+  const fetchPage = useCallback(async (cursor) => {
+    const params = { limit: PAGE_SIZE };
+    ['district', 'min_price', 'max_price', 'max_days', 'travelers', 'transport_mode'].forEach((key) => {
+      if (filters[key] !== '') params[key] = filters[key];
+    });
+
+    if (debouncedSearch) {
+      params.q = debouncedSearch;
+      params.offset = cursor || 0;
+      const response = await axios.get(`${process.env.REACT_APP_API_URL}/api/packages/search`, { params });
+      const data = response.data;
+      const nextOffset = data.offset + data.results.length;
+      return {
+        packages: data.results,
+        total: data.total,
+        next_cursor: nextOffset < data.total ? nextOffset : null,
+        suggestions: data.suggestions || [],
+      };
+    }
+
+    params.sort = filters.sort;
+    if (cursor) params.cursor = cursor;
+    const response = await axios.get(`${process.env.REACT_APP_API_URL}/api/packages`, { params });
+    return { ...response.data, suggestions: [] };
+  }, [filters, debouncedSearch]);
 
   useEffect(() => {
-    const fetchPackages = async () => {
+    const loadFirstPage = async () => {
       setLoading(true);
+      setError('');
       try {
-        const response = await axios.get(`${process.env.REACT_APP_API_URL}/api/packages`);
-        setPackages(response.data);
+        const data = await fetchPage(null);
+        setPackages(data.packages || []);
+        setTotal(data.total || 0);
+        setNextCursor(data.next_cursor || null);
+        setSuggestions(data.suggestions);
       } catch (err) {
         setError('Unable to load travel packages right now.');
       } finally {
@@ -24,17 +82,28 @@ function TravelPackages() {
       }
     };
 
-    fetchPackages();
-  }, []);
+    loadFirstPage();
+  }, [fetchPage]);
+
+  const loadMore = async () => {
+    if (!nextCursor) return;
+    setLoadingMore(true);
+    try {
+      const data = await fetchPage(nextCursor);
+      setPackages((prev) => [...prev, ...(data.packages || [])]);
+      setTotal(data.total || 0);
+      setNextCursor(data.next_cursor || null);
+    } catch (err) {
+      setError('Unable to load more packages right now.');
+    } finally {
+      setLoadingMore(false);
+    }
+  };
 
-  const filteredPackages = useMemo(() => {
-    if (!search.trim()) return packages;
-    return packages.filter((pkg) =>
-      `${pkg.title} ${pkg.location} ${pkg.initial_destination} ${pkg.agency_name}`
-        .toLowerCase()
-        .includes(search.toLowerCase())
-    );
-  }, [packages, search]);
+  const handleFilterChange = (e) => {
+    const { name, value } = e.target;
+    setFilters((prev) => ({ ...prev, [name]: value }));
+  };
 
   return (
     <div className="packages-container">
@@ -48,34 +117,108 @@ function TravelPackages() {
         <input
           type="text"
           className="search-input"
-          placeholder="Search by destination, agency, or trip name"
+          placeholder="Search packages, e.g. Jog falls or Coorg coffee"
           value={search}
           onChange={(e) => setSearch(e.target.value)}
         />
       </div>
 
+      <div className="package-filters">
+        <select name="district" value={filters.district} onChange={handleFilterChange}>
+          <option value="">All districts</option>
+          {districts.map((d) => (
+            <option key={d.name} value={d.name}>{d.name}</option>
+          ))}
+        </select>
+        <input
+          type="number"
+          name="min_price"
+          min="0"
+          placeholder="Min ₹"
+          value={filters.min_price}
+          onChange={handleFilterChange}
+        />
+        <input
+          type="number"
+          name="max_price"
+          min="0"
+          placeholder="Max ₹"
+          value={filters.max_price}
+          onChange={handleFilterChange}
+        />
+        <input
+          type="number"
+          name="max_days"
+          min="1"
+          placeholder="Max days"
+          value={filters.max_days}
+          onChange={handleFilterChange}
+        />
+        <input
+          type="number"
+          name="travelers"
+          min="1"
+          placeholder="Travelers"
+          value={filters.travelers}
+          onChange={handleFilterChange}
+        />
+        <select name="transport_mode" value={filters.transport_mode} onChange={handleFilterChange}>
+          <option value="">Any transport</option>
+          <option value="flight">✈️ Flight</option>
+          <option value="train">🚆 Train</option>
+          <option value="bus">🚌 Bus</option>
+          <option value="car">🚗 Car</option>
+          <option value="cruise">🛳️ Cruise</option>
+          <option value="mixed">🔀 Mixed</option>
+        </select>
+        <select name="sort" value={filters.sort} onChange={handleFilterChange}>
+          <option value="newest">Newest</option>
+          <option value="price_asc">Price: low to high</option>
+          <option value="price_desc">Price: high to low</option>
+          <option value="duration_asc">Shortest first</option>
+          <option value="duration_desc">Longest first</option>
+          <option value="rating">Top rated agencies</option>
+        </select>
+        <button type="button" className="secondary-button" onClick={() => setFilters(EMPTY_FILTERS)}>
+          Reset
+        </button>
+      </div>
+
       {error && <p className="error-message">{error}</p>}
 
+      {suggestions.length > 0 && (
+        <p className="search-suggestions">
+          Did you mean:{' '}
+          {suggestions.map((term) => (
+            <button key={term} type="button" className="suggestion-chip" onClick={() => setSearch(term)}>
+              {term}
+            </button>
+          ))}
+        </p>
+      )}
+
       {loading ? (
         <div className="loading-container">
           <div className="loading-spinner"></div>
           <p>Loading packages...</p>
         </div>
-      ) : filteredPackages.length === 0 ? (
+      ) : packages.length === 0 ? (
         <div className="empty-state">
           <div className="empty-icon">🧳</div>
           <h3>No packages found</h3>
-          <p>Try adjusting your search or check back later.</p>
+          <p>Try adjusting your search or filters, or check back later.</p>
         </div>
       ) : (
+        <>
+        <p className="packages-count">Showing {packages.length} of {total} package{total === 1 ? '' : 's'}</p>
         <div className="packages-grid">
-          {filteredPackages.map((pkg) => (
+          {packages.map((pkg) => (
             <div key={pkg.package_id} className="package-card">
               {/* Photo Preview */}
               {pkg.photos && pkg.photos.length > 0 && (
                 <div className="card-image-preview">
                   <img
-                    src={`${process.env.REACT_APP_API_URL}/uploads/packages/${pkg.photos[0]}`}
+                    src={packagePhotoUrl(pkg, 0, 'medium')}
                     alt={pkg.title}
                     className="card-preview-img"
                   />
@@ -85,8 +228,15 @@ function TravelPackages() {
                 </div>
               )}
               <div className="package-card-header">
-                <h4>{pkg.title}</h4>
-                <span className="agency-badge">{pkg.agency_name}</span>
+                {pkg.title_highlight ? (
+                  <h4 dangerouslySetInnerHTML={{ __html: pkg.title_highlight }} />
+                ) : (
+                  <h4>{pkg.title}</h4>
+                )}
+                <span className="agency-badge">
+                  {pkg.agency_name}
+                  {pkg.agency_rating != null && ` · ⭐ ${Number(pkg.agency_rating).toFixed(1)}`}
+                </span>
               </div>
               <p className="package-location">
                 📍 {pkg.initial_destination} → {pkg.location}
@@ -106,7 +256,11 @@ function TravelPackages() {
               </p>
               <p className="package-duration">🚉 Mode: {pkg.transport_mode}</p>
               <p className="package-price">₹{Number(pkg.price).toLocaleString('en-IN')}</p>
-              <p className="package-description">{pkg.description}</p>
+              {pkg.snippet ? (
+                <p className="package-description" dangerouslySetInnerHTML={{ __html: pkg.snippet }} />
+              ) : (
+                <p className="package-description">{pkg.description}</p>
+              )}
               <div className="card-footer">
                 <span>Offered by {pkg.agency_name}</span>
                 <button
@@ -119,6 +273,14 @@ function TravelPackages() {
             </div>
           ))}
         </div>
+        {nextCursor && (
+          <div className="load-more">
+            <button type="button" className="view-details-btn" onClick={loadMore} disabled={loadingMore}>
+              {loadingMore ? 'Loading...' : 'Load more packages'}
+            </button>
+          </div>
+        )}
+        </>
       )}
     </div>
   );
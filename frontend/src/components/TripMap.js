@@ -101,6 +101,10 @@ const FAMOUS_PLACES = {
   "madikeri": { lat: 12.4244, lng: 75.7382 },
 };
 
+// One colour per itinerary day, reused after the sixth day
+const DAY_COLORS = ['#f59e0b', '#10b981', '#ec4899', '#0ea5e9', '#8b5cf6', '#f97316'];
+const dayColor = (day) => DAY_COLORS[(day - 1) % DAY_COLORS.length];
+
 // Component to fit map bounds
 function MapBoundsHandler({ bounds }) {
   const map = useMap();
@@ -115,7 +119,7 @@ function MapBoundsHandler({ bounds }) {
   return null;
 }
 
-function TripMap({ source, destination, mustVisitPlaces }) {
+function TripMap({ source, destination, mustVisitPlaces, dayRoutes = [] }) {
   const [routeCoordinates, setRouteCoordinates] = useState([]);
   const [loading, setLoading] = useState(true);
 
@@ -180,10 +184,32 @@ function TripMap({ source, destination, mustVisitPlaces }) {
     return markersList;
   }, [source, destination, mustVisitPlaces]);
 
+  // Optimised day routes: numbered stops joined from the day's start point
+  const dayLayers = useMemo(() => {
+    return dayRoutes
+      .filter(route => route.stops && route.stops.length > 0)
+      .map(route => ({
+        day: route.day,
+        color: dayColor(route.day),
+        path: [
+          [route.start.latitude, route.start.longitude],
+          ...route.stops.map(stop => [stop.latitude, stop.longitude])
+        ],
+        markers: route.stops.map(stop => ({
+          position: [stop.latitude, stop.longitude],
+          stop,
+          icon: createCustomIcon(dayColor(route.day), `${route.day}.${stop.order}`)
+        }))
+      }));
+  }, [dayRoutes]);
+
   // Calculate bounds
   const bounds = useMemo(() => {
-    return markers.map(m => m.position);
-  }, [markers]);
+    return [
+      ...markers.map(m => m.position),
+      ...dayLayers.flatMap(layer => layer.path)
+    ];
+  }, [markers, dayLayers]);
 
   // Fetch route
   useEffect(() => {
@@ -265,6 +291,11 @@ function TripMap({ source, destination, mustVisitPlaces }) {
         <span className="legend-item">
           <span className="legend-dot place"></span> Must-Visit Places
         </span>
+        {dayLayers.map(layer => (
+          <span className="legend-item" key={`legend-day-${layer.day}`}>
+            <span className="legend-dot" style={{ background: layer.color }}></span> Day {layer.day}
+          </span>
+        ))}
       </div>
       <div className="map-wrapper">
         <MapContainer
@@ -293,6 +324,34 @@ function TripMap({ source, destination, mustVisitPlaces }) {
             />
           )}
           
+          {/* Day routes */}
+          {dayLayers.map(layer => (
+            <Polyline
+              key={`day-route-${layer.day}`}
+              positions={layer.path}
+              pathOptions={{ color: layer.color, weight: 4, opacity: 0.9 }}
+            />
+          ))}
+          {dayLayers.flatMap(layer => layer.markers.map(marker => (
+            <Marker
+              key={`day-${layer.day}-stop-${marker.stop.order}`}
+              position={marker.position}
+              icon={marker.icon}
+            >
+              <Popup>
+                <div style={{ textAlign: 'center', padding: '5px' }}>
+                  <strong style={{ fontSize: '14px' }}>{marker.stop.name}</strong>
+                  <p style={{ fontSize: '12px', color: '#666', margin: '5px 0' }}>
+                    Day {layer.day}, stop {marker.stop.order}: {marker.stop.start}–{marker.stop.depart}
+                  </p>
+                  {marker.stop.warning && (
+                    <p style={{ fontSize: '12px', color: '#dc2626', margin: '5px 0' }}>{marker.stop.warning}</p>
+                  )}
+                </div>
+              </Popup>
+            </Marker>
+          )))}
+
           {/* Markers */}
           {markers.map((marker, index) => (
             <Marker
@@ -319,6 +378,33 @@ function TripMap({ source, destination, mustVisitPlaces }) {
           ))}
         </MapContainer>
       </div>
+      {dayLayers.length > 0 && (
+        <div className="day-routes">
+          {dayRoutes.filter(route => route.stops && route.stops.length > 0).map(route => (
+            <div className="day-route" key={`day-plan-${route.day}`} style={{ borderColor: dayColor(route.day) }}>
+              <h4>
+                Day {route.day}{route.date && ` · ${route.date}`}
+                <span className="day-route-total">
+                  {route.total_distance_km} km · {route.total_travel_minutes} min driving
+                  {route.source === 'haversine' && ' (estimated)'}
+                </span>
+              </h4>
+              <ol>
+                {route.stops.map(stop => (
+                  <li key={stop.order}>
+                    <span className="day-route-time">{stop.start}–{stop.depart}</span> {stop.name}
+                    <span className="day-route-leg">
+                      {stop.leg_distance_km} km, {stop.leg_minutes} min
+                      {stop.wait_minutes > 0 && `, wait ${stop.wait_minutes} min`}
+                    </span>
+                    {stop.warning && <span className="day-route-warning">⚠️ {stop.warning}</span>}
+                  </li>
+                ))}
+              </ol>
+            </div>
+          ))}
+        </div>
+      )}
       <p className="map-note">
         📌 Click on markers to see details. The route shows the path through all must-visit places.
       </p>
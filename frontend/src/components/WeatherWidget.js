@@ -1,7 +1,7 @@
 import React, { useState, useEffect } from 'react';
 import axios from 'axios';
 
-function WeatherWidget({ destination }) {
+function WeatherWidget({ destination, startDate, endDate }) {
   const [weather, setWeather] = useState(null);
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState('');
@@ -10,15 +10,17 @@ function WeatherWidget({ destination }) {
     if (!destination) return;
     
     fetchWeather();
-  }, [destination]);
+  }, [destination, startDate, endDate]);
 
   const fetchWeather = async () => {
     setLoading(true);
     setError('');
     
     try {
+      const params = startDate ? { start_date: startDate, end_date: endDate || startDate } : {};
       const response = await axios.get(
-        `${process.env.REACT_APP_API_URL}/api/weather/${encodeURIComponent(destination)}`
+        `${process.env.REACT_APP_API_URL}/api/weather/${encodeURIComponent(destination)}`,
+        { params }
       );
       setWeather(response.data);
     } catch (err) {
@@ -48,10 +50,44 @@ function WeatherWidget({ destination }) {
   return (
     <div className="weather-widget">
       <h3 className="weather-title">
-        🌤️ Weather Forecast for {weather.city}, {weather.country}
+        🌤️ Weather Forecast for {weather.city ? `${weather.city}, ${weather.country}` : weather.destination}
       </h3>
       
       <div className="weather-forecasts">
+        {weather.days && weather.days.map((day, index) => (
+          day.source === 'forecast' ? (
+            <div key={index} className="weather-card">
+              <div className="weather-date">{new Date(day.date).toLocaleDateString('en-US', { weekday: 'short', month: 'short', day: 'numeric' })}</div>
+              <img
+                src={`https://openweathermap.org/img/wn/${day.forecast.icon}@2x.png`}
+                alt={day.forecast.description}
+                className="weather-icon"
+              />
+              <div className="weather-temp">{Math.round(day.forecast.temp)}°C</div>
+              <div className="weather-desc">{day.forecast.description}</div>
+              <div className="weather-details">
+                <span>💧 {day.forecast.humidity}%</span>
+                <span>🌧️ {Math.round(day.forecast.rain_chance)}%</span>
+              </div>
+              <div className="weather-minmax">
+                {Math.round(day.forecast.temp_min)}° / {Math.round(day.forecast.temp_max)}°
+              </div>
+            </div>
+          ) : (
+            <div key={index} className="weather-card weather-card-normal">
+              <div className="weather-date">{new Date(day.date).toLocaleDateString('en-US', { weekday: 'short', month: 'short', day: 'numeric' })}</div>
+              <div className="weather-normal-label">Typical conditions</div>
+              <div className="weather-temp">{Math.round(day.normal.avg_high_c)}°C</div>
+              <div className="weather-desc">{day.normal.monsoon ? 'Monsoon season' : 'Climate average'}</div>
+              <div className="weather-details">
+                <span>🌧️ {Math.round(day.normal.rainfall_mm)} mm/month</span>
+              </div>
+              <div className="weather-minmax">
+                {Math.round(day.normal.avg_low_c)}° / {Math.round(day.normal.avg_high_c)}°
+              </div>
+            </div>
+          )
+        ))}
         {weather.forecasts && weather.forecasts.map((forecast, index) => (
           <div key={index} className="weather-card">
             <div className="weather-date">{new Date(forecast.date).toLocaleDateString('en-US', { weekday: 'short', month: 'short', day: 'numeric' })}</div>
@@ -76,6 +112,12 @@ function WeatherWidget({ destination }) {
       <div className="weather-tips">
         <p><strong>💡 Weather Tips:</strong></p>
         <ul>
+          {weather.days && weather.days.some(day => day.source !== 'forecast') && (
+            <li>Cards marked "Typical conditions" are climate averages, not forecasts</li>
+          )}
+          {weather.normals_missing && (
+            <li>Typical conditions aren't available for this destination, so later dates have no card</li>
+          )}
           <li>Check weather updates closer to your travel dates</li>
           <li>Pack accordingly based on the forecast</li>
           <li>Consider weather when planning outdoor activities</li>
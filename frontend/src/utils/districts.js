@@ -0,0 +1,69 @@
+import { useEffect, useState } from 'react';
+import axios from 'axios';
+
+// Bundled copy of the districts table, used until /api/districts answers
+// or when it can't be reached. Coordinates are (longitude, latitude) for
+// OpenRouteService.
+const FALLBACK_DISTRICTS = [
+  { name: "Bagalkot", longitude: 75.6615, latitude: 16.1691 },
+  { name: "Ballari (Bellary)", longitude: 76.9214, latitude: 15.1394 },
+  { name: "Belagavi (Belgaum)", longitude: 74.4977, latitude: 15.8497 },
+  { name: "Bengaluru Rural", longitude: 77.3910, latitude: 13.2257 },
+  { name: "Bengaluru Urban", longitude: 77.5946, latitude: 12.9716 },
+  { name: "Bidar", longitude: 77.5199, latitude: 17.9104 },
+  { name: "Chamarajanagar", longitude: 76.9398, latitude: 11.9261 },
+  { name: "Chikkaballapur", longitude: 77.7278, latitude: 13.4355 },
+  { name: "Chikkamagaluru", longitude: 75.7747, latitude: 13.3161 },
+  { name: "Chitradurga", longitude: 76.3980, latitude: 14.2251 },
+  { name: "Dakshina Kannada", longitude: 74.8560, latitude: 12.9141 },
+  { name: "Davanagere", longitude: 75.9218, latitude: 14.4644 },
+  { name: "Dharwad", longitude: 75.0078, latitude: 15.4589 },
+  { name: "Gadag", longitude: 75.6290, latitude: 15.4166 },
+  { name: "Hassan", longitude: 76.0996, latitude: 13.0068 },
+  { name: "Haveri", longitude: 75.3990, latitude: 14.7951 },
+  { name: "Kalaburagi (Gulbarga)", longitude: 76.8343, latitude: 17.3297 },
+  { name: "Kodagu (Coorg)", longitude: 75.7382, latitude: 12.4244 },
+  { name: "Kolar", longitude: 78.1290, latitude: 13.1360 },
+  { name: "Koppal", longitude: 76.1548, latitude: 15.3550 },
+  { name: "Mandya", longitude: 76.8958, latitude: 12.5218 },
+  { name: "Mysuru (Mysore)", longitude: 76.6394, latitude: 12.2958 },
+  { name: "Raichur", longitude: 77.3566, latitude: 16.2120 },
+  { name: "Ramanagara", longitude: 77.2826, latitude: 12.7159 },
+  { name: "Shivamogga (Shimoga)", longitude: 75.5681, latitude: 13.9299 },
+  { name: "Tumakuru (Tumkur)", longitude: 77.1010, latitude: 13.3379 },
+  { name: "Udupi", longitude: 74.7421, latitude: 13.3409 },
+  { name: "Uttara Kannada (Karwar)", longitude: 74.1240, latitude: 14.8182 },
+  { name: "Vijayapura (Bijapur)", longitude: 75.7100, latitude: 16.8302 },
+  { name: "Yadgir", longitude: 77.1383, latitude: 16.7700 },
+  { name: "Vijayanagara", longitude: 76.4700, latitude: 15.3350 }
+];
+
+let cachedDistricts = null;
+
+// useDistricts loads the district list once per page load and shares it
+// between components
+export const useDistricts = () => {
+  const [districts, setDistricts] = useState(cachedDistricts || FALLBACK_DISTRICTS);
+
+  useEffect(() => {
+    if (cachedDistricts) return;
+    let cancelled = false;
+    axios.get(`${process.env.REACT_APP_API_URL}/api/districts`)
+      .then(res => {
+        if (Array.isArray(res.data) && res.data.length > 0) {
+          cachedDistricts = res.data;
+          if (!cancelled) setDistricts(res.data);
+        }
+      })
+      .catch(err => console.error('Failed to fetch districts, using bundled list:', err));
+    return () => { cancelled = true; };
+  }, []);
+
+  return districts;
+};
+
+// districtCoordinates returns [longitude, latitude] for a district name
+export const districtCoordinates = (districts, name) => {
+  const district = districts.find(d => d.name === name);
+  return district ? [district.longitude, district.latitude] : null;
+};
@@ -0,0 +1,15 @@
+// Upload URLs are relative to the API with local storage and absolute
+// (bucket, CDN or signed link) with S3 storage
+export const mediaUrl = (url) => {
+  if (!url) return '';
+  return /^https?:\/\//.test(url) ? url : `${process.env.REACT_APP_API_URL}${url}`;
+};
+
+// Picks a size of a package photo, falling back to the stored file for
+// responses without variants
+export const packagePhotoUrl = (pkg, index, size = 'large') => {
+  const variant = pkg?.photo_variants?.[index]?.[size]?.jpeg;
+  if (variant) return mediaUrl(variant);
+  const filename = pkg?.photos?.[index];
+  return filename ? `${process.env.REACT_APP_API_URL}/uploads/packages/${filename}` : '';
+};